@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"path/filepath"
 	"runtime/debug"
 	"stock-analyzer-wails/controllers"
 	"stock-analyzer-wails/models"
@@ -35,9 +36,16 @@ type App struct {
 	alertMutex        sync.Mutex
 	alertConfigMutex  sync.RWMutex
 	alertConfig       models.AlertConfig
-	klineSyncService  *services.KLineSyncService // K线同步服务
-	syncService       *services.SyncService      // 全量同步服务
-	priceAlertMonitor *services.AlertMonitor     // 价格预警监控引擎
+	klineSyncService  *services.KLineSyncService      // K线同步服务
+	syncService       *services.SyncService           // 全量同步服务
+	dataQuality       *services.DataQualityService    // 缓存数据质量检查
+	userData          *services.UserDataService       // 用户数据导出/导入
+	researchExport    *services.ResearchExportService // 研究数据导出
+	retention         *services.RetentionService      // 历史数据保留与压缩
+	syncJobs          *services.SyncJobStore          // 可续传的同步任务
+	jobManager        *services.JobManager            // 长任务的暂停、继续、取消
+	scheduler         *services.SchedulerService      // 收盘后定时同步、扫描
+	priceAlertMonitor *services.AlertMonitor          // 价格预警监控引擎
 
 	// Controllers (Wails Bindings)
 	WatchlistController   *controllers.WatchlistController
@@ -77,6 +85,10 @@ func NewApp() *App {
 		stockSvc.SetDBService(dbSvc)
 	}
 
+	// 同步、扫描等长任务共用一个任务管理器（数据库不可用时扫描也需要它）
+	jobManager := services.NewJobManager()
+	stockSvc.SetJobManager(jobManager)
+
 	// 如果数据库不可用，相关 controller/service 置空，避免启动阶段 panic。
 	if dbSvc == nil {
 		logger.Warn("SQLite 功能已降级：依赖数据库的模块将不可用（包括价格预警/自选股/配置/策略等）",
@@ -87,6 +99,7 @@ func NewApp() *App {
 			stockService: stockSvc,
 			aiService:    nil,
 			dbService:    nil,
+			jobManager:   jobManager,
 			alertConfig: models.AlertConfig{
 				Sensitivity: 0.005,
 				Cooldown:    1,
@@ -115,11 +128,81 @@ func NewApp() *App {
 	stockMarketSvc := services.NewStockMarketService(dbSvc)
 	priceAlertSvc := services.NewPriceAlertService(priceAlertRepo)
 
+	// 行情数据源按配置选择；配置非法时保留默认东方财富，不影响启动。
+	providerName, err := configSvc.GetMarketDataProvider()
+	if err != nil {
+		logger.Warn("读取行情数据源配置失败，使用默认数据源",
+			zap.String("module", "app"),
+			zap.String("op", "NewApp"),
+			zap.Error(err),
+		)
+	} else if err := stockSvc.UseProvider(providerName); err != nil {
+		logger.Warn("行情数据源配置无效，使用默认数据源",
+			zap.String("module", "app"),
+			zap.String("op", "NewApp"),
+			zap.String("provider", providerName),
+			zap.Error(err),
+		)
+	}
+
+	if ttl, err := configSvc.GetQuoteCacheTTL(); err != nil {
+		logger.Warn("读取行情缓存配置失败，使用默认有效期",
+			zap.String("module", "app"),
+			zap.String("op", "NewApp"),
+			zap.Error(err),
+		)
+	} else {
+		stockSvc.SetQuoteCacheTTL(ttl)
+	}
+
+	if enabled, err := configSvc.GetIntradayPersistence(); err != nil {
+		logger.Warn("读取分时保存配置失败，保持关闭",
+			zap.String("module", "app"),
+			zap.String("op", "NewApp"),
+			zap.Error(err),
+		)
+	} else {
+		stockSvc.SetIntradayPersistence(enabled)
+	}
+
 	var klineSyncSvc *services.KLineSyncService
 	var syncSvc *services.SyncService
+	var dataQualitySvc *services.DataQualityService
+	var userDataSvc *services.UserDataService
+	var researchExportSvc *services.ResearchExportService
+	var retentionSvc *services.RetentionService
+	var syncJobStore *services.SyncJobStore
+	var schedulerSvc *services.SchedulerService
 	if dbSvc != nil {
 		klineSyncSvc = services.NewKLineSyncService(dbSvc)
 		syncSvc = services.NewSyncService(dbSvc, stockMarketSvc, moneyFlowRepo)
+		klineSyncSvc.SetJobManager(jobManager)
+		if n, err := configSvc.GetKLineSyncConcurrency(); err != nil {
+			logger.Warn("读取K线同步并发数配置失败，使用默认值",
+				zap.String("module", "app"),
+				zap.String("op", "NewApp"),
+				zap.Error(err),
+			)
+		} else {
+			klineSyncSvc.SetConcurrency(n)
+		}
+		syncSvc.SetJobManager(jobManager)
+		dataQualitySvc = services.NewDataQualityService(dbSvc, klineSyncSvc, syncSvc)
+		userDataSvc = services.NewUserDataService(dbSvc)
+		researchExportSvc = services.NewResearchExportService(dbSvc)
+		retentionSvc = services.NewRetentionService(dbSvc)
+		schedulerSvc = services.NewSchedulerService(dbSvc)
+		schedulerSvc.SetJobManager(jobManager)
+
+		// 上次退出时仍在运行的同步任务标记为中断，供续传
+		syncJobStore = services.NewSyncJobStore(dbSvc)
+		if _, err := syncJobStore.MarkInterrupted(); err != nil {
+			logger.Warn("标记中断的同步任务失败",
+				zap.String("module", "app"),
+				zap.String("op", "NewApp"),
+				zap.Error(err),
+			)
+		}
 	}
 
 	// 3. Controller 层 (Wails 绑定)
@@ -149,13 +232,20 @@ func NewApp() *App {
 	// 4. 回测服务
 	backtestSvc := services.NewBacktestService(stockSvc, strategySvc)
 
-	return &App{
+	app := &App{
 		stockService:     stockSvc,
 		aiService:        nil,
-		dbService:        dbSvc,        // 存储 DBService
-		klineSyncService: klineSyncSvc, // K线同步服务
-		syncService:      syncSvc,      // 全量同步服务
-		backtestService:  backtestSvc,  // 回测服务
+		dbService:        dbSvc,             // 存储 DBService
+		klineSyncService: klineSyncSvc,      // K线同步服务
+		syncService:      syncSvc,           // 全量同步服务
+		dataQuality:      dataQualitySvc,    // 数据质量服务
+		userData:         userDataSvc,       // 用户数据导出/导入
+		researchExport:   researchExportSvc, // 研究数据导出
+		retention:        retentionSvc,      // 数据保留策略
+		syncJobs:         syncJobStore,      // 同步任务与检查点
+		jobManager:       jobManager,        // 长任务管理
+		scheduler:        schedulerSvc,      // 定时任务
+		backtestService:  backtestSvc,       // 回测服务
 
 		// Controllers
 		WatchlistController:   watchlistCtrl,
@@ -181,6 +271,8 @@ func NewApp() *App {
 			Enabled:     true,
 		},
 	}
+	app.registerScheduleSteps(stockMarketSvc)
+	return app
 }
 
 // startup 在应用程序启动时调用
@@ -188,6 +280,8 @@ func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 
 	// 注册需要上下文的服务的 Startup 方法
+	a.jobManager.SetContext(ctx)
+	services.GetOutboundGateway().SetContext(ctx)
 	a.stockService.Startup(ctx)
 	if a.klineSyncService != nil {
 		a.klineSyncService.SetContext(ctx)
@@ -195,6 +289,12 @@ func (a *App) startup(ctx context.Context) {
 	if a.syncService != nil {
 		a.syncService.SetContext(ctx)
 	}
+	if a.researchExport != nil {
+		a.researchExport.SetContext(ctx)
+	}
+	if a.retention != nil {
+		a.retention.Start(ctx)
+	}
 
 	// 迁移旧的 AI 配置（数据库不可用时 configService 为空，需要安全跳过）
 	if a.configService != nil {
@@ -240,6 +340,11 @@ func (a *App) startup(ctx context.Context) {
 		a.priceAlertMonitor.Start()
 		logger.Info("价格预警监控引擎已启动")
 	}
+
+	// 定时任务最后启动：补跑错过的计划时 AI 服务已初始化
+	if a.scheduler != nil {
+		a.scheduler.Start(ctx)
+	}
 }
 
 // startPositionMonitor 启动持仓逻辑监控引擎
@@ -252,6 +357,10 @@ func (a *App) startPositionMonitor() {
 		case <-a.ctx.Done():
 			return
 		case <-ticker.C:
+			// 休市期间行情不变，无需轮询
+			if !services.GetTradingCalendar().IsSessionOpen(time.Now()) {
+				continue
+			}
 			a.checkPositionLogics()
 		}
 	}
@@ -267,14 +376,26 @@ func (a *App) checkPositionLogics() {
 		return
 	}
 
+	// 1. 批量获取最新实时数据
+	codes := make([]string, 0, len(positions))
+	for _, pos := range positions {
+		if pos.CurrentStatus == "holding" {
+			codes = append(codes, pos.StockCode)
+		}
+	}
+	if len(codes) == 0 {
+		return
+	}
+	// 批量失败或缺少部分代码时逐个获取，单只失败只跳过该持仓
+	quotes := services.FetchQuotesWithFallback(a.stockService, codes)
+
 	for _, pos := range positions {
 		if pos.CurrentStatus != "holding" {
 			continue
 		}
 
-		// 1. 获取最新实时数据
-		stock, err := a.stockService.GetStockByCode(pos.StockCode)
-		if err != nil {
+		stock, ok := quotes[pos.StockCode]
+		if !ok {
 			continue
 		}
 
@@ -380,6 +501,10 @@ func (a *App) startAlertMonitor() {
 		case <-a.ctx.Done():
 			return
 		case <-ticker.C:
+			// 休市期间行情不变，无需轮询
+			if !services.GetTradingCalendar().IsSessionOpen(time.Now()) {
+				continue
+			}
 			a.checkAlerts()
 		}
 	}
@@ -409,9 +534,16 @@ func (a *App) checkAlerts() {
 	}
 
 	// 批量获取最新价进行比对
+	codes := make([]string, 0, len(activeAlerts))
 	for _, alert := range activeAlerts {
-		stock, err := a.stockService.GetStockByCode(alert.StockCode)
-		if err != nil {
+		codes = append(codes, alert.StockCode)
+	}
+	// 批量失败或缺少部分代码时逐个获取，单只失败只跳过该预警
+	quotes := services.FetchQuotesWithFallback(a.stockService, codes)
+
+	for _, alert := range activeAlerts {
+		stock, ok := quotes[alert.StockCode]
+		if !ok {
 			continue
 		}
 
@@ -672,6 +804,72 @@ func (a *App) AnalyzePastSignals(days int) (*models.SignalAnalysisResult, error)
 	return a.backtestService.AnalyzePastSignals(days)
 }
 
+// GetMarketStatus 获取当前市场状态（是否交易日、所处交易阶段、前后交易日）
+func (a *App) GetMarketStatus() services.MarketStatus {
+	return services.GetTradingCalendar().Status(time.Now())
+}
+
+// GetTradingCalendarInfo 获取交易日历概况（版本、覆盖范围、来源）
+func (a *App) GetTradingCalendarInfo() services.TradingCalendarInfo {
+	return services.GetTradingCalendar().Info()
+}
+
+// ReloadTradingCalendar 重新加载交易日历（更新应用数据目录下的 trading_calendar.json 后调用）
+func (a *App) ReloadTradingCalendar() services.TradingCalendarInfo {
+	return services.ReloadTradingCalendar()
+}
+
+// GetQuoteCacheStats 获取行情缓存命中统计
+func (a *App) GetQuoteCacheStats() (services.QuoteCacheStats, error) {
+	if a.stockService == nil {
+		return services.QuoteCacheStats{}, fmt.Errorf("股票服务未初始化")
+	}
+	return a.stockService.QuoteCacheStats(), nil
+}
+
+// SetQuoteCacheTTL 设置行情缓存有效期（毫秒，<= 0 表示不缓存），立即生效并持久化
+func (a *App) SetQuoteCacheTTL(ms int) error {
+	if a.stockService == nil {
+		return fmt.Errorf("股票服务未初始化")
+	}
+	a.stockService.SetQuoteCacheTTL(time.Duration(ms) * time.Millisecond)
+	if a.configService == nil {
+		return fmt.Errorf("配置服务未初始化")
+	}
+	return a.configService.SetQuoteCacheTTL(ms)
+}
+
+// GetIntradayPersistence 是否保存盘中分时推送
+func (a *App) GetIntradayPersistence() bool {
+	if a.stockService == nil {
+		return false
+	}
+	return a.stockService.IntradayPersistenceEnabled()
+}
+
+// SetIntradayPersistence 设置是否保存盘中分时推送（对之后打开的分时推送生效）并持久化
+func (a *App) SetIntradayPersistence(enabled bool) error {
+	if a.stockService == nil {
+		return fmt.Errorf("股票服务未初始化")
+	}
+	a.stockService.SetIntradayPersistence(enabled)
+	if a.configService == nil {
+		return fmt.Errorf("配置服务未初始化")
+	}
+	return a.configService.SetIntradayPersistence(enabled)
+}
+
+// GetStoredIntradayData 获取本地保存的分时数据，tradeDate 为空时取最近一个交易日
+func (a *App) GetStoredIntradayData(code string, tradeDate string) (*models.IntradayResponse, error) {
+	if code == "" {
+		return nil, fmt.Errorf("股票代码不能为空")
+	}
+	if a.stockService == nil {
+		return nil, fmt.Errorf("股票服务未初始化")
+	}
+	return a.stockService.GetStoredIntradayData(code, tradeDate)
+}
+
 // GetStockData 获取股票数据
 func (a *App) GetStockData(code string) (*models.StockData, error) {
 	if code == "" {
@@ -680,6 +878,14 @@ func (a *App) GetStockData(code string) (*models.StockData, error) {
 	return a.stockService.GetStockByCode(code)
 }
 
+// GetQuotes 批量获取行情，返回 代码 -> 行情
+func (a *App) GetQuotes(codes []string) (map[string]*models.StockData, error) {
+	if a.stockService == nil {
+		return nil, fmt.Errorf("股票服务未初始化")
+	}
+	return a.stockService.GetQuotes(codes)
+}
+
 // GetStockDetail 获取个股详情页所需的所有数据
 func (a *App) GetStockDetail(code string) (*models.StockDetail, error) {
 	if code == "" {
@@ -713,6 +919,26 @@ func (a *App) StopIntradayStream(code string) {
 	a.stockService.StopIntradayStream(code)
 }
 
+// GetStreamHealth 获取所有活跃分时推送连接的健康状况
+// 状态变化时另会推送事件：intradayStreamStatus
+func (a *App) GetStreamHealth() ([]services.StreamHealth, error) {
+	if a.stockService == nil {
+		return nil, fmt.Errorf("股票服务未初始化")
+	}
+	return a.stockService.GetStreamHealth(), nil
+}
+
+// GetGatewayStatus 获取各数据源主机的限速与熔断状态
+// 熔断状态变化时另会推送事件：circuitBreaker
+func (a *App) GetGatewayStatus() []services.GatewayHostStatus {
+	return services.GetOutboundGateway().Status()
+}
+
+// ResetCircuitBreaker 手动恢复数据源主机的熔断，立即允许请求
+func (a *App) ResetCircuitBreaker(host string) error {
+	return services.GetOutboundGateway().ResetBreaker(host)
+}
+
 // GetMoneyFlowData 获取资金流向数据
 func (a *App) GetMoneyFlowData(code string) (*models.MoneyFlowResponse, error) {
 	if code == "" {
@@ -817,7 +1043,7 @@ func (a *App) AnalyzeEntryStrategy(code string) (res *models.EntryStrategyResult
 	}
 
 	stepStart = time.Now()
-	klines, e := a.stockService.GetKLineData(code, 100, "daily")
+	klines, e := a.stockService.GetKLineData(code, 100, services.KLinePeriodDaily, services.KLineAdjustForward)
 	if e != nil {
 		logger.Error("建仓分析失败：获取K线数据失败",
 			zap.String("module", "app.entry_strategy"),
@@ -919,12 +1145,36 @@ func (a *App) BatchAnalyzeStocks(codes []string, role string) error {
 	return a.stockService.BatchAnalyzeStocks(a.ctx, codes, role, a.aiService)
 }
 
-// GetKLineData 获取K线数据，支持周期参数
-func (a *App) GetKLineData(code string, limit int, period string) ([]*models.KLineData, error) {
+// GetKLineData 获取K线数据，支持周期与复权参数（adjust: none/forward/backward，空值为前复权）
+func (a *App) GetKLineData(code string, limit int, period string, adjust string) ([]*models.KLineData, error) {
 	if code == "" {
 		return nil, fmt.Errorf("股票代码不能为空")
 	}
-	return a.stockService.GetKLineData(code, limit, period)
+	return a.stockService.GetKLineData(code, limit, period, adjust)
+}
+
+// GetLocallyAdjustedKLines 基于本地不复权缓存和除权除息事件计算复权 K 线（不访问行情源）
+func (a *App) GetLocallyAdjustedKLines(code string, limit int, adjust string) ([]*models.KLineData, error) {
+	if code == "" {
+		return nil, fmt.Errorf("股票代码不能为空")
+	}
+	return a.stockService.GetLocallyAdjustedKLines(code, limit, adjust)
+}
+
+// SyncExRightsEvents 同步指定股票的除权除息事件，返回事件数量
+func (a *App) SyncExRightsEvents(code string) (int, error) {
+	if code == "" {
+		return 0, fmt.Errorf("股票代码不能为空")
+	}
+	return a.stockService.SyncExRightsEvents(code)
+}
+
+// SyncFinancialStatements 同步指定股票的财务报表（用于财务摘要计算）
+func (a *App) SyncFinancialStatements(code string) (int, error) {
+	if code == "" {
+		return 0, fmt.Errorf("股票代码不能为空")
+	}
+	return a.stockService.SyncFinancialStatements(code)
 }
 
 // --- Watchlist 转发器 ---
@@ -967,11 +1217,16 @@ func (a *App) AnalyzeTechnical(code string, period string, role string) (*models
 	if a.aiService == nil {
 		return nil, fmt.Errorf("AI服务未就绪")
 	}
+	// 支持日/周/月线及 1/5/15/30/60 分钟线；规范化后作为分析缓存的 key
+	period, err := services.NormalizeKLinePeriod(period)
+	if err != nil {
+		return nil, err
+	}
 	stock, err := a.stockService.GetStockByCode(code)
 	if err != nil {
 		return nil, err
 	}
-	klines, err := a.stockService.GetKLineData(code, 100, period)
+	klines, err := a.stockService.GetKLineData(code, 100, period, services.KLineAdjustForward)
 	if err != nil {
 		return nil, err
 	}
@@ -1009,6 +1264,7 @@ func (a *App) RunStrategyScan(codes []string) []map[string]interface{} {
 	}
 
 	var results []map[string]interface{}
+	var signals []*models.StrategySignal
 
 	for _, code := range codes {
 		signal, err := a.strategyService.CalculateBuildSignals(code)
@@ -1018,52 +1274,7 @@ func (a *App) RunStrategyScan(codes []string) []map[string]interface{} {
 		}
 
 		if signal != nil {
-			// 触发 AI 验证 (异步)
-			if a.aiService != nil {
-				go func(sig *models.StrategySignal) {
-					// 1. 获取最近 7 天资金流向
-					flows, err := a.strategyService.GetRecentMoneyFlows(sig.Code, 7)
-					if err != nil {
-						logger.Error("获取近期资金流向失败", zap.String("code", sig.Code), zap.Error(err))
-						return
-					}
-
-					// 2. 获取股票基本信息
-					stock, err := a.stockService.GetStockByCode(sig.Code)
-					if err != nil {
-						logger.Error("获取股票信息失败", zap.String("code", sig.Code), zap.Error(err))
-						// 构建一个临时的 StockData
-						stock = &models.StockData{Code: sig.Code, Name: sig.Code}
-					}
-
-					// 3. 调用 AI 验证
-					verifyChan := a.aiService.VerifySignalAsync(stock, flows)
-					res := <-verifyChan
-
-					if res != nil {
-						// 4. 更新数据库
-						err := a.strategyService.UpdateSignalAIResult(sig.Code, sig.TradeDate, sig.StrategyName, res.Score, res.Opinion)
-						if err != nil {
-							logger.Error("更新 AI 结果失败", zap.Error(err))
-						}
-
-						// 5. 通知前端
-						signalData := map[string]interface{}{
-							"code":         sig.Code,
-							"tradeDate":    sig.TradeDate,
-							"signalType":   sig.SignalType,
-							"score":        sig.Score,
-							"strategyName": sig.StrategyName,
-							"aiScore":      res.Score,
-							"aiReason":     res.Opinion,
-							"riskLevel":    res.RiskLevel,
-						}
-						runtime.EventsEmit(a.ctx, "signal_verified", signalData)
-						runtime.EventsEmit(a.ctx, "new_signal", signalData)
-					}
-				}(signal)
-			}
-
+			signals = append(signals, signal)
 			results = append(results, map[string]interface{}{
 				"code":         signal.Code,
 				"tradeDate":    signal.TradeDate,
@@ -1075,172 +1286,286 @@ func (a *App) RunStrategyScan(codes []string) []map[string]interface{} {
 		}
 	}
 
-	return results
-}
+	// 触发 AI 验证 (异步)，股票基本信息一次性批量获取
+	if a.aiService != nil && len(signals) > 0 {
+		stocks := a.signalStocks(signals)
+		for _, signal := range signals {
+			go func(sig *models.StrategySignal, stock *models.StockData) {
+				// 1. 获取最近 7 天资金流向
+				flows, err := a.strategyService.GetRecentMoneyFlows(sig.Code, 7)
+				if err != nil {
+					logger.Error("获取近期资金流向失败", zap.String("code", sig.Code), zap.Error(err))
+					return
+				}
 
-// StartMassScan 启动全市场策略扫描
-// 前端调用此方法后会立即返回，扫描过程在后台进行，通过事件推送进度
-func (a *App) StartMassScan() {
-	go func() {
-		logger.Info("启动全市场扫描任务")
-
-		// 1. 获取所有待扫描股票
-		// 这里我们使用 stockMarketService 获取股票代码，而不是 stockService
-		// 因为 stockMarketService 包含完整的市场股票列表
-		if a.StockMarketController == nil || a.stockService == nil {
-			runtime.EventsEmit(a.ctx, "scan_error", "市场股票服务未初始化")
-			return
-		}
+				// 2. 调用 AI 验证
+				verifyChan := a.aiService.VerifySignalAsync(stock, flows)
+				res := <-verifyChan
 
-		// 通过 service 直接获取，避免 controller 的封装
-		// 我们需要在 App 结构体中添加 stockMarketService 字段的直接访问，或者通过 Controller 获取
-		// 这里假设 App 结构体中有 stockMarketService 字段，但实际上是通过 NewApp 注入到了 Controller
-		// 我们需要修改 App 结构体或者直接使用 stockMarketCtrl 对应的 service
-		// 查看 App 结构体，发现没有直接保存 stockMarketService 的引用，只在 NewApp 局部变量里
-		// 所以我们需要先解决这个问题，或者暂时通过数据库直接查询
-
-		// 修正：App 结构体实际上没有保存 stockMarketService，只保存了 StockMarketController
-		// 但 NewApp 中确实初始化了 stockMarketSvc 并传给了 StockMarketController
-		// 为了简单起见，我们可以在 App 中增加一个 stockMarketService 字段，
-		// 或者直接在 NewApp 中把 stockMarketSvc 赋值给 App 的新字段。
-		// 不过，既然我们已经在 StockMarketService 中添加了 GetAllStockCodes，
-		// 我们最好是在 App 结构体中添加 stockMarketService 字段。
-		// 考虑到无法修改结构体定义（需要修改文件头部），我们尝试通过 StockMarketController 调用，
-		// 但 StockMarketController 可能没有暴露这个方法。
-
-		// 既然我们已经在前面的步骤中修改了 services/stock_market_service.go，
-		// 我们可以尝试通过 a.dbService 直接查询，但这重复了逻辑。
-		// 最好的办法是修改 App 结构体，添加 stockMarketService *services.StockMarketService 字段。
-		// 但由于我只能通过 SearchReplace 修改文件，添加字段比较麻烦。
-
-		// 替代方案：在 RunStrategyScan 中我们已经有现成的逻辑。
-		// 我们可以通过 dbService 获取所有股票代码。
-
-		// 让我们先尝试使用 dbService 获取所有代码，模拟 stockMarketService.GetAllStockCodes 的逻辑
-		if a.dbService == nil {
-			runtime.EventsEmit(a.ctx, "scan_error", "数据库服务未初始化")
-			return
+				if res != nil {
+					// 3. 更新数据库
+					err := a.strategyService.UpdateSignalAIResult(sig.Code, sig.TradeDate, sig.StrategyName, res.Score, res.Opinion)
+					if err != nil {
+						logger.Error("更新 AI 结果失败", zap.Error(err))
+					}
+
+					// 4. 通知前端
+					signalData := map[string]interface{}{
+						"code":         sig.Code,
+						"tradeDate":    sig.TradeDate,
+						"signalType":   sig.SignalType,
+						"score":        sig.Score,
+						"strategyName": sig.StrategyName,
+						"aiScore":      res.Score,
+						"aiReason":     res.Opinion,
+						"riskLevel":    res.RiskLevel,
+					}
+					runtime.EventsEmit(a.ctx, "signal_verified", signalData)
+					runtime.EventsEmit(a.ctx, "new_signal", signalData)
+				}
+			}(signal, stocks[signal.Code])
 		}
+	}
 
-		db := a.dbService.GetDB()
-		var codes []string
-		err := db.Model(&models.StockEntity{}).
-			Where("is_active = 1").
-			Order("code ASC").
-			Pluck("code", &codes).Error
+	return results
+}
 
-		if err != nil {
-			logger.Error("获取股票代码失败", zap.Error(err))
-			runtime.EventsEmit(a.ctx, "scan_error", fmt.Sprintf("获取股票列表失败: %v", err))
-			return
+// signalStocks 批量获取信号对应股票的基本信息，取不到时用代码构建临时的 StockData
+func (a *App) signalStocks(signals []*models.StrategySignal) map[string]*models.StockData {
+	codes := make([]string, 0, len(signals))
+	for _, sig := range signals {
+		codes = append(codes, sig.Code)
+	}
+	quotes, err := a.stockService.GetQuotes(codes)
+	if err != nil {
+		logger.Error("批量获取股票信息失败", zap.Int("count", len(codes)), zap.Error(err))
+		quotes = map[string]*models.StockData{}
+	}
+	for _, code := range codes {
+		if _, ok := quotes[code]; !ok {
+			quotes[code] = &models.StockData{Code: code, Name: code}
 		}
+	}
+	return quotes
+}
 
-		total := len(codes)
-		foundCount := 0
+// StartMassScan 启动全市场策略扫描
+// 前端调用此方法后会立即返回任务ID，扫描过程在后台进行，通过事件推送进度；
+// 可通过 PauseJob/ResumeJob/CancelJob 控制，取消时 scan_complete 带 status=cancelled 和已扫描数量
+func (a *App) StartMassScan() string {
+	job := a.jobManager.Begin(services.JobMassScan, "")
+	go a.runMassScan(job, true)
+	return job.ID()
+}
+
+// runMassScan 执行全市场扫描并结束任务，返回扫描结果（取消时为已扫描部分）。
+// verify 为 true 时发现的信号随即交给 AI 验证；定时任务中由单独的 AI 验证步骤处理。
+func (a *App) runMassScan(job *services.Job, verify bool) (map[string]interface{}, error) {
+	logger.Info("启动全市场扫描任务")
+
+	// 1. 获取所有待扫描股票
+	// 这里我们使用 stockMarketService 获取股票代码，而不是 stockService
+	// 因为 stockMarketService 包含完整的市场股票列表
+	if a.StockMarketController == nil || a.stockService == nil {
+		runtime.EventsEmit(a.ctx, "scan_error", "市场股票服务未初始化")
+		a.jobManager.Finish(job, services.JobFailed, nil, "市场股票服务未初始化")
+		return nil, fmt.Errorf("市场股票服务未初始化")
+	}
+
+	// 通过 service 直接获取，避免 controller 的封装
+	// 我们需要在 App 结构体中添加 stockMarketService 字段的直接访问，或者通过 Controller 获取
+	// 这里假设 App 结构体中有 stockMarketService 字段，但实际上是通过 NewApp 注入到了 Controller
+	// 我们需要修改 App 结构体或者直接使用 stockMarketCtrl 对应的 service
+	// 查看 App 结构体，发现没有直接保存 stockMarketService 的引用，只在 NewApp 局部变量里
+	// 所以我们需要先解决这个问题，或者暂时通过数据库直接查询
+
+	// 修正：App 结构体实际上没有保存 stockMarketService，只保存了 StockMarketController
+	// 但 NewApp 中确实初始化了 stockMarketSvc 并传给了 StockMarketController
+	// 为了简单起见，我们可以在 App 中增加一个 stockMarketService 字段，
+	// 或者直接在 NewApp 中把 stockMarketSvc 赋值给 App 的新字段。
+	// 不过，既然我们已经在 StockMarketService 中添加了 GetAllStockCodes，
+	// 我们最好是在 App 结构体中添加 stockMarketService 字段。
+	// 考虑到无法修改结构体定义（需要修改文件头部），我们尝试通过 StockMarketController 调用，
+	// 但 StockMarketController 可能没有暴露这个方法。
+
+	// 既然我们已经在前面的步骤中修改了 services/stock_market_service.go，
+	// 我们可以尝试通过 a.dbService 直接查询，但这重复了逻辑。
+	// 最好的办法是修改 App 结构体，添加 stockMarketService *services.StockMarketService 字段。
+	// 但由于我只能通过 SearchReplace 修改文件，添加字段比较麻烦。
+
+	// 替代方案：在 RunStrategyScan 中我们已经有现成的逻辑。
+	// 我们可以通过 dbService 获取所有股票代码。
+
+	// 让我们先尝试使用 dbService 获取所有代码，模拟 stockMarketService.GetAllStockCodes 的逻辑
+	if a.dbService == nil {
+		runtime.EventsEmit(a.ctx, "scan_error", "数据库服务未初始化")
+		a.jobManager.Finish(job, services.JobFailed, nil, "数据库服务未初始化")
+		return nil, fmt.Errorf("数据库服务未初始化")
+	}
 
-		// 发送扫描开始事件
-		runtime.EventsEmit(a.ctx, "scan_start", map[string]interface{}{
-			"total": total,
-		})
+	db := a.dbService.GetDB()
+	var codes []string
+	// 只扫描股票：指数、ETF、可转债不参与策略扫描，且指数与同号股票的 code 会重复
+	err := services.ActiveEquities(db).
+		Order("code ASC").
+		Pluck("code", &codes).Error
 
-		logger.Info("开始扫描股票", zap.Int("total", total))
+	if err != nil {
+		logger.Error("获取股票代码失败", zap.Error(err))
+		msg := fmt.Sprintf("获取股票列表失败: %v", err)
+		runtime.EventsEmit(a.ctx, "scan_error", msg)
+		a.jobManager.Finish(job, services.JobFailed, nil, msg)
+		return nil, fmt.Errorf("获取股票列表失败: %w", err)
+	}
+
+	total := len(codes)
+	foundCount := 0
+
+	// 发送扫描开始事件
+	runtime.EventsEmit(a.ctx, "scan_start", map[string]interface{}{
+		"jobId": job.ID(),
+		"total": total,
+	})
+
+	logger.Info("开始扫描股票", zap.Int("total", total))
+
+	// 待 AI 验证的信号
+	var pending []*models.StrategySignal
+
+	// 2. 遍历扫描
+	scanned := 0
+	cancelled := false
+	for i, code := range codes {
+		// 暂停时在这里等待；任务被取消或程序退出时停止扫描
+		if job.Wait() != nil {
+			cancelled = true
+			break
+		}
 
-		// 2. 遍历扫描
-		for i, code := range codes {
-			// 检查上下文是否已取消（程序退出）
-			select {
-			case <-a.ctx.Done():
-				return
-			default:
-			}
+		// 计算策略信号
+		signal, err := a.strategyService.CalculateBuildSignals(code)
+		if err != nil {
+			// 单个失败不中断整体扫描，仅记录日志
+			// logger.Debug("策略计算跳过", zap.String("code", code), zap.Error(err))
+		}
 
-			// 计算策略信号
-			signal, err := a.strategyService.CalculateBuildSignals(code)
-			if err != nil {
-				// 单个失败不中断整体扫描，仅记录日志
-				// logger.Debug("策略计算跳过", zap.String("code", code), zap.Error(err))
-			}
+		// 3. 发现信号后的处理
+		if signal != nil {
+			foundCount++
 
-			// 3. 发现信号后的处理
-			if signal != nil {
-				foundCount++
-
-				// 立即发送基础信号发现事件
-				runtime.EventsEmit(a.ctx, "scan_signal_found", signal)
-
-				// 触发 AI 深度验证 (异步)
-				if a.aiService != nil {
-					go func(sig *models.StrategySignal) {
-						// 获取辅助数据
-						flows, _ := a.strategyService.GetRecentMoneyFlows(sig.Code, 7)
-						stock, err := a.stockService.GetStockByCode(sig.Code)
-						if err != nil {
-							stock = &models.StockData{Code: sig.Code, Name: sig.Code}
-						}
-
-						// 调用 AI 分析
-						verifyChan := a.aiService.VerifySignalAsync(stock, flows)
-						res := <-verifyChan
-
-						if res != nil {
-							// 更新 AI 评分结果
-							_ = a.strategyService.UpdateSignalAIResult(sig.Code, sig.TradeDate, sig.StrategyName, res.Score, res.Opinion)
-
-							// 组装完整数据推送到前端
-							signalData := map[string]interface{}{
-								"code":         sig.Code,
-								"tradeDate":    sig.TradeDate,
-								"signalType":   sig.SignalType,
-								"score":        sig.Score,
-								"strategyName": sig.StrategyName,
-								"aiScore":      res.Score,
-								"aiReason":     res.Opinion,
-								"riskLevel":    res.RiskLevel,
-								"details":      sig.Details,
-							}
-
-							// 推送 AI 验证完成事件
-							runtime.EventsEmit(a.ctx, "signal_verified", signalData)
-							// 兼容旧的信号事件
-							runtime.EventsEmit(a.ctx, "new_signal", signalData)
-						}
-					}(signal)
-				} else {
-					// 无 AI 服务时，直接推送原始信号
-					runtime.EventsEmit(a.ctx, "new_signal", map[string]interface{}{
-						"code":         signal.Code,
-						"tradeDate":    signal.TradeDate,
-						"signalType":   signal.SignalType,
-						"score":        signal.Score,
-						"strategyName": signal.StrategyName,
-						"details":      signal.Details,
-					})
-				}
-			}
+			// 立即发送基础信号发现事件
+			runtime.EventsEmit(a.ctx, "scan_signal_found", signal)
 
-			// 4. 发送进度事件 (每 20 个或最后一个发送一次，减少前端渲染压力)
-			if (i+1)%20 == 0 || i == total-1 {
-				runtime.EventsEmit(a.ctx, "scan_progress", map[string]interface{}{
-					"current":  i + 1,
-					"total":    total,
-					"found":    foundCount,
-					"lastCode": code,
+			// 触发 AI 深度验证 (异步)：先攒批，在进度事件时批量获取行情后再验证
+			if a.aiService != nil {
+				if verify {
+					pending = append(pending, signal)
+				}
+			} else {
+				// 无 AI 服务时，直接推送原始信号
+				runtime.EventsEmit(a.ctx, "new_signal", map[string]interface{}{
+					"code":         signal.Code,
+					"tradeDate":    signal.TradeDate,
+					"signalType":   signal.SignalType,
+					"score":        signal.Score,
+					"strategyName": signal.StrategyName,
+					"details":      signal.Details,
 				})
 			}
+		}
 
-			// 简单的限流，防止瞬间 CPU 占用过高
-			if i%100 == 0 {
-				time.Sleep(5 * time.Millisecond)
+		// 4. 发送进度事件 (每 20 个或最后一个发送一次，减少前端渲染压力)
+		if (i+1)%20 == 0 || i == total-1 {
+			if len(pending) > 0 {
+				a.verifySignalsAsync(pending)
+				pending = nil
 			}
+			runtime.EventsEmit(a.ctx, "scan_progress", map[string]interface{}{
+				"jobId":    job.ID(),
+				"current":  i + 1,
+				"total":    total,
+				"found":    foundCount,
+				"lastCode": code,
+			})
 		}
 
-		// 5. 扫描完成
-		logger.Info("全市场扫描完成", zap.Int("total", total), zap.Int("found", foundCount))
-		runtime.EventsEmit(a.ctx, "scan_complete", map[string]interface{}{
-			"total": total,
-			"found": foundCount,
-		})
-	}()
+		scanned = i + 1
+		job.SetProgress(scanned, total)
+
+		// 简单的限流，防止瞬间 CPU 占用过高
+		if i%100 == 0 {
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// 5. 扫描结束（取消时已发现的信号照常验证）
+	if len(pending) > 0 {
+		a.verifySignalsAsync(pending)
+	}
+	status := services.JobCompleted
+	if cancelled {
+		status = services.JobCancelled
+	}
+	logger.Info("全市场扫描结束",
+		zap.String("job_id", job.ID()),
+		zap.String("status", status),
+		zap.Int("total", total),
+		zap.Int("scanned", scanned),
+		zap.Int("found", foundCount),
+	)
+	result := map[string]interface{}{
+		"jobId":   job.ID(),
+		"status":  status,
+		"total":   total,
+		"scanned": scanned,
+		"found":   foundCount,
+	}
+	runtime.EventsEmit(a.ctx, "scan_complete", result)
+	a.jobManager.Finish(job, status, result, "")
+	return result, nil
+}
+
+// verifySignalsAsync 批量获取信号对应股票的行情后，逐个异步触发 AI 深度验证。
+// 返回的 WaitGroup 在全部验证结束后完成。
+func (a *App) verifySignalsAsync(signals []*models.StrategySignal) *sync.WaitGroup {
+	stocks := a.signalStocks(signals)
+	var wg sync.WaitGroup
+	for _, signal := range signals {
+		wg.Add(1)
+		go func(sig *models.StrategySignal, stock *models.StockData) {
+			defer wg.Done()
+			// 获取辅助数据
+			flows, _ := a.strategyService.GetRecentMoneyFlows(sig.Code, 7)
+
+			// 调用 AI 分析
+			verifyChan := a.aiService.VerifySignalAsync(stock, flows)
+			res := <-verifyChan
+
+			if res != nil {
+				// 更新 AI 评分结果
+				_ = a.strategyService.UpdateSignalAIResult(sig.Code, sig.TradeDate, sig.StrategyName, res.Score, res.Opinion)
+
+				// 组装完整数据推送到前端
+				signalData := map[string]interface{}{
+					"code":         sig.Code,
+					"tradeDate":    sig.TradeDate,
+					"signalType":   sig.SignalType,
+					"score":        sig.Score,
+					"strategyName": sig.StrategyName,
+					"aiScore":      res.Score,
+					"aiReason":     res.Opinion,
+					"riskLevel":    res.RiskLevel,
+					"details":      sig.Details,
+				}
+
+				// 推送 AI 验证完成事件
+				runtime.EventsEmit(a.ctx, "signal_verified", signalData)
+				// 兼容旧的信号事件
+				runtime.EventsEmit(a.ctx, "new_signal", signalData)
+			}
+		}(signal, stocks[signal.Code])
+	}
+	return &wg
 }
 
 // GetLatestSignals 获取最新的策略信号
@@ -1269,12 +1594,12 @@ func (a *App) shutdown(ctx context.Context) {
 
 // --- 数据同步功能 开始 ---
 
-// SyncStockData 同步单个股票的历史数据到本地 SQLite
-func (a *App) SyncStockData(code string, startDate string, endDate string) (*models.SyncResult, error) {
+// SyncStockData 同步单个股票的历史数据到本地 SQLite（adjust 为复权方式，空值为前复权）
+func (a *App) SyncStockData(code string, startDate string, endDate string, adjust string) (*models.SyncResult, error) {
 	startTime := time.Now()
 
 	// 调用 stockService 同步数据
-	result, err := a.stockService.SyncStockData(code, startDate, endDate)
+	result, err := a.stockService.SyncStockData(code, startDate, endDate, adjust)
 
 	// 保存同步历史记录
 	duration := int(time.Since(startTime).Seconds())
@@ -1324,10 +1649,10 @@ func (a *App) GetDataSyncStats() (*models.DataSyncStats, error) {
 }
 
 // BatchSyncStockData 批量同步多个股票的历史数据
-func (a *App) BatchSyncStockData(codes []string, startDate string, endDate string) error {
+func (a *App) BatchSyncStockData(codes []string, startDate string, endDate string, adjust string) error {
 	// 直接调用 stockService 批量同步
 	// 每个股票的同步历史记录会在 SyncStockData 中单独记录
-	return a.stockService.BatchSyncStockData(codes, startDate, endDate)
+	return a.stockService.BatchSyncStockData(codes, startDate, endDate, adjust)
 }
 
 // ClearStockCache 清除指定股票的本地缓存数据
@@ -1497,12 +1822,28 @@ func (a *App) GetStocksList(page int, pageSize int, search string, industry stri
 	return a.StockMarketController.GetStocksList(page, pageSize, search, industry)
 }
 
-// GetIndustries 获取行业列表
-func (a *App) GetIndustries() (interface{}, error) {
+// GetIndustries 获取板块列表，boardType 为 industry（默认）或 concept
+func (a *App) GetIndustries(boardType string) (interface{}, error) {
+	if a.StockMarketController == nil {
+		return nil, fmt.Errorf("市场股票控制器未初始化")
+	}
+	return a.StockMarketController.GetIndustries(boardType)
+}
+
+// SyncBoards 同步行业、概念板块及成分股
+func (a *App) SyncBoards() (interface{}, error) {
+	if a.StockMarketController == nil {
+		return nil, fmt.Errorf("市场股票控制器未初始化")
+	}
+	return a.StockMarketController.SyncBoards()
+}
+
+// GetBoardMembers 获取板块成分股
+func (a *App) GetBoardMembers(boardCode string) (interface{}, error) {
 	if a.StockMarketController == nil {
 		return nil, fmt.Errorf("市场股票控制器未初始化")
 	}
-	return a.StockMarketController.GetIndustries()
+	return a.StockMarketController.GetBoardMembers(boardCode)
 }
 
 // GetSyncStats 获取同步统计信息
@@ -1515,12 +1856,48 @@ func (a *App) GetSyncStats() (interface{}, error) {
 
 // ============ K线数据同步 API ============
 
-// StartKLineSync 开始K线数据同步
-func (a *App) StartKLineSync(days int) (interface{}, error) {
+// StartKLineSync 开始K线数据同步（adjust 为复权方式，空值为前复权）
+func (a *App) StartKLineSync(days int, adjust string) (interface{}, error) {
 	if a.klineSyncService == nil {
 		return nil, fmt.Errorf("K线同步服务未初始化")
 	}
-	return a.klineSyncService.StartKLineSync(days)
+	return a.klineSyncService.StartKLineSync(days, adjust)
+}
+
+// StartFullKLineSync 忽略本地缓存全量重新同步K线（adjust 为复权方式，空值为前复权）
+func (a *App) StartFullKLineSync(days int, adjust string) (interface{}, error) {
+	if a.klineSyncService == nil {
+		return nil, fmt.Errorf("K线同步服务未初始化")
+	}
+	return a.klineSyncService.StartFullKLineSync(days, adjust)
+}
+
+// ResumeKLineSync 续传中断的K线同步任务（只处理未完成或失败的股票）
+func (a *App) ResumeKLineSync(jobID string) (interface{}, error) {
+	if a.klineSyncService == nil {
+		return nil, fmt.Errorf("K线同步服务未初始化")
+	}
+	return a.klineSyncService.ResumeKLineSync(jobID)
+}
+
+// GetKLineSyncConcurrency 获取K线同步的抓取并发数
+func (a *App) GetKLineSyncConcurrency() (int, error) {
+	if a.klineSyncService == nil {
+		return 0, fmt.Errorf("K线同步服务未初始化")
+	}
+	return a.klineSyncService.Concurrency(), nil
+}
+
+// SetKLineSyncConcurrency 设置K线同步的抓取并发数（对之后开始的同步生效）并持久化
+func (a *App) SetKLineSyncConcurrency(n int) error {
+	if a.klineSyncService == nil {
+		return fmt.Errorf("K线同步服务未初始化")
+	}
+	a.klineSyncService.SetConcurrency(n)
+	if a.configService == nil {
+		return fmt.Errorf("配置服务未初始化")
+	}
+	return a.configService.SetKLineSyncConcurrency(a.klineSyncService.Concurrency())
 }
 
 // GetKLineSyncProgress 获取K线同步进度
@@ -1539,6 +1916,30 @@ func (a *App) GetKLineSyncHistory(limit int) (interface{}, error) {
 	return a.klineSyncService.GetKLineSyncHistory(limit)
 }
 
+// AuditDataQuality 检查单只股票缓存的日K线与资金流历史
+func (a *App) AuditDataQuality(code string) (*services.DataQualityReport, error) {
+	if a.dataQuality == nil {
+		return nil, fmt.Errorf("数据质量服务未初始化")
+	}
+	return a.dataQuality.AuditStock(code)
+}
+
+// AuditAllDataQuality 检查所有已同步 K 线的股票，只返回有问题的股票
+func (a *App) AuditAllDataQuality() (*services.DataQualityOverview, error) {
+	if a.dataQuality == nil {
+		return nil, fmt.Errorf("数据质量服务未初始化")
+	}
+	return a.dataQuality.AuditAll()
+}
+
+// ResyncDataQualityIssues 按检查报告重新同步单只股票有问题的日期范围
+func (a *App) ResyncDataQualityIssues(code string) (*services.DataQualityResyncResult, error) {
+	if a.dataQuality == nil {
+		return nil, fmt.Errorf("数据质量服务未初始化")
+	}
+	return a.dataQuality.ResyncIssues(code)
+}
+
 // StartFullMarketSync 启动全市场资金流同步
 func (a *App) StartFullMarketSync() error {
 	if a.syncService == nil {
@@ -1547,6 +1948,54 @@ func (a *App) StartFullMarketSync() error {
 	return a.syncService.StartFullMarketSync()
 }
 
+// ResumeFullMarketSync 续传中断的全市场资金流同步任务（只处理未完成或失败的股票）
+func (a *App) ResumeFullMarketSync(jobID string) error {
+	if a.syncService == nil {
+		return fmt.Errorf("全量同步服务未初始化")
+	}
+	return a.syncService.ResumeFullMarketSync(jobID)
+}
+
+// ListSyncJobs 列出最近的同步任务（kind 为 kline/money_flow，空值不限）
+func (a *App) ListSyncJobs(kind string, limit int) ([]models.SyncJobEntity, error) {
+	if a.syncJobs == nil {
+		return nil, fmt.Errorf("同步任务服务未初始化")
+	}
+	return a.syncJobs.List(kind, limit)
+}
+
+// ListJobs 列出运行中和最近结束的长任务（同步、扫描）
+func (a *App) ListJobs() ([]services.JobInfo, error) {
+	if a.jobManager == nil {
+		return nil, fmt.Errorf("任务管理器未初始化")
+	}
+	return a.jobManager.List(), nil
+}
+
+// PauseJob 暂停任务（正在处理的股票完成后暂停）
+func (a *App) PauseJob(id string) error {
+	if a.jobManager == nil {
+		return fmt.Errorf("任务管理器未初始化")
+	}
+	return a.jobManager.Pause(id)
+}
+
+// ResumeJob 继续已暂停的任务
+func (a *App) ResumeJob(id string) error {
+	if a.jobManager == nil {
+		return fmt.Errorf("任务管理器未初始化")
+	}
+	return a.jobManager.Resume(id)
+}
+
+// CancelJob 取消任务，已完成的部分保留；同步任务之后可以续传
+func (a *App) CancelJob(id string) error {
+	if a.jobManager == nil {
+		return fmt.Errorf("任务管理器未初始化")
+	}
+	return a.jobManager.Cancel(id)
+}
+
 // ScanSingleStock 扫描单只股票 (支持按需同步)
 // 前端输入股票代码后调用此方法
 func (a *App) ScanSingleStock(code string) ([]models.StrategySignal, error) {
@@ -1556,3 +2005,193 @@ func (a *App) ScanSingleStock(code string) ([]models.StrategySignal, error) {
 	// 调用 syncService 的组合方法：同步 -> 扫描 -> 返回
 	return a.syncService.SyncAndScanSingleStock(code)
 }
+
+// ============ 备份与数据迁移 API ============
+
+// BackupDatabase 手动备份整个数据库到数据目录下的 backups 目录
+func (a *App) BackupDatabase() (*services.DatabaseBackup, error) {
+	if a.dbService == nil {
+		return nil, fmt.Errorf("数据库服务未初始化")
+	}
+	return a.dbService.Backup()
+}
+
+// ListDatabaseBackups 列出数据库备份（手动、迁移前、恢复前、导入前）
+func (a *App) ListDatabaseBackups() ([]services.DatabaseBackup, error) {
+	if a.dbService == nil {
+		return nil, fmt.Errorf("数据库服务未初始化")
+	}
+	return a.dbService.ListBackups()
+}
+
+// SelectDatabaseBackupFile 打开文件对话框选择要恢复的数据库备份，取消时返回空字符串
+func (a *App) SelectDatabaseBackupFile() (string, error) {
+	return runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:            "选择数据库备份",
+		DefaultDirectory: filepath.Join(services.GetAppDataDir(), "backups"),
+		Filters:          []runtime.FileFilter{{DisplayName: "数据库备份 (*.bak;*.db)", Pattern: "*.bak;*.db"}},
+	})
+}
+
+// RestoreDatabase 校验并暂存数据库备份，重启应用后生效（当前数据库会先另存为 pre-restore 备份）
+func (a *App) RestoreDatabase(path string) error {
+	if a.dbService == nil {
+		return fmt.Errorf("数据库服务未初始化")
+	}
+	if path == "" {
+		return fmt.Errorf("备份文件不能为空")
+	}
+	return a.dbService.StageRestore(path)
+}
+
+// HasPendingDatabaseRestore 是否有等待重启后恢复的数据库
+func (a *App) HasPendingDatabaseRestore() bool {
+	return a.dbService != nil && a.dbService.HasPendingRestore()
+}
+
+// ExportUserData 选择保存位置并导出用户数据（自选股、预警、模板、持仓、策略、设置），取消时返回空字符串。
+// includeSecrets 为 true 时才包含 API Key。
+func (a *App) ExportUserData(includeSecrets bool) (string, error) {
+	if a.userData == nil {
+		return "", fmt.Errorf("用户数据服务未初始化")
+	}
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "导出用户数据",
+		DefaultFilename: fmt.Sprintf("stock-analyzer-%s.json", time.Now().Format("20060102")),
+		Filters:         []runtime.FileFilter{{DisplayName: "JSON (*.json)", Pattern: "*.json"}},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	if _, err := a.userData.ExportToFile(path, includeSecrets); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// SelectUserDataArchive 打开文件对话框选择要导入的用户数据存档，取消时返回空字符串
+func (a *App) SelectUserDataArchive() (string, error) {
+	return runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:   "导入用户数据",
+		Filters: []runtime.FileFilter{{DisplayName: "JSON (*.json)", Pattern: "*.json"}},
+	})
+}
+
+// PreviewUserDataImport 预览导入：按分区统计新增、相同与冲突的记录
+func (a *App) PreviewUserDataImport(path string) (*services.UserDataImportPreview, error) {
+	if a.userData == nil {
+		return nil, fmt.Errorf("用户数据服务未初始化")
+	}
+	archive, err := services.ReadUserDataArchive(path)
+	if err != nil {
+		return nil, err
+	}
+	return a.userData.PreviewImport(archive)
+}
+
+// ImportUserData 按分区导入用户数据，modes 的值为 skip / merge / replace
+func (a *App) ImportUserData(path string, modes map[string]string) (*services.UserDataImportResult, error) {
+	if a.userData == nil {
+		return nil, fmt.Errorf("用户数据服务未初始化")
+	}
+	archive, err := services.ReadUserDataArchive(path)
+	if err != nil {
+		return nil, err
+	}
+	result, err := a.userData.Import(archive, modes)
+	if err != nil {
+		return nil, err
+	}
+	if mode := modes[services.UserDataSettings]; mode == services.UserDataImportMerge || mode == services.UserDataImportReplace {
+		a.applyImportedSettings()
+	}
+	return result, nil
+}
+
+// applyImportedSettings 导入设置后让行情数据源、缓存、分时保存与 AI 配置立即生效
+func (a *App) applyImportedSettings() {
+	if a.configService == nil || a.stockService == nil {
+		return
+	}
+	if name, err := a.configService.GetMarketDataProvider(); err == nil {
+		if err := a.stockService.UseProvider(name); err != nil {
+			logger.Warn("导入的行情数据源配置无效，保持当前数据源",
+				zap.String("module", "app"),
+				zap.String("op", "applyImportedSettings"),
+				zap.String("provider", name),
+				zap.Error(err),
+			)
+		}
+	}
+	if ttl, err := a.configService.GetQuoteCacheTTL(); err == nil {
+		a.stockService.SetQuoteCacheTTL(ttl)
+	}
+	if enabled, err := a.configService.GetIntradayPersistence(); err == nil {
+		a.stockService.SetIntradayPersistence(enabled)
+	}
+	if n, err := a.configService.GetKLineSyncConcurrency(); err == nil && a.klineSyncService != nil {
+		a.klineSyncService.SetConcurrency(n)
+	}
+	if err := a.initAIService(); err != nil {
+		logger.Warn("导入设置后重新初始化 AI 服务失败", zap.Error(err))
+	}
+}
+
+// ============ 研究数据导出 API ============
+
+// SelectExportDirectory 打开目录对话框选择导出目录，取消时返回空字符串
+func (a *App) SelectExportDirectory() (string, error) {
+	return runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:                "选择导出目录",
+		CanCreateDirectories: true,
+	})
+}
+
+// ExportResearchData 将缓存的 K 线、资金流与策略信号导出为 CSV / Parquet，进度通过 researchExportProgress 事件推送
+func (a *App) ExportResearchData(req services.ResearchExportRequest) (*services.ResearchExportResult, error) {
+	if a.researchExport == nil {
+		return nil, fmt.Errorf("研究数据导出服务未初始化")
+	}
+	return a.researchExport.Export(req)
+}
+
+// CancelResearchExport 取消正在进行的研究数据导出
+func (a *App) CancelResearchExport() {
+	if a.researchExport != nil {
+		a.researchExport.Cancel()
+	}
+}
+
+// ============ 数据保留 API ============
+
+// GetRetentionPolicy 获取历史数据保留策略
+func (a *App) GetRetentionPolicy() (services.RetentionPolicy, error) {
+	if a.retention == nil {
+		return services.RetentionPolicy{}, fmt.Errorf("数据保留服务未初始化")
+	}
+	return a.retention.GetPolicy()
+}
+
+// SaveRetentionPolicy 保存历史数据保留策略
+func (a *App) SaveRetentionPolicy(policy services.RetentionPolicy) error {
+	if a.retention == nil {
+		return fmt.Errorf("数据保留服务未初始化")
+	}
+	return a.retention.SavePolicy(policy)
+}
+
+// PreviewRetention 预演：按当前策略统计将被清理的记录数，不修改数据
+func (a *App) PreviewRetention() (*services.RetentionReport, error) {
+	if a.retention == nil {
+		return nil, fmt.Errorf("数据保留服务未初始化")
+	}
+	return a.retention.Preview()
+}
+
+// RunRetention 立即按当前策略清理历史数据，并执行 VACUUM / ANALYZE
+func (a *App) RunRetention() (*services.RetentionReport, error) {
+	if a.retention == nil {
+		return nil, fmt.Errorf("数据保留服务未初始化")
+	}
+	return a.retention.Run()
+}
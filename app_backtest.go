@@ -7,28 +7,28 @@ import (
 
 // --- 回测功能 ---
 
-// BacktestSimpleMA 使用简单双均线策略
-func (a *App) BacktestSimpleMA(code string, shortPeriod int, longPeriod int, initialCapital float64, startDate string, endDate string) (*models.BacktestResult, error) {
+// BacktestSimpleMA 使用简单双均线策略（period 为 K 线周期，空值为日线；adjust 为复权方式，空值为前复权）
+func (a *App) BacktestSimpleMA(code string, shortPeriod int, longPeriod int, initialCapital float64, startDate string, endDate string, period string, adjust string) (*models.BacktestResult, error) {
 	if a.backtestService == nil {
 		return nil, fmt.Errorf("回测服务未初始化")
 	}
-	return a.backtestService.BacktestSimpleMA(code, shortPeriod, longPeriod, initialCapital, startDate, endDate)
+	return a.backtestService.BacktestSimpleMA(code, shortPeriod, longPeriod, initialCapital, startDate, endDate, period, adjust)
 }
 
-// BacktestMACD 使用MACD策略
-func (a *App) BacktestMACD(code string, fastPeriod int, slowPeriod int, signalPeriod int, initialCapital float64, startDate string, endDate string) (*models.BacktestResult, error) {
+// BacktestMACD 使用MACD策略（klinePeriod 为 K 线周期，空值为日线；adjust 为复权方式，空值为前复权）
+func (a *App) BacktestMACD(code string, fastPeriod int, slowPeriod int, signalPeriod int, initialCapital float64, startDate string, endDate string, klinePeriod string, adjust string) (*models.BacktestResult, error) {
 	if a.backtestService == nil {
 		return nil, fmt.Errorf("回测服务未初始化")
 	}
-	return a.backtestService.BacktestMACD(code, fastPeriod, slowPeriod, signalPeriod, initialCapital, startDate, endDate)
+	return a.backtestService.BacktestMACD(code, fastPeriod, slowPeriod, signalPeriod, initialCapital, startDate, endDate, klinePeriod, adjust)
 }
 
-// BacktestRSI 使用RSI策略
-func (a *App) BacktestRSI(code string, period int, buyThreshold float64, sellThreshold float64, initialCapital float64, startDate string, endDate string) (*models.BacktestResult, error) {
+// BacktestRSI 使用RSI策略（klinePeriod 为 K 线周期，空值为日线；adjust 为复权方式，空值为前复权）
+func (a *App) BacktestRSI(code string, period int, buyThreshold float64, sellThreshold float64, initialCapital float64, startDate string, endDate string, klinePeriod string, adjust string) (*models.BacktestResult, error) {
 	if a.backtestService == nil {
 		return nil, fmt.Errorf("回测服务未初始化")
 	}
-	return a.backtestService.BacktestRSI(code, period, buyThreshold, sellThreshold, initialCapital, startDate, endDate)
+	return a.backtestService.BacktestRSI(code, period, buyThreshold, sellThreshold, initialCapital, startDate, endDate, klinePeriod, adjust)
 }
 
 // BacktestDecisionPioneer 使用决策先锋策略
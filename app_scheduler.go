@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"stock-analyzer-wails/models"
+	"stock-analyzer-wails/services"
+)
+
+// scheduledKLineSyncDays 定时 K 线同步的窗口（天），已有缓存的股票只增量补齐
+const scheduledKLineSyncDays = 200
+
+// --- 定时任务 ---
+
+// registerScheduleSteps 注册定时任务各步骤的执行函数
+func (a *App) registerScheduleSteps(stockMarketSvc *services.StockMarketService) {
+	if a.scheduler == nil {
+		return
+	}
+
+	a.scheduler.RegisterStep(services.ScheduleStepStockList, func(time.Time) (string, error) {
+		result, err := stockMarketSvc.SyncAllStocks()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("股票列表 %d 只，新增 %d，更新 %d", result.Total, result.Inserted, result.Updated), nil
+	})
+
+	a.scheduler.RegisterStep(services.ScheduleStepKLineSync, func(time.Time) (string, error) {
+		if a.klineSyncService == nil {
+			return "", fmt.Errorf("K线同步服务未初始化")
+		}
+		// 已有缓存的股票沿用缓存的复权方式，新股票按前复权同步
+		result, err := a.klineSyncService.StartKLineSyncKeepingAdjust(scheduledKLineSyncDays, services.KLineAdjustForward)
+		if err != nil {
+			return "", err
+		}
+		if result.Cancelled {
+			return result.Message, fmt.Errorf("K线同步已取消")
+		}
+		return result.Message, nil
+	})
+
+	a.scheduler.RegisterStep(services.ScheduleStepMoneyFlowSync, func(time.Time) (string, error) {
+		if a.syncService == nil {
+			return "", fmt.Errorf("全量同步服务未初始化")
+		}
+		progress, err := a.syncService.RunFullMarketSync()
+		if err != nil {
+			return "", err
+		}
+		summary := fmt.Sprintf("全市场资金流同步 %d 只，成功 %d，失败 %d", progress.Current, progress.SuccessCount, progress.FailedCount)
+		if progress.Status == "cancelled" {
+			return summary, fmt.Errorf("全市场资金流同步已取消")
+		}
+		return summary, nil
+	})
+
+	a.scheduler.RegisterStep(services.ScheduleStepStrategyScan, func(time.Time) (string, error) {
+		// 扫描单独登记为任务，可在任务列表中暂停、取消；AI 验证交给后续步骤
+		result, err := a.runMassScan(a.jobManager.Begin(services.JobMassScan, ""), false)
+		if err != nil {
+			return "", err
+		}
+		summary := fmt.Sprintf("扫描 %v 只，发现 %v 个信号", result["scanned"], result["found"])
+		if result["status"] == services.JobCancelled {
+			return summary, fmt.Errorf("策略扫描已取消")
+		}
+		return summary, nil
+	})
+
+	a.scheduler.RegisterStep(services.ScheduleStepAIVerify, a.verifyLatestSignals)
+}
+
+// verifyLatestSignals 对计划时间所在（或之前最近）交易日尚未经过 AI 验证的信号逐个验证，等待全部完成。
+// 按计划时间而不是当前时间取交易日，跨日补跑时验证的仍是本次扫描产生的信号
+func (a *App) verifyLatestSignals(scheduledAt time.Time) (string, error) {
+	if a.aiService == nil {
+		return "", fmt.Errorf("AI 服务未初始化")
+	}
+	if a.strategyService == nil {
+		return "", fmt.Errorf("策略服务未初始化")
+	}
+	tradeDate := services.GetTradingCalendar().LatestTradingDay(scheduledAt).Format("2006-01-02")
+	signals, err := a.strategyService.GetSignalsByDateRange(tradeDate, tradeDate)
+	if err != nil {
+		return "", err
+	}
+
+	var pending []*models.StrategySignal
+	for i := range signals {
+		if signals[i].AIScore == 0 && signals[i].AIReason == "" {
+			pending = append(pending, &signals[i])
+		}
+	}
+	if len(pending) == 0 {
+		return fmt.Sprintf("%s 没有待验证的信号", tradeDate), nil
+	}
+	a.verifySignalsAsync(pending).Wait()
+	return fmt.Sprintf("%s 验证 %d 个信号", tradeDate, len(pending)), nil
+}
+
+// ListSchedules 列出定时任务及下一次执行时间
+func (a *App) ListSchedules() ([]services.Schedule, error) {
+	if a.scheduler == nil {
+		return nil, fmt.Errorf("定时任务服务未初始化")
+	}
+	return a.scheduler.ListSchedules()
+}
+
+// SaveSchedule 新建（ID 为 0）或更新定时任务
+func (a *App) SaveSchedule(schedule services.Schedule) (*services.Schedule, error) {
+	if a.scheduler == nil {
+		return nil, fmt.Errorf("定时任务服务未初始化")
+	}
+	return a.scheduler.SaveSchedule(schedule)
+}
+
+// DeleteSchedule 删除定时任务及其执行记录
+func (a *App) DeleteSchedule(id uint) error {
+	if a.scheduler == nil {
+		return fmt.Errorf("定时任务服务未初始化")
+	}
+	return a.scheduler.DeleteSchedule(id)
+}
+
+// RunScheduleNow 立即在后台执行定时任务
+func (a *App) RunScheduleNow(id uint) error {
+	if a.scheduler == nil {
+		return fmt.Errorf("定时任务服务未初始化")
+	}
+	return a.scheduler.RunNow(id)
+}
+
+// ListScheduleRuns 列出定时任务执行记录（scheduleID 为 0 时不限）
+func (a *App) ListScheduleRuns(scheduleID uint, limit int) ([]models.ScheduleRunEntity, error) {
+	if a.scheduler == nil {
+		return nil, fmt.Errorf("定时任务服务未初始化")
+	}
+	return a.scheduler.ListRuns(scheduleID, limit)
+}
@@ -43,9 +43,19 @@ func (c *StockMarketController) GetStocksList(page int, pageSize int, search str
 	}, nil
 }
 
-// GetIndustries 获取行业列表
-func (c *StockMarketController) GetIndustries() ([]services.IndustryInfo, error) {
-	return c.stockMarketService.GetIndustries()
+// GetIndustries 获取板块列表（industry 行业 / concept 概念）
+func (c *StockMarketController) GetIndustries(boardType string) ([]services.IndustryInfo, error) {
+	return c.stockMarketService.GetIndustries(boardType)
+}
+
+// SyncBoards 同步行业、概念板块及成分股
+func (c *StockMarketController) SyncBoards() (*services.SyncBoardsResult, error) {
+	return c.stockMarketService.SyncBoards()
+}
+
+// GetBoardMembers 获取板块成分股
+func (c *StockMarketController) GetBoardMembers(boardCode string) ([]services.StockMarketData, error) {
+	return c.stockMarketService.GetBoardMembers(boardCode)
 }
 
 // GetSyncStats 获取同步统计信息
@@ -30,6 +30,8 @@ const BacktestPanel: React.FC<BacktestPanelProps> = ({ stockCode }) => {
   const [rsiSellThreshold, setRsiSellThreshold] = useState<number>(70);
 
   const [initialCapital, setInitialCapital] = useState<number>(100000);
+  const [klinePeriod, setKlinePeriod] = useState<string>('daily');
+  const [klineAdjust, setKlineAdjust] = useState<string>('forward');
   const [startDate, setStartDate] = useState<string>('2023-01-01');
   const [endDate, setEndDate] = useState<string>('2023-12-31');
 
@@ -72,16 +74,16 @@ const BacktestPanel: React.FC<BacktestPanelProps> = ({ stockCode }) => {
       // 使用 activeStrategyType 判断策略类型
       if (activeStrategyType === 'macd') {
         // MACD策略回测
-        result = await BacktestMACD(stockCode, shortPeriod, longPeriod, signalPeriod, initialCapital, startDate, endDate);
+        result = await BacktestMACD(stockCode, shortPeriod, longPeriod, signalPeriod, initialCapital, startDate, endDate, klinePeriod, klineAdjust);
       } else if (activeStrategyType === 'rsi') {
         // RSI策略回测
-        result = await BacktestRSI(stockCode, rsiPeriod, rsiBuyThreshold, rsiSellThreshold, initialCapital, startDate, endDate);
+        result = await BacktestRSI(stockCode, rsiPeriod, rsiBuyThreshold, rsiSellThreshold, initialCapital, startDate, endDate, klinePeriod, klineAdjust);
       } else if (activeStrategyType === 'decision_pioneer') {
         // 决策先锋策略回测
         result = await BacktestDecisionPioneer(stockCode, initialCapital, startDate, endDate);
       } else {
         // 默认使用双均线策略回测
-        result = await BacktestSimpleMA(stockCode, shortPeriod, longPeriod, initialCapital, startDate, endDate);
+        result = await BacktestSimpleMA(stockCode, shortPeriod, longPeriod, initialCapital, startDate, endDate, klinePeriod, klineAdjust);
       }
       
       setBacktestResult(result);
@@ -449,6 +451,41 @@ const BacktestPanel: React.FC<BacktestPanelProps> = ({ stockCode }) => {
         )}
 
         {/* 通用参数 */}
+        {activeStrategyType !== 'decision_pioneer' && (
+          <div>
+            <label htmlFor="klinePeriod" className="block text-sm font-medium text-gray-300">K线周期:</label>
+            <select
+              id="klinePeriod"
+              value={klinePeriod}
+              onChange={(e) => setKlinePeriod(e.target.value)}
+              className="mt-1 block w-full rounded-md bg-gray-700 border-gray-600 text-gray-100 shadow-sm focus:border-blue-500 focus:ring-blue-500"
+            >
+              <option value="daily">日线</option>
+              <option value="week">周线</option>
+              <option value="month">月线</option>
+              <option value="60min">60分钟</option>
+              <option value="30min">30分钟</option>
+              <option value="15min">15分钟</option>
+              <option value="5min">5分钟</option>
+              <option value="1min">1分钟</option>
+            </select>
+          </div>
+        )}
+        {activeStrategyType !== 'decision_pioneer' && (
+          <div>
+            <label htmlFor="klineAdjust" className="block text-sm font-medium text-gray-300">复权方式:</label>
+            <select
+              id="klineAdjust"
+              value={klineAdjust}
+              onChange={(e) => setKlineAdjust(e.target.value)}
+              className="mt-1 block w-full rounded-md bg-gray-700 border-gray-600 text-gray-100 shadow-sm focus:border-blue-500 focus:ring-blue-500"
+            >
+              <option value="forward">前复权</option>
+              <option value="backward">后复权</option>
+              <option value="none">不复权</option>
+            </select>
+          </div>
+        )}
         <div>
           <label htmlFor="initialCapital" className="block text-sm font-medium text-gray-300">初始资金:</label>
           <input
@@ -55,7 +55,9 @@ const FinancialPanel: React.FC<FinancialPanelProps> = ({ financialSummary }) =>
           </div>
         ))}
       </div>
-      <p className="text-xs text-gray-500 mt-4">数据来源: 东方财富 (Placeholder)</p>
+      <p className="text-xs text-gray-500 mt-4">
+        数据来源: 东方财富{financialSummary.report_date ? `（报告期 ${financialSummary.report_date.slice(0, 10)}）` : ''}
+      </p>
     </div>
   )
 }
@@ -0,0 +1,100 @@
+import { useEffect, useState } from 'react'
+import { EventsOn } from '../../wailsjs/runtime/runtime'
+import { useWailsAPI } from '../hooks/useWailsAPI'
+import type { GatewayHostStatus } from '../types'
+
+const stateLabels: Record<GatewayHostStatus['state'], { text: string; className: string }> = {
+  closed: { text: '正常', className: 'bg-green-100 text-green-700' },
+  half_open: { text: '试探中', className: 'bg-yellow-100 text-yellow-700' },
+  open: { text: '已熔断', className: 'bg-red-100 text-red-700' },
+}
+
+const formatTime = (ms: number) => (ms ? new Date(ms).toLocaleTimeString('zh-CN', { hour12: false }) : '-')
+
+// GatewayHealthPanel 数据源限速与熔断面板：初次加载拉取列表，之后按 circuitBreaker 事件更新
+function GatewayHealthPanel() {
+  const { getGatewayStatus, resetCircuitBreaker } = useWailsAPI()
+  const [hosts, setHosts] = useState<Record<string, GatewayHostStatus>>({})
+
+  const load = () => {
+    getGatewayStatus()
+      .then((list) => {
+        const next: Record<string, GatewayHostStatus> = {}
+        ;(list || []).forEach((h) => {
+          next[h.host] = h
+        })
+        setHosts(next)
+      })
+      .catch((err) => console.error('获取数据源状态失败:', err))
+  }
+
+  useEffect(() => {
+    load()
+    const off = EventsOn('circuitBreaker', (h: GatewayHostStatus) => {
+      setHosts((prev) => ({ ...prev, [h.host]: h }))
+    })
+    return () => off()
+  }, [getGatewayStatus])
+
+  const handleReset = async (host: string) => {
+    try {
+      await resetCircuitBreaker(host)
+      load()
+    } catch (err) {
+      console.error('恢复熔断失败:', err)
+    }
+  }
+
+  const list = Object.values(hosts).sort((a, b) => a.host.localeCompare(b.host))
+
+  if (list.length === 0) {
+    return <p className="text-sm text-gray-500">尚未访问任何数据源。</p>
+  }
+
+  return (
+    <table className="w-full text-sm">
+      <thead>
+        <tr className="text-left text-gray-500 border-b">
+          <th className="py-2">主机</th>
+          <th>状态</th>
+          <th>限速</th>
+          <th>请求</th>
+          <th>失败</th>
+          <th>拒绝</th>
+          <th>最近错误</th>
+          <th></th>
+        </tr>
+      </thead>
+      <tbody>
+        {list.map((h) => {
+          const label = stateLabels[h.state] || stateLabels.closed
+          return (
+            <tr key={h.host} className="border-b last:border-0">
+              <td className="py-2 font-mono">{h.host}</td>
+              <td>
+                <span className={`px-2 py-0.5 rounded text-xs ${label.className}`}>{label.text}</span>
+                {h.state === 'open' && <span className="ml-1 text-xs text-gray-500">{formatTime(h.retryAt)} 后重试</span>}
+              </td>
+              <td>{h.rate > 0 ? `${h.rate}/秒` : '不限'}</td>
+              <td>{h.requests}</td>
+              <td>{h.consecutiveFailures > 0 ? `${h.failures}（连续 ${h.consecutiveFailures}）` : h.failures}</td>
+              <td>{h.rejected}</td>
+              <td className="text-xs text-red-600 max-w-xs truncate" title={h.lastError}>
+                {h.lastError ? `${formatTime(h.lastErrorAt)} ${h.lastError}` : '-'}
+              </td>
+              <td>
+                {h.state !== 'closed' && (
+                  <button onClick={() => handleReset(h.host)} className="text-xs text-blue-600 hover:underline">
+                    立即恢复
+                  </button>
+                )}
+              </td>
+            </tr>
+          )
+        })}
+      </tbody>
+    </table>
+  )
+}
+
+export default GatewayHealthPanel
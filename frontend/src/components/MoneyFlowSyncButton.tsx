@@ -1,20 +1,23 @@
 import React, { useState, useEffect } from 'react';
 import { useWailsAPI } from '../hooks/useWailsAPI';
 import { EventsOn, EventsOff } from '../../wailsjs/runtime/runtime';
-import { Database, Loader2, CheckCircle2, AlertCircle } from 'lucide-react';
+import { Database, Loader2, CheckCircle2, AlertCircle, Pause, Play, X } from 'lucide-react';
 
 const MoneyFlowSyncButton: React.FC = () => {
-  const { StartFullMarketSync } = useWailsAPI();
+  const { StartFullMarketSync, pauseJob, resumeJob, cancelJob } = useWailsAPI();
   const [syncing, setSyncing] = useState(false);
   const [progress, setProgress] = useState({ current: 0, total: 0, currentStock: '', success: 0, failed: 0 });
-  const [status, setStatus] = useState<'idle' | 'syncing' | 'completed' | 'error'>('idle');
+  const [status, setStatus] = useState<'idle' | 'syncing' | 'completed' | 'cancelled' | 'error'>('idle');
   const [errorMessage, setErrorMessage] = useState('');
+  const [jobId, setJobId] = useState('');
+  const [paused, setPaused] = useState(false);
 
   useEffect(() => {
     const onProgress = (data: any) => {
       // console.log('Sync progress:', data);
       setSyncing(true);
       setStatus('syncing');
+      setJobId(data.jobId || '');
       setProgress({
         current: data.current,
         total: data.total,
@@ -27,6 +30,11 @@ const MoneyFlowSyncButton: React.FC = () => {
         setSyncing(false);
         setStatus('completed');
         setTimeout(() => setStatus('idle'), 5000);
+      } else if (data.status === 'cancelled') {
+        setSyncing(false);
+        setPaused(false);
+        setStatus('cancelled');
+        setTimeout(() => setStatus('idle'), 5000);
       } else if (data.status === 'error') {
         setSyncing(false);
         setStatus('error');
@@ -35,13 +43,43 @@ const MoneyFlowSyncButton: React.FC = () => {
       }
     };
 
+    const onJobStatus = (job: any) => {
+      if (job.kind === 'money_flow_sync') {
+        setPaused(job.status === 'paused');
+      }
+    };
+
     EventsOn('sync_progress', onProgress);
+    EventsOn('jobStatus', onJobStatus);
 
     return () => {
       EventsOff('sync_progress');
+      EventsOff('jobStatus');
     };
   }, []);
 
+  const handlePauseResume = async () => {
+    if (!jobId) return;
+    try {
+      if (paused) {
+        await resumeJob(jobId);
+      } else {
+        await pauseJob(jobId);
+      }
+    } catch (e) {
+      console.error('Failed to pause/resume sync:', e);
+    }
+  };
+
+  const handleCancel = async () => {
+    if (!jobId) return;
+    try {
+      await cancelJob(jobId);
+    } catch (e) {
+      console.error('Failed to cancel sync:', e);
+    }
+  };
+
   const handleSync = async () => {
     if (syncing) return;
     try {
@@ -57,10 +95,28 @@ const MoneyFlowSyncButton: React.FC = () => {
     const percent = progress.total > 0 ? Math.round((progress.current / progress.total) * 100) : 0;
     return (
       <div className="flex flex-col items-end gap-1">
-        <button disabled className="flex items-center gap-2 bg-purple-600/50 text-white/80 px-4 py-2 rounded-lg cursor-not-allowed border border-purple-500/30">
-          <Loader2 className="w-4 h-4 animate-spin" />
-          <span className="font-mono text-sm">{percent}%</span>
-        </button>
+        <div className="flex items-center gap-1">
+          <button disabled className="flex items-center gap-2 bg-purple-600/50 text-white/80 px-4 py-2 rounded-lg cursor-not-allowed border border-purple-500/30">
+            {paused ? <Pause className="w-4 h-4" /> : <Loader2 className="w-4 h-4 animate-spin" />}
+            <span className="font-mono text-sm">{paused ? '已暂停' : `${percent}%`}</span>
+          </button>
+          <button
+            onClick={handlePauseResume}
+            disabled={!jobId}
+            className="p-2 rounded-lg bg-slate-700 hover:bg-slate-600 text-white disabled:opacity-50"
+            title={paused ? '继续' : '暂停'}
+          >
+            {paused ? <Play className="w-4 h-4" /> : <Pause className="w-4 h-4" />}
+          </button>
+          <button
+            onClick={handleCancel}
+            disabled={!jobId}
+            className="p-2 rounded-lg bg-slate-700 hover:bg-red-600 text-white disabled:opacity-50"
+            title="取消"
+          >
+            <X className="w-4 h-4" />
+          </button>
+        </div>
         <span className="text-[10px] text-gray-400 font-mono">
           {progress.current}/{progress.total} | {progress.currentStock}
         </span>
@@ -77,6 +133,18 @@ const MoneyFlowSyncButton: React.FC = () => {
     );
   }
 
+  if (status === 'cancelled') {
+    return (
+      <button
+        className="flex items-center gap-2 bg-slate-600 text-white px-4 py-2 rounded-lg"
+        title="已完成的股票已保存，可在同步任务列表中续传"
+      >
+        <X className="w-4 h-4" />
+        <span>已取消 {progress.success}/{progress.total}</span>
+      </button>
+    );
+  }
+
   if (status === 'error') {
     return (
       <button 
@@ -1,14 +1,16 @@
 import React, { useState, useEffect } from 'react';
 import { useWailsAPI } from '../hooks/useWailsAPI';
 import { EventsOn, EventsOff } from '../../wailsjs/runtime/runtime';
-import { Radar, Loader2, CheckCircle2, AlertCircle } from 'lucide-react';
+import { Radar, Loader2, CheckCircle2, AlertCircle, Pause, Play, X } from 'lucide-react';
 
 const ScanButton: React.FC = () => {
-  const { StartMassScan } = useWailsAPI();
+  const { StartMassScan, pauseJob, resumeJob, cancelJob } = useWailsAPI();
   const [scanning, setScanning] = useState(false);
   const [progress, setProgress] = useState({ current: 0, total: 0, found: 0 });
-  const [status, setStatus] = useState<'idle' | 'scanning' | 'completed' | 'error'>('idle');
+  const [status, setStatus] = useState<'idle' | 'scanning' | 'completed' | 'cancelled' | 'error'>('idle');
   const [errorMessage, setErrorMessage] = useState('');
+  const [jobId, setJobId] = useState('');
+  const [paused, setPaused] = useState(false);
 
   useEffect(() => {
     // 监听扫描开始
@@ -16,6 +18,8 @@ const ScanButton: React.FC = () => {
       console.log('Scan started:', data);
       setScanning(true);
       setStatus('scanning');
+      setPaused(false);
+      if (data.jobId) setJobId(data.jobId);
       setProgress({ current: 0, total: data.total || 0, found: 0 });
     };
 
@@ -34,8 +38,9 @@ const ScanButton: React.FC = () => {
     const onComplete = (data: any) => {
       console.log('Scan complete:', data);
       setScanning(false);
-      setStatus('completed');
-      setProgress(prev => ({ ...prev, found: data.found }));
+      setPaused(false);
+      setStatus(data.status === 'cancelled' ? 'cancelled' : 'completed');
+      setProgress(prev => ({ ...prev, current: data.scanned ?? prev.current, found: data.found }));
       
       // 3秒后重置为空闲状态
       setTimeout(() => setStatus('idle'), 3000);
@@ -52,7 +57,15 @@ const ScanButton: React.FC = () => {
       setTimeout(() => setStatus('idle'), 3000);
     };
 
+    // 监听暂停/继续
+    const onJobStatus = (job: any) => {
+      if (job.kind === 'mass_scan') {
+        setPaused(job.status === 'paused');
+      }
+    };
+
     EventsOn('scan_start', onStart);
+    EventsOn('jobStatus', onJobStatus);
     EventsOn('scan_progress', onProgress);
     EventsOn('scan_complete', onComplete);
     EventsOn('scan_error', onError);
@@ -62,27 +75,69 @@ const ScanButton: React.FC = () => {
       EventsOff('scan_progress');
       EventsOff('scan_complete');
       EventsOff('scan_error');
+      EventsOff('jobStatus');
     };
   }, []);
 
   const handleScan = async () => {
     if (scanning) return;
     try {
-      await StartMassScan();
+      const id = await StartMassScan();
+      setJobId(id);
     } catch (e) {
       console.error('Failed to start scan:', e);
       setStatus('error');
     }
   };
 
+  const handlePauseResume = async () => {
+    if (!jobId) return;
+    try {
+      if (paused) {
+        await resumeJob(jobId);
+      } else {
+        await pauseJob(jobId);
+      }
+    } catch (e) {
+      console.error('Failed to pause/resume scan:', e);
+    }
+  };
+
+  const handleCancel = async () => {
+    if (!jobId) return;
+    try {
+      await cancelJob(jobId);
+    } catch (e) {
+      console.error('Failed to cancel scan:', e);
+    }
+  };
+
   if (status === 'scanning') {
     const percent = progress.total > 0 ? Math.round((progress.current / progress.total) * 100) : 0;
     return (
       <div className="flex flex-col items-end gap-1">
-        <button disabled className="flex items-center gap-2 bg-blue-600/50 text-white/80 px-4 py-2 rounded-lg cursor-not-allowed border border-blue-500/30">
-          <Loader2 className="w-4 h-4 animate-spin" />
-          <span className="font-mono text-sm">{percent}%</span>
-        </button>
+        <div className="flex items-center gap-1">
+          <button disabled className="flex items-center gap-2 bg-blue-600/50 text-white/80 px-4 py-2 rounded-lg cursor-not-allowed border border-blue-500/30">
+            {paused ? <Pause className="w-4 h-4" /> : <Loader2 className="w-4 h-4 animate-spin" />}
+            <span className="font-mono text-sm">{paused ? '已暂停' : `${percent}%`}</span>
+          </button>
+          <button
+            onClick={handlePauseResume}
+            disabled={!jobId}
+            className="p-2 rounded-lg bg-slate-700 hover:bg-slate-600 text-white disabled:opacity-50"
+            title={paused ? '继续' : '暂停'}
+          >
+            {paused ? <Play className="w-4 h-4" /> : <Pause className="w-4 h-4" />}
+          </button>
+          <button
+            onClick={handleCancel}
+            disabled={!jobId}
+            className="p-2 rounded-lg bg-slate-700 hover:bg-red-600 text-white disabled:opacity-50"
+            title="取消"
+          >
+            <X className="w-4 h-4" />
+          </button>
+        </div>
         <span className="text-[10px] text-gray-400 font-mono">
           正在扫描 {progress.current}/{progress.total}...
         </span>
@@ -99,6 +154,15 @@ const ScanButton: React.FC = () => {
     );
   }
 
+  if (status === 'cancelled') {
+    return (
+      <button className="flex items-center gap-2 bg-slate-600 text-white px-4 py-2 rounded-lg">
+        <X className="w-4 h-4" />
+        <span>已取消 ({progress.current}/{progress.total}，发现 {progress.found})</span>
+      </button>
+    );
+  }
+
   if (status === 'error') {
     return (
       <button 
@@ -1,6 +1,8 @@
 import { useState, useEffect } from 'react'
 import { useWailsAPI } from '../hooks/useWailsAPI'
 import type { AppConfig } from '../types'
+import StreamHealthPanel from './StreamHealthPanel'
+import GatewayHealthPanel from './GatewayHealthPanel'
 
 interface SettingsProps {
   onConfigSaved?: () => void
@@ -288,6 +290,20 @@ function Settings({ onConfigSaved }: SettingsProps) {
         </div>
       </div>
 
+      <div className="mt-8 border-t pt-8">
+        <h2 className="text-2xl font-bold text-gray-800 mb-6 flex items-center">
+          <span className="mr-2">📡</span> 实时连接状态
+        </h2>
+        <StreamHealthPanel />
+      </div>
+
+      <div className="mt-8 border-t pt-8">
+        <h2 className="text-2xl font-bold text-gray-800 mb-6 flex items-center">
+          <span className="mr-2">🛡️</span> 数据源限速与熔断
+        </h2>
+        <GatewayHealthPanel />
+      </div>
+
       <div className="mt-8 p-4 bg-blue-50 rounded-lg border border-blue-100">
         <h3 className="text-sm font-semibold text-blue-800 mb-2">💡 提示</h3>
         <ul className="text-xs text-blue-700 space-y-1 list-disc pl-4">
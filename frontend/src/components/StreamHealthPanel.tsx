@@ -0,0 +1,88 @@
+import { useEffect, useState } from 'react'
+import { EventsOn } from '../../wailsjs/runtime/runtime'
+import { useWailsAPI } from '../hooks/useWailsAPI'
+import type { StreamHealth } from '../types'
+
+const stateLabels: Record<StreamHealth['state'], { text: string; className: string }> = {
+  connecting: { text: '连接中', className: 'bg-yellow-100 text-yellow-700' },
+  streaming: { text: '推送中', className: 'bg-green-100 text-green-700' },
+  reconnecting: { text: '等待重连', className: 'bg-red-100 text-red-700' },
+  stopped: { text: '已停止', className: 'bg-gray-100 text-gray-500' },
+}
+
+const formatTime = (ms: number) => (ms ? new Date(ms).toLocaleTimeString('zh-CN', { hour12: false }) : '-')
+
+// StreamHealthPanel 实时分时推送连接健康面板：初次加载拉取列表，之后按 intradayStreamStatus 事件更新
+function StreamHealthPanel() {
+  const { getStreamHealth } = useWailsAPI()
+  const [streams, setStreams] = useState<Record<string, StreamHealth>>({})
+
+  useEffect(() => {
+    getStreamHealth()
+      .then((list) => {
+        const next: Record<string, StreamHealth> = {}
+        ;(list || []).forEach((h) => {
+          next[h.code] = h
+        })
+        setStreams(next)
+      })
+      .catch((err) => console.error('获取连接状态失败:', err))
+
+    const off = EventsOn('intradayStreamStatus', (h: StreamHealth) => {
+      setStreams((prev) => {
+        const next = { ...prev }
+        if (h.state === 'stopped') {
+          delete next[h.code]
+        } else {
+          next[h.code] = h
+        }
+        return next
+      })
+    })
+    return () => off()
+  }, [getStreamHealth])
+
+  const list = Object.values(streams).sort((a, b) => a.code.localeCompare(b.code))
+
+  if (list.length === 0) {
+    return <p className="text-sm text-gray-500">当前没有活跃的实时推送连接。</p>
+  }
+
+  return (
+    <table className="w-full text-sm">
+      <thead>
+        <tr className="text-left text-gray-500 border-b">
+          <th className="py-2">代码</th>
+          <th>状态</th>
+          <th>订阅</th>
+          <th>重连</th>
+          <th>错误</th>
+          <th>最近推送</th>
+          <th>最近错误</th>
+        </tr>
+      </thead>
+      <tbody>
+        {list.map((h) => {
+          const label = stateLabels[h.state] || stateLabels.stopped
+          return (
+            <tr key={h.code} className="border-b last:border-0">
+              <td className="py-2 font-mono">{h.code}</td>
+              <td>
+                <span className={`px-2 py-0.5 rounded text-xs ${label.className}`}>{label.text}</span>
+              </td>
+              <td>{h.subscribers}</td>
+              <td>{h.reconnects}</td>
+              <td>{h.consecutiveErrors > 0 ? `${h.errors}（连续 ${h.consecutiveErrors}）` : h.errors}</td>
+              <td>{formatTime(h.lastFrameAt)}</td>
+              <td className="text-xs text-red-600 max-w-xs truncate" title={h.lastError}>
+                {h.lastError ? `${formatTime(h.lastErrorAt)} ${h.lastError}` : '-'}
+              </td>
+            </tr>
+          )
+        })}
+      </tbody>
+    </table>
+  )
+}
+
+export default StreamHealthPanel
@@ -4,7 +4,7 @@ import type { StockData } from '../types'
 import BatchAnalyzeModal from './BatchAnalyzeModal'
 import { Brain } from 'lucide-react'
 import PositionMonitor from './PositionMonitor'
-import { EventsOn, EventsOff } from '../../wailsjs/runtime/runtime'
+import { EventsOn } from '../../wailsjs/runtime/runtime'
 
 interface WatchlistProps {
   onSelectStock: (code: string) => void
@@ -17,7 +17,8 @@ function Watchlist({ onSelectStock, refreshTrigger }: WatchlistProps) {
   const [isBatchModalOpen, setIsBatchModalOpen] = useState(false)
   const [positions, setPositions] = useState<Record<string, any>>({})
   const { getWatchlist, removeFromWatchlist, batchAnalyzeStocks, getPositions, streamIntradayData, stopIntradayStream } = useWailsAPI()
-  const handlersRef = useRef<Record<string, (payload: string[]) => void>>({})
+  // code -> 取消监听函数（只移除本组件的监听，不影响详情页等其他订阅者）
+  const handlersRef = useRef<Record<string, () => void>>({})
 
   useEffect(() => {
     loadWatchlist()
@@ -58,21 +59,18 @@ function Watchlist({ onSelectStock, refreshTrigger }: WatchlistProps) {
           )
         )
       }
-      handlersRef.current[eventName] = handler
       streamIntradayData(stock.code)
-      EventsOn(eventName, handler)
+      handlersRef.current[stock.code] = EventsOn(eventName, handler)
     })
   }
 
   const cleanupSSE = () => {
-    const codes = Object.keys(handlersRef.current)
-    codes.forEach((eventName) => {
-      EventsOff(eventName)
+    // 后端按订阅计数，只有最后一个订阅者离开时才关闭上游连接
+    Object.entries(handlersRef.current).forEach(([code, off]) => {
+      off()
+      stopIntradayStream(code)
     })
     handlersRef.current = {}
-    stocks.forEach((s) => {
-      stopIntradayStream(s.code)
-    })
   }
 
   useEffect(() => {
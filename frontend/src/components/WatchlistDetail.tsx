@@ -2,7 +2,7 @@ import { useState, useEffect } from 'react'
 import ReactMarkdown from 'react-markdown'
 import remarkGfm from 'remark-gfm'
 import { StockData, KLineData, TechnicalAnalysisResult, IntradayData, MoneyFlowResponse, HealthCheckResult, EntryStrategyResult, StockDetail } from '../types'
-import { EventsOn, EventsOff } from '../../wailsjs/runtime/runtime'
+import { EventsOn } from '../../wailsjs/runtime/runtime'
 import { parseError } from '../utils/errorHandler'
 import { useWailsAPI } from '../hooks/useWailsAPI'
 import KLineChart from './KLineChart'
@@ -144,11 +144,12 @@ export default function WatchlistDetail({ stock }: WatchlistDetailProps) {
        }
     }
     
-    EventsOn("intradayDataUpdate:" + stock.code, handleIntradayUpdate)
+    // 只移除本组件的监听，自选列表可能仍在订阅同一代码
+    const offIntraday = EventsOn("intradayDataUpdate:" + stock.code, handleIntradayUpdate)
     
     return () => {
       mounted = false
-      EventsOff("intradayDataUpdate:" + stock.code)
+      offIntraday()
       stopIntradayStream(stock.code)
     }
   }, [stock.code, analyzeEntryStrategy, analyzeTechnical, getIntradayData, getKLineData, getMoneyFlowData, getStockDetail, getStockHealthCheck, stopIntradayStream, streamIntradayData])
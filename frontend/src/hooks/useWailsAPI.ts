@@ -1,5 +1,5 @@
 import { useCallback } from 'react'
-import type { StockData, AnalysisReport, AppConfig, KLineData, TechnicalAnalysisResult, IntradayResponse, MoneyFlowResponse, HealthCheckResult, EntryStrategyResult, StockDetail, BacktestResult, StrategySignal, SignalAnalysisResult } from '../types'
+import type { StockData, AnalysisReport, AppConfig, KLineData, TechnicalAnalysisResult, IntradayResponse, MoneyFlowResponse, HealthCheckResult, EntryStrategyResult, StockDetail, BacktestResult, StrategySignal, SignalAnalysisResult, StreamHealth, GatewayHostStatus, MarketStatus, DataQualityReport, DataQualityOverview, DataQualityResyncResult, DatabaseBackup, UserDataImportPreview, UserDataImportResult, UserDataSection, UserDataImportMode, ResearchExportRequest, ResearchExportResult, RetentionPolicy, RetentionReport, SyncJob, SyncJobKind, JobInfo, Schedule, ScheduleRun } from '../types'
 import { StreamIntradayData } from '../../wailsjs/go/main/App'
 import { StopIntradayStream as StopIntradayStreamAPI } from '../../wailsjs/go/main/App'
 
@@ -9,9 +9,9 @@ export const useWailsAPI = () => {
     return window.go.main.App.GetStockData(code)
   }, [])
 
-	  const getKLineData = useCallback(async (code: string, limit: number, period: string = 'daily'): Promise<KLineData[]> => {
+	  const getKLineData = useCallback(async (code: string, limit: number, period: string = 'daily', adjust: string = 'forward'): Promise<KLineData[]> => {
 	    // @ts-ignore
-	    return window.go.main.App.GetKLineData(code, limit, period)
+	    return window.go.main.App.GetKLineData(code, limit, period, adjust)
 	  }, [])
 	
 const getIntradayData = useCallback(async (code: string): Promise<IntradayResponse> => {
@@ -32,6 +32,201 @@ const getIntradayData = useCallback(async (code: string): Promise<IntradayRespon
     return StopIntradayStreamAPI(code)
   }, [])
 
+  const getStoredIntradayData = useCallback(async (code: string, tradeDate: string = ''): Promise<IntradayResponse> => {
+    // @ts-ignore
+    return window.go.main.App.GetStoredIntradayData(code, tradeDate)
+  }, [])
+
+  const setIntradayPersistence = useCallback(async (enabled: boolean): Promise<void> => {
+    // @ts-ignore
+    return window.go.main.App.SetIntradayPersistence(enabled)
+  }, [])
+
+  const getStreamHealth = useCallback(async (): Promise<StreamHealth[]> => {
+    // @ts-ignore
+    return window.go.main.App.GetStreamHealth()
+  }, [])
+
+  const getGatewayStatus = useCallback(async (): Promise<GatewayHostStatus[]> => {
+    // @ts-ignore
+    return window.go.main.App.GetGatewayStatus()
+  }, [])
+
+  const resetCircuitBreaker = useCallback(async (host: string): Promise<void> => {
+    // @ts-ignore
+    return window.go.main.App.ResetCircuitBreaker(host)
+  }, [])
+
+  const getMarketStatus = useCallback(async (): Promise<MarketStatus> => {
+    // @ts-ignore
+    return window.go.main.App.GetMarketStatus()
+  }, [])
+
+  const auditDataQuality = useCallback(async (code: string): Promise<DataQualityReport> => {
+    // @ts-ignore
+    return window.go.main.App.AuditDataQuality(code)
+  }, [])
+
+  const auditAllDataQuality = useCallback(async (): Promise<DataQualityOverview> => {
+    // @ts-ignore
+    return window.go.main.App.AuditAllDataQuality()
+  }, [])
+
+  const resyncDataQualityIssues = useCallback(async (code: string): Promise<DataQualityResyncResult> => {
+    // @ts-ignore
+    return window.go.main.App.ResyncDataQualityIssues(code)
+  }, [])
+
+  const backupDatabase = useCallback(async (): Promise<DatabaseBackup> => {
+    // @ts-ignore
+    return window.go.main.App.BackupDatabase()
+  }, [])
+
+  const listDatabaseBackups = useCallback(async (): Promise<DatabaseBackup[]> => {
+    // @ts-ignore
+    return window.go.main.App.ListDatabaseBackups()
+  }, [])
+
+  const selectDatabaseBackupFile = useCallback(async (): Promise<string> => {
+    // @ts-ignore
+    return window.go.main.App.SelectDatabaseBackupFile()
+  }, [])
+
+  const restoreDatabase = useCallback(async (path: string): Promise<void> => {
+    // @ts-ignore
+    return window.go.main.App.RestoreDatabase(path)
+  }, [])
+
+  const hasPendingDatabaseRestore = useCallback(async (): Promise<boolean> => {
+    // @ts-ignore
+    return window.go.main.App.HasPendingDatabaseRestore()
+  }, [])
+
+  const exportUserData = useCallback(async (includeSecrets: boolean): Promise<string> => {
+    // @ts-ignore
+    return window.go.main.App.ExportUserData(includeSecrets)
+  }, [])
+
+  const selectUserDataArchive = useCallback(async (): Promise<string> => {
+    // @ts-ignore
+    return window.go.main.App.SelectUserDataArchive()
+  }, [])
+
+  const previewUserDataImport = useCallback(async (path: string): Promise<UserDataImportPreview> => {
+    // @ts-ignore
+    return window.go.main.App.PreviewUserDataImport(path)
+  }, [])
+
+  const importUserData = useCallback(async (path: string, modes: Partial<Record<UserDataSection, UserDataImportMode>>): Promise<UserDataImportResult> => {
+    // @ts-ignore
+    return window.go.main.App.ImportUserData(path, modes)
+  }, [])
+
+  const selectExportDirectory = useCallback(async (): Promise<string> => {
+    // @ts-ignore
+    return window.go.main.App.SelectExportDirectory()
+  }, [])
+
+  const exportResearchData = useCallback(async (req: ResearchExportRequest): Promise<ResearchExportResult> => {
+    // @ts-ignore
+    return window.go.main.App.ExportResearchData(req)
+  }, [])
+
+  const cancelResearchExport = useCallback(async (): Promise<void> => {
+    // @ts-ignore
+    return window.go.main.App.CancelResearchExport()
+  }, [])
+
+  const getRetentionPolicy = useCallback(async (): Promise<RetentionPolicy> => {
+    // @ts-ignore
+    return window.go.main.App.GetRetentionPolicy()
+  }, [])
+
+  const saveRetentionPolicy = useCallback(async (policy: RetentionPolicy): Promise<void> => {
+    // @ts-ignore
+    return window.go.main.App.SaveRetentionPolicy(policy)
+  }, [])
+
+  const previewRetention = useCallback(async (): Promise<RetentionReport> => {
+    // @ts-ignore
+    return window.go.main.App.PreviewRetention()
+  }, [])
+
+  const runRetention = useCallback(async (): Promise<RetentionReport> => {
+    // @ts-ignore
+    return window.go.main.App.RunRetention()
+  }, [])
+
+  const listSyncJobs = useCallback(async (kind: SyncJobKind | '' = '', limit: number = 20): Promise<SyncJob[]> => {
+    // @ts-ignore
+    return window.go.main.App.ListSyncJobs(kind, limit)
+  }, [])
+
+  const resumeKLineSync = useCallback(async (jobId: string): Promise<any> => {
+    // @ts-ignore
+    return window.go.main.App.ResumeKLineSync(jobId)
+  }, [])
+
+  const getKLineSyncConcurrency = useCallback(async (): Promise<number> => {
+    // @ts-ignore
+    return window.go.main.App.GetKLineSyncConcurrency()
+  }, [])
+
+  const setKLineSyncConcurrency = useCallback(async (n: number): Promise<void> => {
+    // @ts-ignore
+    return window.go.main.App.SetKLineSyncConcurrency(n)
+  }, [])
+
+  const resumeFullMarketSync = useCallback(async (jobId: string): Promise<void> => {
+    // @ts-ignore
+    return window.go.main.App.ResumeFullMarketSync(jobId)
+  }, [])
+
+  const listJobs = useCallback(async (): Promise<JobInfo[]> => {
+    // @ts-ignore
+    return window.go.main.App.ListJobs()
+  }, [])
+
+  const pauseJob = useCallback(async (id: string): Promise<void> => {
+    // @ts-ignore
+    return window.go.main.App.PauseJob(id)
+  }, [])
+
+  const resumeJob = useCallback(async (id: string): Promise<void> => {
+    // @ts-ignore
+    return window.go.main.App.ResumeJob(id)
+  }, [])
+
+  const cancelJob = useCallback(async (id: string): Promise<void> => {
+    // @ts-ignore
+    return window.go.main.App.CancelJob(id)
+  }, [])
+
+  const listSchedules = useCallback(async (): Promise<Schedule[]> => {
+    // @ts-ignore
+    return window.go.main.App.ListSchedules()
+  }, [])
+
+  const saveSchedule = useCallback(async (schedule: Schedule): Promise<Schedule> => {
+    // @ts-ignore
+    return window.go.main.App.SaveSchedule(schedule)
+  }, [])
+
+  const deleteSchedule = useCallback(async (id: number): Promise<void> => {
+    // @ts-ignore
+    return window.go.main.App.DeleteSchedule(id)
+  }, [])
+
+  const runScheduleNow = useCallback(async (id: number): Promise<void> => {
+    // @ts-ignore
+    return window.go.main.App.RunScheduleNow(id)
+  }, [])
+
+  const listScheduleRuns = useCallback(async (scheduleId: number = 0, limit: number = 50): Promise<ScheduleRun[]> => {
+    // @ts-ignore
+    return window.go.main.App.ListScheduleRuns(scheduleId, limit)
+  }, [])
+
   const getStockDetail = useCallback(async (code: string): Promise<StockDetail> => {
     // @ts-ignore
     return window.go.main.App.GetStockDetail(code)
@@ -133,19 +328,19 @@ const getIntradayData = useCallback(async (code: string): Promise<IntradayRespon
     return window.go.main.App.RemovePosition(code)
   }, [])
 
-  const BacktestSimpleMA = useCallback(async (code: string, shortPeriod: number, longPeriod: number, initialCapital: number, startDate: string, endDate: string): Promise<BacktestResult> => {
+  const BacktestSimpleMA = useCallback(async (code: string, shortPeriod: number, longPeriod: number, initialCapital: number, startDate: string, endDate: string, period: string = 'daily', adjust: string = 'forward'): Promise<BacktestResult> => {
     // @ts-ignore
-    return window.go.main.App.BacktestSimpleMA(code, shortPeriod, longPeriod, initialCapital, startDate, endDate)
+    return window.go.main.App.BacktestSimpleMA(code, shortPeriod, longPeriod, initialCapital, startDate, endDate, period, adjust)
   }, [])
 
-  const BacktestMACD = useCallback(async (code: string, fastPeriod: number, slowPeriod: number, signalPeriod: number, initialCapital: number, startDate: string, endDate: string): Promise<BacktestResult> => {
+  const BacktestMACD = useCallback(async (code: string, fastPeriod: number, slowPeriod: number, signalPeriod: number, initialCapital: number, startDate: string, endDate: string, klinePeriod: string = 'daily', adjust: string = 'forward'): Promise<BacktestResult> => {
     // @ts-ignore
-    return window.go.main.App.BacktestMACD(code, fastPeriod, slowPeriod, signalPeriod, initialCapital, startDate, endDate)
+    return window.go.main.App.BacktestMACD(code, fastPeriod, slowPeriod, signalPeriod, initialCapital, startDate, endDate, klinePeriod, adjust)
   }, [])
 
-  const BacktestRSI = useCallback(async (code: string, period: number, buyThreshold: number, sellThreshold: number, initialCapital: number, startDate: string, endDate: string): Promise<BacktestResult> => {
+  const BacktestRSI = useCallback(async (code: string, period: number, buyThreshold: number, sellThreshold: number, initialCapital: number, startDate: string, endDate: string, klinePeriod: string = 'daily', adjust: string = 'forward'): Promise<BacktestResult> => {
     // @ts-ignore
-    return window.go.main.App.BacktestRSI(code, period, buyThreshold, sellThreshold, initialCapital, startDate, endDate)
+    return window.go.main.App.BacktestRSI(code, period, buyThreshold, sellThreshold, initialCapital, startDate, endDate, klinePeriod, adjust)
   }, [])
 
   const BacktestDecisionPioneer = useCallback(async (code: string, initialCapital: number, startDate: string, endDate: string): Promise<BacktestResult> => {
@@ -153,9 +348,9 @@ const getIntradayData = useCallback(async (code: string): Promise<IntradayRespon
     return window.go.main.App.BacktestDecisionPioneer(code, initialCapital, startDate, endDate)
   }, [])
 
-  const SyncStockData = useCallback(async (code: string, startDate: string, endDate: string): Promise<any> => {
+  const SyncStockData = useCallback(async (code: string, startDate: string, endDate: string, adjust: string = 'forward'): Promise<any> => {
     // @ts-ignore
-    return window.go.main.App.SyncStockData(code, startDate, endDate)
+    return window.go.main.App.SyncStockData(code, startDate, endDate, adjust)
   }, [])
 
   const GetDataSyncStats = useCallback(async (): Promise<any> => {
@@ -163,9 +358,9 @@ const getIntradayData = useCallback(async (code: string): Promise<IntradayRespon
     return window.go.main.App.GetDataSyncStats()
   }, [])
 
-  const BatchSyncStockData = useCallback(async (codes: string[], startDate: string, endDate: string): Promise<void> => {
+  const BatchSyncStockData = useCallback(async (codes: string[], startDate: string, endDate: string, adjust: string = 'forward'): Promise<void> => {
     // @ts-ignore
-    return window.go.main.App.BatchSyncStockData(codes, startDate, endDate)
+    return window.go.main.App.BatchSyncStockData(codes, startDate, endDate, adjust)
   }, [])
 
   const ClearStockCache = useCallback(async (code: string): Promise<void> => {
@@ -249,9 +444,19 @@ const getIntradayData = useCallback(async (code: string): Promise<IntradayRespon
     return window.go.main.App.GetStocksList(page, pageSize, search, industry)
   }, [])
 
-  const getIndustries = useCallback(async (): Promise<any> => {
+  const getIndustries = useCallback(async (boardType: string = 'industry'): Promise<any> => {
+    // @ts-ignore
+    return window.go.main.App.GetIndustries(boardType)
+  }, [])
+
+  const syncBoards = useCallback(async (): Promise<any> => {
+    // @ts-ignore
+    return window.go.main.App.SyncBoards()
+  }, [])
+
+  const getBoardMembers = useCallback(async (boardCode: string): Promise<any> => {
     // @ts-ignore
-    return window.go.main.App.GetIndustries()
+    return window.go.main.App.GetBoardMembers(boardCode)
   }, [])
 
   const getSyncStats = useCallback(async (): Promise<any> => {
@@ -368,7 +573,7 @@ const getIntradayData = useCallback(async (code: string): Promise<IntradayRespon
     return window.go.main.App.GetSignalsByStockCode(code)
   }, [])
 
-  const StartMassScan = useCallback(async (): Promise<void> => {
+  const StartMassScan = useCallback(async (): Promise<string> => {
     // @ts-ignore
     return window.go.main.App.StartMassScan()
   }, [])
@@ -398,6 +603,45 @@ const getIntradayData = useCallback(async (code: string): Promise<IntradayRespon
 		    getMoneyFlowData,
     streamIntradayData,
     stopIntradayStream,
+    getStoredIntradayData,
+    setIntradayPersistence,
+    getStreamHealth,
+    getGatewayStatus,
+    resetCircuitBreaker,
+    getMarketStatus,
+    auditDataQuality,
+    auditAllDataQuality,
+    resyncDataQualityIssues,
+    backupDatabase,
+    listDatabaseBackups,
+    selectDatabaseBackupFile,
+    restoreDatabase,
+    hasPendingDatabaseRestore,
+    exportUserData,
+    selectUserDataArchive,
+    previewUserDataImport,
+    importUserData,
+    selectExportDirectory,
+    exportResearchData,
+    cancelResearchExport,
+    getRetentionPolicy,
+    saveRetentionPolicy,
+    previewRetention,
+    runRetention,
+    listSyncJobs,
+    resumeKLineSync,
+    getKLineSyncConcurrency,
+    setKLineSyncConcurrency,
+    resumeFullMarketSync,
+    listJobs,
+    pauseJob,
+    resumeJob,
+    cancelJob,
+    listSchedules,
+    saveSchedule,
+    deleteSchedule,
+    runScheduleNow,
+    listScheduleRuns,
 		    getStockDetail,
 	    getStockHealthCheck,
     batchAnalyzeStocks,
@@ -437,6 +681,8 @@ const getIntradayData = useCallback(async (code: string): Promise<IntradayRespon
     syncAllStocks,
     getStocksList,
     getIndustries,
+    syncBoards,
+    getBoardMembers,
     getSyncStats,
     // Strategy Management API
     CreateStrategy,
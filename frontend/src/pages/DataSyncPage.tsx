@@ -1,6 +1,6 @@
 import React, { useState, useEffect } from 'react';
 import { parseError } from '../utils/errorHandler';
-import { Download, Trash2, RefreshCw, CheckCircle, AlertCircle, Clock } from 'lucide-react';
+import { Download, Trash2, RefreshCw, CheckCircle, AlertCircle, Clock, X } from 'lucide-react';
 
 interface SyncResult {
   stock_code: string;
@@ -12,6 +12,7 @@ interface SyncResult {
 }
 
 interface SyncProgress {
+  jobId?: string;
   stock_code: string;
   status: 'syncing' | 'completed' | 'failed';
   current_index: number;
@@ -116,7 +117,7 @@ const DataSyncPage: React.FC = () => {
 
     try {
       // @ts-ignore
-      const result = await window.go.main.App.SyncStockData(selectedStock.trim(), startDate, endDate);
+      const result = await window.go.main.App.SyncStockData(selectedStock.trim(), startDate, endDate, 'forward');
       setSyncResults([result]);
       setSyncLog((prev) => [...prev, `${selectedStock} 同步完成: ${result.message}`]);
       await loadSyncStats();
@@ -146,13 +147,13 @@ const DataSyncPage: React.FC = () => {
 
     try {
       // @ts-ignore
-      await window.go.main.App.BatchSyncStockData(codes, startDate, endDate);
+      await window.go.main.App.BatchSyncStockData(codes, startDate, endDate, 'forward');
       // 批量同步完成后，逐个获取结果
       const results: SyncResult[] = [];
       for (const code of codes) {
         try {
           // @ts-ignore
-          const result = await window.go.main.App.SyncStockData(code, startDate, endDate);
+          const result = await window.go.main.App.SyncStockData(code, startDate, endDate, 'forward');
           results.push(result);
         } catch (err) {
           results.push({
@@ -175,6 +176,18 @@ const DataSyncPage: React.FC = () => {
     }
   };
 
+  // 取消批量同步：当前股票完成后停止，已同步的数据保留
+  const handleCancelBatch = async () => {
+    if (!syncProgress?.jobId) return;
+    try {
+      // @ts-ignore
+      await window.go.main.App.CancelJob(syncProgress.jobId);
+      setSyncLog((prev) => [...prev, '已请求取消批量同步']);
+    } catch (err) {
+      setError(parseError(err).message);
+    }
+  };
+
   const handleClearCache = async (code: string) => {
     if (!window.confirm(`确定要清除 ${code} 的本地缓存数据吗？`)) {
       return;
@@ -225,7 +238,18 @@ const DataSyncPage: React.FC = () => {
         {/* 实时进度显示 */}
         {syncProgress && (
           <div className="bg-gray-800 rounded-lg shadow-lg p-6 mb-6">
-            <h2 className="text-xl font-bold mb-4">同步进度</h2>
+            <div className="flex items-center justify-between mb-4">
+              <h2 className="text-xl font-bold">同步进度</h2>
+              {loading && syncProgress.jobId && (
+                <button
+                  onClick={handleCancelBatch}
+                  className="flex items-center gap-1 px-3 py-1 text-sm bg-gray-700 hover:bg-red-600 rounded"
+                >
+                  <X className="w-4 h-4" />
+                  取消
+                </button>
+              )}
+            </div>
             <div className="space-y-4">
               <div>
                 <div className="flex justify-between mb-2">
@@ -1,18 +1,24 @@
 import React, { useState, useEffect } from 'react';
 import { parseError } from '../utils/errorHandler';
-import { RefreshCw, CheckCircle, AlertCircle, Clock, Zap, Database } from 'lucide-react';
+import { RefreshCw, CheckCircle, AlertCircle, Clock, Zap, Database, Pause, Play, X } from 'lucide-react';
+import type { SyncJob, JobInfo } from '../types';
 
 interface KLineSyncResult {
+  jobId: string;
   success: boolean;
+  cancelled: boolean;
   total_count: number;
   success_count: number;
   failed_count: number;
+  incrementalCount: number;
+  fullCount: number;
   total_records: number;
   duration: number;
   message: string;
 }
 
 interface KLineSyncProgress {
+  jobId: string;
   is_running: boolean;
   current_index: number;
   total_count: number;
@@ -32,6 +38,7 @@ interface KLineSyncHistory {
   stockCode: string;
   stockName: string;
   syncType: string;
+  syncMode: string;
   startDate: string;
   endDate: string;
   status: string;
@@ -44,15 +51,21 @@ interface KLineSyncHistory {
 
 const KLineSyncPage: React.FC = () => {
   const [days, setDays] = useState<number>(200);
+  const [fullSync, setFullSync] = useState<boolean>(false);
   const [syncProgress, setSyncProgress] = useState<KLineSyncProgress | null>(null);
   const [syncHistory, setSyncHistory] = useState<KLineSyncHistory[]>([]);
   const [syncLog, setSyncLog] = useState<string[]>([]);
   const [loading, setLoading] = useState<boolean>(false);
   const [error, setError] = useState<string | null>(null);
   const [syncResult, setSyncResult] = useState<KLineSyncResult | null>(null);
+  const [resumableJobs, setResumableJobs] = useState<SyncJob[]>([]);
+  const [paused, setPaused] = useState<boolean>(false);
+  const [concurrency, setConcurrency] = useState<number>(3);
 
   useEffect(() => {
     loadSyncHistory();
+    loadResumableJobs();
+    loadConcurrency();
 
     // 监听K线同步进度事件
     const EventsOn = (window as any).EventsOn;
@@ -78,11 +91,97 @@ const KLineSyncPage: React.FC = () => {
       }
     });
 
+    // 监听暂停/继续
+    const unsubscribeJobStatus = EventsOn('jobStatus', (job: JobInfo) => {
+      if (job.kind === 'kline_sync') {
+        setPaused(job.status === 'paused');
+      }
+    });
+
     return () => {
       if (unsubscribeProgress) unsubscribeProgress();
+      if (unsubscribeJobStatus) unsubscribeJobStatus();
     };
   }, []);
 
+  const handlePauseResume = async () => {
+    if (!syncProgress?.jobId) return;
+    try {
+      // @ts-ignore
+      await (paused ? window.go.main.App.ResumeJob(syncProgress.jobId) : window.go.main.App.PauseJob(syncProgress.jobId));
+    } catch (err) {
+      setError(parseError(err).message);
+    }
+  };
+
+  // 取消后已同步的股票保留，任务可在下方列表中续传
+  const handleCancelSync = async () => {
+    if (!syncProgress?.jobId) return;
+    try {
+      // @ts-ignore
+      await window.go.main.App.CancelJob(syncProgress.jobId);
+      setSyncLog((prev) => [...prev, '已请求取消，当前股票处理完后停止']);
+    } catch (err) {
+      setError(parseError(err).message);
+    }
+  };
+
+  // 中断或有失败股票的任务可以续传
+  const loadResumableJobs = async () => {
+    try {
+      // @ts-ignore
+      const jobs: SyncJob[] = await window.go.main.App.ListSyncJobs('kline', 10);
+      if (Array.isArray(jobs)) {
+        setResumableJobs(jobs.filter((job) => job.status !== 'running' && job.succeeded < job.total));
+      }
+    } catch (err) {
+      console.error('加载同步任务失败:', err);
+    }
+  };
+
+  const loadConcurrency = async () => {
+    try {
+      // @ts-ignore
+      const n: number = await window.go.main.App.GetKLineSyncConcurrency();
+      if (n > 0) setConcurrency(n);
+    } catch (err) {
+      console.error('加载并发数失败:', err);
+    }
+  };
+
+  // 并发数对之后开始的同步生效
+  const handleConcurrencyChange = async (n: number) => {
+    setConcurrency(n);
+    try {
+      // @ts-ignore
+      await window.go.main.App.SetKLineSyncConcurrency(n);
+    } catch (err) {
+      setError(parseError(err).message);
+    }
+  };
+
+  const handleResumeSync = async (jobId: string) => {
+    setLoading(true);
+    setError(null);
+    setSyncResult(null);
+    setSyncProgress(null);
+    setSyncLog([`续传K线同步任务 ${jobId}`]);
+
+    try {
+      // @ts-ignore
+      const result = await window.go.main.App.ResumeKLineSync(jobId);
+      setSyncResult(result as KLineSyncResult);
+      await loadSyncHistory();
+    } catch (err) {
+      const errorResult = parseError(err);
+      setError(errorResult.message);
+      setSyncLog((prev) => [...prev, `错误: ${errorResult.message}`]);
+      setLoading(false);
+    } finally {
+      await loadResumableJobs();
+    }
+  };
+
   const loadSyncHistory = async () => {
     try {
       // @ts-ignore
@@ -105,11 +204,14 @@ const KLineSyncPage: React.FC = () => {
     setError(null);
     setSyncResult(null);
     setSyncProgress(null);
-    setSyncLog([`开始K线数据同步，同步最近 ${days} 天的数据`]);
+    setSyncLog([`开始K线数据${fullSync ? '全量' : '增量'}同步，同步窗口为最近 ${days} 天`]);
 
     try {
-      // @ts-ignore
-      const result = await window.go.main.App.StartKLineSync(days);
+      const result = fullSync
+        // @ts-ignore
+        ? await window.go.main.App.StartFullKLineSync(days, 'forward')
+        // @ts-ignore
+        : await window.go.main.App.StartKLineSync(days, 'forward');
       setSyncResult(result as KLineSyncResult);
       setSyncLog((prev) => [...prev, '同步任务已启动']);
       await loadSyncHistory();
@@ -118,6 +220,8 @@ const KLineSyncPage: React.FC = () => {
       setError(errorResult.message);
       setSyncLog((prev) => [...prev, `错误: ${errorResult.message}`]);
       setLoading(false);
+    } finally {
+      await loadResumableJobs();
     }
   };
 
@@ -149,7 +253,7 @@ const KLineSyncPage: React.FC = () => {
             K线数据同步
           </h1>
           <p className="text-gray-400">
-            批量同步所有活跃股票的K线数据到本地数据库，顺序执行避免数据库锁定问题
+            批量同步所有活跃股票的K线数据到本地数据库，并发抓取、单一写入避免数据库锁定问题
           </p>
         </div>
 
@@ -166,11 +270,11 @@ const KLineSyncPage: React.FC = () => {
             </div>
             <div className="flex items-start gap-2">
               <CheckCircle className="w-4 h-4 text-green-400 mt-1 flex-shrink-0" />
-              <span>顺序执行每只股票同步，避免SQLite并发锁库问题</span>
+              <span>多只股票并发抓取，由单一写入协程分批提交事务，避免SQLite并发锁库问题</span>
             </div>
             <div className="flex items-start gap-2">
               <CheckCircle className="w-4 h-4 text-green-400 mt-1 flex-shrink-0" />
-              <span>随机延迟（200-500ms）模拟真人行为，防止IP被封</span>
+              <span>所有抓取共用限速器（每秒最多5只股票），防止IP被封</span>
             </div>
             <div className="flex items-start gap-2">
               <CheckCircle className="w-4 h-4 text-green-400 mt-1 flex-shrink-0" />
@@ -205,6 +309,28 @@ const KLineSyncPage: React.FC = () => {
                 className="w-full px-4 py-2 rounded-md bg-gray-700 border border-gray-600 text-gray-100 focus:outline-none focus:border-blue-500 focus:ring-1 focus:ring-blue-500"
               />
               <p className="text-xs text-gray-400 mt-1">建议：200天（约10个月数据）</p>
+              <label className="flex items-center gap-2 mt-2 text-sm text-gray-300">
+                <input
+                  type="checkbox"
+                  checked={fullSync}
+                  onChange={(e) => setFullSync(e.target.checked)}
+                />
+                全量同步（忽略本地缓存，默认只拉取最新缓存日期之后的数据）
+              </label>
+              <label htmlFor="concurrency" className="block text-sm font-medium text-gray-300 mt-3 mb-2">
+                抓取并发数
+              </label>
+              <select
+                id="concurrency"
+                value={concurrency}
+                onChange={(e) => handleConcurrencyChange(parseInt(e.target.value))}
+                disabled={syncProgress?.is_running}
+                className="w-full px-4 py-2 rounded-md bg-gray-700 border border-gray-600 text-gray-100 focus:outline-none focus:border-blue-500 focus:ring-1 focus:ring-blue-500"
+              >
+                {[1, 2, 3, 4, 5, 6, 7, 8].map((n) => (
+                  <option key={n} value={n}>{n}</option>
+                ))}
+              </select>
             </div>
             <div className="flex items-end">
               <button
@@ -219,13 +345,62 @@ const KLineSyncPage: React.FC = () => {
           </div>
         </div>
 
+        {/* 可续传的任务 */}
+        {resumableJobs.length > 0 && (
+          <div className="bg-gray-800 rounded-lg shadow-lg p-6 mb-6">
+            <h2 className="text-xl font-bold mb-4">未完成的同步任务</h2>
+            <div className="space-y-2">
+              {resumableJobs.map((job) => (
+                <div key={job.id} className="flex items-center justify-between bg-gray-900 rounded-md px-4 py-2 text-sm">
+                  <span className="text-gray-300">
+                    {job.createdAt.replace('T', ' ').slice(0, 19)}
+                    <span className="text-gray-400 ml-3">
+                      {job.status === 'interrupted' ? '已中断' : job.status === 'failed' ? '失败' : '部分失败'}
+                    </span>
+                    <span className="text-gray-400 ml-3">
+                      已完成 {job.succeeded}/{job.total}，失败 {job.failed}
+                    </span>
+                  </span>
+                  <button
+                    onClick={() => handleResumeSync(job.id)}
+                    disabled={loading || syncProgress?.is_running}
+                    className="px-3 py-1 bg-blue-600 hover:bg-blue-700 text-white rounded-md disabled:opacity-50 disabled:cursor-not-allowed"
+                  >
+                    续传
+                  </button>
+                </div>
+              ))}
+            </div>
+          </div>
+        )}
+
         {/* 实时进度显示 */}
         {syncProgress && (
           <div className="bg-gray-800 rounded-lg shadow-lg p-6 mb-6 border border-blue-600">
-            <h2 className="text-xl font-bold mb-4 flex items-center gap-2">
-              <Clock className="w-5 h-5 text-blue-400" />
-              同步进度
-            </h2>
+            <div className="flex items-center justify-between mb-4">
+              <h2 className="text-xl font-bold flex items-center gap-2">
+                <Clock className="w-5 h-5 text-blue-400" />
+                同步进度{paused && <span className="text-sm text-yellow-400">（已暂停）</span>}
+              </h2>
+              {syncProgress.is_running && syncProgress.jobId && (
+                <div className="flex gap-2">
+                  <button
+                    onClick={handlePauseResume}
+                    className="flex items-center gap-1 px-3 py-1 text-sm bg-gray-700 hover:bg-gray-600 rounded"
+                  >
+                    {paused ? <Play className="w-4 h-4" /> : <Pause className="w-4 h-4" />}
+                    {paused ? '继续' : '暂停'}
+                  </button>
+                  <button
+                    onClick={handleCancelSync}
+                    className="flex items-center gap-1 px-3 py-1 text-sm bg-gray-700 hover:bg-red-600 rounded"
+                  >
+                    <X className="w-4 h-4" />
+                    取消
+                  </button>
+                </div>
+              )}
+            </div>
             
             {/* 进度条 */}
             <div className="mb-6">
@@ -352,6 +527,7 @@ const KLineSyncPage: React.FC = () => {
                     <th className="text-left py-2 px-3 text-gray-400 font-medium">时间</th>
                     <th className="text-left py-2 px-3 text-gray-400 font-medium">股票</th>
                     <th className="text-left py-2 px-3 text-gray-400 font-medium">日期范围</th>
+                    <th className="text-left py-2 px-3 text-gray-400 font-medium">方式</th>
                     <th className="text-left py-2 px-3 text-gray-400 font-medium">状态</th>
                     <th className="text-left py-2 px-3 text-gray-400 font-medium">新增</th>
                     <th className="text-left py-2 px-3 text-gray-400 font-medium">更新</th>
@@ -370,6 +546,9 @@ const KLineSyncPage: React.FC = () => {
                       <td className="py-2 px-3 text-gray-400">
                         {history.startDate} ~ {history.endDate}
                       </td>
+                      <td className="py-2 px-3 text-gray-400">
+                        {history.syncMode === 'incremental' ? '增量' : history.syncMode === 'full' ? '全量' : '-'}
+                      </td>
                       <td className="py-2 px-3">
                         <span
                           className={`px-2 py-1 rounded text-xs font-medium ${
@@ -184,9 +184,9 @@ const PriceAlertPage: React.FC = () => {
     }
   };
 
-  // 股票代码自动查询
+  // 股票代码自动查询（支持 6 位代码，以及指数等带交易所前缀的代码，如 SH000001）
   const handleStockCodeBlur = async (code: string) => {
-    if (!code || code.length !== 6) {
+    if (!code || !/^((SH|SZ|BJ)\d{6}|\d{6})$/i.test(code.trim())) {
       return;
     }
 
@@ -4,7 +4,7 @@ import { useWailsAPI } from '../hooks/useWailsAPI';
 import { StockMarketData, SyncStocksResult } from '../types';
 
 const StockListPage: React.FC = () => {
-  const { getStocksList, syncAllStocks, getSyncStats, getIndustries } = useWailsAPI();
+  const { getStocksList, syncAllStocks, getSyncStats, getIndustries, syncBoards } = useWailsAPI();
 
   const [stocks, setStocks] = useState<StockMarketData[]>([]);
   const [total, setTotal] = useState<number>(0);
@@ -16,6 +16,8 @@ const StockListPage: React.FC = () => {
   const [syncResult, setSyncResult] = useState<SyncStocksResult | null>(null);
   const [lastSyncTime, setLastSyncTime] = useState<string>('-');
   const [industries, setIndustries] = useState<Array<{code: string, name: string}>>([]);
+  const [concepts, setConcepts] = useState<Array<{code: string, name: string}>>([]);
+  const [syncingBoards, setSyncingBoards] = useState<boolean>(false);
   const [selectedIndustry, setSelectedIndustry] = useState<string>('');
 
   // 加载股票列表
@@ -34,16 +36,35 @@ const StockListPage: React.FC = () => {
     }
   };
 
-  // 加载行业列表
+  // 加载行业与概念板块列表
   const loadIndustries = async () => {
     try {
-      const list = await getIndustries();
+      const list = await getIndustries('industry');
       setIndustries(list || []);
+      const conceptList = await getIndustries('concept');
+      setConcepts(conceptList || []);
     } catch (err) {
       console.error('加载行业列表失败:', err);
     }
   };
 
+  // 同步行业、概念板块及成分股
+  const handleSyncBoards = async () => {
+    setSyncingBoards(true);
+    try {
+      const result = await syncBoards();
+      if (result?.message) {
+        alert(result.message);
+      }
+      await loadIndustries();
+    } catch (err) {
+      console.error('同步板块失败:', err);
+      alert('同步板块失败，请查看控制台日志');
+    } finally {
+      setSyncingBoards(false);
+    }
+  };
+
   // 加载同步统计信息
   const loadSyncStats = async () => {
     try {
@@ -156,6 +177,18 @@ const StockListPage: React.FC = () => {
             <RefreshCw className={`w-4 h-4 ${syncing ? 'animate-spin' : ''}`} />
             {syncing ? '同步中...' : '同步数据'}
           </button>
+          <button
+            onClick={handleSyncBoards}
+            disabled={syncingBoards}
+            className={`flex items-center gap-2 px-4 py-2 rounded-md font-medium transition-colors ${
+              syncingBoards
+                ? 'bg-gray-600 text-gray-400 cursor-not-allowed'
+                : 'bg-gray-700 hover:bg-gray-600 text-white'
+            }`}
+          >
+            <RefreshCw className={`w-4 h-4 ${syncingBoards ? 'animate-spin' : ''}`} />
+            {syncingBoards ? '同步板块中...' : '同步板块'}
+          </button>
         </div>
       </div>
 
@@ -178,11 +211,22 @@ const StockListPage: React.FC = () => {
           className="px-4 py-2 bg-gray-800 border border-gray-700 rounded-lg text-gray-100 focus:outline-none focus:border-blue-500"
         >
           <option value="">所有行业</option>
-          {industries.map((ind) => (
-            <option key={ind.code} value={ind.name}>
-              {ind.name}
-            </option>
-          ))}
+          <optgroup label="行业">
+            {industries.map((ind) => (
+              <option key={ind.code} value={ind.name}>
+                {ind.name}
+              </option>
+            ))}
+          </optgroup>
+          {concepts.length > 0 && (
+            <optgroup label="概念">
+              {concepts.map((c) => (
+                <option key={c.code} value={c.code}>
+                  {c.name}
+                </option>
+              ))}
+            </optgroup>
+          )}
         </select>
 
         <div className="flex-1 relative">
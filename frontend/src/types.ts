@@ -75,6 +75,314 @@ export interface IntradayResponse {
   preClose: number
 }
 
+/**
+ * 分时推送连接健康状况（时间为毫秒时间戳，0 表示尚无）
+ */
+export interface StreamHealth {
+  code: string
+  provider: string
+  state: 'connecting' | 'streaming' | 'reconnecting' | 'stopped'
+  subscribers: number
+  reconnects: number
+  errors: number
+  consecutiveErrors: number
+  frames: number
+  lastError: string
+  lastErrorAt: number
+  lastFrameAt: number
+  startedAt: number
+  stateChangedAt: number
+}
+
+/**
+ * 数据源主机的限速与熔断状态（时间为毫秒时间戳，0 表示尚无）
+ */
+export interface GatewayHostStatus {
+  host: string
+  state: 'closed' | 'open' | 'half_open'
+  rate: number
+  burst: number
+  requests: number
+  failures: number
+  rejected: number
+  consecutiveFailures: number
+  waiting: number
+  lastError: string
+  lastErrorAt: number
+  openedAt: number
+  retryAt: number
+}
+
+/**
+ * 市场状态（交易日历）
+ */
+export interface MarketStatus {
+  date: string
+  time: string
+  isTradingDay: boolean
+  phase: 'closed' | 'call_auction' | 'continuous' | 'closing_auction' | 'break'
+  isOpen: boolean
+  prevTradingDay: string
+  nextTradingDay: string
+  covered: boolean
+}
+
+/**
+ * 缓存数据质量问题（相邻记录上的同类问题已合并）
+ */
+export interface DataQualityIssue {
+  series: 'kline' | 'money_flow'
+  type: 'missing_days' | 'non_trading_day' | 'duplicate_date' | 'non_positive' | 'invalid_ohlc' | 'volume_outlier' | 'close_mismatch'
+  severity: 'error' | 'warning'
+  startDate: string
+  endDate: string
+  count: number
+  detail: string
+}
+
+export interface DataQualityRange {
+  series: 'kline' | 'money_flow'
+  startDate: string
+  endDate: string
+}
+
+/**
+ * 单只股票的缓存数据质量报告
+ */
+export interface DataQualityReport {
+  code: string
+  checkedAt: string
+  klineBars: number
+  klineFrom: string
+  klineTo: string
+  moneyFlowDays: number
+  moneyFlowFrom: string
+  moneyFlowTo: string
+  errors: number
+  warnings: number
+  issues: DataQualityIssue[]
+  resyncRanges: DataQualityRange[]
+}
+
+export interface DataQualityOverview {
+  checked: number
+  withIssues: number
+  failed: number
+  reports: DataQualityReport[]
+  duration: number
+}
+
+export interface DataQualityResyncResult {
+  code: string
+  ranges: DataQualityRange[]
+  records: number
+  failures: string[]
+  report: DataQualityReport
+}
+
+export type DatabaseBackupKind = 'manual' | 'migration' | 'pre-restore' | 'pre-import'
+
+export interface DatabaseBackup {
+  name: string
+  path: string
+  kind: DatabaseBackupKind
+  size: number
+  createdAt: string
+}
+
+export type UserDataSection =
+  | 'watchlist'
+  | 'price_alerts'
+  | 'alert_templates'
+  | 'positions'
+  | 'strategy_configs'
+  | 'global_strategy_config'
+  | 'settings'
+
+export type UserDataImportMode = 'skip' | 'merge' | 'replace'
+
+export interface UserDataSectionPreview {
+  section: UserDataSection
+  total: number
+  local: number
+  new: number
+  identical: number
+  conflicts: number
+  conflictKeys: string[]
+}
+
+export interface UserDataImportPreview {
+  exportedAt: string
+  schemaVersion: number
+  includesSecrets: boolean
+  sections: UserDataSectionPreview[]
+}
+
+export interface UserDataSectionResult {
+  section: UserDataSection
+  mode: UserDataImportMode
+  deleted: number
+  written: number
+  kept: number
+}
+
+export interface UserDataImportResult {
+  backupPath: string
+  sections: UserDataSectionResult[]
+}
+
+export type ResearchDataset = 'kline' | 'money_flow' | 'signals'
+
+export interface ResearchExportRequest {
+  codes: string[]
+  startDate: string
+  endDate: string
+  datasets: ResearchDataset[]
+  format: 'csv' | 'parquet'
+  period?: string
+  adjust?: string
+  outputDir?: string
+}
+
+export interface ResearchExportFile {
+  dataset: ResearchDataset
+  path: string
+  rows: number
+  size: number
+}
+
+export interface ResearchExportResult {
+  dir: string
+  format: 'csv' | 'parquet'
+  files: ResearchExportFile[]
+  duration: number
+}
+
+export interface ResearchExportProgress {
+  dataset: ResearchDataset | ''
+  rows: number
+  totalRows: number
+  done: boolean
+}
+
+export type RetentionTable =
+  | 'price_alert_trigger_history'
+  | 'alert_history'
+  | 'sync_history'
+  | 'stock_strategy_signals'
+  | 'stock_money_flow_hist'
+
+export interface RetentionRule {
+  table: RetentionTable
+  enabled: boolean
+  keepDays: number
+  keepRowsPerCode: number
+}
+
+export interface RetentionPolicy {
+  rules: RetentionRule[]
+  intervalHours: number
+  vacuum: boolean
+}
+
+export interface RetentionTableReport {
+  table: RetentionTable
+  rule: RetentionRule
+  totalRows: number
+  deleted: number
+}
+
+export interface RetentionReport {
+  dryRun: boolean
+  tables: RetentionTableReport[]
+  deleted: number
+  sizeBefore: number
+  sizeAfter: number
+  vacuumed: boolean
+  ranAt: string
+  durationMs: number
+}
+
+export type SyncJobKind = 'kline' | 'money_flow'
+
+export type SyncJobStatus = 'running' | 'completed' | 'interrupted' | 'failed'
+
+/**
+ * 可续传的批量同步任务
+ */
+export interface SyncJob {
+  id: string
+  kind: SyncJobKind
+  status: SyncJobStatus
+  params: string
+  total: number
+  succeeded: number
+  failed: number
+  error: string
+  createdAt: string
+  updatedAt: string
+  finishedAt?: string | null
+}
+
+export type JobKind = 'kline_sync' | 'money_flow_sync' | 'batch_sync' | 'mass_scan'
+
+export type JobStatus = 'running' | 'paused' | 'completed' | 'cancelled' | 'failed'
+
+/**
+ * 运行中或最近结束的长任务（可暂停、继续、取消）
+ */
+export interface JobInfo {
+  id: string
+  kind: JobKind
+  status: JobStatus
+  current: number
+  total: number
+  startedAt: string
+  finishedAt?: string
+  result?: any
+  error?: string
+}
+
+export type ScheduleStep = 'stock_list' | 'kline_sync' | 'money_flow_sync' | 'strategy_scan' | 'ai_verify'
+
+/**
+ * 定时任务：按 cron 规则（分 时 日 月 周，北京时间）依次执行步骤
+ */
+export interface Schedule {
+  id: number
+  name: string
+  cron: string
+  tradingDaysOnly: boolean
+  steps: ScheduleStep[]
+  enabled: boolean
+  lastFireAt?: string
+  nextFireAt?: string
+}
+
+export interface ScheduleStepResult {
+  step: ScheduleStep
+  status: 'completed' | 'failed'
+  summary: string
+  error?: string
+  durationMs: number
+}
+
+/**
+ * 定时任务执行记录，steps 为 ScheduleStepResult[] 的 JSON
+ */
+export interface ScheduleRun {
+  id: number
+  scheduleId: number
+  scheduleName: string
+  trigger: 'scheduled' | 'catchup' | 'manual'
+  status: 'running' | 'completed' | 'failed' | 'cancelled' | 'interrupted'
+  steps: string
+  error: string
+  scheduledAt: string
+  startedAt: string
+  finishedAt?: string | null
+}
+
 /**
  * 资金流向数据点
  */
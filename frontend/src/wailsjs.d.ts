@@ -71,16 +71,25 @@ declare global {
           GetStockList(pageNum: number, pageSize: number): Promise<StockData[]>;
           Greet(name: string): Promise<string>;
           GetDataSyncStats(): Promise<any>;
-          SyncStockData(code: string, startDate: string, endDate: string): Promise<any>;
-          BatchSyncStockData(codes: string[], startDate: string, endDate: string): Promise<void>;
+          SyncStockData(code: string, startDate: string, endDate: string, adjust: string): Promise<any>;
+          BatchSyncStockData(codes: string[], startDate: string, endDate: string, adjust: string): Promise<void>;
           ClearStockCache(code: string): Promise<void>;
           // 同步历史方法
           GetAllSyncHistory(limit: number, offset: number): Promise<SyncHistoryItem[]>;
           GetSyncHistoryByCode(code: string, limit: number): Promise<SyncHistoryItem[]>;
           GetSyncHistoryCount(): Promise<number>;
           ClearAllSyncHistory(): Promise<void>;
+          // K线数据（period: daily/week/month 或 1min/5min/15min/30min/60min；adjust: none/forward/backward）
+          GetKLineData(code: string, limit: number, period: string, adjust: string): Promise<any[]>;
+          GetLocallyAdjustedKLines(code: string, limit: number, adjust: string): Promise<any[]>;
+          StartKLineSync(days: number, adjust: string): Promise<any>;
+          StartFullKLineSync(days: number, adjust: string): Promise<any>;
           // 获取已同步的K线数据
           GetSyncedKLineData(code: string, startDate: string, endDate: string, page: number, pageSize: number): Promise<{ data: any[], total: number }>;
+          // 回测方法
+          BacktestSimpleMA(code: string, shortPeriod: number, longPeriod: number, initialCapital: number, startDate: string, endDate: string, period: string, adjust: string): Promise<any>;
+          BacktestMACD(code: string, fastPeriod: number, slowPeriod: number, signalPeriod: number, initialCapital: number, startDate: string, endDate: string, klinePeriod: string, adjust: string): Promise<any>;
+          BacktestRSI(code: string, period: number, buyThreshold: number, sellThreshold: number, initialCapital: number, startDate: string, endDate: string, klinePeriod: string, adjust: string): Promise<any>;
           // 策略管理方法
           CreateStrategy(jsonData: string): Promise<any>;
           UpdateStrategy(jsonData: string): Promise<any>;
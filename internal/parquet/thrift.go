@@ -0,0 +1,94 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Thrift compact protocol 类型编号
+const (
+	tI32    = 5
+	tI64    = 6
+	tBinary = 8
+	tList   = 9
+	tStruct = 12
+)
+
+// compactWriter 只实现 Parquet 元数据用到的 Thrift compact protocol 子集
+type compactWriter struct {
+	buf    bytes.Buffer
+	last   int16   // 当前结构体中上一个字段的编号
+	parent []int16 // 外层结构体的 last
+}
+
+func (w *compactWriter) varint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	w.buf.Write(tmp[:n])
+}
+
+func (w *compactWriter) zigzag(v int64) {
+	w.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (w *compactWriter) field(id int16, typ byte) {
+	if delta := id - w.last; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.zigzag(int64(id))
+	}
+	w.last = id
+}
+
+func (w *compactWriter) i32(id int16, v int32) {
+	w.field(id, tI32)
+	w.zigzag(int64(v))
+}
+
+func (w *compactWriter) i64(id int16, v int64) {
+	w.field(id, tI64)
+	w.zigzag(v)
+}
+
+func (w *compactWriter) binary(id int16, v string) {
+	w.field(id, tBinary)
+	w.rawBinary(v)
+}
+
+func (w *compactWriter) rawBinary(v string) {
+	w.varint(uint64(len(v)))
+	w.buf.WriteString(v)
+}
+
+// beginStruct 开始一个结构体字段；id 为 0 时表示列表中的结构体元素
+func (w *compactWriter) beginStruct(id int16) {
+	if id != 0 {
+		w.field(id, tStruct)
+	}
+	w.parent = append(w.parent, w.last)
+	w.last = 0
+}
+
+func (w *compactWriter) endStruct() {
+	w.buf.WriteByte(0)
+	w.last = w.parent[len(w.parent)-1]
+	w.parent = w.parent[:len(w.parent)-1]
+}
+
+// list 写入列表头，随后由调用方依次写入 n 个元素
+func (w *compactWriter) list(id int16, elem byte, n int) {
+	w.field(id, tList)
+	if n < 15 {
+		w.buf.WriteByte(byte(n)<<4 | elem)
+	} else {
+		w.buf.WriteByte(0xF0 | elem)
+		w.varint(uint64(n))
+	}
+}
+
+// endMessage 结束顶层结构体
+func (w *compactWriter) endMessage() []byte {
+	w.buf.WriteByte(0)
+	return w.buf.Bytes()
+}
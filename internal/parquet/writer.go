@@ -0,0 +1,262 @@
+// Package parquet 提供一个最小化的 Parquet 文件写入器：
+// 扁平结构、全部列必填（REQUIRED）、PLAIN 编码、不压缩，每次 WriteRowGroup 写出一个行组。
+// 适用于研究数据导出这类按批流式写入的场景，行组元数据在 Close 时写入文件尾。
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Type 列类型
+type Type int
+
+const (
+	Int64  Type = iota // INT64
+	Double             // DOUBLE
+	String             // BYTE_ARRAY (UTF8)
+)
+
+// Column 列定义
+type Column struct {
+	Name string
+	Type Type
+}
+
+// Parquet 枚举值
+const (
+	physicalInt64     = 2
+	physicalDouble    = 5
+	physicalByteArray = 6
+
+	repetitionRequired = 0
+	convertedUTF8      = 0
+	encodingPlain      = 0
+	encodingRLE        = 3
+	codecUncompressed  = 0
+	pageTypeData       = 0
+)
+
+const magic = "PAR1"
+
+// columnChunkMeta 一个行组中一列的元数据
+type columnChunkMeta struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+type rowGroupMeta struct {
+	columns []columnChunkMeta
+	rows    int64
+	size    int64
+}
+
+// Writer Parquet 写入器，非并发安全
+type Writer struct {
+	w         io.Writer
+	columns   []Column
+	offset    int64
+	rowGroups []rowGroupMeta
+	rows      int64
+	createdBy string
+	closed    bool
+}
+
+// NewWriter 创建写入器并写入文件头
+func NewWriter(w io.Writer, columns []Column, createdBy string) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("parquet: 至少需要一列")
+	}
+	pw := &Writer{w: w, columns: columns, createdBy: createdBy}
+	if err := pw.write([]byte(magic)); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+func (pw *Writer) write(b []byte) error {
+	n, err := pw.w.Write(b)
+	pw.offset += int64(n)
+	return err
+}
+
+// WriteRowGroup 写入一个行组。每行的值按列顺序排列，类型须为 int64 / float64 / string。
+func (pw *Writer) WriteRowGroup(rows [][]interface{}) error {
+	if pw.closed {
+		return fmt.Errorf("parquet: 写入器已关闭")
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	group := rowGroupMeta{rows: int64(len(rows))}
+	for i, col := range pw.columns {
+		data, err := encodePlain(col, i, rows)
+		if err != nil {
+			return err
+		}
+		header := pageHeader(len(rows), len(data))
+
+		chunk := columnChunkMeta{offset: pw.offset, size: int64(len(header) + len(data)), values: int64(len(rows))}
+		if err := pw.write(header); err != nil {
+			return err
+		}
+		if err := pw.write(data); err != nil {
+			return err
+		}
+		group.columns = append(group.columns, chunk)
+		group.size += chunk.size
+	}
+	pw.rowGroups = append(pw.rowGroups, group)
+	pw.rows += group.rows
+	return nil
+}
+
+// Rows 已写入的行数
+func (pw *Writer) Rows() int64 {
+	return pw.rows
+}
+
+// Close 写入文件尾（元数据、长度与魔数），不关闭底层 io.Writer
+func (pw *Writer) Close() error {
+	if pw.closed {
+		return nil
+	}
+	pw.closed = true
+
+	footer := pw.fileMetaData()
+	if err := pw.write(footer); err != nil {
+		return err
+	}
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(footer)))
+	if err := pw.write(size[:]); err != nil {
+		return err
+	}
+	return pw.write([]byte(magic))
+}
+
+// encodePlain 按 PLAIN 编码第 i 列（必填列不写定义级别）
+func encodePlain(col Column, i int, rows [][]interface{}) ([]byte, error) {
+	var out []byte
+	var tmp [8]byte
+	for r, row := range rows {
+		if i >= len(row) {
+			return nil, fmt.Errorf("parquet: 第 %d 行缺少列 %s", r, col.Name)
+		}
+		switch col.Type {
+		case Int64:
+			v, ok := row[i].(int64)
+			if !ok {
+				return nil, fmt.Errorf("parquet: 列 %s 需要 int64，得到 %T", col.Name, row[i])
+			}
+			binary.LittleEndian.PutUint64(tmp[:], uint64(v))
+			out = append(out, tmp[:]...)
+		case Double:
+			v, ok := row[i].(float64)
+			if !ok {
+				return nil, fmt.Errorf("parquet: 列 %s 需要 float64，得到 %T", col.Name, row[i])
+			}
+			binary.LittleEndian.PutUint64(tmp[:], math.Float64bits(v))
+			out = append(out, tmp[:]...)
+		case String:
+			v, ok := row[i].(string)
+			if !ok {
+				return nil, fmt.Errorf("parquet: 列 %s 需要 string，得到 %T", col.Name, row[i])
+			}
+			binary.LittleEndian.PutUint32(tmp[:4], uint32(len(v)))
+			out = append(out, tmp[:4]...)
+			out = append(out, v...)
+		default:
+			return nil, fmt.Errorf("parquet: 列 %s 的类型不受支持", col.Name)
+		}
+	}
+	return out, nil
+}
+
+// pageHeader 数据页头（DATA_PAGE v1）
+func pageHeader(values, size int) []byte {
+	var w compactWriter
+	w.i32(1, pageTypeData)
+	w.i32(2, int32(size)) // uncompressed_page_size
+	w.i32(3, int32(size)) // compressed_page_size
+	w.beginStruct(5)      // data_page_header
+	w.i32(1, int32(values))
+	w.i32(2, encodingPlain)
+	w.i32(3, encodingRLE)
+	w.i32(4, encodingRLE)
+	w.endStruct()
+	return w.endMessage()
+}
+
+func physicalType(t Type) int32 {
+	switch t {
+	case Int64:
+		return physicalInt64
+	case Double:
+		return physicalDouble
+	default:
+		return physicalByteArray
+	}
+}
+
+// fileMetaData 文件元数据（FileMetaData）
+func (pw *Writer) fileMetaData() []byte {
+	var w compactWriter
+	w.i32(1, 1) // version
+
+	// schema：根节点 + 叶子列
+	w.list(2, tStruct, len(pw.columns)+1)
+	w.beginStruct(0)
+	w.binary(4, "schema")
+	w.i32(5, int32(len(pw.columns)))
+	w.endStruct()
+	for _, col := range pw.columns {
+		w.beginStruct(0)
+		w.i32(1, physicalType(col.Type))
+		w.i32(3, repetitionRequired)
+		w.binary(4, col.Name)
+		if col.Type == String {
+			w.i32(6, convertedUTF8)
+		}
+		w.endStruct()
+	}
+
+	w.i64(3, pw.rows)
+
+	w.list(4, tStruct, len(pw.rowGroups))
+	for _, group := range pw.rowGroups {
+		w.beginStruct(0)
+		w.list(1, tStruct, len(group.columns))
+		for i, chunk := range group.columns {
+			col := pw.columns[i]
+			w.beginStruct(0)
+			w.i64(2, chunk.offset) // file_offset
+			w.beginStruct(3)       // meta_data
+			w.i32(1, physicalType(col.Type))
+			w.list(2, tI32, 2)
+			w.zigzag(encodingPlain)
+			w.zigzag(encodingRLE)
+			w.list(3, tBinary, 1)
+			w.rawBinary(col.Name)
+			w.i32(4, codecUncompressed)
+			w.i64(5, chunk.values)
+			w.i64(6, chunk.size)
+			w.i64(7, chunk.size)
+			w.i64(9, chunk.offset) // data_page_offset
+			w.endStruct()
+			w.endStruct()
+		}
+		w.i64(2, group.size)
+		w.i64(3, group.rows)
+		w.endStruct()
+	}
+
+	if pw.createdBy != "" {
+		w.binary(6, pw.createdBy)
+	}
+	return w.endMessage()
+}
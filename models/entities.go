@@ -79,6 +79,7 @@ type SyncHistoryEntity struct {
 	StockCode      string    `gorm:"column:stock_code;not null" json:"stockCode"`
 	StockName      string    `gorm:"column:stock_name" json:"stockName"`
 	SyncType       string    `gorm:"column:sync_type;not null" json:"syncType"` // 'single' or 'batch'
+	SyncMode       string    `gorm:"column:sync_mode" json:"syncMode"`          // K线同步方式: 'incremental' or 'full'
 	StartDate      string    `gorm:"column:start_date;not null" json:"startDate"`
 	EndDate        string    `gorm:"column:end_date;not null" json:"endDate"`
 	Status         string    `gorm:"column:status;not null" json:"status"` // 'success' or 'failed'
@@ -112,7 +113,7 @@ func (StrategyConfigEntity) TableName() string {
 // StockEntity 对应 stocks 表
 type StockEntity struct {
 	ID           uint      `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
-	Code         string    `gorm:"column:code;not null;uniqueIndex" json:"code"`
+	Code         string    `gorm:"column:code;not null;index" json:"code"` // 不唯一：SH000001（上证指数）与 SZ000001（平安银行）同号
 	Name         string    `gorm:"column:name;index" json:"name"`
 	Market       string    `gorm:"column:market;index" json:"market"`
 	FullCode     string    `gorm:"column:full_code;not null;uniqueIndex" json:"fullCode"`
@@ -235,7 +236,7 @@ func (StockStrategySignalEntity) TableName() string {
 	return "stock_strategy_signals"
 }
 
-// KLineEntity 对应 kline_{code} 表 (动态表名)
+// KLineEntity 对应旧版 kline_{code} 表 (动态表名)，仅用于迁移到 kline_bars
 type KLineEntity struct {
 	ID        uint      `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
 	Date      string    `gorm:"column:date;not null;uniqueIndex" json:"date"`
@@ -244,6 +245,186 @@ type KLineEntity struct {
 	Low       float64   `gorm:"column:low;not null" json:"low"`
 	Close     float64   `gorm:"column:close;not null" json:"close"`
 	Volume    int64     `gorm:"column:volume;not null" json:"volume"`
+	Adjust    string    `gorm:"column:adjust;not null;default:forward" json:"adjust"` // 复权方式: none/forward/backward（历史数据均为前复权）
 	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"createdAt"`
 	UpdatedAt time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updatedAt"`
 }
+
+// KLineBarEntity 对应 kline_bars 表：所有股票、所有周期的 K 线缓存
+// 主键 (code, period, adjust, date)；date 索引用于读取某一日全市场的截面数据
+type KLineBarEntity struct {
+	Code      string    `gorm:"primaryKey;column:code" json:"code"`                                          // 缓存键（股票为 6 位代码，指数为带市场前缀的代码）
+	Period    string    `gorm:"primaryKey;column:period;index:idx_kline_bars_date,priority:2" json:"period"` // 周期: daily/week/month/1min/...
+	Adjust    string    `gorm:"primaryKey;column:adjust" json:"adjust"`                                      // 复权方式: none/forward/backward
+	Date      string    `gorm:"primaryKey;column:date;index:idx_kline_bars_date,priority:1" json:"date"`     // 日期（分钟线为 YYYY-MM-DD HH:mm）
+	Open      float64   `gorm:"column:open;not null" json:"open"`
+	High      float64   `gorm:"column:high;not null" json:"high"`
+	Low       float64   `gorm:"column:low;not null" json:"low"`
+	Close     float64   `gorm:"column:close;not null" json:"close"`
+	Volume    int64     `gorm:"column:volume;not null" json:"volume"`
+	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (KLineBarEntity) TableName() string {
+	return "kline_bars"
+}
+
+// SchemaVersionEntity 对应 schema_version 表：已执行的数据库结构迁移
+type SchemaVersionEntity struct {
+	Version     int       `gorm:"primaryKey;autoIncrement:false;column:version" json:"version"`
+	Description string    `gorm:"column:description" json:"description"`
+	AppliedAt   time.Time `gorm:"column:applied_at" json:"appliedAt"`
+	DurationMs  int64     `gorm:"column:duration_ms" json:"durationMs"` // 执行耗时（毫秒）
+}
+
+func (SchemaVersionEntity) TableName() string {
+	return "schema_version"
+}
+
+// ExRightsEventEntity 对应 ex_rights_events 表（除权除息事件，用于本地复权）
+// 送转、配股、派息均折算为每股数值
+type ExRightsEventEntity struct {
+	Code           string    `gorm:"primaryKey;column:code" json:"code"`
+	ExDate         string    `gorm:"primaryKey;column:ex_date" json:"exDate"`                // 除权除息日 (YYYY-MM-DD)
+	CashDividend   float64   `gorm:"column:cash_dividend;default:0" json:"cashDividend"`     // 每股派息（税前，元）
+	BonusShares    float64   `gorm:"column:bonus_shares;default:0" json:"bonusShares"`       // 每股送股
+	TransferShares float64   `gorm:"column:transfer_shares;default:0" json:"transferShares"` // 每股转增
+	RightsRatio    float64   `gorm:"column:rights_ratio;default:0" json:"rightsRatio"`       // 每股配股
+	RightsPrice    float64   `gorm:"column:rights_price;default:0" json:"rightsPrice"`       // 配股价
+	UpdatedAt      time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (ExRightsEventEntity) TableName() string {
+	return "ex_rights_events"
+}
+
+// FinancialStatementEntity 对应 financial_statements 表（按报告期保存的核心报表科目）
+// 利润表科目为年初至报告期末的累计值，资产负债表科目为报告期末时点值，单位均为元
+type FinancialStatementEntity struct {
+	Code          string    `gorm:"primaryKey;column:code" json:"code"`
+	ReportDate    string    `gorm:"primaryKey;column:report_date" json:"reportDate"`      // 报告期 (YYYY-MM-DD)
+	Revenue       float64   `gorm:"column:revenue;default:0" json:"revenue"`              // 营业总收入
+	OperatingCost float64   `gorm:"column:operating_cost;default:0" json:"operatingCost"` // 营业成本
+	NetProfit     float64   `gorm:"column:net_profit;default:0" json:"netProfit"`         // 归属于母公司股东的净利润
+	ParentEquity  float64   `gorm:"column:parent_equity;default:0" json:"parentEquity"`   // 归属于母公司股东权益
+	UpdatedAt     time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (FinancialStatementEntity) TableName() string {
+	return "financial_statements"
+}
+
+// BoardEntity 对应 boards 表（东方财富行业/概念板块）
+type BoardEntity struct {
+	Code        string    `gorm:"primaryKey;column:code" json:"code"`               // 板块代码，如 BK0477
+	Name        string    `gorm:"column:name;index" json:"name"`                    // 板块名称
+	Type        string    `gorm:"column:type;index" json:"type"`                    // 板块类型: industry/concept
+	MemberCount int       `gorm:"column:member_count;default:0" json:"memberCount"` // 成分股数量
+	UpdatedAt   time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (BoardEntity) TableName() string {
+	return "boards"
+}
+
+// BoardMemberEntity 对应 board_members 表（板块成分股）
+type BoardMemberEntity struct {
+	BoardCode string    `gorm:"primaryKey;column:board_code" json:"boardCode"`
+	StockCode string    `gorm:"primaryKey;column:stock_code;index" json:"stockCode"`
+	StockName string    `gorm:"column:stock_name" json:"stockName"`
+	UpdatedAt time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (BoardMemberEntity) TableName() string {
+	return "board_members"
+}
+
+// IntradayPointEntity 对应 intraday_points 表（盘中实时推送的分时点，按股票与交易日保存）
+type IntradayPointEntity struct {
+	Code      string    `gorm:"primaryKey;column:code" json:"code"`
+	TradeDate string    `gorm:"primaryKey;column:trade_date" json:"tradeDate"` // 交易日 (YYYY-MM-DD)
+	Time      string    `gorm:"primaryKey;column:time" json:"time"`            // 分钟 (HH:MM)
+	Price     float64   `gorm:"column:price" json:"price"`                     // 最新价
+	AvgPrice  float64   `gorm:"column:avg_price" json:"avgPrice"`              // 均价
+	Volume    int64     `gorm:"column:volume" json:"volume"`                   // 该分钟成交量
+	Amount    float64   `gorm:"column:amount" json:"amount"`                   // 该分钟成交额
+	UpdatedAt time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (IntradayPointEntity) TableName() string {
+	return "intraday_points"
+}
+
+// SyncJobEntity 对应 sync_jobs 表：批量同步任务（K线、资金流），用于中断后续传
+type SyncJobEntity struct {
+	ID         string     `gorm:"primaryKey;column:id" json:"id"`              // 任务ID（类型-时间戳）
+	Kind       string     `gorm:"column:kind;not null;index" json:"kind"`      // 任务类型: kline/money_flow
+	Status     string     `gorm:"column:status;not null;index" json:"status"`  // running/completed/interrupted/failed
+	Params     string     `gorm:"column:params" json:"params"`                 // 任务参数（JSON）
+	Total      int        `gorm:"column:total;default:0" json:"total"`         // 股票总数
+	Succeeded  int        `gorm:"column:succeeded;default:0" json:"succeeded"` // 已成功
+	Failed     int        `gorm:"column:failed;default:0" json:"failed"`       // 已失败（续传时重试）
+	Error      string     `gorm:"column:error" json:"error"`                   // 任务级错误
+	CreatedAt  time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+	FinishedAt *time.Time `gorm:"column:finished_at" json:"finishedAt"`
+}
+
+func (SyncJobEntity) TableName() string {
+	return "sync_jobs"
+}
+
+// SyncJobItemEntity 对应 sync_job_items 表：同步任务中每只股票的检查点
+type SyncJobItemEntity struct {
+	JobID     string    `gorm:"primaryKey;column:job_id" json:"jobId"`
+	ItemKey   string    `gorm:"primaryKey;column:item_key" json:"itemKey"` // 缓存键（指数带市场前缀，避免与同号股票冲突）
+	Seq       int       `gorm:"column:seq" json:"seq"`                     // 原始顺序
+	Code      string    `gorm:"column:code" json:"code"`
+	Name      string    `gorm:"column:name" json:"name"`
+	Market    string    `gorm:"column:market" json:"market"`
+	Type      string    `gorm:"column:type" json:"type"`
+	Status    string    `gorm:"column:status;not null;index" json:"status"` // pending/success/failed
+	Attempts  int       `gorm:"column:attempts;default:0" json:"attempts"`
+	Error     string    `gorm:"column:error" json:"error"`
+	UpdatedAt time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (SyncJobItemEntity) TableName() string {
+	return "sync_job_items"
+}
+
+// ScheduleEntity 对应 schedules 表：定时任务，按 cron 规则依次执行一组步骤
+type ScheduleEntity struct {
+	ID              uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name            string     `gorm:"column:name;not null" json:"name"`
+	Cron            string     `gorm:"column:cron;not null" json:"cron"`                // 分 时 日 月 周（交易所时区）
+	TradingDaysOnly bool       `gorm:"column:trading_days_only" json:"tradingDaysOnly"` // 只在交易日执行（跳过周末和节假日）
+	Steps           string     `gorm:"column:steps;not null" json:"steps"`              // 依次执行的步骤（逗号分隔）
+	Enabled         bool       `gorm:"column:enabled" json:"enabled"`
+	LastFireAt      *time.Time `gorm:"column:last_fire_at" json:"lastFireAt"` // 最近一次已触发的计划时间
+	CreatedAt       time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt       time.Time  `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (ScheduleEntity) TableName() string {
+	return "schedules"
+}
+
+// ScheduleRunEntity 对应 schedule_runs 表：定时任务的执行记录
+type ScheduleRunEntity struct {
+	ID           uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	ScheduleID   uint       `gorm:"column:schedule_id;not null;index" json:"scheduleId"`
+	ScheduleName string     `gorm:"column:schedule_name" json:"scheduleName"`
+	Trigger      string     `gorm:"column:trigger_type" json:"trigger"`         // scheduled/catchup/manual
+	Status       string     `gorm:"column:status;not null;index" json:"status"` // running/completed/failed/cancelled/interrupted
+	Steps        string     `gorm:"column:steps" json:"steps"`                  // 各步骤的执行结果（JSON）
+	Error        string     `gorm:"column:error" json:"error"`
+	ScheduledAt  time.Time  `gorm:"column:scheduled_at" json:"scheduledAt"` // 计划执行时间
+	StartedAt    time.Time  `gorm:"column:started_at;index" json:"startedAt"`
+	FinishedAt   *time.Time `gorm:"column:finished_at" json:"finishedAt"`
+}
+
+func (ScheduleRunEntity) TableName() string {
+	return "schedule_runs"
+}
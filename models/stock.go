@@ -30,6 +30,7 @@ type StockData struct {
 	CircMV       float64 `json:"circMV"`       // 流通市值
 	VolumeRatio  float64 `json:"volumeRatio"`  // 量比
 	WarrantRatio float64 `json:"warrantRatio"` // 委比
+	FetchedAt    int64   `json:"fetchedAt"`    // 行情获取时间(Unix 毫秒)，用于判断行情新鲜度
 }
 
 // KLineData K线数据点
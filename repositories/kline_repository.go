@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"fmt"
+	"stock-analyzer-wails/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// KLineRepository K 线缓存仓库（kline_bars 单表）
+type KLineRepository struct {
+	db *gorm.DB
+}
+
+// NewKLineRepository 创建 K 线缓存仓库
+func NewKLineRepository(db *gorm.DB) *KLineRepository {
+	return &KLineRepository{db: db}
+}
+
+// SaveBars 批量写入 K 线，按 (code, period, adjust, date) 去重，返回受影响的行数
+func (r *KLineRepository) SaveBars(bars []models.KLineBarEntity) (int64, error) {
+	if len(bars) == 0 {
+		return 0, nil
+	}
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}, {Name: "period"}, {Name: "adjust"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"open", "high", "low", "close", "volume", "updated_at"}),
+	}).CreateInBatches(bars, 200)
+	if result.Error != nil {
+		return 0, fmt.Errorf("保存 K 线数据失败: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// ReplaceBars 删除该股票该周期的 K 线后写入（复权方式变更时使用），adjust 为空时删除全部复权方式
+func (r *KLineRepository) ReplaceBars(code, period, adjust string, bars []models.KLineBarEntity) (int64, error) {
+	var affected int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		del := tx.Where("code = ? AND period = ?", code, period)
+		if adjust != "" {
+			del = del.Where("adjust = ?", adjust)
+		}
+		if err := del.Delete(&models.KLineBarEntity{}).Error; err != nil {
+			return fmt.Errorf("清空 K 线缓存失败: %w", err)
+		}
+		n, err := NewKLineRepository(tx).SaveBars(bars)
+		affected = n
+		return err
+	})
+	return affected, err
+}
+
+// GetRecentBars 获取最近 limit 根 K 线（按日期升序），adjust 为空时不限复权方式
+func (r *KLineRepository) GetRecentBars(code, period, adjust string, limit int) ([]models.KLineBarEntity, error) {
+	var bars []models.KLineBarEntity
+	tx := r.db.Where("code = ? AND period = ?", code, period)
+	if adjust != "" {
+		tx = tx.Where("adjust = ?", adjust)
+	}
+	if err := tx.Order("date DESC").Limit(limit).Find(&bars).Error; err != nil {
+		return nil, fmt.Errorf("查询 K 线数据失败: %w", err)
+	}
+	for i, j := 0, len(bars)-1; i < j; i, j = i+1, j-1 {
+		bars[i], bars[j] = bars[j], bars[i]
+	}
+	return bars, nil
+}
+
+// GetBarsBetween 获取日期范围（含首尾）内的全部 K 线（按日期升序），adjust 为空时不限复权方式
+func (r *KLineRepository) GetBarsBetween(code, period, adjust, startDate, endDate string) ([]models.KLineBarEntity, error) {
+	var bars []models.KLineBarEntity
+	tx := r.db.Where("code = ? AND period = ? AND date >= ? AND date <= ?", code, period, startDate, endDate)
+	if adjust != "" {
+		tx = tx.Where("adjust = ?", adjust)
+	}
+	if err := tx.Order("date ASC").Find(&bars).Error; err != nil {
+		return nil, fmt.Errorf("查询 K 线数据失败: %w", err)
+	}
+	return bars, nil
+}
+
+// GetBarsPage 分页获取日期范围内的 K 线（按日期降序），同时返回总数；adjust/startDate/endDate 为空表示不限
+func (r *KLineRepository) GetBarsPage(code, period, adjust, startDate, endDate string, page, pageSize int) ([]models.KLineBarEntity, int64, error) {
+	tx := r.db.Model(&models.KLineBarEntity{}).Where("code = ? AND period = ?", code, period)
+	if adjust != "" {
+		tx = tx.Where("adjust = ?", adjust)
+	}
+	if startDate != "" {
+		tx = tx.Where("date >= ?", startDate)
+	}
+	if endDate != "" {
+		tx = tx.Where("date <= ?", endDate)
+	}
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计 K 线数据失败: %w", err)
+	}
+	var bars []models.KLineBarEntity
+	if err := tx.Order("date DESC").Limit(pageSize).Offset((page - 1) * pageSize).Find(&bars).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询 K 线数据失败: %w", err)
+	}
+	return bars, total, nil
+}
+
+// GetBarsByDate 获取某一日全部股票的 K 线截面（按代码升序），adjust 为空时不限复权方式
+func (r *KLineRepository) GetBarsByDate(period, adjust, date string) ([]models.KLineBarEntity, error) {
+	var bars []models.KLineBarEntity
+	tx := r.db.Where("date = ? AND period = ?", date, period)
+	if adjust != "" {
+		tx = tx.Where("adjust = ?", adjust)
+	}
+	if err := tx.Order("code ASC").Find(&bars).Error; err != nil {
+		return nil, fmt.Errorf("查询 K 线截面失败: %w", err)
+	}
+	return bars, nil
+}
+
+// GetLatestDate 获取该股票该周期最新的 K 线日期，没有数据时返回空字符串
+func (r *KLineRepository) GetLatestDate(code, period string) (string, error) {
+	var date string
+	err := r.db.Model(&models.KLineBarEntity{}).Select("date").
+		Where("code = ? AND period = ?", code, period).
+		Order("date DESC").Limit(1).Scan(&date).Error
+	if err != nil {
+		return "", fmt.Errorf("查询最新 K 线日期失败: %w", err)
+	}
+	return date, nil
+}
+
+// GetEarliestDate 获取该股票该周期最早的 K 线日期，没有数据时返回空字符串
+func (r *KLineRepository) GetEarliestDate(code, period string) (string, error) {
+	var date string
+	err := r.db.Model(&models.KLineBarEntity{}).Select("date").
+		Where("code = ? AND period = ?", code, period).
+		Order("date ASC").Limit(1).Scan(&date).Error
+	if err != nil {
+		return "", fmt.Errorf("查询最早 K 线日期失败: %w", err)
+	}
+	return date, nil
+}
+
+// GetLatestAdjust 获取该股票该周期最新一根 K 线的复权方式，没有数据时返回空字符串
+// （用于每个周期只保存一种复权方式的日线及以上周期）
+func (r *KLineRepository) GetLatestAdjust(code, period string) (string, error) {
+	var adjust string
+	err := r.db.Model(&models.KLineBarEntity{}).Select("adjust").
+		Where("code = ? AND period = ?", code, period).
+		Order("date DESC").Limit(1).Scan(&adjust).Error
+	if err != nil {
+		return "", fmt.Errorf("查询 K 线缓存复权方式失败: %w", err)
+	}
+	return adjust, nil
+}
+
+// CountBars 统计该股票该周期的 K 线数量
+func (r *KLineRepository) CountBars(code, period string) (int64, error) {
+	var count int64
+	if err := r.db.Model(&models.KLineBarEntity{}).Where("code = ? AND period = ?", code, period).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("查询 K 线数据总数失败: %w", err)
+	}
+	return count, nil
+}
+
+// CountBarsByCode 统计该周期各股票的 K 线数量
+func (r *KLineRepository) CountBarsByCode(period string) (map[string]int64, error) {
+	var rows []struct {
+		Code  string
+		Count int64
+	}
+	err := r.db.Model(&models.KLineBarEntity{}).Select("code, COUNT(*) AS count").
+		Where("period = ?", period).Group("code").Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("统计 K 线数据失败: %w", err)
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Code] = row.Count
+	}
+	return counts, nil
+}
+
+// ListCodes 获取该周期有缓存的股票代码（升序）
+func (r *KLineRepository) ListCodes(period string) ([]string, error) {
+	var codes []string
+	err := r.db.Model(&models.KLineBarEntity{}).Distinct("code").
+		Where("period = ?", period).Order("code ASC").Pluck("code", &codes).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询已同步股票列表失败: %w", err)
+	}
+	return codes, nil
+}
+
+// DeleteCode 删除该股票全部周期的 K 线
+func (r *KLineRepository) DeleteCode(code string) error {
+	if err := r.db.Where("code = ?", code).Delete(&models.KLineBarEntity{}).Error; err != nil {
+		return fmt.Errorf("清除 K 线缓存失败: %w", err)
+	}
+	return nil
+}
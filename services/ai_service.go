@@ -554,7 +554,7 @@ func (s *AIService) AnalyzeTechnical(stock *models.StockData, klines []*models.K
 	prompt := fmt.Sprintf("%s 请对股票 %s (%s) 进行深度多维度评估。\n"+
 		"%s\n"+
 		"你的受众包含大量股票新手，请在提到专业术语时，使用括号附带通俗易懂的解释。\n\n"+
-		"最近60个交易日数据(T-0为最新):\n%s\n\n"+
+		"最近60根%s数据(T-0为最新):\n%s\n\n"+
 		"当前指标: %s\n\n"+
 		"请输出五部分内容，**必须严格遵守以下标签格式，不要在标签内包含任何 Markdown 代码块标记（如 ```json）**：\n"+
 		"1. 【文字分析】：识别经典形态、量价配合、趋势阶段及操盘建议。\n"+
@@ -565,7 +565,7 @@ func (s *AIService) AnalyzeTechnical(stock *models.StockData, klines []*models.K
 		"5. 【智能交易计划】：请以纯 JSON 格式输出具体的交易建议，放在 <TRADE_JSON> 标签内。\n"+
 		"包括：建议仓位(suggestedPosition, 如\"30%%\")、止损价(stopLoss)、止盈价(takeProfit)、盈亏比(riskRewardRatio)、操作策略(strategy)。\n\n"+
 		"**重要：即使你正在扮演特定角色，也请确保 JSON 标签内的内容是纯净的 JSON 字符串，以便程序解析。**",
-		selectedRole.System, stock.Name, stock.Code, selectedRole.Style, strings.Join(klineSummary, "\n"), indicatorInfo)
+		selectedRole.System, stock.Name, stock.Code, selectedRole.Style, KLinePeriodLabel(period), strings.Join(klineSummary, "\n"), indicatorInfo)
 
 	ctx := context.Background()
 	messages := []*schema.Message{
@@ -31,11 +31,12 @@ type AlertMonitor struct {
 // StockDataService 股票数据服务接口
 type StockDataService interface {
 	GetStockByCode(code string) (*models.StockData, error)
+	GetQuotes(codes []string) (map[string]*models.StockData, error)
 }
 
 // KLineDataService K线数据服务接口（用于获取MA数据）
 type KLineDataService interface {
-	GetKLineData(code string, count int, period string) ([]*models.KLineData, error)
+	GetKLineData(code string, count int, period string, adjust string) ([]*models.KLineData, error)
 }
 
 // NewAlertMonitor 创建价格预警监控引擎
@@ -172,6 +173,10 @@ func (m *AlertMonitor) run() {
 			m.Stop()
 			return
 		case <-m.ticker.C:
+			// 休市期间行情不变，无需检查
+			if !GetTradingCalendar().IsSessionOpen(time.Now()) {
+				continue
+			}
 			m.checkAllAlerts()
 		}
 	}
@@ -193,25 +198,23 @@ func (m *AlertMonitor) checkAllAlerts() {
 	logger.Debug("开始检查活跃预警", zap.Int("count", len(alerts)))
 
 	// 按股票代码分组，批量获取股票数据
-	stockCodes := make(map[string]bool)
+	seen := make(map[string]bool, len(alerts))
+	stockCodes := make([]string, 0, len(alerts))
 	for _, alert := range alerts {
-		stockCodes[alert.StockCode] = true
+		if !seen[alert.StockCode] {
+			seen[alert.StockCode] = true
+			stockCodes = append(stockCodes, alert.StockCode)
+		}
 	}
+	quotes := m.fetchQuotes(stockCodes)
 
 	// 获取股票数据并转换
 	stockDataMap := make(map[string]*StockDataForAlert)
-	for code := range stockCodes {
-		// 获取实时行情数据
-		stockData, err := m.stockService.GetStockByCode(code)
-		if err != nil {
-			logger.Warn("获取股票数据失败", zap.String("code", code), zap.Error(err))
-			continue
-		}
-
+	for code, stockData := range quotes {
 		// 获取K线数据（用于计算MA和历史高低点）
 		var klineData []*models.KLineData
 		if m.klineService != nil {
-			klineData, err = m.klineService.GetKLineData(code, 100, "daily")
+			klineData, err = m.klineService.GetKLineData(code, 100, KLinePeriodDaily, KLineAdjustForward)
 			if err != nil {
 				logger.Warn("获取K线数据失败", zap.String("code", code), zap.Error(err))
 				// K线数据获取失败不影响预警检测，继续使用实时数据
@@ -258,6 +261,32 @@ func (m *AlertMonitor) checkAllAlerts() {
 	}
 }
 
+// fetchQuotes 批量获取行情，见 FetchQuotesWithFallback
+func (m *AlertMonitor) fetchQuotes(codes []string) map[string]*models.StockData {
+	return FetchQuotesWithFallback(m.stockService, codes)
+}
+
+// FetchQuotesWithFallback 批量获取行情；批量请求失败或缺少部分代码时逐个获取，单只失败只跳过该股票
+func FetchQuotesWithFallback(stocks StockDataService, codes []string) map[string]*models.StockData {
+	quotes, err := stocks.GetQuotes(codes)
+	if err != nil {
+		logger.Warn("批量获取股票数据失败，改为逐个获取", zap.Int("count", len(codes)), zap.Error(err))
+		quotes = make(map[string]*models.StockData, len(codes))
+	}
+	for _, code := range codes {
+		if quotes[code] != nil {
+			continue
+		}
+		stockData, err := stocks.GetStockByCode(code)
+		if err != nil {
+			logger.Warn("获取股票数据失败", zap.String("code", code), zap.Error(err))
+			continue
+		}
+		quotes[code] = stockData
+	}
+	return quotes
+}
+
 // handleTriggeredAlert 处理触发的预警
 func (m *AlertMonitor) handleTriggeredAlert(alert *repositories.PriceThresholdAlert, stockData *StockDataForAlert, message string) {
 	// 1. 更新最后触发时间
@@ -370,7 +399,7 @@ func (m *AlertMonitor) CheckStockAlerts(stockCode string) error {
 	// 获取K线数据（用于计算MA和历史高低点）
 	var klineData []*models.KLineData
 	if m.klineService != nil {
-		klineData, err = m.klineService.GetKLineData(stockCode, 100, "daily")
+		klineData, err = m.klineService.GetKLineData(stockCode, 100, KLinePeriodDaily, KLineAdjustForward)
 		if err != nil {
 			logger.Warn("获取K线数据失败", zap.String("code", stockCode), zap.Error(err))
 		}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"stock-analyzer-wails/models"
+	"testing"
+)
+
+// fakeAlertStocks 批量行情按 batchErr 失败，或只返回 batch 中的代码；单只行情 failing 中的代码失败
+type fakeAlertStocks struct {
+	batch    map[string]bool
+	batchErr error
+	failing  map[string]bool
+	single   []string
+}
+
+func (f *fakeAlertStocks) GetQuotes(codes []string) (map[string]*models.StockData, error) {
+	if f.batchErr != nil {
+		return nil, f.batchErr
+	}
+	quotes := map[string]*models.StockData{}
+	for _, code := range codes {
+		if f.batch[code] {
+			quotes[code] = &models.StockData{Code: code, Price: 10}
+		}
+	}
+	return quotes, nil
+}
+
+func (f *fakeAlertStocks) GetStockByCode(code string) (*models.StockData, error) {
+	f.single = append(f.single, code)
+	if f.failing[code] {
+		return nil, errors.New("timeout")
+	}
+	return &models.StockData{Code: code, Price: 20}, nil
+}
+
+func TestAlertMonitor_FetchQuotesFallsBackPerCode(t *testing.T) {
+	// 批量请求整体失败：逐个获取，单只失败不影响其他股票
+	stocks := &fakeAlertStocks{batchErr: errors.New("HTTP 502"), failing: map[string]bool{"000001": true}}
+	m := &AlertMonitor{stockService: stocks}
+	quotes := m.fetchQuotes([]string{"600519", "000001"})
+	if len(quotes) != 1 || quotes["600519"] == nil || quotes["600519"].Price != 20 {
+		t.Fatalf("expected per-code fallback when the batch fails, got %v", quotes)
+	}
+
+	// 批量结果缺少部分代码：只补取缺少的
+	stocks = &fakeAlertStocks{batch: map[string]bool{"600519": true}}
+	m = &AlertMonitor{stockService: stocks}
+	quotes = m.fetchQuotes([]string{"600519", "000001"})
+	if len(quotes) != 2 || quotes["600519"].Price != 10 || quotes["000001"].Price != 20 {
+		t.Fatalf("expected partial batch results to be completed per code, got %v", quotes)
+	}
+	if len(stocks.single) != 1 || stocks.single[0] != "000001" {
+		t.Fatalf("expected only the missing code to be fetched individually, got %v", stocks.single)
+	}
+}
@@ -38,12 +38,18 @@ func (s *BacktestService) runBacktest(
 	initialCapital float64,
 	startDate string,
 	endDate string,
+	period string,
+	adjust string,
 	limit int,
 	signalGen SignalGenerator,
 ) (*models.BacktestResult, error) {
 
-	// 1. 获取数据
-	klines, err := s.stockService.GetKLineData(code, limit, "daily")
+	// 1. 获取数据（支持日/周/月线及分钟线，以及不复权/前复权/后复权）
+	period, err := NormalizeKLinePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	klines, err := s.stockService.GetKLineData(code, limit, period, adjust)
 	if err != nil {
 		return nil, fmt.Errorf("获取K线失败: %w", err)
 	}
@@ -83,13 +89,17 @@ func (s *BacktestService) runBacktest(
 		date := dates[i]
 		price := closes[i]
 
-		// 检查是否在回测区间内
-		inRange := (startDate == "" || date >= startDate) && (endDate == "" || date <= endDate)
+		// 检查是否在回测区间内（分钟线时间形如 "2006-01-02 15:04"，按日期部分比较）
+		day := date
+		if len(day) > 10 {
+			day = day[:10]
+		}
+		inRange := (startDate == "" || day >= startDate) && (endDate == "" || day <= endDate)
 
 		if !inRange {
 			// 如果还没到开始时间，保持初始状态
 			// 如果已经过了结束时间，可以提前结束（但为了画图完整性，也可以继续算净值但不交易）
-			if endDate != "" && date > endDate {
+			if endDate != "" && day > endDate {
 				break
 			}
 			continue
@@ -163,11 +173,11 @@ func (s *BacktestService) runBacktest(
 	// 计算统计指标
 	ret := final/initialCapital - 1
 
-	// 年化收益
+	// 年化收益（按周期折算每年的 K 线根数）
 	annualized := 0.0
-	days := len(equityCurve)
-	if days > 0 {
-		annualized = math.Pow(final/initialCapital, 252.0/float64(days)) - 1
+	bars := len(equityCurve)
+	if bars > 0 {
+		annualized = math.Pow(final/initialCapital, klinePeriodBarsPerYear(period)/float64(bars)) - 1
 	}
 
 	// 最大回撤
@@ -226,9 +236,15 @@ func (s *BacktestService) AnalyzePastSignals(days int) (*models.SignalAnalysisRe
 		return nil, fmt.Errorf("策略服务未初始化")
 	}
 
-	// 1. 获取日期范围
-	endDate := time.Now().Format("2006-01-02")
-	startDate := time.Now().AddDate(0, 0, -days).Format("2006-01-02")
+	if days <= 0 {
+		return nil, fmt.Errorf("分析天数必须大于 0")
+	}
+
+	// 1. 获取日期范围（days 为交易日数，不含周末与节假日）
+	now := time.Now()
+	cal := GetTradingCalendar()
+	endDate := now.Format("2006-01-02")
+	startDate := cal.AddTradingDays(cal.LatestTradingDay(now), -(days - 1)).Format("2006-01-02")
 
 	// 2. 获取历史信号
 	signals, err := s.strategyService.GetSignalsByDateRange(startDate, endDate)
@@ -258,7 +274,7 @@ func (s *BacktestService) AnalyzePastSignals(days int) (*models.SignalAnalysisRe
 		// 注意：GetKLineData 获取的是最新的 N 条，或者指定日期的。
 		// 这里我们需要信号日期之后的。
 		// 简单起见，我们获取该股票最近 100 天的数据，然后在内存中查找
-		kline, err := s.stockService.GetKLineData(sig.Code, 100, "daily")
+		kline, err := s.stockService.GetKLineData(sig.Code, 100, KLinePeriodDaily, KLineAdjustForward)
 		if err != nil {
 			logger.Warn("获取K线数据失败，跳过", zap.String("code", sig.Code), zap.Error(err))
 			continue
@@ -359,7 +375,8 @@ func (s *BacktestService) AnalyzePastSignals(days int) (*models.SignalAnalysisRe
 }
 
 // BacktestSimpleMA 双均线策略
-func (s *BacktestService) BacktestSimpleMA(code string, shortPeriod int, longPeriod int, initialCapital float64, startDate string, endDate string) (*models.BacktestResult, error) {
+// period 为 K 线周期（daily/week/month/1min/5min/15min/30min/60min），为空时使用日线
+func (s *BacktestService) BacktestSimpleMA(code string, shortPeriod int, longPeriod int, initialCapital float64, startDate string, endDate string, period string, adjust string) (*models.BacktestResult, error) {
 	if shortPeriod <= 0 || longPeriod <= 0 || shortPeriod >= longPeriod {
 		return nil, fmt.Errorf("参数错误: shortPeriod 必须 > 0 且 < longPeriod")
 	}
@@ -367,7 +384,7 @@ func (s *BacktestService) BacktestSimpleMA(code string, shortPeriod int, longPer
 	// 预先计算指标所需的闭包
 	var shortMA, longMA []float64
 
-	return s.runBacktest(code, fmt.Sprintf("SMA(%d,%d)", shortPeriod, longPeriod), initialCapital, startDate, endDate, 5000,
+	return s.runBacktest(code, fmt.Sprintf("SMA(%d,%d)", shortPeriod, longPeriod), initialCapital, startDate, endDate, period, adjust, 5000,
 		func(i int, dates []string, closes []float64) string {
 			// 懒加载计算指标 (只计算一次)
 			if shortMA == nil {
@@ -392,14 +409,15 @@ func (s *BacktestService) BacktestSimpleMA(code string, shortPeriod int, longPer
 }
 
 // BacktestMACD MACD策略
-func (s *BacktestService) BacktestMACD(code string, fastPeriod int, slowPeriod int, signalPeriod int, initialCapital float64, startDate string, endDate string) (*models.BacktestResult, error) {
+// period 为 K 线周期，为空时使用日线
+func (s *BacktestService) BacktestMACD(code string, fastPeriod int, slowPeriod int, signalPeriod int, initialCapital float64, startDate string, endDate string, period string, adjust string) (*models.BacktestResult, error) {
 	if fastPeriod <= 0 || slowPeriod <= 0 || fastPeriod >= slowPeriod {
 		return nil, fmt.Errorf("参数错误: fastPeriod 必须 > 0 且 < slowPeriod")
 	}
 
 	var dif, dea []float64
 
-	return s.runBacktest(code, fmt.Sprintf("MACD(%d,%d,%d)", fastPeriod, slowPeriod, signalPeriod), initialCapital, startDate, endDate, 5000,
+	return s.runBacktest(code, fmt.Sprintf("MACD(%d,%d,%d)", fastPeriod, slowPeriod, signalPeriod), initialCapital, startDate, endDate, period, adjust, 5000,
 		func(i int, dates []string, closes []float64) string {
 			if dif == nil {
 				dif, dea, _ = calculateMACD(closes, fastPeriod, slowPeriod, signalPeriod)
@@ -421,7 +439,8 @@ func (s *BacktestService) BacktestMACD(code string, fastPeriod int, slowPeriod i
 }
 
 // BacktestRSI RSI策略
-func (s *BacktestService) BacktestRSI(code string, period int, buyThreshold float64, sellThreshold float64, initialCapital float64, startDate string, endDate string) (*models.BacktestResult, error) {
+// klinePeriod 为 K 线周期，为空时使用日线（period 为 RSI 计算窗口）
+func (s *BacktestService) BacktestRSI(code string, period int, buyThreshold float64, sellThreshold float64, initialCapital float64, startDate string, endDate string, klinePeriod string, adjust string) (*models.BacktestResult, error) {
 	if period <= 0 {
 		return nil, fmt.Errorf("参数错误: period 必须 > 0")
 	}
@@ -431,7 +450,7 @@ func (s *BacktestService) BacktestRSI(code string, period int, buyThreshold floa
 
 	var rsi []float64
 
-	return s.runBacktest(code, fmt.Sprintf("RSI(%d,%.0f,%.0f)", period, buyThreshold, sellThreshold), initialCapital, startDate, endDate, 5000,
+	return s.runBacktest(code, fmt.Sprintf("RSI(%d,%.0f,%.0f)", period, buyThreshold, sellThreshold), initialCapital, startDate, endDate, klinePeriod, adjust, 5000,
 		func(i int, dates []string, closes []float64) string {
 			if rsi == nil {
 				rsi = calculateRSI(closes, period)
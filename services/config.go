@@ -349,6 +349,73 @@ func (s *ConfigService) UpdateGlobalStrategyConfig(config GlobalStrategyConfig)
 	return nil
 }
 
+// GetMarketDataProvider 读取行情数据源配置，未配置时返回东方财富
+func (s *ConfigService) GetMarketDataProvider() (string, error) {
+	name, err := s.getConfigValue("market_data_provider")
+	if err != nil {
+		return MarketDataProviderEastMoney, err
+	}
+	if strings.TrimSpace(name) == "" {
+		return MarketDataProviderEastMoney, nil
+	}
+	return strings.TrimSpace(name), nil
+}
+
+// SetMarketDataProvider 保存行情数据源配置（重启后生效）
+func (s *ConfigService) SetMarketDataProvider(name string) error {
+	return s.setConfigValue("market_data_provider", strings.TrimSpace(name))
+}
+
+// GetQuoteCacheTTL 读取行情缓存有效期，未配置时返回默认值
+func (s *ConfigService) GetQuoteCacheTTL() (time.Duration, error) {
+	value, err := s.getConfigValue("quote_cache_ttl_ms")
+	if err != nil || strings.TrimSpace(value) == "" {
+		return DefaultQuoteCacheTTL, err
+	}
+	ms, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return DefaultQuoteCacheTTL, fmt.Errorf("行情缓存有效期配置无效: %s", value)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// SetQuoteCacheTTL 保存行情缓存有效期（毫秒），<= 0 表示不缓存
+func (s *ConfigService) SetQuoteCacheTTL(ms int) error {
+	return s.setConfigValue("quote_cache_ttl_ms", strconv.Itoa(ms))
+}
+
+// GetIntradayPersistence 读取是否保存分时推送，未配置时关闭
+func (s *ConfigService) GetIntradayPersistence() (bool, error) {
+	value, err := s.getConfigValue("intraday_persist_enabled")
+	if err != nil || strings.TrimSpace(value) == "" {
+		return false, err
+	}
+	return strconv.ParseBool(strings.TrimSpace(value))
+}
+
+// SetIntradayPersistence 保存是否保存分时推送
+func (s *ConfigService) SetIntradayPersistence(enabled bool) error {
+	return s.setConfigValue("intraday_persist_enabled", strconv.FormatBool(enabled))
+}
+
+// GetKLineSyncConcurrency 读取K线同步的抓取并发数，未配置时返回默认值
+func (s *ConfigService) GetKLineSyncConcurrency() (int, error) {
+	value, err := s.getConfigValue("kline_sync_concurrency")
+	if err != nil || strings.TrimSpace(value) == "" {
+		return DefaultKLineSyncConcurrency, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return DefaultKLineSyncConcurrency, fmt.Errorf("K线同步并发数配置无效: %s", value)
+	}
+	return n, nil
+}
+
+// SetKLineSyncConcurrency 保存K线同步的抓取并发数
+func (s *ConfigService) SetKLineSyncConcurrency(n int) error {
+	return s.setConfigValue("kline_sync_concurrency", strconv.Itoa(n))
+}
+
 func normalizeDashscopeBaseURL(in string) (string, bool) {
 	orig := in
 	s := strings.TrimSpace(in)
@@ -17,8 +17,10 @@ type CookieManager struct {
 }
 
 func NewCookieManager() *CookieManager {
+	client := resty.New().SetRedirectPolicy(resty.FlexibleRedirectPolicy(5))
+	useGateway(client, PriorityInteractive)
 	return &CookieManager{
-		client: resty.New().SetRedirectPolicy(resty.FlexibleRedirectPolicy(5)),
+		client: client,
 	}
 }
 
@@ -1337,6 +1337,8 @@ func TestBacktestService_BacktestMACD(t *testing.T) {
 		initialCapital float64
 		startDate      string
 		endDate        string
+		period         string
+		adjust         string
 	}
 	tests := []struct {
 		name    string
@@ -1353,7 +1355,7 @@ func TestBacktestService_BacktestMACD(t *testing.T) {
 				stockService:    tt.fields.stockService,
 				strategyService: tt.fields.strategyService,
 			}
-			got, err := s.BacktestMACD(tt.args.code, tt.args.fastPeriod, tt.args.slowPeriod, tt.args.signalPeriod, tt.args.initialCapital, tt.args.startDate, tt.args.endDate)
+			got, err := s.BacktestMACD(tt.args.code, tt.args.fastPeriod, tt.args.slowPeriod, tt.args.signalPeriod, tt.args.initialCapital, tt.args.startDate, tt.args.endDate, tt.args.period, tt.args.adjust)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BacktestMACD() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -1378,6 +1380,8 @@ func TestBacktestService_BacktestRSI(t *testing.T) {
 		initialCapital float64
 		startDate      string
 		endDate        string
+		klinePeriod    string
+		adjust         string
 	}
 	tests := []struct {
 		name    string
@@ -1394,7 +1398,7 @@ func TestBacktestService_BacktestRSI(t *testing.T) {
 				stockService:    tt.fields.stockService,
 				strategyService: tt.fields.strategyService,
 			}
-			got, err := s.BacktestRSI(tt.args.code, tt.args.period, tt.args.buyThreshold, tt.args.sellThreshold, tt.args.initialCapital, tt.args.startDate, tt.args.endDate)
+			got, err := s.BacktestRSI(tt.args.code, tt.args.period, tt.args.buyThreshold, tt.args.sellThreshold, tt.args.initialCapital, tt.args.startDate, tt.args.endDate, tt.args.klinePeriod, tt.args.adjust)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BacktestRSI() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -1418,6 +1422,8 @@ func TestBacktestService_BacktestSimpleMA(t *testing.T) {
 		initialCapital float64
 		startDate      string
 		endDate        string
+		period         string
+		adjust         string
 	}
 	tests := []struct {
 		name    string
@@ -1434,7 +1440,7 @@ func TestBacktestService_BacktestSimpleMA(t *testing.T) {
 				stockService:    tt.fields.stockService,
 				strategyService: tt.fields.strategyService,
 			}
-			got, err := s.BacktestSimpleMA(tt.args.code, tt.args.shortPeriod, tt.args.longPeriod, tt.args.initialCapital, tt.args.startDate, tt.args.endDate)
+			got, err := s.BacktestSimpleMA(tt.args.code, tt.args.shortPeriod, tt.args.longPeriod, tt.args.initialCapital, tt.args.startDate, tt.args.endDate, tt.args.period, tt.args.adjust)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BacktestSimpleMA() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -1457,6 +1463,8 @@ func TestBacktestService_runBacktest(t *testing.T) {
 		initialCapital float64
 		startDate      string
 		endDate        string
+		period         string
+		adjust         string
 		limit          int
 		signalGen      SignalGenerator
 	}
@@ -1475,7 +1483,7 @@ func TestBacktestService_runBacktest(t *testing.T) {
 				stockService:    tt.fields.stockService,
 				strategyService: tt.fields.strategyService,
 			}
-			got, err := s.runBacktest(tt.args.code, tt.args.strategyName, tt.args.initialCapital, tt.args.startDate, tt.args.endDate, tt.args.limit, tt.args.signalGen)
+			got, err := s.runBacktest(tt.args.code, tt.args.strategyName, tt.args.initialCapital, tt.args.startDate, tt.args.endDate, tt.args.period, tt.args.adjust, tt.args.limit, tt.args.signalGen)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("runBacktest() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -2081,9 +2089,13 @@ func TestDBService_initTables(t *testing.T) {
 		db     *gorm.DB
 		dbPath string
 	}
+	type args struct {
+		isNewDB bool
+	}
 	tests := []struct {
 		name    string
 		fields  fields
+		args    args
 		wantErr bool
 	}{
 		// TODO: Add test cases.
@@ -2094,7 +2106,7 @@ func TestDBService_initTables(t *testing.T) {
 				db:     tt.fields.db,
 				dbPath: tt.fields.dbPath,
 			}
-			if err := s.initTables(); (err != nil) != tt.wantErr {
+			if err := s.initTables(tt.args.isNewDB); (err != nil) != tt.wantErr {
 				t.Errorf("initTables() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -2365,7 +2377,8 @@ func TestKLineSyncService_StartKLineSync(t *testing.T) {
 		mu        sync.Mutex
 	}
 	type args struct {
-		days int
+		days   int
+		adjust string
 	}
 	tests := []struct {
 		name    string
@@ -2385,7 +2398,7 @@ func TestKLineSyncService_StartKLineSync(t *testing.T) {
 				running:   tt.fields.running,
 				mu:        tt.fields.mu,
 			}
-			got, err := s.StartKLineSync(tt.args.days)
+			got, err := s.StartKLineSync(tt.args.days, tt.args.adjust)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("StartKLineSync() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -2438,8 +2451,9 @@ func TestKLineSyncService_fetchKLineData(t *testing.T) {
 		mu        sync.Mutex
 	}
 	type args struct {
-		task *KLineSyncTask
-		days int
+		task   *KLineSyncTask
+		days   int
+		adjust string
 	}
 	tests := []struct {
 		name    string
@@ -2459,7 +2473,7 @@ func TestKLineSyncService_fetchKLineData(t *testing.T) {
 				running:   tt.fields.running,
 				mu:        tt.fields.mu,
 			}
-			got, err := s.fetchKLineData(tt.args.task, tt.args.days)
+			got, err := s.fetchKLineData(tt.args.task, tt.args.days, tt.args.adjust)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("fetchKLineData() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -2518,7 +2532,7 @@ func TestKLineSyncService_recordSyncHistory(t *testing.T) {
 	}
 	type args struct {
 		task         *KLineSyncTask
-		days         int
+		plan         klineSyncPlan
 		totalRecords int
 		added        int
 		updated      int
@@ -2542,7 +2556,7 @@ func TestKLineSyncService_recordSyncHistory(t *testing.T) {
 				running:   tt.fields.running,
 				mu:        tt.fields.mu,
 			}
-			if err := s.recordSyncHistory(tt.args.task, tt.args.days, tt.args.totalRecords, tt.args.added, tt.args.updated, tt.args.success, tt.args.errorMsg); (err != nil) != tt.wantErr {
+			if err := s.recordSyncHistory(tt.args.task, tt.args.plan, tt.args.totalRecords, tt.args.added, tt.args.updated, tt.args.success, tt.args.errorMsg); (err != nil) != tt.wantErr {
 				t.Errorf("recordSyncHistory() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -2559,6 +2573,7 @@ func TestKLineSyncService_saveKLineData(t *testing.T) {
 	}
 	type args struct {
 		code   string
+		adjust string
 		klines []map[string]interface{}
 	}
 	tests := []struct {
@@ -2580,7 +2595,7 @@ func TestKLineSyncService_saveKLineData(t *testing.T) {
 				running:   tt.fields.running,
 				mu:        tt.fields.mu,
 			}
-			got, got1, err := s.saveKLineData(tt.args.code, tt.args.klines)
+			got, got1, err := s.saveKLineData(tt.args.code, tt.args.adjust, tt.args.klines)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("saveKLineData() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -3832,9 +3847,13 @@ func TestStockMarketService_GetIndustries(t *testing.T) {
 		dbService *DBService
 		client    *resty.Client
 	}
+	type args struct {
+		boardType string
+	}
 	tests := []struct {
 		name    string
 		fields  fields
+		args    args
 		want    []IndustryInfo
 		wantErr bool
 	}{
@@ -3846,7 +3865,7 @@ func TestStockMarketService_GetIndustries(t *testing.T) {
 				dbService: tt.fields.dbService,
 				client:    tt.fields.client,
 			}
-			got, err := s.GetIndustries()
+			got, err := s.GetIndustries(tt.args.boardType)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetIndustries() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -4004,9 +4023,7 @@ func TestStockService_BatchAnalyzeStocks(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		ctx   context.Context
@@ -4035,9 +4052,7 @@ func TestStockService_BatchAnalyzeStocks(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			if err := s.BatchAnalyzeStocks(tt.args.ctx, tt.args.codes, tt.args.role, tt.args.aiSvc); (err != nil) != tt.wantErr {
 				t.Errorf("BatchAnalyzeStocks() error = %v, wantErr %v", err, tt.wantErr)
@@ -4058,14 +4073,13 @@ func TestStockService_BatchSyncStockData(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		codes     []string
 		startDate string
 		endDate   string
+		adjust    string
 	}
 	tests := []struct {
 		name    string
@@ -4088,11 +4102,9 @@ func TestStockService_BatchSyncStockData(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
-			if err := s.BatchSyncStockData(tt.args.codes, tt.args.startDate, tt.args.endDate); (err != nil) != tt.wantErr {
+			if err := s.BatchSyncStockData(tt.args.codes, tt.args.startDate, tt.args.endDate, tt.args.adjust); (err != nil) != tt.wantErr {
 				t.Errorf("BatchSyncStockData() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -4111,9 +4123,7 @@ func TestStockService_ClearStockCache(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		code string
@@ -4139,9 +4149,7 @@ func TestStockService_ClearStockCache(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			if err := s.ClearStockCache(tt.args.code); (err != nil) != tt.wantErr {
 				t.Errorf("ClearStockCache() error = %v, wantErr %v", err, tt.wantErr)
@@ -4162,9 +4170,7 @@ func TestStockService_GetDataSyncStats(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	tests := []struct {
 		name    string
@@ -4187,9 +4193,7 @@ func TestStockService_GetDataSyncStats(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			got, err := s.GetDataSyncStats()
 			if (err != nil) != tt.wantErr {
@@ -4215,9 +4219,7 @@ func TestStockService_GetIntradayData(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		code string
@@ -4244,9 +4246,7 @@ func TestStockService_GetIntradayData(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			got, err := s.GetIntradayData(tt.args.code)
 			if (err != nil) != tt.wantErr {
@@ -4272,14 +4272,13 @@ func TestStockService_GetKLineData(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		code   string
 		limit  int
 		period string
+		adjust string
 	}
 	tests := []struct {
 		name    string
@@ -4303,11 +4302,9 @@ func TestStockService_GetKLineData(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
-			got, err := s.GetKLineData(tt.args.code, tt.args.limit, tt.args.period)
+			got, err := s.GetKLineData(tt.args.code, tt.args.limit, tt.args.period, tt.args.adjust)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetKLineData() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -4331,9 +4328,7 @@ func TestStockService_GetKLineFromCache(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		code  string
@@ -4361,9 +4356,7 @@ func TestStockService_GetKLineFromCache(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			got, err := s.GetKLineFromCache(tt.args.code, tt.args.limit)
 			if (err != nil) != tt.wantErr {
@@ -4389,9 +4382,7 @@ func TestStockService_GetMoneyFlowData(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		code string
@@ -4418,9 +4409,7 @@ func TestStockService_GetMoneyFlowData(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			got, err := s.GetMoneyFlowData(tt.args.code)
 			if (err != nil) != tt.wantErr {
@@ -4446,9 +4435,7 @@ func TestStockService_GetStockByCode(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		code string
@@ -4475,9 +4462,7 @@ func TestStockService_GetStockByCode(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			got, err := s.GetStockByCode(tt.args.code)
 			if (err != nil) != tt.wantErr {
@@ -4503,9 +4488,7 @@ func TestStockService_GetStockDetail(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		code string
@@ -4532,9 +4515,7 @@ func TestStockService_GetStockDetail(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			got, err := s.GetStockDetail(tt.args.code)
 			if (err != nil) != tt.wantErr {
@@ -4560,9 +4541,7 @@ func TestStockService_GetStockHealthCheck(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		code string
@@ -4589,9 +4568,7 @@ func TestStockService_GetStockHealthCheck(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			got, err := s.GetStockHealthCheck(tt.args.code)
 			if (err != nil) != tt.wantErr {
@@ -4617,9 +4594,7 @@ func TestStockService_SearchStock(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		keyword string
@@ -4646,9 +4621,7 @@ func TestStockService_SearchStock(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			got, err := s.SearchStock(tt.args.keyword)
 			if (err != nil) != tt.wantErr {
@@ -4674,9 +4647,7 @@ func TestStockService_SearchStockLegacy(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		keyword string
@@ -4703,9 +4674,7 @@ func TestStockService_SearchStockLegacy(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			got, err := s.SearchStockLegacy(tt.args.keyword)
 			if (err != nil) != tt.wantErr {
@@ -4731,9 +4700,7 @@ func TestStockService_SetDBService(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		db *DBService
@@ -4758,9 +4725,7 @@ func TestStockService_SetDBService(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			s.SetDBService(tt.args.db)
 		})
@@ -4779,9 +4744,7 @@ func TestStockService_Startup(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		ctx context.Context
@@ -4806,9 +4769,7 @@ func TestStockService_Startup(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			s.Startup(tt.args.ctx)
 		})
@@ -4827,9 +4788,7 @@ func TestStockService_StopIntradayStream(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		code string
@@ -4854,9 +4813,7 @@ func TestStockService_StopIntradayStream(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			s.StopIntradayStream(tt.args.code)
 		})
@@ -4875,9 +4832,7 @@ func TestStockService_StreamIntradayData(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		code string
@@ -4902,9 +4857,7 @@ func TestStockService_StreamIntradayData(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			s.StreamIntradayData(tt.args.code)
 		})
@@ -4923,14 +4876,13 @@ func TestStockService_SyncStockData(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		code      string
 		startDate string
 		endDate   string
+		adjust    string
 	}
 	tests := []struct {
 		name    string
@@ -4954,11 +4906,9 @@ func TestStockService_SyncStockData(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
-			got, err := s.SyncStockData(tt.args.code, tt.args.startDate, tt.args.endDate)
+			got, err := s.SyncStockData(tt.args.code, tt.args.startDate, tt.args.endDate, tt.args.adjust)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("SyncStockData() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -4982,9 +4932,7 @@ func TestStockService_calculateIndicators(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		klines []*models.KLineData
@@ -5009,9 +4957,7 @@ func TestStockService_calculateIndicators(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			s.calculateIndicators(tt.args.klines)
 		})
@@ -5030,9 +4976,7 @@ func TestStockService_getFinancialSummary(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		code string
@@ -5059,9 +5003,7 @@ func TestStockService_getFinancialSummary(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			got, err := s.getFinancialSummary(tt.args.code)
 			if (err != nil) != tt.wantErr {
@@ -5087,9 +5029,7 @@ func TestStockService_getIndustryInfo(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		code string
@@ -5116,9 +5056,7 @@ func TestStockService_getIndustryInfo(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			got, err := s.getIndustryInfo(tt.args.code)
 			if (err != nil) != tt.wantErr {
@@ -5144,9 +5082,7 @@ func TestStockService_getOrderBook(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		code string
@@ -5173,9 +5109,7 @@ func TestStockService_getOrderBook(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			got, err := s.getOrderBook(tt.args.code)
 			if (err != nil) != tt.wantErr {
@@ -5201,9 +5135,7 @@ func TestStockService_getSecID(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		code string
@@ -5229,9 +5161,7 @@ func TestStockService_getSecID(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			if got := s.getSecID(tt.args.code); got != tt.want {
 				t.Errorf("getSecID() = %v, want %v", got, tt.want)
@@ -5252,9 +5182,7 @@ func TestStockService_logWarnThrottled(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		code   string
@@ -5281,9 +5209,7 @@ func TestStockService_logWarnThrottled(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			s.logWarnThrottled(tt.args.code, tt.args.msg, tt.args.fields...)
 		})
@@ -5302,9 +5228,7 @@ func TestStockService_parsePrice(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		p string
@@ -5330,9 +5254,7 @@ func TestStockService_parsePrice(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			if got := s.parsePrice(tt.args.p); got != tt.want {
 				t.Errorf("parsePrice() = %v, want %v", got, tt.want)
@@ -5353,9 +5275,7 @@ func TestStockService_sleepBackoff(t *testing.T) {
 		dbService    *DBService
 		warnMu       sync.Mutex
 		lastWarnAt   map[string]time.Time
-		exactURL     string
 		listURL      string
-		klineURL     string
 	}
 	type args struct {
 		ctx   context.Context
@@ -5382,9 +5302,7 @@ func TestStockService_sleepBackoff(t *testing.T) {
 				dbService:    tt.fields.dbService,
 				warnMu:       tt.fields.warnMu,
 				lastWarnAt:   tt.fields.lastWarnAt,
-				exactURL:     tt.fields.exactURL,
 				listURL:      tt.fields.listURL,
-				klineURL:     tt.fields.klineURL,
 			}
 			if got := s.sleepBackoff(tt.args.ctx, tt.args.retry); got != tt.want {
 				t.Errorf("sleepBackoff() = %v, want %v", got, tt.want)
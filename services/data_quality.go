@@ -0,0 +1,503 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"stock-analyzer-wails/internal/logger"
+	"stock-analyzer-wails/models"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 数据质量问题所属序列
+const (
+	QualitySeriesKLine     = "kline"      // 日K线缓存
+	QualitySeriesMoneyFlow = "money_flow" // 资金流历史（stock_money_flow_hist）
+)
+
+// 数据质量问题类型
+const (
+	QualityIssueMissingDays   = "missing_days"    // 交易日缺失（含缓存未更新到最近交易日）
+	QualityIssueNonTradingDay = "non_trading_day" // 记录落在周末或休市日
+	QualityIssueDuplicateDate = "duplicate_date"  // 同一日期多条记录
+	QualityIssueNonPositive   = "non_positive"    // 价格为 0 或负数
+	QualityIssueInvalidOHLC   = "invalid_ohlc"    // 最高/最低价与开盘/收盘价矛盾
+	QualityIssueVolumeOutlier = "volume_outlier"  // 成交量为 0 或远超近期中位数
+	QualityIssueCloseMismatch = "close_mismatch"  // K 线收盘价与资金流收盘价不一致
+)
+
+// 数据质量问题严重程度
+const (
+	QualitySeverityError   = "error"   // 会影响指标计算，建议重新同步
+	QualitySeverityWarning = "warning" // 可能是真实行情（停牌复牌、放量），需人工确认
+)
+
+const (
+	qualityMaxBars        = 100000 // 单只股票读取的最大 K 线数
+	qualityVolumeWindow   = 20     // 成交量异常的参考窗口（交易日）
+	qualityVolumeMinBars  = 5      // 参考窗口内至少需要的有效成交量个数
+	qualityVolumeMultiple = 20.0   // 超过近期中位数的倍数视为异常
+	qualityCloseTolerance = 0.01   // K 线与资金流收盘价的相对偏差容忍度
+	qualityPriceEpsilon   = 1e-6   // 价格比较的误差
+	qualityMergeGapDays   = 5      // 相距不超过该交易日数的修复范围合并为一次请求
+)
+
+// DataQualityIssue 数据质量问题，相邻记录上的同类问题合并为一条
+type DataQualityIssue struct {
+	Series    string `json:"series"`
+	Type      string `json:"type"`
+	Severity  string `json:"severity"`
+	StartDate string `json:"startDate"`
+	EndDate   string `json:"endDate"`
+	Count     int    `json:"count"`  // 涉及的记录数（缺失问题为缺失的交易日数）
+	Detail    string `json:"detail"` // 说明（合并时为第一条记录的说明）
+}
+
+// DataQualityRange 建议重新同步的日期范围
+type DataQualityRange struct {
+	Series    string `json:"series"`
+	StartDate string `json:"startDate"`
+	EndDate   string `json:"endDate"`
+}
+
+// DataQualityReport 单只股票的数据质量报告
+type DataQualityReport struct {
+	Code          string             `json:"code"`
+	CheckedAt     string             `json:"checkedAt"`
+	KLineBars     int                `json:"klineBars"`
+	KLineFrom     string             `json:"klineFrom"`
+	KLineTo       string             `json:"klineTo"`
+	MoneyFlowDays int                `json:"moneyFlowDays"`
+	MoneyFlowFrom string             `json:"moneyFlowFrom"`
+	MoneyFlowTo   string             `json:"moneyFlowTo"`
+	Errors        int                `json:"errors"`
+	Warnings      int                `json:"warnings"`
+	Issues        []DataQualityIssue `json:"issues"`
+	ResyncRanges  []DataQualityRange `json:"resyncRanges"` // 仅包含 error 级问题
+}
+
+// DataQualityOverview 全部已同步股票的检查汇总
+type DataQualityOverview struct {
+	Checked    int                  `json:"checked"`
+	WithIssues int                  `json:"withIssues"`
+	Failed     int                  `json:"failed"`
+	Reports    []*DataQualityReport `json:"reports"` // 只包含有问题的股票
+	Duration   float64              `json:"duration"`
+}
+
+// DataQualityResyncResult 按报告重新同步的结果
+type DataQualityResyncResult struct {
+	Code     string             `json:"code"`
+	Ranges   []DataQualityRange `json:"ranges"`
+	Records  int                `json:"records"`
+	Failures []string           `json:"failures"`
+	Report   *DataQualityReport `json:"report"` // 重新同步后的复检报告
+}
+
+// qualityBar 参与检查的日线数据
+type qualityBar struct {
+	date                   string
+	open, high, low, close float64
+	volume                 int64
+}
+
+// DataQualityService 检查本地缓存的日K线与资金流历史，并按问题范围重新同步
+type DataQualityService struct {
+	dbService   *DBService
+	klineSync   *KLineSyncService
+	syncService *SyncService
+	now         func() time.Time
+}
+
+// NewDataQualityService 创建数据质量服务，klineSync 与 syncService 可为 nil（此时不能修复对应序列）
+func NewDataQualityService(dbService *DBService, klineSync *KLineSyncService, syncService *SyncService) *DataQualityService {
+	return &DataQualityService{
+		dbService:   dbService,
+		klineSync:   klineSync,
+		syncService: syncService,
+		now:         time.Now,
+	}
+}
+
+// AuditStock 检查单只股票的日K线与资金流历史
+func (s *DataQualityService) AuditStock(code string) (*DataQualityReport, error) {
+	if s.dbService == nil {
+		return nil, fmt.Errorf("数据库服务未初始化")
+	}
+	inst, err := NewInstrumentResolver(s.dbService.GetDB()).Resolve(code)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.dbService.GetKLinePeriodDataFromCache(inst.Key(), KLinePeriodDaily, "", qualityMaxBars)
+	if err != nil {
+		return nil, err
+	}
+	bars := make([]qualityBar, 0, len(rows))
+	adjust := ""
+	for _, r := range rows {
+		bars = append(bars, qualityBar{
+			date:   r["date"].(string),
+			open:   r["open"].(float64),
+			high:   r["high"].(float64),
+			low:    r["low"].(float64),
+			close:  r["close"].(float64),
+			volume: r["volume"].(int64),
+		})
+		adjust, _ = r["adjust"].(string)
+	}
+
+	var flows []models.StockMoneyFlowHistEntity
+	if !inst.IsIndex() {
+		if err := s.dbService.GetDB().Where("code = ?", inst.Code).Order("trade_date ASC").Find(&flows).Error; err != nil {
+			return nil, fmt.Errorf("查询资金流历史失败: %w", err)
+		}
+	}
+
+	// 资金流收盘价为前复权，只有 K 线缓存同为前复权时才比较
+	compareClose := adjust == "" || adjust == KLineAdjustForward
+	return auditDataQuality(inst.Key(), bars, flows, compareClose, GetTradingCalendar(), s.now()), nil
+}
+
+// AuditAll 检查所有已同步 K 线的股票，返回有问题的股票报告
+func (s *DataQualityService) AuditAll() (*DataQualityOverview, error) {
+	if s.dbService == nil {
+		return nil, fmt.Errorf("数据库服务未初始化")
+	}
+	codes, err := s.dbService.GetAllSyncedStocks()
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	overview := &DataQualityOverview{Reports: []*DataQualityReport{}}
+	for _, code := range codes {
+		report, err := s.AuditStock(code)
+		if err != nil {
+			overview.Failed++
+			logger.Warn("数据质量检查失败",
+				zap.String("module", "services.data_quality"),
+				zap.String("op", "AuditAll"),
+				zap.String("code", code),
+				zap.Error(err),
+			)
+			continue
+		}
+		overview.Checked++
+		if len(report.Issues) > 0 {
+			overview.WithIssues++
+			overview.Reports = append(overview.Reports, report)
+		}
+	}
+	overview.Duration = time.Since(start).Seconds()
+
+	logger.Info("数据质量检查完成",
+		zap.String("module", "services.data_quality"),
+		zap.String("op", "AuditAll"),
+		zap.Int("checked", overview.Checked),
+		zap.Int("with_issues", overview.WithIssues),
+		zap.Int("failed", overview.Failed),
+		zap.Float64("duration", overview.Duration),
+	)
+	return overview, nil
+}
+
+// ResyncIssues 检查单只股票并重新同步报告中建议修复的范围，返回修复后的复检报告。
+// 单个范围失败不影响其他范围。
+func (s *DataQualityService) ResyncIssues(code string) (*DataQualityResyncResult, error) {
+	report, err := s.AuditStock(code)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DataQualityResyncResult{Code: report.Code, Ranges: report.ResyncRanges, Failures: []string{}}
+	for _, r := range report.ResyncRanges {
+		var (
+			n   int
+			err error
+		)
+		switch r.Series {
+		case QualitySeriesKLine:
+			if s.klineSync == nil {
+				err = fmt.Errorf("K线同步服务未初始化")
+				break
+			}
+			n, err = s.klineSync.SyncKLineRange(report.Code, r.StartDate, r.EndDate)
+		case QualitySeriesMoneyFlow:
+			if s.syncService == nil {
+				err = fmt.Errorf("同步服务未初始化")
+				break
+			}
+			n, err = s.syncService.ResyncMoneyFlowRange(report.Code, r.StartDate, r.EndDate)
+		}
+		if err != nil {
+			result.Failures = append(result.Failures, fmt.Sprintf("%s %s~%s: %v", r.Series, r.StartDate, r.EndDate, err))
+			continue
+		}
+		result.Records += n
+	}
+
+	result.Report, err = s.AuditStock(code)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// auditDataQuality 检查日K线与资金流历史（均按日期升序），now 用于判断缓存是否更新到最近交易日
+func auditDataQuality(code string, bars []qualityBar, flows []models.StockMoneyFlowHistEntity, compareClose bool, cal *TradingCalendar, now time.Time) *DataQualityReport {
+	report := &DataQualityReport{
+		Code:          code,
+		CheckedAt:     now.Format("2006-01-02 15:04:05"),
+		KLineBars:     len(bars),
+		MoneyFlowDays: len(flows),
+	}
+	if len(bars) > 0 {
+		report.KLineFrom, report.KLineTo = bars[0].date, bars[len(bars)-1].date
+	}
+	if len(flows) > 0 {
+		report.MoneyFlowFrom, report.MoneyFlowTo = flows[0].TradeDate, flows[len(flows)-1].TradeDate
+	}
+
+	c := newIssueCollector()
+	expected := expectedLatestTradingDay(cal, now)
+
+	klineDates := make([]string, len(bars))
+	klineClose := make(map[string]float64, len(bars))
+	for i, b := range bars {
+		klineDates[i] = b.date
+		klineClose[b.date] = b.close
+
+		switch {
+		case b.open <= 0 || b.high <= 0 || b.low <= 0 || b.close <= 0:
+			c.mark(QualitySeriesKLine, QualityIssueNonPositive, QualitySeverityError, i, b.date,
+				fmt.Sprintf("%s 价格非正 O=%.2f H=%.2f L=%.2f C=%.2f", b.date, b.open, b.high, b.low, b.close))
+		case b.high+qualityPriceEpsilon < math.Max(math.Max(b.open, b.close), b.low) || b.low-qualityPriceEpsilon > math.Min(b.open, b.close):
+			c.mark(QualitySeriesKLine, QualityIssueInvalidOHLC, QualitySeverityError, i, b.date,
+				fmt.Sprintf("%s 最高/最低价与开收盘价矛盾 O=%.2f H=%.2f L=%.2f C=%.2f", b.date, b.open, b.high, b.low, b.close))
+		}
+
+		if b.volume <= 0 {
+			c.mark(QualitySeriesKLine, QualityIssueVolumeOutlier, QualitySeverityWarning, i, b.date,
+				fmt.Sprintf("%s 成交量为 0", b.date))
+		} else if median := recentVolumeMedian(bars, i); median > 0 && float64(b.volume) > median*qualityVolumeMultiple {
+			c.mark(QualitySeriesKLine, QualityIssueVolumeOutlier, QualitySeverityWarning, i, b.date,
+				fmt.Sprintf("%s 成交量 %d 为近 %d 日中位数的 %.0f 倍", b.date, b.volume, qualityVolumeWindow, float64(b.volume)/median))
+		}
+	}
+	auditDates(c, QualitySeriesKLine, klineDates, cal, expected)
+
+	flowDates := make([]string, len(flows))
+	for i, f := range flows {
+		flowDates[i] = f.TradeDate
+		if f.ClosePrice <= 0 {
+			c.mark(QualitySeriesMoneyFlow, QualityIssueNonPositive, QualitySeverityError, i, f.TradeDate,
+				fmt.Sprintf("%s 收盘价非正 %.2f", f.TradeDate, f.ClosePrice))
+			continue
+		}
+		if kc, ok := klineClose[f.TradeDate]; compareClose && ok && kc > 0 && math.Abs(f.ClosePrice-kc)/kc > qualityCloseTolerance {
+			c.mark(QualitySeriesMoneyFlow, QualityIssueCloseMismatch, QualitySeverityError, i, f.TradeDate,
+				fmt.Sprintf("%s 资金流收盘价 %.2f 与 K 线收盘价 %.2f 不一致", f.TradeDate, f.ClosePrice, kc))
+		}
+	}
+	auditDates(c, QualitySeriesMoneyFlow, flowDates, cal, expected)
+
+	report.Issues = c.result()
+	for _, issue := range report.Issues {
+		if issue.Severity == QualitySeverityError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	report.ResyncRanges = resyncRanges(report.Issues, cal)
+	return report
+}
+
+// auditDates 检查日期序列：重复日期、非交易日记录、相邻记录之间及末尾缺失的交易日。
+// 缺失检查只在交易日历覆盖范围内进行，范围外的节假日无法区分。
+// 相邻记录之间的缺失为 warning，缓存未更新到最近交易日为 error。
+func auditDates(c *issueCollector, series string, dates []string, cal *TradingCalendar, expected time.Time) {
+	var prev time.Time
+	for i, date := range dates {
+		d, err := time.ParseInLocation("2006-01-02", date, chinaLocation)
+		if err != nil {
+			continue
+		}
+		if i > 0 && date == dates[i-1] {
+			c.mark(series, QualityIssueDuplicateDate, QualitySeverityError, i, date, fmt.Sprintf("%s 存在重复记录", date))
+			continue
+		}
+		if !cal.IsTradingDay(d) {
+			c.mark(series, QualityIssueNonTradingDay, QualitySeverityError, i, date, fmt.Sprintf("%s 不是交易日", date))
+		}
+		if !prev.IsZero() && cal.Covers(prev) && cal.Covers(d) {
+			missing := cal.TradingDaysBetween(prev, d)
+			if cal.IsTradingDay(d) {
+				missing--
+			}
+			// 记录之间的缺失多为停牌，不能与同步遗漏区分，只作为警告，不进入修复范围，
+			// 避免停牌股票每次检查后都被重新同步
+			if missing > 0 {
+				c.add(DataQualityIssue{
+					Series:    series,
+					Type:      QualityIssueMissingDays,
+					Severity:  QualitySeverityWarning,
+					StartDate: cal.NextTradingDay(prev).Format("2006-01-02"),
+					EndDate:   cal.PrevTradingDay(d).Format("2006-01-02"),
+					Count:     missing,
+					Detail:    fmt.Sprintf("%s 与 %s 之间缺失 %d 个交易日（停牌期间可忽略）", prev.Format("2006-01-02"), date, missing),
+				})
+			}
+		}
+		prev = d
+	}
+
+	if !prev.IsZero() && prev.Before(expected) && cal.Covers(expected) {
+		if missing := cal.TradingDaysBetween(prev, expected); missing > 0 {
+			c.add(DataQualityIssue{
+				Series:    series,
+				Type:      QualityIssueMissingDays,
+				Severity:  QualitySeverityError,
+				StartDate: cal.NextTradingDay(prev).Format("2006-01-02"),
+				EndDate:   expected.Format("2006-01-02"),
+				Count:     missing,
+				Detail:    fmt.Sprintf("缓存未更新到最近交易日 %s，缺失 %d 个交易日", expected.Format("2006-01-02"), missing),
+			})
+		}
+	}
+}
+
+// expectedLatestTradingDay 缓存应包含的最近交易日：交易日收盘前为上一交易日
+func expectedLatestTradingDay(cal *TradingCalendar, now time.Time) time.Time {
+	now = now.In(chinaLocation)
+	if cal.IsTradingDay(now) && now.Format("15:04:05") < "15:00:00" {
+		return cal.PrevTradingDay(now)
+	}
+	return cal.LatestTradingDay(now)
+}
+
+// recentVolumeMedian 返回第 i 根 K 线之前 qualityVolumeWindow 根中有效成交量的中位数，样本不足时返回 0
+func recentVolumeMedian(bars []qualityBar, i int) float64 {
+	vols := make([]float64, 0, qualityVolumeWindow)
+	for j := i - 1; j >= 0 && j >= i-qualityVolumeWindow; j-- {
+		if bars[j].volume > 0 {
+			vols = append(vols, float64(bars[j].volume))
+		}
+	}
+	if len(vols) < qualityVolumeMinBars {
+		return 0
+	}
+	sort.Float64s(vols)
+	mid := len(vols) / 2
+	if len(vols)%2 == 0 {
+		return (vols[mid-1] + vols[mid]) / 2
+	}
+	return vols[mid]
+}
+
+// resyncRanges 根据 error 级问题生成各序列的修复范围，相近的范围合并。
+// 收盘价不一致无法判断哪一方有误，两个序列都重新同步。
+func resyncRanges(issues []DataQualityIssue, cal *TradingCalendar) []DataQualityRange {
+	bySeries := make(map[string][]DataQualityRange)
+	for _, issue := range issues {
+		if issue.Severity != QualitySeverityError {
+			continue
+		}
+		series := []string{issue.Series}
+		if issue.Type == QualityIssueCloseMismatch {
+			series = []string{QualitySeriesKLine, QualitySeriesMoneyFlow}
+		}
+		for _, sr := range series {
+			bySeries[sr] = append(bySeries[sr], DataQualityRange{Series: sr, StartDate: issue.StartDate, EndDate: issue.EndDate})
+		}
+	}
+
+	ranges := []DataQualityRange{}
+	for _, series := range []string{QualitySeriesKLine, QualitySeriesMoneyFlow} {
+		list := bySeries[series]
+		sort.Slice(list, func(i, j int) bool { return list[i].StartDate < list[j].StartDate })
+		for _, r := range list {
+			if n := len(ranges); n > 0 && ranges[n-1].Series == series {
+				last := &ranges[n-1]
+				end, _ := time.ParseInLocation("2006-01-02", last.EndDate, chinaLocation)
+				if r.StartDate <= cal.AddTradingDays(end, qualityMergeGapDays).Format("2006-01-02") {
+					if r.EndDate > last.EndDate {
+						last.EndDate = r.EndDate
+					}
+					continue
+				}
+			}
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
+
+// issueRun 正在合并的同类问题
+type issueRun struct {
+	issue   DataQualityIssue
+	lastIdx int
+}
+
+// issueCollector 收集问题：同一序列中相邻记录上的同类问题合并为一条
+type issueCollector struct {
+	runs   map[string]*issueRun
+	issues []DataQualityIssue
+}
+
+func newIssueCollector() *issueCollector {
+	return &issueCollector{runs: make(map[string]*issueRun)}
+}
+
+// mark 记录第 idx 条记录上的问题
+func (c *issueCollector) mark(series, typ, severity string, idx int, date, detail string) {
+	key := series + "/" + typ
+	if r, ok := c.runs[key]; ok {
+		if idx <= r.lastIdx+1 {
+			r.issue.EndDate = date
+			r.issue.Count++
+			r.lastIdx = idx
+			return
+		}
+		c.issues = append(c.issues, r.issue)
+	}
+	c.runs[key] = &issueRun{
+		issue: DataQualityIssue{
+			Series:    series,
+			Type:      typ,
+			Severity:  severity,
+			StartDate: date,
+			EndDate:   date,
+			Count:     1,
+			Detail:    detail,
+		},
+		lastIdx: idx,
+	}
+}
+
+// add 直接记录一条问题（如缺失区间）
+func (c *issueCollector) add(issue DataQualityIssue) {
+	c.issues = append(c.issues, issue)
+}
+
+// result 返回全部问题，按序列、开始日期、类型排序
+func (c *issueCollector) result() []DataQualityIssue {
+	issues := append([]DataQualityIssue{}, c.issues...)
+	for _, r := range c.runs {
+		issues = append(issues, r.issue)
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.Series != b.Series {
+			return a.Series < b.Series
+		}
+		if a.StartDate != b.StartDate {
+			return a.StartDate < b.StartDate
+		}
+		return a.Type < b.Type
+	})
+	return issues
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"stock-analyzer-wails/models"
+	"testing"
+	"time"
+)
+
+// qualityFixture 生成 2024-02-01 ~ 2024-03-08 的日线与 2024-02-19 起的资金流，并注入以下问题：
+// 缺失 02-21、02-22，02-26 收盘价为 0，02-27 最高价低于收盘价，02-28 放量 100 倍，
+// 02-29 资金流收盘价偏离 5%，缺少最近交易日 03-08 的 K 线
+func qualityFixture(t *testing.T) ([]qualityBar, []models.StockMoneyFlowHistEntity) {
+	t.Helper()
+	c := mustBundledCalendar(t)
+
+	var bars []qualityBar
+	var flows []models.StockMoneyFlowHistEntity
+	for d := cst("2024-02-01 00:00:00"); d.Format("2006-01-02") <= "2024-03-08"; d = c.NextTradingDay(d) {
+		date := d.Format("2006-01-02")
+		if date >= "2024-02-19" {
+			closePrice := 10.0
+			if date == "2024-02-29" {
+				closePrice = 10.5
+			}
+			flows = append(flows, models.StockMoneyFlowHistEntity{Code: "600519", TradeDate: date, ClosePrice: closePrice})
+		}
+
+		switch date {
+		case "2024-02-21", "2024-02-22", "2024-03-08":
+			continue
+		}
+		b := qualityBar{date: date, open: 10, high: 10.2, low: 9.8, close: 10, volume: 1000}
+		switch date {
+		case "2024-02-26":
+			b.close = 0
+		case "2024-02-27":
+			b.high = 9.9
+		case "2024-02-28":
+			b.volume = 100000
+		}
+		bars = append(bars, b)
+	}
+	return bars, flows
+}
+
+func findQualityIssue(issues []DataQualityIssue, series, typ, start string) *DataQualityIssue {
+	for i := range issues {
+		if issues[i].Series == series && issues[i].Type == typ && issues[i].StartDate == start {
+			return &issues[i]
+		}
+	}
+	return nil
+}
+
+func TestAuditDataQuality_DetectsIssues(t *testing.T) {
+	c := mustBundledCalendar(t)
+	bars, flows := qualityFixture(t)
+	// 重复日期无法写入缓存表，只在纯函数检查中覆盖
+	bars = append(bars[:len(bars)-1], bars[len(bars)-2], bars[len(bars)-1])
+
+	report := auditDataQuality("600519", bars, flows, true, c, cst("2024-03-08 16:00:00"))
+
+	want := []struct {
+		series, typ, start, end string
+		count                   int
+	}{
+		{QualitySeriesKLine, QualityIssueMissingDays, "2024-02-21", "2024-02-22", 2},
+		{QualitySeriesKLine, QualityIssueNonPositive, "2024-02-26", "2024-02-26", 1},
+		{QualitySeriesKLine, QualityIssueInvalidOHLC, "2024-02-27", "2024-02-27", 1},
+		{QualitySeriesKLine, QualityIssueVolumeOutlier, "2024-02-28", "2024-02-28", 1},
+		{QualitySeriesKLine, QualityIssueDuplicateDate, "2024-03-06", "2024-03-06", 1},
+		{QualitySeriesKLine, QualityIssueMissingDays, "2024-03-08", "2024-03-08", 1},
+		{QualitySeriesMoneyFlow, QualityIssueCloseMismatch, "2024-02-29", "2024-02-29", 1},
+	}
+	for _, w := range want {
+		issue := findQualityIssue(report.Issues, w.series, w.typ, w.start)
+		if issue == nil || issue.EndDate != w.end || issue.Count != w.count {
+			t.Fatalf("expected %s/%s %s~%s x%d, got %+v in %+v", w.series, w.typ, w.start, w.end, w.count, issue, report.Issues)
+		}
+	}
+	// 春节休市不算缺失
+	if len(report.Issues) != len(want) {
+		t.Fatalf("expected %d issues, got %+v", len(want), report.Issues)
+	}
+	// 记录之间的缺失可能是停牌，只作为警告
+	if report.Errors != 5 || report.Warnings != 2 {
+		t.Fatalf("unexpected severity counts: errors=%d warnings=%d", report.Errors, report.Warnings)
+	}
+
+	// 相近的 error 级问题合并为一个修复范围，收盘价不一致两个序列都修复；停牌缺失不修复
+	wantRanges := []DataQualityRange{
+		{Series: QualitySeriesKLine, StartDate: "2024-02-26", EndDate: "2024-03-08"},
+		{Series: QualitySeriesMoneyFlow, StartDate: "2024-02-29", EndDate: "2024-02-29"},
+	}
+	if len(report.ResyncRanges) != len(wantRanges) {
+		t.Fatalf("unexpected resync ranges: %+v", report.ResyncRanges)
+	}
+	for i, r := range wantRanges {
+		if report.ResyncRanges[i] != r {
+			t.Fatalf("resync range %d = %+v, want %+v", i, report.ResyncRanges[i], r)
+		}
+	}
+
+	// 交易日收盘前不要求包含当天数据
+	intraday := auditDataQuality("600519", bars, flows, true, c, cst("2024-03-08 10:00:00"))
+	if issue := findQualityIssue(intraday.Issues, QualitySeriesKLine, QualityIssueMissingDays, "2024-03-08"); issue != nil {
+		t.Fatalf("unexpected tail gap before close: %+v", issue)
+	}
+}
+
+func TestDataQualityService_AuditStock(t *testing.T) {
+	db := newTestDB(t)
+
+	bars, flows := qualityFixture(t)
+	records := make([]map[string]interface{}, 0, len(bars))
+	for _, b := range bars {
+		records = append(records, map[string]interface{}{
+			"date": b.date, "open": b.open, "high": b.high, "low": b.low, "close": b.close, "volume": b.volume,
+		})
+	}
+	if _, _, err := db.InsertOrUpdateKLinePeriodData("600519", KLinePeriodDaily, KLineAdjustForward, records); err != nil {
+		t.Fatalf("insert klines: %v", err)
+	}
+	if err := db.GetDB().Create(&flows).Error; err != nil {
+		t.Fatalf("insert money flows: %v", err)
+	}
+
+	svc := NewDataQualityService(db, nil, nil)
+	svc.now = func() time.Time { return cst("2024-03-08 16:00:00") }
+	report, err := svc.AuditStock("600519")
+	if err != nil {
+		t.Fatalf("AuditStock: %v", err)
+	}
+	if report.KLineBars != len(bars) || report.MoneyFlowDays != len(flows) || report.KLineTo != "2024-03-07" {
+		t.Fatalf("unexpected coverage: %+v", report)
+	}
+	if len(report.Issues) != 6 || findQualityIssue(report.Issues, QualitySeriesMoneyFlow, QualityIssueCloseMismatch, "2024-02-29") == nil {
+		t.Fatalf("unexpected issues: %+v", report.Issues)
+	}
+
+	// 没有同步服务时修复失败会记录在结果中，并返回复检报告
+	result, err := svc.ResyncIssues("600519")
+	if err != nil {
+		t.Fatalf("ResyncIssues: %v", err)
+	}
+	if len(result.Failures) != len(result.Ranges) || result.Report == nil || len(result.Report.Issues) != 6 {
+		t.Fatalf("unexpected resync result: %+v", result)
+	}
+}
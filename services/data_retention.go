@@ -0,0 +1,403 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"stock-analyzer-wails/internal/logger"
+	"stock-analyzer-wails/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+// 数据保留策略的配置键
+const (
+	configRetentionPolicy  = "data_retention_policy"
+	configRetentionLastRun = "data_retention_last_run"
+)
+
+// retentionCheckInterval 定时检查是否到期执行清理的间隔
+const retentionCheckInterval = 30 * time.Minute
+
+// RetentionRule 单张表的保留规则，KeepDays 与 KeepRowsPerCode 同时设置时满足任一条件的记录都会被清理
+type RetentionRule struct {
+	Table           string `json:"table"`
+	Enabled         bool   `json:"enabled"`
+	KeepDays        int    `json:"keepDays"`        // 保留最近 N 天，0 表示不按天数清理
+	KeepRowsPerCode int    `json:"keepRowsPerCode"` // 每只股票保留最近 N 条，0 表示不按条数清理
+}
+
+// RetentionPolicy 数据保留策略
+type RetentionPolicy struct {
+	Rules         []RetentionRule `json:"rules"`
+	IntervalHours int             `json:"intervalHours"` // 自动执行间隔（小时），0 表示只手动执行
+	Vacuum        bool            `json:"vacuum"`        // 有记录被清理时执行 VACUUM 回收空间
+}
+
+// RetentionTableReport 单张表的清理结果（预演时为将要清理的记录数）
+type RetentionTableReport struct {
+	Table     string        `json:"table"`
+	Rule      RetentionRule `json:"rule"`
+	TotalRows int64         `json:"totalRows"`
+	Deleted   int64         `json:"deleted"`
+}
+
+// RetentionReport 清理报告
+type RetentionReport struct {
+	DryRun     bool                   `json:"dryRun"`
+	Tables     []RetentionTableReport `json:"tables"`
+	Deleted    int64                  `json:"deleted"`
+	SizeBefore int64                  `json:"sizeBefore"` // 数据库文件大小（字节）
+	SizeAfter  int64                  `json:"sizeAfter"`
+	Vacuumed   bool                   `json:"vacuumed"`
+	RanAt      string                 `json:"ranAt"`
+	DurationMs int64                  `json:"durationMs"`
+}
+
+// retentionTable 可清理的表：日期列用于按天数清理，代码列用于按股票保留条数
+type retentionTable struct {
+	name    string
+	dateCol string
+	codeCol string
+}
+
+var retentionTables = []retentionTable{
+	{name: "price_alert_trigger_history", dateCol: "triggered_at", codeCol: "stock_code"},
+	{name: "alert_history", dateCol: "triggered_at", codeCol: "stock_code"},
+	{name: "sync_history", dateCol: "created_at", codeCol: "stock_code"},
+	{name: "stock_strategy_signals", dateCol: "trade_date", codeCol: "code"},
+	{name: "stock_money_flow_hist", dateCol: "trade_date", codeCol: "code"},
+}
+
+// DefaultRetentionPolicy 默认策略：规则均未启用，启用后每天检查一次
+func DefaultRetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{
+		IntervalHours: 24,
+		Vacuum:        true,
+		Rules: []RetentionRule{
+			{Table: "price_alert_trigger_history", KeepDays: 180},
+			{Table: "alert_history", KeepDays: 180},
+			{Table: "sync_history", KeepDays: 90},
+			{Table: "stock_strategy_signals", KeepDays: 365},
+			{Table: "stock_money_flow_hist", KeepRowsPerCode: 500},
+		},
+	}
+}
+
+// RetentionService 按保留策略清理历史数据，并在清理后执行 VACUUM / ANALYZE
+type RetentionService struct {
+	dbService *DBService
+	now       func() time.Time
+
+	mu      sync.Mutex // 同一时间只执行一次清理
+	stateMu sync.Mutex
+	stop    chan struct{}
+}
+
+// NewRetentionService 创建数据保留服务
+func NewRetentionService(db *DBService) *RetentionService {
+	return &RetentionService{dbService: db, now: time.Now}
+}
+
+// GetPolicy 读取保留策略，未配置的表使用默认规则（未启用）
+func (s *RetentionService) GetPolicy() (RetentionPolicy, error) {
+	policy := DefaultRetentionPolicy()
+	var entity models.ConfigEntity
+	err := s.dbService.GetDB().Where("key = ?", configRetentionPolicy).Limit(1).Find(&entity).Error
+	if err != nil {
+		return policy, fmt.Errorf("读取数据保留策略失败: %w", err)
+	}
+	if entity.Value == "" {
+		return policy, nil
+	}
+
+	var saved RetentionPolicy
+	if err := json.Unmarshal([]byte(entity.Value), &saved); err != nil {
+		logger.Warn("数据保留策略格式错误，使用默认策略",
+			zap.String("module", "services.retention"),
+			zap.String("op", "GetPolicy"),
+			zap.Error(err),
+		)
+		return policy, nil
+	}
+	return mergeRetentionPolicy(saved), nil
+}
+
+// mergeRetentionPolicy 补齐缺失表的默认规则并丢弃未知表，规则顺序与 retentionTables 一致
+func mergeRetentionPolicy(saved RetentionPolicy) RetentionPolicy {
+	byTable := make(map[string]RetentionRule, len(saved.Rules))
+	for _, r := range saved.Rules {
+		byTable[r.Table] = r
+	}
+	merged := saved
+	merged.Rules = nil
+	for _, def := range DefaultRetentionPolicy().Rules {
+		if r, ok := byTable[def.Table]; ok {
+			merged.Rules = append(merged.Rules, r)
+		} else {
+			merged.Rules = append(merged.Rules, def)
+		}
+	}
+	return merged
+}
+
+// SavePolicy 校验并保存保留策略
+func (s *RetentionService) SavePolicy(policy RetentionPolicy) error {
+	if policy.IntervalHours < 0 {
+		return fmt.Errorf("执行间隔不能为负数")
+	}
+	for _, r := range policy.Rules {
+		if _, ok := findRetentionTable(r.Table); !ok {
+			return fmt.Errorf("不支持清理的表: %s", r.Table)
+		}
+		if r.KeepDays < 0 || r.KeepRowsPerCode < 0 {
+			return fmt.Errorf("%s 的保留天数和条数不能为负数", r.Table)
+		}
+		if r.Enabled && r.KeepDays == 0 && r.KeepRowsPerCode == 0 {
+			return fmt.Errorf("%s 已启用但未设置保留天数或条数", r.Table)
+		}
+	}
+
+	data, err := json.Marshal(mergeRetentionPolicy(policy))
+	if err != nil {
+		return fmt.Errorf("序列化数据保留策略失败: %w", err)
+	}
+	return s.setConfig(configRetentionPolicy, string(data))
+}
+
+func (s *RetentionService) setConfig(key, value string) error {
+	err := s.dbService.GetDB().Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value"}),
+	}).Create(&models.ConfigEntity{Key: key, Value: value}).Error
+	if err != nil {
+		return fmt.Errorf("保存配置项 %s 失败: %w", key, err)
+	}
+	return nil
+}
+
+func findRetentionTable(name string) (retentionTable, bool) {
+	for _, t := range retentionTables {
+		if t.name == name {
+			return t, true
+		}
+	}
+	return retentionTable{}, false
+}
+
+// predicate 返回需要清理的记录条件；规则未启用或未设置任何限制时返回空
+func (t retentionTable) predicate(rule RetentionRule, today time.Time) (string, []interface{}) {
+	if !rule.Enabled {
+		return "", nil
+	}
+	var parts []string
+	var args []interface{}
+	if rule.KeepDays > 0 {
+		// 日期列为 YYYY-MM-DD 开头的文本，按字符串比较即可
+		cutoff := today.AddDate(0, 0, -rule.KeepDays).Format("2006-01-02")
+		parts = append(parts, fmt.Sprintf("%s < ?", t.dateCol))
+		args = append(args, cutoff)
+	}
+	if rule.KeepRowsPerCode > 0 {
+		parts = append(parts, fmt.Sprintf(
+			"rowid IN (SELECT rowid FROM (SELECT rowid, ROW_NUMBER() OVER (PARTITION BY %s ORDER BY %s DESC) AS rn FROM %s) WHERE rn > ?)",
+			t.codeCol, t.dateCol, t.name))
+		args = append(args, rule.KeepRowsPerCode)
+	}
+	return strings.Join(parts, " OR "), args
+}
+
+// Preview 预演：统计按当前策略将被清理的记录数，不修改数据
+func (s *RetentionService) Preview() (*RetentionReport, error) {
+	policy, err := s.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+	return s.execute(policy, true)
+}
+
+// Run 按当前策略清理，之后执行 VACUUM（有记录被清理且策略允许时）与 ANALYZE
+func (s *RetentionService) Run() (*RetentionReport, error) {
+	policy, err := s.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+	return s.execute(policy, false)
+}
+
+func (s *RetentionService) execute(policy RetentionPolicy, dryRun bool) (*RetentionReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := time.Now()
+	now := s.now().In(chinaLocation)
+	today := startOfChinaDay(now)
+	db := s.dbService.GetDB()
+	report := &RetentionReport{DryRun: dryRun, RanAt: now.Format("2006-01-02 15:04:05"), SizeBefore: s.dbSize()}
+
+	for _, rule := range policy.Rules {
+		t, ok := findRetentionTable(rule.Table)
+		if !ok || !db.Migrator().HasTable(t.name) {
+			continue
+		}
+		tr := RetentionTableReport{Table: t.name, Rule: rule}
+		if err := db.Table(t.name).Count(&tr.TotalRows).Error; err != nil {
+			return nil, fmt.Errorf("统计 %s 失败: %w", t.name, err)
+		}
+
+		if where, args := t.predicate(rule, today); where != "" {
+			var err error
+			if dryRun {
+				err = db.Table(t.name).Where(where, args...).Count(&tr.Deleted).Error
+			} else {
+				result := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", t.name, where), args...)
+				tr.Deleted, err = result.RowsAffected, result.Error
+			}
+			if err != nil {
+				return nil, fmt.Errorf("清理 %s 失败: %w", t.name, err)
+			}
+		}
+		report.Deleted += tr.Deleted
+		report.Tables = append(report.Tables, tr)
+	}
+
+	if !dryRun {
+		if err := s.compact(report, policy.Vacuum && report.Deleted > 0); err != nil {
+			return nil, err
+		}
+		if err := s.setConfig(configRetentionLastRun, now.Format(time.RFC3339)); err != nil {
+			return nil, err
+		}
+	}
+	report.SizeAfter = s.dbSize()
+	report.DurationMs = time.Since(start).Milliseconds()
+
+	logger.Info("数据保留策略执行完成",
+		zap.String("module", "services.retention"),
+		zap.String("op", "execute"),
+		zap.Bool("dryRun", dryRun),
+		zap.Int64("deleted", report.Deleted),
+		zap.Bool("vacuumed", report.Vacuumed),
+		zap.Int64("sizeBefore", report.SizeBefore),
+		zap.Int64("sizeAfter", report.SizeAfter),
+		zap.Int64("duration_ms", report.DurationMs),
+	)
+	return report, nil
+}
+
+// compact 执行 VACUUM（可选）与 ANALYZE，并截断 WAL 使文件大小反映实际占用
+func (s *RetentionService) compact(report *RetentionReport, vacuum bool) error {
+	db := s.dbService.GetDB()
+	if vacuum {
+		if err := db.Exec("VACUUM").Error; err != nil {
+			return fmt.Errorf("VACUUM 失败: %w", err)
+		}
+		report.Vacuumed = true
+	}
+	if err := db.Exec("ANALYZE").Error; err != nil {
+		return fmt.Errorf("ANALYZE 失败: %w", err)
+	}
+	if err := db.Exec("PRAGMA wal_checkpoint(TRUNCATE)").Error; err != nil {
+		logger.Warn("WAL 检查点失败",
+			zap.String("module", "services.retention"),
+			zap.String("op", "compact"),
+			zap.Error(err),
+		)
+	}
+	return nil
+}
+
+// dbSize 数据库文件与 WAL 文件的总大小
+func (s *RetentionService) dbSize() int64 {
+	var size int64
+	for _, path := range []string{s.dbService.GetDBPath(), s.dbService.GetDBPath() + "-wal"} {
+		if info, err := os.Stat(path); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// lastRun 上次实际执行清理的时间，未执行过时返回零值
+func (s *RetentionService) lastRun() time.Time {
+	var entity models.ConfigEntity
+	if err := s.dbService.GetDB().Where("key = ?", configRetentionLastRun).Limit(1).Find(&entity).Error; err != nil {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339, entity.Value)
+	return t
+}
+
+// due 是否到了自动执行的时间：有启用的规则、间隔已到且不在交易时段（避免 VACUUM 阻塞盘中写入）
+func (s *RetentionService) due(policy RetentionPolicy, now time.Time) bool {
+	if policy.IntervalHours <= 0 || GetTradingCalendar().IsSessionOpen(now) {
+		return false
+	}
+	enabled := false
+	for _, r := range policy.Rules {
+		enabled = enabled || r.Enabled
+	}
+	return enabled && now.Sub(s.lastRun()) >= time.Duration(policy.IntervalHours)*time.Hour
+}
+
+// Start 启动定时清理，ctx 结束或调用 Stop 时退出
+func (s *RetentionService) Start(ctx context.Context) {
+	s.stateMu.Lock()
+	if s.stop != nil {
+		s.stateMu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	s.stop = stop
+	s.stateMu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(retentionCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.runIfDue()
+			}
+		}
+	}()
+	logger.Info("数据保留定时清理已启动",
+		zap.String("module", "services.retention"),
+		zap.String("op", "Start"),
+		zap.Duration("checkInterval", retentionCheckInterval),
+	)
+}
+
+// Stop 停止定时清理
+func (s *RetentionService) Stop() {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+func (s *RetentionService) runIfDue() {
+	policy, err := s.GetPolicy()
+	if err != nil || !s.due(policy, s.now()) {
+		return
+	}
+	if _, err := s.execute(policy, false); err != nil {
+		logger.Error("定时数据清理失败",
+			zap.String("module", "services.retention"),
+			zap.String("op", "runIfDue"),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"stock-analyzer-wails/models"
+)
+
+func TestRetention_DefaultPolicyDeletesNothing(t *testing.T) {
+	db := newTestDB(t)
+	mustCreate(t, db, &models.SyncHistoryEntity{StockCode: "600519", SyncType: "single", StartDate: "2020-01-01", EndDate: "2020-12-31", Status: "success", CreatedAt: cst("2020-12-31 15:00:00")})
+
+	svc := NewRetentionService(db)
+	policy, err := svc.GetPolicy()
+	if err != nil || len(policy.Rules) != len(retentionTables) {
+		t.Fatalf("GetPolicy = %+v, %v", policy, err)
+	}
+	for _, r := range policy.Rules {
+		if r.Enabled {
+			t.Fatalf("default rules must be disabled, got %+v", r)
+		}
+	}
+	report, err := svc.Run()
+	if err != nil || report.Deleted != 0 || report.Vacuumed {
+		t.Fatalf("Run = %+v, %v", report, err)
+	}
+}
+
+func TestRetention_PreviewThenRun(t *testing.T) {
+	db := newTestDB(t)
+	mustCreate(t, db,
+		&models.PriceAlertTriggerHistoryEntity{AlertID: 1, StockCode: "600519", AlertType: "target_price", TriggeredAt: cst("2023-01-10 10:00:00")},
+		&models.PriceAlertTriggerHistoryEntity{AlertID: 1, StockCode: "600519", AlertType: "target_price", TriggeredAt: cst("2024-06-10 10:00:00")},
+	)
+	for _, date := range []string{"2024-06-03", "2024-06-04", "2024-06-05", "2024-06-06", "2024-06-07"} {
+		mustCreate(t, db, &models.StockMoneyFlowHistEntity{Code: "600519", TradeDate: date})
+	}
+	mustCreate(t, db,
+		&models.StockMoneyFlowHistEntity{Code: "000001", TradeDate: "2024-06-06"},
+		&models.StockMoneyFlowHistEntity{Code: "000001", TradeDate: "2024-06-07"},
+	)
+
+	svc := NewRetentionService(db)
+	svc.now = func() time.Time { return cst("2024-06-15 20:00:00") } // 周六
+	policy := DefaultRetentionPolicy()
+	for i := range policy.Rules {
+		switch policy.Rules[i].Table {
+		case "price_alert_trigger_history":
+			policy.Rules[i].Enabled, policy.Rules[i].KeepDays = true, 90
+		case "stock_money_flow_hist":
+			policy.Rules[i].Enabled, policy.Rules[i].KeepRowsPerCode = true, 3
+		}
+	}
+	if err := svc.SavePolicy(policy); err != nil {
+		t.Fatalf("SavePolicy: %v", err)
+	}
+
+	preview, err := svc.Preview()
+	if err != nil || !preview.DryRun || preview.Deleted != 3 {
+		t.Fatalf("Preview = %+v, %v", preview, err)
+	}
+	var flows int64
+	db.GetDB().Model(&models.StockMoneyFlowHistEntity{}).Count(&flows)
+	if flows != 7 {
+		t.Fatalf("dry run must not delete, got %d money flow rows", flows)
+	}
+	if !svc.due(policy, svc.now()) {
+		t.Fatalf("expected the first scheduled run to be due")
+	}
+
+	report, err := svc.Run()
+	if err != nil || report.Deleted != 3 || !report.Vacuumed {
+		t.Fatalf("Run = %+v, %v", report, err)
+	}
+	var dates []string
+	db.GetDB().Model(&models.StockMoneyFlowHistEntity{}).Where("code = ?", "600519").Order("trade_date").Pluck("trade_date", &dates)
+	if len(dates) != 3 || dates[0] != "2024-06-05" {
+		t.Fatalf("expected the latest 3 rows to be kept, got %v", dates)
+	}
+	var history int64
+	db.GetDB().Model(&models.PriceAlertTriggerHistoryEntity{}).Count(&history)
+	if history != 1 {
+		t.Fatalf("expected old trigger history to be deleted, got %d", history)
+	}
+
+	// 刚执行过，间隔未到；交易时段内也不自动执行
+	if svc.due(policy, svc.now().Add(time.Hour)) {
+		t.Fatalf("expected next run to wait for the interval")
+	}
+	if svc.due(policy, cst("2024-06-17 10:00:00")) {
+		t.Fatalf("expected no scheduled run during a trading session")
+	}
+	if !svc.due(policy, cst("2024-06-17 20:00:00")) {
+		t.Fatalf("expected a run after the interval outside trading hours")
+	}
+}
+
+func TestRetention_SavePolicyValidation(t *testing.T) {
+	svc := NewRetentionService(newTestDB(t))
+	cases := []RetentionPolicy{
+		{Rules: []RetentionRule{{Table: "stocks", Enabled: true, KeepDays: 1}}},
+		{Rules: []RetentionRule{{Table: "sync_history", Enabled: true}}},
+		{Rules: []RetentionRule{{Table: "sync_history", KeepDays: -1}}},
+		{IntervalHours: -1},
+	}
+	for _, p := range cases {
+		if err := svc.SavePolicy(p); err == nil {
+			t.Fatalf("expected %+v to be rejected", p)
+		}
+	}
+
+	if err := svc.SavePolicy(RetentionPolicy{Rules: []RetentionRule{{Table: "sync_history", Enabled: true, KeepDays: 30}}}); err != nil {
+		t.Fatalf("SavePolicy: %v", err)
+	}
+	policy, err := svc.GetPolicy()
+	if err != nil || len(policy.Rules) != len(retentionTables) || policy.IntervalHours != 0 {
+		t.Fatalf("GetPolicy = %+v, %v", policy, err)
+	}
+	for _, r := range policy.Rules {
+		if r.Table == "sync_history" && (!r.Enabled || r.KeepDays != 30) {
+			t.Fatalf("saved rule not kept: %+v", r)
+		}
+	}
+}
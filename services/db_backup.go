@@ -0,0 +1,256 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"stock-analyzer-wails/internal/logger"
+
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gorm_logger "gorm.io/gorm/logger"
+)
+
+// 备份类型（由文件名区分）
+const (
+	DatabaseBackupManual     = "manual"      // 手动备份
+	DatabaseBackupMigration  = "migration"   // 结构迁移前自动备份
+	DatabaseBackupPreRestore = "pre-restore" // 恢复前对被替换数据库的备份
+	DatabaseBackupPreImport  = "pre-import"  // 导入用户数据前的备份
+)
+
+// restoreSuffix 待恢复数据库的暂存文件后缀，下次启动打开数据库前替换
+const restoreSuffix = ".restore"
+
+// DatabaseBackup 一份数据库备份文件
+type DatabaseBackup struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Kind      string `json:"kind"`
+	Size      int64  `json:"size"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// backupDir 备份目录（与数据库文件同级的 backups 目录）
+func backupDir(dbPath string) string {
+	return filepath.Join(filepath.Dir(dbPath), "backups")
+}
+
+// backupPath 按类型生成备份文件路径：{base}.{kind}-{时间}.bak，同一秒内重复备份时追加序号
+func backupPath(dbPath, kind string) string {
+	prefix := filepath.Join(backupDir(dbPath), fmt.Sprintf("%s.%s-%s", filepath.Base(dbPath), kind, time.Now().Format("20060102-150405")))
+	path := prefix + ".bak"
+	for i := 2; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = fmt.Sprintf("%s-%d.bak", prefix, i)
+	}
+}
+
+// Backup 手动备份整个数据库，返回备份文件信息
+func (s *DBService) Backup() (*DatabaseBackup, error) {
+	return s.backupAs(DatabaseBackupManual)
+}
+
+// backupAs 按指定类型备份数据库
+func (s *DBService) backupAs(kind string) (*DatabaseBackup, error) {
+	path := backupPath(s.dbPath, kind)
+	if err := s.backupTo(path); err != nil {
+		return nil, err
+	}
+	logger.Info("数据库已备份",
+		zap.String("module", "services.db"),
+		zap.String("op", "backupAs"),
+		zap.String("kind", kind),
+		zap.String("backup", path),
+	)
+	return describeBackup(path, kind)
+}
+
+// ListBackups 列出备份目录中的数据库备份（按时间倒序）
+func (s *DBService) ListBackups() ([]DatabaseBackup, error) {
+	base := filepath.Base(s.dbPath)
+	paths, err := filepath.Glob(filepath.Join(backupDir(s.dbPath), base+".*.bak"))
+	if err != nil {
+		return nil, fmt.Errorf("读取备份目录失败: %w", err)
+	}
+
+	backups := make([]DatabaseBackup, 0, len(paths))
+	for _, path := range paths {
+		b, err := describeBackup(path, backupKind(base, filepath.Base(path)))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, *b)
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt > backups[j].CreatedAt })
+	return backups, nil
+}
+
+// backupKind 根据文件名判断备份类型
+func backupKind(base, name string) string {
+	rest := strings.TrimPrefix(name, base+".")
+	for _, kind := range []string{DatabaseBackupManual, DatabaseBackupPreRestore, DatabaseBackupPreImport} {
+		if strings.HasPrefix(rest, kind+"-") {
+			return kind
+		}
+	}
+	return DatabaseBackupMigration
+}
+
+// describeBackup 读取备份文件信息
+func describeBackup(path, kind string) (*DatabaseBackup, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取备份文件失败: %w", err)
+	}
+	return &DatabaseBackup{
+		Name:      filepath.Base(path),
+		Path:      path,
+		Kind:      kind,
+		Size:      info.Size(),
+		CreatedAt: info.ModTime().Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+// StageRestore 校验备份文件并暂存为待恢复数据库，重启应用后生效。
+// 运行中的连接不受影响；恢复时当前数据库会先另存为 pre-restore 备份。
+func (s *DBService) StageRestore(path string) error {
+	staged := s.dbPath + restoreSuffix
+	tmp := staged + ".tmp"
+	_ = os.Remove(tmp)
+
+	// 先复制再校验，避免打开用户选择的文件时对其产生任何写入
+	if err := copyFile(path, tmp); err != nil {
+		return fmt.Errorf("复制备份文件失败: %w", err)
+	}
+	if err := validateDatabaseFile(tmp); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, staged); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("暂存待恢复数据库失败: %w", err)
+	}
+
+	logger.Info("已暂存待恢复数据库，重启后生效",
+		zap.String("module", "services.db"),
+		zap.String("op", "StageRestore"),
+		zap.String("source", path),
+	)
+	return nil
+}
+
+// HasPendingRestore 是否有等待重启后恢复的数据库
+func (s *DBService) HasPendingRestore() bool {
+	_, err := os.Stat(s.dbPath + restoreSuffix)
+	return err == nil
+}
+
+// validateDatabaseFile 校验文件是完整的本应用数据库，且结构版本不高于当前程序
+func validateDatabaseFile(path string) error {
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: gorm_logger.Default.LogMode(gorm_logger.Silent)})
+	if err != nil {
+		return fmt.Errorf("无法打开备份文件: %w", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	var result string
+	if err := db.Raw("PRAGMA integrity_check").Scan(&result).Error; err != nil {
+		return fmt.Errorf("备份文件不是有效的 SQLite 数据库: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("备份文件已损坏: %s", result)
+	}
+	if !db.Migrator().HasTable("watchlist") || !db.Migrator().HasTable("config") {
+		return fmt.Errorf("备份文件不是本应用的数据库")
+	}
+	if db.Migrator().HasTable("schema_version") {
+		var version int
+		if err := db.Raw("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version).Error; err != nil {
+			return fmt.Errorf("读取备份结构版本失败: %w", err)
+		}
+		if version > LatestSchemaVersion() {
+			return fmt.Errorf("备份由更新版本的程序创建（结构版本 v%d，当前支持 v%d）", version, LatestSchemaVersion())
+		}
+	}
+	return nil
+}
+
+// applyPendingRestore 在打开数据库前应用暂存的恢复文件：
+// 当前数据库先另存为 pre-restore 备份，再用暂存文件替换（旧版本备份随后由结构迁移升级）。
+func applyPendingRestore(dbPath string) error {
+	staged := dbPath + restoreSuffix
+	if _, err := os.Stat(staged); err != nil {
+		return nil
+	}
+
+	if _, err := os.Stat(dbPath); err == nil {
+		preRestore := backupPath(dbPath, DatabaseBackupPreRestore)
+		if err := vacuumInto(dbPath, preRestore); err != nil {
+			return fmt.Errorf("恢复前备份当前数据库失败: %w", err)
+		}
+		logger.Info("恢复前已备份当前数据库",
+			zap.String("module", "services.db"),
+			zap.String("op", "applyPendingRestore"),
+			zap.String("backup", preRestore),
+		)
+	}
+
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("清理数据库日志文件失败: %w", err)
+		}
+	}
+	if err := os.Rename(staged, dbPath); err != nil {
+		return fmt.Errorf("替换数据库文件失败: %w", err)
+	}
+	logger.Info("已从备份恢复数据库",
+		zap.String("module", "services.db"),
+		zap.String("op", "applyPendingRestore"),
+		zap.String("dbPath", dbPath),
+	)
+	return nil
+}
+
+// vacuumInto 打开指定数据库并生成一致的副本（会合并 WAL 中的数据）
+func vacuumInto(src, dst string) error {
+	db, err := gorm.Open(sqlite.Open(src), &gorm.Config{Logger: gorm_logger.Default.LogMode(gorm_logger.Silent)})
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+	return (&DBService{db: db, dbPath: src}).backupTo(dst)
+}
+
+// copyFile 复制文件内容
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
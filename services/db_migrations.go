@@ -0,0 +1,174 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"stock-analyzer-wails/internal/logger"
+	"stock-analyzer-wails/models"
+
+	"go.uber.org/zap"
+)
+
+// migrationBackupKeep 保留的迁移前备份数量
+const migrationBackupKeep = 3
+
+// schemaMigration 一次数据库结构迁移。
+// up 必须幂等：版本号在 up 成功后才写入 schema_version，中途退出时下次启动会重新执行。
+type schemaMigration struct {
+	version     int
+	description string
+	up          func(s *DBService) error
+}
+
+// schemaMigrations 按版本升序排列。已发布的迁移不能修改或删除，只能追加新版本。
+var schemaMigrations = []schemaMigration{
+	{version: 1, description: "修复 watchlist 表结构与空名称", up: (*DBService).manualMigrateWatchlistTable},
+	{version: 2, description: "stocks.code 唯一索引改为普通索引", up: (*DBService).relaxStockCodeIndex},
+	{version: 3, description: "按股票分表的 K 线缓存迁入 kline_bars", up: (*DBService).migrateToKLineBars},
+	{version: 4, description: "修正 stocks 中交易所与代码不符的记录", up: (*DBService).repairStockMarkets},
+}
+
+// LatestSchemaVersion 当前程序支持的最新结构版本
+func LatestSchemaVersion() int {
+	return schemaMigrations[len(schemaMigrations)-1].version
+}
+
+// SchemaVersion 返回数据库已执行到的结构版本（未执行过任何迁移时为 0）
+func (s *DBService) SchemaVersion() (int, error) {
+	var version int
+	if err := s.db.Model(&models.SchemaVersionEntity{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+		return 0, fmt.Errorf("查询数据库结构版本失败: %w", err)
+	}
+	return version, nil
+}
+
+// AppliedMigrations 返回已执行的结构迁移记录（按版本升序）
+func (s *DBService) AppliedMigrations() ([]models.SchemaVersionEntity, error) {
+	var applied []models.SchemaVersionEntity
+	if err := s.db.Order("version ASC").Find(&applied).Error; err != nil {
+		return nil, fmt.Errorf("查询结构迁移记录失败: %w", err)
+	}
+	return applied, nil
+}
+
+// runMigrations 按版本执行尚未执行的结构迁移。
+// 已有数据库在迁移前先备份，备份失败时不执行迁移。
+func (s *DBService) runMigrations(isNewDB bool) error {
+	if err := s.db.AutoMigrate(&models.SchemaVersionEntity{}); err != nil {
+		return fmt.Errorf("创建 schema_version 表失败: %w", err)
+	}
+	current, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	var pending []schemaMigration
+	for _, m := range schemaMigrations {
+		if m.version > current {
+			pending = append(pending, m)
+		}
+	}
+	if len(pending) == 0 {
+		if current > LatestSchemaVersion() {
+			logger.Warn("数据库结构版本高于当前程序，可能由更新版本创建",
+				zap.String("module", "services.db"),
+				zap.String("op", "runMigrations"),
+				zap.Int("current", current),
+				zap.Int("latest", LatestSchemaVersion()),
+			)
+		}
+		return nil
+	}
+
+	if !isNewDB {
+		backupPath, err := s.backupBeforeMigration(current)
+		if err != nil {
+			return err
+		}
+		logger.Info("数据库迁移前已备份",
+			zap.String("module", "services.db"),
+			zap.String("op", "runMigrations"),
+			zap.String("backup", backupPath),
+			zap.Int("from", current),
+			zap.Int("to", LatestSchemaVersion()),
+		)
+	}
+
+	for _, m := range pending {
+		start := time.Now()
+		if err := m.up(s); err != nil {
+			return fmt.Errorf("数据库迁移 v%d（%s）失败: %w", m.version, m.description, err)
+		}
+		record := models.SchemaVersionEntity{
+			Version:     m.version,
+			Description: m.description,
+			AppliedAt:   time.Now(),
+			DurationMs:  time.Since(start).Milliseconds(),
+		}
+		if err := s.db.Create(&record).Error; err != nil {
+			return fmt.Errorf("记录数据库迁移 v%d 失败: %w", m.version, err)
+		}
+		logger.Info("数据库迁移完成",
+			zap.String("module", "services.db"),
+			zap.String("op", "runMigrations"),
+			zap.Int("version", m.version),
+			zap.String("description", m.description),
+			zap.Int64("duration_ms", record.DurationMs),
+		)
+	}
+	return nil
+}
+
+// backupBeforeMigration 将数据库备份到 backups 目录，只保留最近 migrationBackupKeep 份迁移前备份
+func (s *DBService) backupBeforeMigration(version int) (string, error) {
+	dir := backupDir(s.dbPath)
+	base := filepath.Base(s.dbPath)
+	path := filepath.Join(dir, fmt.Sprintf("%s.v%d-%s.bak", base, version, time.Now().Format("20060102-150405")))
+	if err := s.backupTo(path); err != nil {
+		return "", fmt.Errorf("迁移前备份数据库失败: %w", err)
+	}
+
+	// 文件名中版本号位数可能不同，按修改时间排序
+	old, _ := filepath.Glob(filepath.Join(dir, base+".v*-*.bak"))
+	sort.Slice(old, func(i, j int) bool { return modTime(old[i]).Before(modTime(old[j])) })
+	for len(old) > migrationBackupKeep {
+		if err := os.Remove(old[0]); err != nil {
+			logger.Warn("删除旧的迁移备份失败",
+				zap.String("module", "services.db"),
+				zap.String("op", "backupBeforeMigration"),
+				zap.String("path", old[0]),
+				zap.Error(err),
+			)
+		}
+		old = old[1:]
+	}
+	return path, nil
+}
+
+// backupTo 使用 VACUUM INTO 生成一致的数据库副本（包含 WAL 中尚未合并的数据），目标文件不能已存在
+func (s *DBService) backupTo(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建备份目录失败: %w", err)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("备份文件已存在: %s", path)
+	}
+	if err := s.db.Exec("VACUUM INTO ?", path).Error; err != nil {
+		_ = os.Remove(path)
+		return fmt.Errorf("备份数据库失败: %w", err)
+	}
+	return nil
+}
+
+// modTime 返回文件修改时间，出错时返回零值
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"stock-analyzer-wails/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// openFixtureDB 按 testdata/db 下的 SQL 导出生成旧版数据库文件，再用 DBService 打开（触发迁移）
+func openFixtureDB(t *testing.T, fixture string) *DBService {
+	t.Helper()
+	script, err := os.ReadFile(filepath.Join("testdata", "db", fixture))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "stock_analyzer_v2.db")
+	raw, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open raw db: %v", err)
+	}
+	for _, stmt := range strings.Split(string(script), ";\n") {
+		var lines []string
+		for _, line := range strings.Split(stmt, "\n") {
+			if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+				lines = append(lines, line)
+			}
+		}
+		if stmt = strings.TrimSpace(strings.Join(lines, "\n")); stmt == "" {
+			continue
+		}
+		if err := raw.Exec(stmt).Error; err != nil {
+			t.Fatalf("exec fixture statement %q: %v", stmt, err)
+		}
+	}
+	if sqlDB, err := raw.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+
+	db, err := NewDBServiceWithPath(path)
+	if err != nil {
+		t.Fatalf("migrate fixture %s: %v", fixture, err)
+	}
+	t.Cleanup(db.Close)
+	return db
+}
+
+// assertMigrated 检查已执行到最新版本，且迁移前生成了备份
+func assertMigrated(t *testing.T, db *DBService) {
+	t.Helper()
+	version, err := db.SchemaVersion()
+	if err != nil || version != LatestSchemaVersion() {
+		t.Fatalf("SchemaVersion = %d, %v; want %d", version, err, LatestSchemaVersion())
+	}
+	backups, _ := filepath.Glob(filepath.Join(filepath.Dir(db.GetDBPath()), "backups", "*.bak"))
+	if len(backups) != 1 {
+		t.Fatalf("expected one pre-migration backup, got %v", backups)
+	}
+}
+
+func watchlistNames(t *testing.T, db *DBService) map[string]string {
+	t.Helper()
+	var rows []models.WatchlistEntity
+	if err := db.GetDB().Find(&rows).Error; err != nil {
+		t.Fatalf("query watchlist: %v", err)
+	}
+	names := make(map[string]string, len(rows))
+	for _, r := range rows {
+		names[r.Code] = r.Name
+	}
+	return names
+}
+
+func TestMigrations_FromEarlyDatabase(t *testing.T) {
+	db := openFixtureDB(t, "v0_early.sql")
+	assertMigrated(t, db)
+
+	// v1：补齐 name 列，名称取自 data 中的 name，没有时使用代码
+	want := map[string]string{"600519": "贵州茅台", "000002": "000002"}
+	if got := watchlistNames(t, db); !reflect.DeepEqual(got, want) {
+		t.Fatalf("watchlist names = %v, want %v", got, want)
+	}
+
+	// v2：同号的沪市指数可以写入
+	if err := db.GetDB().Create(&models.StockEntity{Code: "000001", Market: MarketSH, FullCode: "SH000001", Type: InstrumentTypeIndex}).Error; err != nil {
+		t.Fatalf("expected stocks.code to be non-unique: %v", err)
+	}
+
+	// v3：没有 adjust 列的旧表按前复权迁入
+	bars, err := db.GetKLinePeriodDataFromCache("600519", KLinePeriodDaily, KLineAdjustForward, 10)
+	if err != nil || len(bars) != 2 || bars[1]["close"] != 10.8 {
+		t.Fatalf("unexpected migrated bars: %v, err=%v", bars, err)
+	}
+	if db.GetDB().Migrator().HasTable("kline_600519") {
+		t.Fatalf("expected legacy kline table to be dropped")
+	}
+}
+
+func TestMigrations_FromNullNamesDatabase(t *testing.T) {
+	db := openFixtureDB(t, "v0_null_names.sql")
+	assertMigrated(t, db)
+
+	want := map[string]string{"600519": "贵州茅台", "000002": "000002", "300750": "宁德时代"}
+	if got := watchlistNames(t, db); !reflect.DeepEqual(got, want) {
+		t.Fatalf("watchlist names = %v, want %v", got, want)
+	}
+
+	// 已有配置不被默认值覆盖
+	var provider models.ConfigEntity
+	if err := db.GetDB().First(&provider, "key = ?", "market_data_provider").Error; err != nil || provider.Value != "tencent" {
+		t.Fatalf("expected existing config to be kept, got %+v, err=%v", provider, err)
+	}
+
+	stocks, err := db.GetAllSyncedStocks()
+	if err != nil || !reflect.DeepEqual(stocks, []string{"600519", "SH000001"}) {
+		t.Fatalf("GetAllSyncedStocks = %v, %v", stocks, err)
+	}
+	if adjust, _ := db.GetKLineCacheAdjust("600519", KLinePeriodDaily); adjust != KLineAdjustNone {
+		t.Fatalf("expected adjust mode to be kept, got %q", adjust)
+	}
+	if minute, _ := db.GetKLinePeriodDataFromCache("600519", KLinePeriod5Min, KLineAdjustForward, 10); len(minute) != 1 {
+		t.Fatalf("expected minute bars to be migrated, got %v", minute)
+	}
+}
+
+func TestMigrations_ResumesFromRecordedVersion(t *testing.T) {
+	db := openFixtureDB(t, "v2_kline_tables.sql")
+	assertMigrated(t, db)
+
+	applied, err := db.AppliedMigrations()
+	if err != nil || len(applied) != LatestSchemaVersion() || applied[0].DurationMs != 3 || applied[2].Version != 3 {
+		t.Fatalf("unexpected migration records: %+v, err=%v", applied, err)
+	}
+	if n, _ := db.GetKLineCountByCode("000001"); n != 2 {
+		t.Fatalf("expected 2 migrated bars, got %d", n)
+	}
+
+	// 再次打开不重复迁移、不再备份；上次未迁入的旧版 K 线缓存表在启动时重试
+	if err := db.GetDB().Exec(`CREATE TABLE kline_600000 (date TEXT PRIMARY KEY, open REAL, high REAL, low REAL, close REAL, volume INTEGER, created_at DATETIME, updated_at DATETIME)`).Error; err != nil {
+		t.Fatalf("create leftover table: %v", err)
+	}
+	if err := db.GetDB().Exec(`INSERT INTO kline_600000 (date, open, high, low, close, volume) VALUES ('2024-01-02', 10, 11, 9, 10.5, 100)`).Error; err != nil {
+		t.Fatalf("insert leftover bar: %v", err)
+	}
+	path := db.GetDBPath()
+	db.Close()
+	db, err = NewDBServiceWithPath(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	assertMigrated(t, db)
+	if applied, _ := db.AppliedMigrations(); len(applied) != LatestSchemaVersion() {
+		t.Fatalf("expected migrations not to be re-recorded, got %+v", applied)
+	}
+	if n, _ := db.GetKLineCountByCode("600000"); n != 1 || db.GetDB().Migrator().HasTable("kline_600000") {
+		t.Fatalf("expected the leftover table to be migrated on startup, got %d bars", n)
+	}
+}
+
+func TestMigrations_RepairsStockMarkets(t *testing.T) {
+	db := openFixtureDB(t, "v3_stale_markets.sql")
+	assertMigrated(t, db)
+
+	var rows []models.StockEntity
+	if err := db.GetDB().Order("full_code ASC").Find(&rows).Error; err != nil {
+		t.Fatalf("query stocks: %v", err)
+	}
+	var got []string
+	for _, r := range rows {
+		got = append(got, r.FullCode+":"+r.Market+":"+r.Type)
+	}
+	// SH920001 改为北交所；SH920002 已有正确记录，删除；沪市指数与深市股票不变
+	want := []string{"BJ920001:BJ:北交所", "BJ920002:BJ:北交所", "SH000001:SH:指数", "SZ000001:SZ:主板"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("stocks = %v, want %v", got, want)
+	}
+	if err := db.repairStockMarkets(); err != nil {
+		t.Fatalf("repairStockMarkets is not idempotent: %v", err)
+	}
+}
+
+func TestMigrations_Idempotent(t *testing.T) {
+	db := openFixtureDB(t, "v0_null_names.sql")
+	for _, m := range schemaMigrations {
+		if err := m.up(db); err != nil {
+			t.Fatalf("migration v%d is not idempotent: %v", m.version, err)
+		}
+	}
+	want := map[string]string{"600519": "贵州茅台", "000002": "000002", "300750": "宁德时代"}
+	if got := watchlistNames(t, db); !reflect.DeepEqual(got, want) {
+		t.Fatalf("watchlist names changed after re-running migrations: %v", got)
+	}
+	if n, _ := db.GetKLineCountByCode("600519"); n != 1 {
+		t.Fatalf("expected bars to be unchanged, got %d", n)
+	}
+}
+
+func TestMigrations_NewDatabaseSkipsBackup(t *testing.T) {
+	db := newTestDB(t)
+
+	if version, _ := db.SchemaVersion(); version != LatestSchemaVersion() {
+		t.Fatalf("expected new database at latest version, got %d", version)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(db.GetDBPath()), "backups")); !os.IsNotExist(err) {
+		t.Fatalf("expected no backup for a new database, err=%v", err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"stock-analyzer-wails/internal/logger"
 	"stock-analyzer-wails/models"
+	"stock-analyzer-wails/repositories"
 
 	"github.com/glebarez/sqlite"
 	"go.uber.org/zap"
@@ -27,11 +28,14 @@ type DBService struct {
 // NewDBService 初始化数据库连接并创建表
 func NewDBService() (*DBService, error) {
 	appDir := GetAppDataDir()
-	dbPath := filepath.Join(appDir, "stock_analyzer_v2.db")
+	return NewDBServiceWithPath(filepath.Join(appDir, "stock_analyzer_v2.db"))
+}
+
+// NewDBServiceWithPath 使用指定的数据库文件初始化（测试、备份恢复等场景）
+func NewDBServiceWithPath(dbPath string) (*DBService, error) {
 	logger.Info("开始初始化 SQLite 数据库服务 (GORM)",
 		zap.String("module", "services.db"),
 		zap.String("op", "NewDBService"),
-		zap.String("appDir", appDir),
 		zap.String("dbPath", dbPath),
 	)
 
@@ -47,6 +51,17 @@ func NewDBService() (*DBService, error) {
 		return nil, fmt.Errorf("创建数据库目录失败: %w", err)
 	}
 
+	// 应用上次暂存的备份恢复；失败时继续使用当前数据库
+	if err := applyPendingRestore(dbPath); err != nil {
+		logger.Error("从备份恢复数据库失败，继续使用当前数据库",
+			zap.String("module", "services.db"),
+			zap.String("op", "NewDBService"),
+			zap.String("step", "restore"),
+			zap.String("dbPath", dbPath),
+			zap.Error(err),
+		)
+	}
+
 	// 检查数据库文件是否存在
 	_, err := os.Stat(dbPath)
 	isNewDB := os.IsNotExist(err)
@@ -108,7 +123,7 @@ func NewDBService() (*DBService, error) {
 
 	// 初始化表结构
 	logger.Info("开始初始化数据库表结构", zap.String("path", dbPath))
-	if err := svc.initTables(); err != nil {
+	if err := svc.initTables(isNewDB); err != nil {
 		_ = sqlDB.Close()
 		logger.Error("初始化数据库表结构失败",
 			zap.String("module", "services.db"),
@@ -144,9 +159,16 @@ func (s *DBService) GetDBPath() string {
 }
 
 // initTables 初始化数据库表结构
-// 只进行表结构检查和创建，不进行数据迁移
-func (s *DBService) initTables() error {
-	// 清理可能存在的临时表（防止之前失败的迁移留下的临时表）
+// 先按版本执行结构迁移（修复旧库、搬迁数据），再用 AutoMigrate 补齐当前模型的表和列
+func (s *DBService) initTables(isNewDB bool) error {
+	if err := s.runMigrations(isNewDB); err != nil {
+		logger.Error("数据库结构迁移失败",
+			zap.String("module", "services.db"),
+			zap.String("op", "initTables"),
+			zap.Error(err),
+		)
+		return err
+	}
 
 	// 使用 AutoMigrate 自动创建/更新表结构
 	err := s.db.AutoMigrate(
@@ -164,6 +186,17 @@ func (s *DBService) initTables() error {
 		&models.StockMoneyFlowHistEntity{},
 		&models.StockStrategySignalEntity{},
 		&models.StockMoneyFlowHistEntity{},
+		&models.ExRightsEventEntity{},
+		&models.FinancialStatementEntity{},
+		&models.BoardEntity{},
+		&models.BoardMemberEntity{},
+		&models.IntradayPointEntity{},
+		&models.KLineBarEntity{},
+		&models.SchemaVersionEntity{},
+		&models.SyncJobEntity{},
+		&models.SyncJobItemEntity{},
+		&models.ScheduleEntity{},
+		&models.ScheduleRunEntity{},
 	)
 	if err != nil {
 		// 如果迁移失败，清理临时表并记录错误
@@ -175,10 +208,95 @@ func (s *DBService) initTables() error {
 		return fmt.Errorf("表结构迁移失败: %w", err)
 	}
 
+	// 结构迁移 v3 中迁移失败的旧版 K 线缓存表保留原样，每次启动重试迁入 kline_bars
+	if err := s.migrateLegacyKLineTables(); err != nil {
+		return err
+	}
+
 	// 插入默认配置
 	return s.insertDefaultConfigs()
 }
 
+// relaxStockCodeIndex 删除旧版 stocks 表上的 code 唯一索引，由 AutoMigrate 重建为普通索引
+func (s *DBService) relaxStockCodeIndex() error {
+	var indexSQL string
+	if err := s.db.Raw("SELECT sql FROM sqlite_master WHERE type='index' AND name='idx_stocks_code'").Scan(&indexSQL).Error; err != nil {
+		return fmt.Errorf("查询 stocks 索引失败: %w", err)
+	}
+	if !strings.Contains(strings.ToUpper(indexSQL), "UNIQUE") {
+		return nil
+	}
+
+	logger.Info("stocks.code 唯一索引改为普通索引",
+		zap.String("module", "services.db"),
+		zap.String("op", "relaxStockCodeIndex"),
+	)
+	if err := s.db.Exec("DROP INDEX IF EXISTS idx_stocks_code").Error; err != nil {
+		return fmt.Errorf("删除 stocks.code 唯一索引失败: %w", err)
+	}
+	return nil
+}
+
+// repairStockMarkets 修正 stocks 中交易所与代码不符的记录。
+// 早期版本无法识别 f13（数值）时一律记为沪市，北交所 920 代码段被存成 SH920xxx；
+// 已有正确记录的直接删除，否则改为正确的交易所、完整代码与类型
+func (s *DBService) repairStockMarkets() error {
+	if !s.db.Migrator().HasTable("stocks") {
+		return nil
+	}
+	var rows []struct {
+		ID     uint
+		Code   string
+		Market string
+	}
+	if err := s.db.Table("stocks").Select("id, code, market").Where("market <> ?", MarketBJ).Scan(&rows).Error; err != nil {
+		return fmt.Errorf("查询 stocks 失败: %w", err)
+	}
+
+	var repaired, removed int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, row := range rows {
+			if row.Code == "" || !isBJCode(row.Code) {
+				continue
+			}
+			inst := instrumentFromMarketID(0, row.Code)
+			var exists int64
+			if err := tx.Table("stocks").Where("full_code = ?", inst.FullCode()).Count(&exists).Error; err != nil {
+				return fmt.Errorf("查询 stocks 失败: %w", err)
+			}
+			if exists > 0 {
+				if err := tx.Table("stocks").Where("id = ?", row.ID).Delete(nil).Error; err != nil {
+					return fmt.Errorf("删除 %s%s 失败: %w", row.Market, row.Code, err)
+				}
+				removed++
+				continue
+			}
+			err := tx.Table("stocks").Where("id = ?", row.ID).Updates(map[string]interface{}{
+				"market":    inst.Market,
+				"full_code": inst.FullCode(),
+				"type":      inst.Type,
+			}).Error
+			if err != nil {
+				return fmt.Errorf("修正 %s%s 失败: %w", row.Market, row.Code, err)
+			}
+			repaired++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if repaired+removed > 0 {
+		logger.Info("已修正交易所错误的股票记录",
+			zap.String("module", "services.db"),
+			zap.String("op", "repairStockMarkets"),
+			zap.Int("repaired", repaired),
+			zap.Int("removed", removed),
+		)
+	}
+	return nil
+}
+
 // prepareWatchlistTable 在迁移前准备 watchlist 表
 // 1. 检查并添加缺失的字段（如果表存在但字段缺失）
 // 2. 修复 NULL 值
@@ -353,8 +471,8 @@ func (s *DBService) fixWatchlistNullValues() error {
 	return nil
 }
 
-// manualMigrateWatchlistTable 手动迁移 watchlist 表
-// 当 GORM AutoMigrate 失败时，使用此方法手动迁移
+// manualMigrateWatchlistTable 手动迁移 watchlist 表（结构迁移 v1）
+// 补齐字段、修复空名称，AutoMigrate 失败时重建表
 func (s *DBService) manualMigrateWatchlistTable() error {
 	// 检查表是否存在
 	if !s.db.Migrator().HasTable("watchlist") {
@@ -519,6 +637,7 @@ func (s *DBService) insertDefaultConfigs() error {
 	defaults := []models.ConfigEntity{
 		{Key: "trailing_stop_default_activation", Value: "0.05"}, // 默认盈利 5% 启动
 		{Key: "trailing_stop_default_callback", Value: "0.03"},   // 默认回撤 3% 止盈
+		{Key: "market_data_provider", Value: "eastmoney"},        // 默认行情数据源
 	}
 
 	for _, config := range defaults {
@@ -611,194 +730,448 @@ func (s *DBService) insertDefaultAlertTemplates() error {
 	return nil
 }
 
-// CreateKLineCacheTable 为指定股票创建 K 线缓存表
-// 表名格式：kline_{code}，例如 kline_600519
+// klineRepo 返回 K 线缓存仓库
+func (s *DBService) klineRepo() *repositories.KLineRepository {
+	return repositories.NewKLineRepository(s.db)
+}
+
+// klineStorePeriod 返回 kline_bars 中保存的周期名称，无法识别的周期按日线处理
+func klineStorePeriod(period string) string {
+	p, err := NormalizeKLinePeriod(period)
+	if err != nil {
+		return KLinePeriodDaily
+	}
+	return p
+}
+
+// klineBarsToRecords 将 K 线实体转换为缓存记录（与写入时的 map 结构一致）
+func klineBarsToRecords(bars []models.KLineBarEntity) []map[string]interface{} {
+	var klines []map[string]interface{}
+	for _, e := range bars {
+		klines = append(klines, map[string]interface{}{
+			"date":   e.Date,
+			"open":   e.Open,
+			"high":   e.High,
+			"low":    e.Low,
+			"close":  e.Close,
+			"volume": e.Volume,
+			"adjust": e.Adjust,
+		})
+	}
+	return klines
+}
+
+// CreateKLineCacheTable 确保 K 线缓存表存在。
+// 所有股票共用 kline_bars 表（初始化时已创建），保留该方法以兼容旧调用
 func (s *DBService) CreateKLineCacheTable(code string) error {
-	tableName := fmt.Sprintf("kline_%s", code)
-	// 使用 GORM 的 Table 方法指定表名，并使用 AutoMigrate 创建表
-	if err := s.db.Table(tableName).AutoMigrate(&models.KLineEntity{}); err != nil {
-		return fmt.Errorf("创建 K 线缓存表 %s 失败: %w", tableName, err)
+	if err := s.db.AutoMigrate(&models.KLineBarEntity{}); err != nil {
+		return fmt.Errorf("创建 K 线缓存表失败: %w", err)
 	}
 	return nil
 }
 
 // InsertOrUpdateKLineData 批量插入或更新 K 线数据
 func (s *DBService) InsertOrUpdateKLineData(code string, klines []map[string]interface{}) (int64, int64, error) {
-	tableName := fmt.Sprintf("kline_%s", code)
+	return s.InsertOrUpdateKLinePeriodData(code, KLinePeriodDaily, KLineAdjustForward, klines)
+}
+
+// InsertOrUpdateKLinePeriodData 批量插入或更新指定周期的 K 线数据（按 date 去重），返回新增和更新的条数。
+// 日线及以上周期每只股票只保存一种复权方式：若已有数据的复权方式不同，先清空再写入，避免价格基准混杂；
+// 分钟线按复权方式分别保存（行情源只保留最近几天，清空会永久丢失历史）
+func (s *DBService) InsertOrUpdateKLinePeriodData(code string, period string, adjust string, klines []map[string]interface{}) (int64, int64, error) {
+	counts, err := s.SaveKLineBatch(period, []KLineWrite{{Code: code, Adjust: adjust, KLines: klines}})
+	if err != nil {
+		return 0, 0, err
+	}
+	return counts[0].Added, counts[0].Updated, nil
+}
+
+// ReplaceKLinePeriodData 清空指定周期的 K 线缓存后写入（复权基准变化、旧数据整体失效时使用）；
+// 分钟线只清空该复权方式的缓存
+func (s *DBService) ReplaceKLinePeriodData(code string, period string, adjust string, klines []map[string]interface{}) (int64, error) {
+	counts, err := s.SaveKLineBatch(period, []KLineWrite{{Code: code, Adjust: adjust, Replace: true, KLines: klines}})
+	if err != nil {
+		return 0, fmt.Errorf("替换 K 线数据失败: %w", err)
+	}
+	return counts[0].Added + counts[0].Updated, nil
+}
+
+// KLineWrite 一只股票待写入的 K 线
+type KLineWrite struct {
+	Code    string
+	Adjust  string
+	Replace bool // 先清空该股票该周期的缓存（复权基准已变，旧数据整体失效；分钟线只清空该复权方式）
+	KLines  []map[string]interface{}
+}
+
+// KLineWriteCount 一只股票的写入统计
+type KLineWriteCount struct {
+	Added   int64 // 缓存中原本没有的日期
+	Updated int64 // 缓存中已有、数值或复权方式有变化的日期
+}
+
+// SaveKLineBatch 在一个事务中写入多只股票的 K 线，按顺序返回每只股票的新增、更新条数。
+// 与缓存完全相同的 K 线不重写也不计数；任一股票写入失败时整批回滚。
+func (s *DBService) SaveKLineBatch(period string, writes []KLineWrite) ([]KLineWriteCount, error) {
+	period = klineStorePeriod(period)
+	counts := make([]KLineWriteCount, len(writes))
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := repositories.NewKLineRepository(tx)
+		for i, w := range writes {
+			added, updated, err := saveKLineBars(repo, w.Code, period, w.Adjust, w.Replace, klineRecordsToBars(w.Code, period, w.Adjust, w.KLines))
+			if err != nil {
+				return fmt.Errorf("插入/更新 K 线数据失败(%s): %w", w.Code, err)
+			}
+			counts[i] = KLineWriteCount{Added: added, Updated: updated}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// saveKLineBars 写入一只股票的 K 线，与写入范围内的缓存逐日比较，只写入新增和有变化的 K 线
+func saveKLineBars(repo *repositories.KLineRepository, code, period, adjust string, replace bool, bars []models.KLineBarEntity) (int64, int64, error) {
+	if len(bars) == 0 {
+		return 0, 0, nil
+	}
+
+	// scope 为比较与清空的复权范围：分钟线各复权方式互不影响，其他周期不限复权方式
+	scope := ""
+	if IsMinuteKLinePeriod(period) {
+		scope = adjust
+	} else if !replace {
+		cachedAdjust, err := repo.GetLatestAdjust(code, period)
+		if err != nil {
+			return 0, 0, err
+		}
+		if cachedAdjust != "" && cachedAdjust != adjust {
+			logger.Info("K线缓存复权方式变更，清空旧数据",
+				zap.String("module", "services.db"),
+				zap.String("op", "saveKLineBars"),
+				zap.String("code", code),
+				zap.String("period", period),
+				zap.String("from", cachedAdjust),
+				zap.String("to", adjust),
+			)
+			replace = true
+		}
+	}
+
+	// 同一日期出现多次时以最后一条为准
+	unique := make([]models.KLineBarEntity, 0, len(bars))
+	index := make(map[string]int, len(bars))
+	for _, bar := range bars {
+		if i, ok := index[bar.Date]; ok {
+			unique[i] = bar
+			continue
+		}
+		index[bar.Date] = len(unique)
+		unique = append(unique, bar)
+	}
+	minDate, maxDate := unique[0].Date, unique[0].Date
+	for _, bar := range unique {
+		if bar.Date < minDate {
+			minDate = bar.Date
+		}
+		if bar.Date > maxDate {
+			maxDate = bar.Date
+		}
+	}
+	cached, err := repo.GetBarsBetween(code, period, scope, minDate, maxDate)
+	if err != nil {
+		return 0, 0, err
+	}
+	existing := make(map[string]models.KLineBarEntity, len(cached))
+	for _, bar := range cached {
+		existing[bar.Date] = bar
+	}
+
+	var added, updated int64
+	changed := make([]models.KLineBarEntity, 0, len(unique))
+	for _, bar := range unique {
+		old, ok := existing[bar.Date]
+		switch {
+		case !ok:
+			added++
+		case replace || !sameKLineBar(old, bar):
+			updated++
+		default:
+			continue
+		}
+		changed = append(changed, bar)
+	}
 
-	// 先确保表存在
-	if err := s.CreateKLineCacheTable(code); err != nil {
+	if replace {
+		// 清空后需要写入全部 K 线，包括与旧缓存相同的
+		_, err = repo.ReplaceBars(code, period, scope, unique)
+	} else {
+		_, err = repo.SaveBars(changed)
+	}
+	if err != nil {
 		return 0, 0, err
 	}
+	return added, updated, nil
+}
 
-	// 转换 map 到 KLineEntity 结构体切片
-	var entities []models.KLineEntity
+// sameKLineBar 两根 K 线的复权方式和数值是否相同
+func sameKLineBar(a, b models.KLineBarEntity) bool {
+	return a.Adjust == b.Adjust && a.Open == b.Open && a.High == b.High &&
+		a.Low == b.Low && a.Close == b.Close && a.Volume == b.Volume
+}
+
+// klineRecordsToBars 将 K 线记录（map）转换为 kline_bars 实体
+func klineRecordsToBars(code, period, adjust string, klines []map[string]interface{}) []models.KLineBarEntity {
+	var bars []models.KLineBarEntity
 	for _, kline := range klines {
-		entity := models.KLineEntity{
+		bars = append(bars, models.KLineBarEntity{
+			Code:   code,
+			Period: period,
+			Adjust: adjust,
 			Date:   kline["date"].(string),
 			Open:   kline["open"].(float64),
 			High:   kline["high"].(float64),
 			Low:    kline["low"].(float64),
 			Close:  kline["close"].(float64),
 			Volume: kline["volume"].(int64),
-		}
-		entities = append(entities, entity)
+		})
 	}
+	return bars
+}
 
-	if len(entities) == 0 {
-		return 0, 0, nil
-	}
+// GetKLineDailyBarsBetween 获取日期范围（含首尾）内最近 limit 根日 K 线（按日期降序），同时返回范围内的总数
+func (s *DBService) GetKLineDailyBarsBetween(code string, startDate string, endDate string, limit int) ([]models.KLineBarEntity, int64, error) {
+	return s.klineRepo().GetBarsPage(code, KLinePeriodDaily, "", startDate, endDate, 1, limit)
+}
 
-	// 使用 GORM 的 CreateInBatches 和 Clauses 进行 upsert
-	result := s.db.Table(tableName).Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "date"}},
-		DoUpdates: clause.AssignmentColumns([]string{"open", "high", "low", "close", "volume", "updated_at"}),
-	}).CreateInBatches(entities, 100) // 每批 100 条
+// GetLatestKLineDate 获取指定股票在本地缓存中的最新日 K 线日期
+func (s *DBService) GetLatestKLineDate(code string) (string, error) {
+	// 如果没有找到记录，返回空字符串，符合预期
+	return s.klineRepo().GetLatestDate(code, KLinePeriodDaily)
+}
 
-	if result.Error != nil {
-		return 0, 0, fmt.Errorf("插入/更新 K 线数据失败: %w", result.Error)
-	}
+// GetEarliestKLineDate 获取指定股票在本地缓存中的最早日 K 线日期
+func (s *DBService) GetEarliestKLineDate(code string) (string, error) {
+	return s.klineRepo().GetEarliestDate(code, KLinePeriodDaily)
+}
 
-	// 注意：GORM 的 RowsAffected 在 upsert 时可能不准确反映“新增”和“更新”的分别计数
-	// 这里简单返回受影响的行数作为 addedCount (实际上是 added + updated)
-	return result.RowsAffected, 0, nil
+// GetKLineDataFromCache 从本地缓存获取 K 线数据
+func (s *DBService) GetKLineDataFromCache(code string, limit int) ([]map[string]interface{}, error) {
+	return s.GetKLinePeriodDataFromCache(code, KLinePeriodDaily, "", limit)
 }
 
-// GetLatestKLineDate 获取指定股票在本地缓存中的最新 K 线日期
-func (s *DBService) GetLatestKLineDate(code string) (string, error) {
-	tableName := fmt.Sprintf("kline_%s", code)
+// GetKLinePeriodDataFromCache 从本地缓存获取指定周期最近 limit 根 K 线（按时间升序）
+// adjust 非空时只返回该复权方式的数据
+func (s *DBService) GetKLinePeriodDataFromCache(code string, period string, adjust string, limit int) ([]map[string]interface{}, error) {
+	bars, err := s.klineRepo().GetRecentBars(code, klineStorePeriod(period), adjust, limit)
+	if err != nil {
+		return nil, err
+	}
+	return klineBarsToRecords(bars), nil
+}
 
-	var date string
-	err := s.db.Table(tableName).Select("date").Order("date DESC").Limit(1).Scan(&date).Error
+// GetKLineCrossSection 获取某一日全部股票的日 K 线截面（按代码升序），adjust 为空时不限复权方式
+func (s *DBService) GetKLineCrossSection(date string, adjust string) ([]models.KLineBarEntity, error) {
+	return s.klineRepo().GetBarsByDate(KLinePeriodDaily, adjust, date)
+}
 
+// GetKLineCountByCode 获取指定股票的日 K 线数据总数
+func (s *DBService) GetKLineCountByCode(code string) (int, error) {
+	count, err := s.klineRepo().CountBars(code, KLinePeriodDaily)
 	if err != nil {
-		return "", fmt.Errorf("查询最新 K 线日期失败: %w", err)
+		return 0, err
 	}
+	return int(count), nil
+}
 
-	// 如果没有找到记录，date 会是空字符串，符合预期
-	return date, nil
+// GetKLineCacheAdjust 返回指定周期缓存当前的复权方式，没有缓存时返回空字符串
+// （日线及以上周期只保存一种复权方式；分钟线各复权方式分别缓存，返回的是最新一根的复权方式）
+func (s *DBService) GetKLineCacheAdjust(code string, period string) (string, error) {
+	return s.klineRepo().GetLatestAdjust(code, klineStorePeriod(period))
 }
 
-// GetKLineDataFromCache 从本地缓存获取 K 线数据
-func (s *DBService) GetKLineDataFromCache(code string, limit int) ([]map[string]interface{}, error) {
-	tableName := fmt.Sprintf("kline_%s", code)
+// SaveExRightsEvents 保存除权除息事件（按 code + ex_date 去重）
+func (s *DBService) SaveExRightsEvents(events []models.ExRightsEventEntity) error {
+	if len(events) == 0 {
+		return nil
+	}
+	result := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}, {Name: "ex_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"cash_dividend", "bonus_shares", "transfer_shares", "rights_ratio", "rights_price", "updated_at"}),
+	}).Create(&events)
+	if result.Error != nil {
+		return fmt.Errorf("保存除权除息事件失败: %w", result.Error)
+	}
+	return nil
+}
 
-	var entities []models.KLineEntity
-	err := s.db.Table(tableName).Order("date DESC").Limit(limit).Find(&entities).Error
-	if err != nil {
-		// 检查表是否存在
-		if strings.Contains(err.Error(), "no such table") {
-			return nil, nil // 表不存在视为空
-		}
-		return nil, fmt.Errorf("查询 K 线数据失败: %w", err)
+// GetExRightsEvents 获取指定股票的除权除息事件（按除权日升序）
+func (s *DBService) GetExRightsEvents(code string) ([]models.ExRightsEventEntity, error) {
+	var events []models.ExRightsEventEntity
+	if err := s.db.Where("code = ?", code).Order("ex_date ASC").Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("查询除权除息事件失败: %w", err)
 	}
+	return events, nil
+}
 
-	var klines []map[string]interface{}
-	for _, e := range entities {
-		klines = append(klines, map[string]interface{}{
-			"date":   e.Date,
-			"open":   e.Open,
-			"high":   e.High,
-			"low":    e.Low,
-			"close":  e.Close,
-			"volume": e.Volume,
-		})
+// SaveFinancialStatements 保存财务报表（按 code + report_date 去重）
+func (s *DBService) SaveFinancialStatements(statements []models.FinancialStatementEntity) error {
+	if len(statements) == 0 {
+		return nil
 	}
+	result := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}, {Name: "report_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revenue", "operating_cost", "net_profit", "parent_equity", "updated_at"}),
+	}).Create(&statements)
+	if result.Error != nil {
+		return fmt.Errorf("保存财务报表失败: %w", result.Error)
+	}
+	return nil
+}
 
-	// 反转切片，使其按日期升序排列
-	for i, j := 0, len(klines)-1; i < j; i, j = i+1, j-1 {
-		klines[i], klines[j] = klines[j], klines[i]
+// GetFinancialStatements 获取指定股票的财务报表（按报告期升序）
+func (s *DBService) GetFinancialStatements(code string) ([]models.FinancialStatementEntity, error) {
+	var statements []models.FinancialStatementEntity
+	if err := s.db.Where("code = ?", code).Order("report_date ASC").Find(&statements).Error; err != nil {
+		return nil, fmt.Errorf("查询财务报表失败: %w", err)
 	}
+	return statements, nil
+}
 
-	return klines, nil
+// ReplaceBoardMembers 保存板块及其成分股（先删除该板块旧的成分股，成分股会随调整而变化）
+func (s *DBService) ReplaceBoardMembers(board models.BoardEntity, members []models.BoardMemberEntity) error {
+	board.MemberCount = len(members)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "code"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "type", "member_count", "updated_at"}),
+		}).Create(&board).Error; err != nil {
+			return fmt.Errorf("保存板块失败: %w", err)
+		}
+		if err := tx.Where("board_code = ?", board.Code).Delete(&models.BoardMemberEntity{}).Error; err != nil {
+			return fmt.Errorf("清理板块成分股失败: %w", err)
+		}
+		if len(members) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(members, 200).Error; err != nil {
+			return fmt.Errorf("保存板块成分股失败: %w", err)
+		}
+		return nil
+	})
 }
 
-// GetKLineCountByCode 获取指定股票的 K 线数据总数
-func (s *DBService) GetKLineCountByCode(code string) (int, error) {
-	tableName := fmt.Sprintf("kline_%s", code)
+// GetBoards 获取指定类型的板块（按名称排序），boardType 为空时返回全部
+func (s *DBService) GetBoards(boardType string) ([]models.BoardEntity, error) {
+	var boards []models.BoardEntity
+	tx := s.db.Order("name ASC")
+	if boardType != "" {
+		tx = tx.Where("type = ?", boardType)
+	}
+	if err := tx.Find(&boards).Error; err != nil {
+		return nil, fmt.Errorf("查询板块失败: %w", err)
+	}
+	return boards, nil
+}
 
-	var count int64
-	err := s.db.Table(tableName).Count(&count).Error
+// GetStockBoards 获取股票所属的板块（行业在前，再按名称排序）
+func (s *DBService) GetStockBoards(code string) ([]models.BoardEntity, error) {
+	var boards []models.BoardEntity
+	err := s.db.Model(&models.BoardEntity{}).
+		Joins("JOIN board_members ON board_members.board_code = boards.code").
+		Where("board_members.stock_code = ?", code).
+		Order("boards.type DESC, boards.name ASC").
+		Find(&boards).Error
 	if err != nil {
-		if strings.Contains(err.Error(), "no such table") {
-			return 0, nil
-		}
-		return 0, fmt.Errorf("查询 K 线数据总数失败: %w", err)
+		return nil, fmt.Errorf("查询所属板块失败: %w", err)
 	}
-
-	return int(count), nil
+	return boards, nil
 }
 
-// GetAllSyncedStocks 获取所有已同步的股票列表
-func (s *DBService) GetAllSyncedStocks() ([]string, error) {
-	// 查询 sqlite_master 表
-	var tables []string
-	err := s.db.Raw("SELECT name FROM sqlite_master WHERE type='table' AND name LIKE 'kline_%'").Scan(&tables).Error
+// GetIndustryAveragePE 基于 stocks 表计算行业平均市盈率（仅统计市盈率为正的个股，排除指数/ETF/可转债）。
+// 行业按 stocks.industry 名称匹配，boardCode 非空时同时纳入该行业板块的成分股。
+func (s *DBService) GetIndustryAveragePE(industry, boardCode string) (float64, error) {
+	var avg *float64
+	err := s.db.Model(&models.StockEntity{}).
+		Select("AVG(pe)").
+		Where("pe > 0 AND type NOT IN ?", []string{InstrumentTypeIndex, InstrumentTypeETF, InstrumentTypeConvertible}).
+		Where("industry = ? OR code IN (?)", industry,
+			s.db.Model(&models.BoardMemberEntity{}).Select("stock_code").Where("board_code = ?", boardCode)).
+		Scan(&avg).Error
 	if err != nil {
-		return nil, fmt.Errorf("查询已同步股票列表失败: %w", err)
+		return 0, fmt.Errorf("计算行业平均市盈率失败: %w", err)
 	}
-
-	var stocks []string
-	for _, tableName := range tables {
-		code := strings.TrimPrefix(tableName, "kline_")
-		stocks = append(stocks, code)
+	if avg == nil {
+		return 0, nil
 	}
-
-	return stocks, nil
+	return *avg, nil
 }
 
-// ClearKLineCacheTable 清除指定股票的 K 线缓存表
-func (s *DBService) ClearKLineCacheTable(code string) error {
-	tableName := fmt.Sprintf("kline_%s", code)
-	// 使用 Migrator 删除表
-	if err := s.db.Migrator().DropTable(tableName); err != nil {
-		return fmt.Errorf("清除 K 线缓存表失败: %w", err)
+// SaveIntradayPoints 保存分时点（同一分钟以最新推送为准）
+func (s *DBService) SaveIntradayPoints(points []models.IntradayPointEntity) error {
+	if len(points) == 0 {
+		return nil
+	}
+	result := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}, {Name: "trade_date"}, {Name: "time"}},
+		DoUpdates: clause.AssignmentColumns([]string{"price", "avg_price", "volume", "amount", "updated_at"}),
+	}).Create(&points)
+	if result.Error != nil {
+		return fmt.Errorf("保存分时数据失败: %w", result.Error)
 	}
 	return nil
 }
 
-// GetKLineDataWithPagination 获取指定股票的 K 线数据（支持分页和日期筛选）
-func (s *DBService) GetKLineDataWithPagination(code string, startDate string, endDate string, page int, pageSize int) ([]map[string]interface{}, int, error) {
-	tableName := fmt.Sprintf("kline_%s", code)
-
-	// 检查表是否存在
-	if !s.db.Migrator().HasTable(tableName) {
-		logger.Info("K线表不存在，返回空数组", zap.String("tableName", tableName))
-		return []map[string]interface{}{}, 0, nil
+// GetIntradayPoints 获取指定交易日的分时点（按时间升序），tradeDate 为空时取最近一个已保存的交易日。
+// 同时返回该交易日的昨收价（上一交易日最后一个分时点的价格，没有时为 0）。
+func (s *DBService) GetIntradayPoints(code, tradeDate string) ([]models.IntradayPointEntity, float64, error) {
+	if tradeDate == "" {
+		if err := s.db.Model(&models.IntradayPointEntity{}).Select("trade_date").
+			Where("code = ?", code).Order("trade_date DESC").Limit(1).Scan(&tradeDate).Error; err != nil {
+			return nil, 0, fmt.Errorf("查询分时数据交易日失败: %w", err)
+		}
 	}
 
-	tx := s.db.Table(tableName)
-
-	if startDate != "" {
-		tx = tx.Where("date >= ?", startDate)
-	}
-	if endDate != "" {
-		tx = tx.Where("date <= ?", endDate)
+	var points []models.IntradayPointEntity
+	if err := s.db.Where("code = ? AND trade_date = ?", code, tradeDate).Order("time ASC").Find(&points).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询分时数据失败: %w", err)
 	}
 
-	var totalCount int64
-	if err := tx.Count(&totalCount).Error; err != nil {
-		return []map[string]interface{}{}, 0, nil
+	var preClose float64
+	if err := s.db.Model(&models.IntradayPointEntity{}).Select("price").
+		Where("code = ? AND trade_date < ?", code, tradeDate).
+		Order("trade_date DESC, time DESC").Limit(1).Scan(&preClose).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询昨收价失败: %w", err)
 	}
+	return points, preClose, nil
+}
 
-	var entities []models.KLineEntity
-	offset := (page - 1) * pageSize
-	if err := tx.Order("date DESC").Limit(pageSize).Offset(offset).Find(&entities).Error; err != nil {
-		return []map[string]interface{}{}, 0, nil
-	}
+// GetAllSyncedStocks 获取所有已同步日 K 线的股票列表
+func (s *DBService) GetAllSyncedStocks() ([]string, error) {
+	return s.klineRepo().ListCodes(KLinePeriodDaily)
+}
 
-	var klines []map[string]interface{}
-	for _, e := range entities {
-		klines = append(klines, map[string]interface{}{
-			"date":   e.Date,
-			"open":   e.Open,
-			"high":   e.High,
-			"low":    e.Low,
-			"close":  e.Close,
-			"volume": e.Volume,
-		})
+// ClearKLineCacheTable 清除指定股票的 K 线缓存（包含各周期缓存）
+func (s *DBService) ClearKLineCacheTable(code string) error {
+	return s.klineRepo().DeleteCode(code)
+}
+
+// GetKLineDataWithPagination 获取指定股票的 K 线数据（支持分页和日期筛选）
+func (s *DBService) GetKLineDataWithPagination(code string, startDate string, endDate string, page int, pageSize int) ([]map[string]interface{}, int, error) {
+	bars, totalCount, err := s.klineRepo().GetBarsPage(code, KLinePeriodDaily, "", startDate, endDate, page, pageSize)
+	if err != nil {
+		logger.Warn("分页查询 K 线失败，返回空数组",
+			zap.String("module", "services.db"),
+			zap.String("op", "GetKLineDataWithPagination"),
+			zap.String("code", code),
+			zap.Error(err),
+		)
+		return []map[string]interface{}{}, 0, nil
 	}
 
+	klines := klineBarsToRecords(bars)
 	// 反转切片，使其按日期升序排列
 	for i, j := 0, len(klines)-1; i < j; i, j = i+1, j-1 {
 		klines[i], klines[j] = klines[j], klines[i]
@@ -0,0 +1,713 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"stock-analyzer-wails/internal/logger"
+	"stock-analyzer-wails/models"
+	"strconv"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+)
+
+// EastMoneyProvider 基于东方财富公开接口的行情数据源（默认实现）
+type EastMoneyProvider struct {
+	client     *resty.Client
+	sseClient  *resty.Client
+	exactURL   string
+	ulistURL   string
+	klineURL   string
+	trendsURL  string
+	sseURL     string
+	fflowURL   string
+	bonusURL   string
+	financeURL string
+
+	instruments *InstrumentResolver // 证券标识解析（为 nil 时按代码前缀推断）
+	ctx         context.Context     // 常规请求携带的上下文（为 nil 时不设置）
+}
+
+// NewEastMoneyProvider 创建东方财富行情数据源
+func NewEastMoneyProvider(client, sseClient *resty.Client) *EastMoneyProvider {
+	return &EastMoneyProvider{
+		client:     client,
+		sseClient:  sseClient,
+		exactURL:   "https://push2.eastmoney.com/api/qt/stock/get",
+		ulistURL:   "https://push2.eastmoney.com/api/qt/ulist.np/get",
+		klineURL:   "https://push2his.eastmoney.com/api/qt/stock/kline/get",
+		trendsURL:  "https://push2.eastmoney.com/api/qt/stock/trends2/get",
+		sseURL:     "https://push2.eastmoney.com/api/qt/stock/trends2/sse",
+		fflowURL:   "http://push2.eastmoney.com/api/qt/stock/fflow/daykline/get",
+		bonusURL:   "https://datacenter-web.eastmoney.com/api/data/v1/get",
+		financeURL: "https://datacenter-web.eastmoney.com/api/data/v1/get",
+	}
+}
+
+// Name 返回数据源名称
+func (p *EastMoneyProvider) Name() string {
+	return MarketDataProviderEastMoney
+}
+
+// SetInstrumentResolver 注入证券标识解析器（以 stocks 表为准）
+func (p *EastMoneyProvider) SetInstrumentResolver(r *InstrumentResolver) {
+	p.instruments = r
+}
+
+// WithContext 返回常规请求携带 ctx 的副本（用于随任务取消请求、指定网关优先级）
+func (p *EastMoneyProvider) WithContext(ctx context.Context) MarketDataProvider {
+	c := *p
+	c.ctx = ctx
+	return &c
+}
+
+// request 创建常规请求
+func (p *EastMoneyProvider) request() *resty.Request {
+	r := p.client.R()
+	if p.ctx != nil {
+		r.SetContext(p.ctx)
+	}
+	return r
+}
+
+// secID 将证券代码转换为东方财富 secid，无法识别时返回空字符串
+func (p *EastMoneyProvider) secID(code string) string {
+	return p.instruments.SecID(code)
+}
+
+// GetStockByCode 根据股票代码获取股票数据（精确查询）
+func (p *EastMoneyProvider) GetStockByCode(code string) (*models.StockData, error) {
+	inst, err := p.instruments.Resolve(code)
+	if err != nil {
+		return nil, fmt.Errorf("无法识别的股票代码格式: %s", code)
+	}
+
+	fields := "f58,f43,f169,f170,f47,f48,f44,f45,f46,f60,f171,f168,f162,f167,f116,f117,f12,f14,f19,f20,f59"
+	url := fmt.Sprintf("%s?secid=%s&fields=%s", p.exactURL, inst.SecID(), fields)
+
+	var result struct {
+		Data map[string]interface{} `json:"data"`
+	}
+
+	resp, err := p.request().
+		SetResult(&result).
+		Get(url)
+
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+
+	if resp.IsError() {
+		return nil, fmt.Errorf("HTTP error: %s", resp.Status())
+	}
+
+	if result.Data == nil {
+		return nil, fmt.Errorf("未找到股票数据: %s", code)
+	}
+
+	data := result.Data
+
+	// 价格字段按 f59（小数位数）放大：股票/指数为 2 位，ETF、可转债为 3 位
+	priceScale := 100.0
+	if digits := getFloat(data["f59"]); digits > 0 {
+		priceScale = math.Pow(10, digits)
+	}
+
+	return &models.StockData{
+		Code:         inst.Key(),
+		Name:         getString(data["f58"]),
+		Price:        getFloat(data["f43"]) / priceScale,
+		Change:       getFloat(data["f169"]) / priceScale,
+		ChangeRate:   getFloat(data["f170"]) / 100,
+		Volume:       getInt64(data["f47"]),
+		Amount:       getFloat(data["f48"]),
+		High:         getFloat(data["f44"]) / priceScale,
+		Low:          getFloat(data["f45"]) / priceScale,
+		Open:         getFloat(data["f46"]) / priceScale,
+		PreClose:     getFloat(data["f60"]) / priceScale,
+		Amplitude:    getFloat(data["f171"]) / 100,
+		Turnover:     getFloat(data["f168"]) / 100,
+		PE:           getFloat(data["f162"]) / 100,
+		PB:           getFloat(data["f167"]) / 100,
+		TotalMV:      getFloat(data["f116"]),
+		CircMV:       getFloat(data["f117"]),
+		VolumeRatio:  getFloat(data["f20"]) / 100,
+		WarrantRatio: getFloat(data["f19"]) / 100,
+	}, nil
+}
+
+// eastMoneyQuoteChunk ulist 接口单次请求的最大证券数量
+const eastMoneyQuoteChunk = 80
+
+// GetQuotes 通过 ulist 接口批量获取实时行情，按 eastMoneyQuoteChunk 分批请求。
+// 部分批次失败时返回已取得的结果；全部失败时返回最后一个错误。
+func (p *EastMoneyProvider) GetQuotes(codes []string) (map[string]*models.StockData, error) {
+	quotes := make(map[string]*models.StockData, len(codes))
+
+	// secid -> 入参代码（同一证券可能以不同写法出现多次）
+	bySecID := make(map[string][]string, len(codes))
+	keys := make(map[string]string, len(codes))
+	secids := make([]string, 0, len(codes))
+	for _, code := range codes {
+		inst, err := p.instruments.Resolve(code)
+		if err != nil {
+			continue
+		}
+		secid := inst.SecID()
+		if _, ok := bySecID[secid]; !ok {
+			secids = append(secids, secid)
+			keys[secid] = inst.Key()
+		}
+		bySecID[secid] = append(bySecID[secid], code)
+	}
+
+	var lastErr error
+	chunks, failed := 0, 0
+	for start := 0; start < len(secids); start += eastMoneyQuoteChunk {
+		end := min(start+eastMoneyQuoteChunk, len(secids))
+		chunks++
+		items, err := p.fetchQuoteChunk(secids[start:end])
+		if err != nil {
+			failed++
+			lastErr = err
+			logger.Warn("批量获取行情失败",
+				zap.String("module", "services.eastmoney"),
+				zap.String("op", "GetQuotes"),
+				zap.Int("chunk_size", end-start),
+				zap.Error(err),
+			)
+			continue
+		}
+		for _, data := range items {
+			secid := fmt.Sprintf("%d.%s", getInt64(data["f13"]), getString(data["f12"]))
+			requested, ok := bySecID[secid]
+			if !ok {
+				continue
+			}
+			stock := quoteToStockData(keys[secid], data)
+			for _, code := range requested {
+				quotes[code] = stock
+			}
+		}
+	}
+
+	if chunks > 0 && failed == chunks {
+		return nil, lastErr
+	}
+	return quotes, nil
+}
+
+// fetchQuoteChunk 请求一批 secid 的行情（fltt=2 返回真实价格，无需按小数位缩放）
+func (p *EastMoneyProvider) fetchQuoteChunk(secids []string) ([]map[string]interface{}, error) {
+	var result struct {
+		Data *struct {
+			Diff []map[string]interface{} `json:"diff"`
+		} `json:"data"`
+	}
+
+	resp, err := p.request().
+		SetQueryParams(map[string]string{
+			"fltt":   "2",
+			"invt":   "2",
+			"secids": strings.Join(secids, ","),
+			"fields": "f2,f3,f4,f5,f6,f7,f8,f9,f10,f12,f13,f14,f15,f16,f17,f18,f20,f21,f23,f33",
+		}).
+		SetResult(&result).
+		Get(p.ulistURL)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("HTTP error: %s", resp.Status())
+	}
+	if result.Data == nil {
+		return nil, nil
+	}
+	return result.Data.Diff, nil
+}
+
+// quoteToStockData 将 ulist 行情转换为 StockData（停牌等无数据字段返回 "-"，按 0 处理）
+func quoteToStockData(code string, data map[string]interface{}) *models.StockData {
+	return &models.StockData{
+		Code:         code,
+		Name:         getString(data["f14"]),
+		Price:        getFloat(data["f2"]),
+		ChangeRate:   getFloat(data["f3"]),
+		Change:       getFloat(data["f4"]),
+		Volume:       getInt64(data["f5"]),
+		Amount:       getFloat(data["f6"]),
+		Amplitude:    getFloat(data["f7"]),
+		Turnover:     getFloat(data["f8"]),
+		PE:           getFloat(data["f9"]),
+		VolumeRatio:  getFloat(data["f10"]),
+		High:         getFloat(data["f15"]),
+		Low:          getFloat(data["f16"]),
+		Open:         getFloat(data["f17"]),
+		PreClose:     getFloat(data["f18"]),
+		TotalMV:      getFloat(data["f20"]),
+		CircMV:       getFloat(data["f21"]),
+		PB:           getFloat(data["f23"]),
+		WarrantRatio: getFloat(data["f33"]),
+	}
+}
+
+// GetKLineData 获取原始 K 线数据，分钟线的时间格式为 "2006-01-02 15:04"
+func (p *EastMoneyProvider) GetKLineData(code string, limit int, period string, adjust string) ([]*models.KLineData, error) {
+	secid := p.secID(code)
+	if secid == "" {
+		return nil, fmt.Errorf("无效的股票代码")
+	}
+
+	normalized, err := NormalizeKLinePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	klt := klinePeriodSpecs[normalized].klt
+	adjust, err = NormalizeKLineAdjust(adjust)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s?secid=%s&fields1=f1,f2,f3,f4,f5,f6&fields2=f51,f52,f53,f54,f55,f56&klt=%s&fqt=%s&end=20500101&lmt=%d", p.klineURL, secid, klt, klineAdjustFQT(adjust), limit)
+
+	var result struct {
+		Data struct {
+			Klines []string `json:"klines"`
+		} `json:"data"`
+	}
+
+	resp, err := p.request().
+		SetResult(&result).
+		Get(url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.IsError() {
+		return nil, fmt.Errorf("HTTP error: %s", resp.Status())
+	}
+
+	klines := make([]*models.KLineData, 0, len(result.Data.Klines))
+	for _, line := range result.Data.Klines {
+		parts := strings.Split(line, ",")
+		if len(parts) < 6 {
+			continue
+		}
+		klines = append(klines, &models.KLineData{
+			Time:   parts[0],
+			Open:   parsePrice(parts[1]),
+			Close:  parsePrice(parts[2]),
+			High:   parsePrice(parts[3]),
+			Low:    parsePrice(parts[4]),
+			Volume: int64(parsePrice(parts[5])),
+		})
+	}
+	return klines, nil
+}
+
+// GetExRightsEvents 获取历史分红送转（已实施）的除权除息事件，数值折算为每股
+func (p *EastMoneyProvider) GetExRightsEvents(code string) ([]models.ExRightsEventEntity, error) {
+	inst, err := p.instruments.Resolve(code)
+	if err != nil {
+		return nil, fmt.Errorf("无效的股票代码")
+	}
+
+	var result struct {
+		Success bool `json:"success"`
+		Result  *struct {
+			Data []struct {
+				ExDividendDate *string  `json:"EX_DIVIDEND_DATE"`
+				PretaxBonusRMB *float64 `json:"PRETAX_BONUS_RMB"` // 每 10 股派息
+				BonusRatio     *float64 `json:"BONUS_RATIO"`      // 每 10 股送股
+				ITRatio        *float64 `json:"IT_RATIO"`         // 每 10 股转增
+			} `json:"data"`
+		} `json:"result"`
+	}
+
+	resp, err := p.request().
+		SetQueryParams(map[string]string{
+			"reportName":  "RPT_SHAREBONUS_DET",
+			"columns":     "SECURITY_CODE,EX_DIVIDEND_DATE,PRETAX_BONUS_RMB,BONUS_RATIO,IT_RATIO",
+			"filter":      fmt.Sprintf(`(SECURITY_CODE="%s")`, inst.Code),
+			"sortColumns": "EX_DIVIDEND_DATE",
+			"sortTypes":   "1",
+			"pageSize":    "500",
+			"pageNumber":  "1",
+		}).
+		SetResult(&result).
+		Get(p.bonusURL)
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("HTTP error: %s", resp.Status())
+	}
+	if result.Result == nil {
+		return []models.ExRightsEventEntity{}, nil
+	}
+
+	perShare := func(v *float64) float64 {
+		if v == nil {
+			return 0
+		}
+		return *v / 10
+	}
+	events := make([]models.ExRightsEventEntity, 0, len(result.Result.Data))
+	for _, d := range result.Result.Data {
+		// 未实施的预案没有除权除息日
+		if d.ExDividendDate == nil || len(*d.ExDividendDate) < 10 {
+			continue
+		}
+		events = append(events, models.ExRightsEventEntity{
+			Code:           code,
+			ExDate:         (*d.ExDividendDate)[:10],
+			CashDividend:   perShare(d.PretaxBonusRMB),
+			BonusShares:    perShare(d.BonusRatio),
+			TransferShares: perShare(d.ITRatio),
+		})
+	}
+	return events, nil
+}
+
+// eastMoneyFinancePeriods 每次获取的报告期数量（最近 3 年，足够计算同比与期初权益）
+const eastMoneyFinancePeriods = 12
+
+// GetFinancialStatements 获取最近若干报告期的利润表与资产负债表核心科目，按报告期升序返回
+func (p *EastMoneyProvider) GetFinancialStatements(code string) ([]models.FinancialStatementEntity, error) {
+	inst, err := p.instruments.Resolve(code)
+	if err != nil {
+		return nil, fmt.Errorf("无效的股票代码")
+	}
+
+	income, err := p.fetchFinanceReport("RPT_DMSK_FN_INCOME", "SECURITY_CODE,REPORT_DATE,TOTAL_OPERATE_INCOME,OPERATE_COST,PARENT_NETPROFIT", inst.Code)
+	if err != nil {
+		return nil, fmt.Errorf("获取利润表失败: %w", err)
+	}
+	balance, err := p.fetchFinanceReport("RPT_DMSK_FN_BALANCE", "SECURITY_CODE,REPORT_DATE,TOTAL_PARENT_EQUITY", inst.Code)
+	if err != nil {
+		return nil, fmt.Errorf("获取资产负债表失败: %w", err)
+	}
+
+	byDate := make(map[string]*models.FinancialStatementEntity)
+	statement := func(row map[string]interface{}) *models.FinancialStatementEntity {
+		date := getString(row["REPORT_DATE"])
+		if len(date) < 10 {
+			return nil
+		}
+		date = date[:10]
+		st, ok := byDate[date]
+		if !ok {
+			st = &models.FinancialStatementEntity{Code: code, ReportDate: date}
+			byDate[date] = st
+		}
+		return st
+	}
+	for _, row := range income {
+		if st := statement(row); st != nil {
+			st.Revenue = getFloat(row["TOTAL_OPERATE_INCOME"])
+			st.OperatingCost = getFloat(row["OPERATE_COST"])
+			st.NetProfit = getFloat(row["PARENT_NETPROFIT"])
+		}
+	}
+	for _, row := range balance {
+		if st := statement(row); st != nil {
+			st.ParentEquity = getFloat(row["TOTAL_PARENT_EQUITY"])
+		}
+	}
+
+	statements := make([]models.FinancialStatementEntity, 0, len(byDate))
+	for _, st := range byDate {
+		statements = append(statements, *st)
+	}
+	sort.Slice(statements, func(i, j int) bool { return statements[i].ReportDate < statements[j].ReportDate })
+	return statements, nil
+}
+
+// fetchFinanceReport 查询东方财富数据中心的财务报表（按报告期倒序取最近若干期）
+func (p *EastMoneyProvider) fetchFinanceReport(reportName, columns, code string) ([]map[string]interface{}, error) {
+	var result struct {
+		Success bool `json:"success"`
+		Result  *struct {
+			Data []map[string]interface{} `json:"data"`
+		} `json:"result"`
+	}
+
+	resp, err := p.request().
+		SetQueryParams(map[string]string{
+			"reportName":  reportName,
+			"columns":     columns,
+			"filter":      fmt.Sprintf(`(SECURITY_CODE="%s")`, code),
+			"sortColumns": "REPORT_DATE",
+			"sortTypes":   "-1",
+			"pageSize":    strconv.Itoa(eastMoneyFinancePeriods),
+			"pageNumber":  "1",
+		}).
+		SetResult(&result).
+		Get(p.financeURL)
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("HTTP error: %s", resp.Status())
+	}
+	if result.Result == nil {
+		return nil, nil
+	}
+	return result.Result.Data, nil
+}
+
+// GetIntradayData 获取分时数据 (非实时快照，用于初始化)
+func (p *EastMoneyProvider) GetIntradayData(code string) (*models.IntradayResponse, error) {
+	secid := p.secID(code)
+	if secid == "" {
+		return nil, fmt.Errorf("无效的股票代码")
+	}
+
+	url := fmt.Sprintf("%s?%s", p.trendsURL, trendsQuery(secid))
+
+	var result struct {
+		Data struct {
+			PreClose float64  `json:"preClose"`
+			Trends   []string `json:"trends"`
+		} `json:"data"`
+	}
+
+	resp, err := p.request().
+		SetResult(&result).
+		Get(url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.IsError() {
+		return nil, fmt.Errorf("HTTP error: %s", resp.Status())
+	}
+
+	intradayData := make([]models.IntradayData, 0)
+	for _, line := range result.Data.Trends {
+		parts := strings.Split(line, ",")
+		if len(parts) < 8 {
+			continue
+		}
+		intradayData = append(intradayData, models.IntradayData{
+			Time:     parts[0],
+			Price:    parsePrice(parts[2]),
+			AvgPrice: parsePrice(parts[7]),
+			Volume:   int64(parsePrice(parts[5])),
+			PreClose: result.Data.PreClose,
+		})
+	}
+
+	return &models.IntradayResponse{
+		Data:     intradayData,
+		PreClose: result.Data.PreClose,
+	}, nil
+}
+
+// GetMoneyFlowData 获取资金流数据
+func (p *EastMoneyProvider) GetMoneyFlowData(code string) (*models.MoneyFlowResponse, error) {
+	secid := p.secID(code)
+	if secid == "" {
+		return nil, fmt.Errorf("无效的股票代码")
+	}
+
+	url := fmt.Sprintf("%s?secid=%s&fields1=f1,f2,f3,f7&fields2=f51,f52,f53,f54,f55,f56,f57,f58,f59,f60,f61,f62,f63,f64,f65&lmt=0&klt=101&fqt=1", p.fflowURL, secid)
+
+	var result struct {
+		Data struct {
+			Flows []string `json:"klines"`
+		} `json:"data"`
+	}
+
+	resp, err := p.request().
+		SetResult(&result).
+		Get(url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.IsError() {
+		return nil, fmt.Errorf("HTTP error: %s", resp.Status())
+	}
+
+	flowData := make([]models.MoneyFlowData2, 0)
+	for _, line := range result.Data.Flows {
+		parts := strings.Split(line, ",")
+		if len(parts) < 11 {
+			continue
+		}
+		flowData = append(flowData, models.MoneyFlowData2{
+			Time:   parts[0],
+			Main:   parsePrice(parts[1]),
+			Retail: parsePrice(parts[4]),
+			Super:  parsePrice(parts[7]),
+			Big:    parsePrice(parts[8]),
+			Medium: parsePrice(parts[9]),
+			Small:  parsePrice(parts[10]),
+		})
+	}
+
+	return &models.MoneyFlowResponse{
+		Data: flowData,
+	}, nil
+}
+
+// GetOrderBook 获取五档盘口数据
+func (p *EastMoneyProvider) GetOrderBook(code string) (*models.OrderBook, error) {
+	secid := p.secID(code)
+	if secid == "" {
+		return nil, fmt.Errorf("无效的股票代码")
+	}
+
+	fields := "f68,f69,f70,f71,f72,f73,f74,f75,f76,f77,f78,f79,f80,f81,f82,f83,f84,f85"
+	fullURL := fmt.Sprintf("%s?secid=%s&fields=%s", p.exactURL, secid, fields)
+
+	var result struct {
+		Data map[string]interface{} `json:"data"`
+	}
+
+	resp, err := p.request().
+		SetResult(&result).
+		Get(fullURL)
+
+	if err != nil {
+		return nil, fmt.Errorf("请求盘口数据失败: %w", err)
+	}
+
+	if resp.IsError() {
+		return nil, fmt.Errorf("HTTP error: %s", resp.Status())
+	}
+
+	if result.Data == nil {
+		return nil, fmt.Errorf("未找到盘口数据: %s", code)
+	}
+
+	data := result.Data
+
+	orderBook := &models.OrderBook{
+		Buy5:  make([]models.OrderBookEntry, 5),
+		Sell5: make([]models.OrderBookEntry, 5),
+	}
+
+	for i := 0; i < 5; i++ {
+		priceField := fmt.Sprintf("f%d", 73+i*2)
+		volumeField := fmt.Sprintf("f%d", 74+i*2)
+		orderBook.Sell5[i] = models.OrderBookEntry{
+			Price:  getFloat(data[priceField]) / 100,
+			Volume: getInt64(data[volumeField]),
+		}
+	}
+
+	for i := 0; i < 5; i++ {
+		priceField := fmt.Sprintf("f%d", 68+i*2)
+		volumeField := fmt.Sprintf("f%d", 69+i*2)
+		orderBook.Buy5[i] = models.OrderBookEntry{
+			Price:  getFloat(data[priceField]) / 100,
+			Volume: getInt64(data[volumeField]),
+		}
+	}
+
+	return orderBook, nil
+}
+
+// StreamIntraday 建立一次 trends2 SSE 连接并逐条回调分时数据。
+// 远端正常关闭（EOF）返回 nil；连接失败、非 200、读流错误返回 error。
+func (p *EastMoneyProvider) StreamIntraday(ctx context.Context, code string, onTrends func(trends []string)) error {
+	secid := p.secID(code)
+	if secid == "" {
+		return fmt.Errorf("无效的股票代码")
+	}
+
+	sseURL := fmt.Sprintf("%s?%s", p.sseURL, trendsQuery(secid))
+
+	// DoNotParseResponse: true -> 拿到原始 Response，自己处理流
+	resp, err := p.sseClient.R().
+		SetContext(ctx).
+		SetDoNotParseResponse(true).
+		Get(sseURL)
+	if err != nil {
+		return fmt.Errorf("连接 SSE 接口失败: %w", err)
+	}
+
+	rawBody := resp.RawBody()
+	defer func() { _ = rawBody.Close() }()
+
+	if resp.StatusCode() != http.StatusOK {
+		// 尽量读一点 body 帮助定位（例如被限流、被 WAF 拦截、返回错误 JSON 等）
+		b, _ := io.ReadAll(io.LimitReader(rawBody, 2048))
+		return fmt.Errorf("SSE 接口返回非 200: status=%d body=%s", resp.StatusCode(), string(b))
+	}
+
+	scanner := bufio.NewScanner(rawBody)
+	// SSE 的单行 data 可能超过默认 64KB，必须放大 buffer，否则会 ErrTooLong
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 2*1024*1024)
+
+	badLines := 0
+	defer func() {
+		// 解析失败通常是服务端偶发推送了非预期格式；按连接汇总一次，避免刷屏。
+		if badLines > 0 {
+			logger.Warn("SSE data JSON 解析失败（已忽略）",
+				zap.String("module", "services.eastmoney"),
+				zap.String("op", "StreamIntraday"),
+				zap.String("code", code),
+				zap.Int("bad_lines", badLines),
+			)
+		}
+	}()
+
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		line := scanner.Text()
+		// SSE 允许空行/注释/心跳行（例如 ": keep-alive"）
+		if line == "" || strings.HasPrefix(line, ":") {
+			continue
+		}
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		jsonStr := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		var sseData struct {
+			Data struct {
+				Trends []string `json:"trends"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(jsonStr), &sseData); err != nil {
+			badLines++
+			continue
+		}
+		if sseData.Data.Trends != nil {
+			onTrends(sseData.Data.Trends)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		return fmt.Errorf("读取 SSE 流发生错误: %w", err)
+	}
+	return nil
+}
+
+// trendsQuery 分时接口（快照与 SSE）共用的查询参数
+func trendsQuery(secid string) string {
+	return fmt.Sprintf("secid=%s&fields1=f1,f2,f3,f4,f5,f6,f7,f8,f9,f10,f11,f12,f13&fields2=f51,f52,f53,f54,f55,f56,f57,f58&ndays=1&iscr=0&ut=fa5fd1943c7b386f172d6893dbfba10b", secid)
+}
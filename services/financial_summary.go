@@ -0,0 +1,175 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"stock-analyzer-wails/internal/logger"
+	"stock-analyzer-wails/models"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// financialStatementsMaxAge 本地财务报表与分红事件的刷新间隔（按季度披露、按年实施，无需频繁请求）
+const financialStatementsMaxAge = 24 * time.Hour
+
+// ComputeFinancialSummary 根据财务报表、除权除息事件与最新行情计算财务摘要。
+// statements 需按报告期升序；stock 可为 nil（此时市值与股息率为 0）。
+//   - ROE：报告期累计归母净利润 / 平均归母权益（上年末与报告期末），未年化
+//   - 净利润增长率：与上年同期累计归母净利润相比
+//   - 毛利率：(营业收入 - 营业成本) / 营业收入
+//   - 股息率：近 12 个月每股现金分红 / 最新价
+func ComputeFinancialSummary(statements []models.FinancialStatementEntity, events []models.ExRightsEventEntity, stock *models.StockData, now time.Time) (*models.FinancialSummary, error) {
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("暂无财务报表数据")
+	}
+
+	byDate := make(map[string]models.FinancialStatementEntity, len(statements))
+	for _, st := range statements {
+		byDate[st.ReportDate] = st
+	}
+	latest := statements[len(statements)-1]
+
+	summary := &models.FinancialSummary{}
+	if t, err := time.ParseInLocation("2006-01-02", latest.ReportDate, time.Local); err == nil {
+		summary.ReportDate = t
+
+		// 上年末权益（年报）作为期初权益；没有时仅用期末权益
+		equity := latest.ParentEquity
+		if prev, ok := byDate[fmt.Sprintf("%d-12-31", t.Year()-1)]; ok && prev.ParentEquity > 0 {
+			equity = (prev.ParentEquity + latest.ParentEquity) / 2
+		}
+		if equity > 0 {
+			summary.ROE = round2(latest.NetProfit / equity * 100)
+		}
+
+		if prev, ok := byDate[t.AddDate(-1, 0, 0).Format("2006-01-02")]; ok && prev.NetProfit != 0 {
+			summary.NetProfitGrowthRate = round2((latest.NetProfit - prev.NetProfit) / math.Abs(prev.NetProfit) * 100)
+		}
+	}
+
+	if latest.Revenue > 0 {
+		summary.GrossProfitMargin = round2((latest.Revenue - latest.OperatingCost) / latest.Revenue * 100)
+	}
+
+	if stock != nil {
+		summary.TotalMarketValue = round2(stock.TotalMV / 1e8)
+		summary.CirculatingMarketValue = round2(stock.CircMV / 1e8)
+
+		if stock.Price > 0 {
+			since := now.AddDate(-1, 0, 0).Format("2006-01-02")
+			today := now.Format("2006-01-02")
+			dividend := 0.0
+			for _, e := range events {
+				if e.ExDate > since && e.ExDate <= today {
+					dividend += e.CashDividend
+				}
+			}
+			summary.DividendYield = round2(dividend / stock.Price * 100)
+		}
+	}
+	return summary, nil
+}
+
+// round2 保留两位小数
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// SyncFinancialStatements 从行情源同步指定股票的财务报表到本地，返回报告期数量
+func (s *StockService) SyncFinancialStatements(code string) (int, error) {
+	db := s.dbService
+	if db == nil {
+		return 0, fmt.Errorf("数据库服务未初始化")
+	}
+	p, ok := s.provider.(FinancialStatementProvider)
+	if !ok {
+		return 0, fmt.Errorf("行情数据源 %s 不支持财务报表数据", s.provider.Name())
+	}
+
+	statements, err := p.GetFinancialStatements(code)
+	if err != nil {
+		return 0, err
+	}
+	if err := db.SaveFinancialStatements(statements); err != nil {
+		return 0, err
+	}
+	return len(statements), nil
+}
+
+// loadFinancialStatements 优先读取本地财务报表，缺失或超过刷新间隔时从行情源同步；
+// 同步失败但本地有数据时继续使用本地数据。未注入数据库时直接请求行情源。
+func (s *StockService) loadFinancialStatements(code string) ([]models.FinancialStatementEntity, error) {
+	db := s.dbService
+	if db == nil {
+		p, ok := s.provider.(FinancialStatementProvider)
+		if !ok {
+			return nil, fmt.Errorf("行情数据源 %s 不支持财务报表数据", s.provider.Name())
+		}
+		return p.GetFinancialStatements(code)
+	}
+
+	statements, err := db.GetFinancialStatements(code)
+	if err != nil {
+		return nil, err
+	}
+	fresh := false
+	for _, st := range statements {
+		if time.Since(st.UpdatedAt) < financialStatementsMaxAge {
+			fresh = true
+			break
+		}
+	}
+	if fresh {
+		return statements, nil
+	}
+
+	if _, err := s.SyncFinancialStatements(code); err != nil {
+		if len(statements) > 0 {
+			logger.Warn("同步财务报表失败，使用本地数据",
+				zap.String("module", "services.stock"),
+				zap.String("op", "loadFinancialStatements"),
+				zap.String("code", code),
+				zap.Error(err),
+			)
+			return statements, nil
+		}
+		return nil, err
+	}
+	return db.GetFinancialStatements(code)
+}
+
+// loadDividendEvents 读取除权除息事件用于计算股息率。本地缺失或超过刷新间隔时从行情源同步，
+// 否则新实施的分红永远不会入库，近 12 个月股息率随旧事件移出窗口而归零；
+// 同步失败时使用本地数据（没有时视为无分红）。
+func (s *StockService) loadDividendEvents(code string) []models.ExRightsEventEntity {
+	if s.dbService != nil {
+		events, err := s.dbService.GetExRightsEvents(code)
+		if err != nil {
+			return nil
+		}
+		for _, e := range events {
+			if time.Since(e.UpdatedAt) < financialStatementsMaxAge {
+				return events
+			}
+		}
+		if _, err := s.SyncExRightsEvents(code); err != nil {
+			if len(events) > 0 {
+				logger.Warn("同步除权除息事件失败，使用本地数据",
+					zap.String("module", "services.stock"),
+					zap.String("op", "loadDividendEvents"),
+					zap.String("code", code),
+					zap.Error(err),
+				)
+			}
+			return events
+		}
+		events, _ = s.dbService.GetExRightsEvents(code)
+		return events
+	}
+	if p, ok := s.provider.(ExRightsProvider); ok {
+		events, _ := p.GetExRightsEvents(code)
+		return events
+	}
+	return nil
+}
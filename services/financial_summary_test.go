@@ -0,0 +1,102 @@
+package services
+
+import (
+	"stock-analyzer-wails/models"
+	"testing"
+	"time"
+)
+
+func TestComputeFinancialSummary(t *testing.T) {
+	statements := []models.FinancialStatementEntity{
+		{ReportDate: "2024-06-30", Revenue: 100, OperatingCost: 60, NetProfit: 10, ParentEquity: 180},
+		{ReportDate: "2024-12-31", Revenue: 220, OperatingCost: 130, NetProfit: 24, ParentEquity: 200},
+		{ReportDate: "2025-06-30", Revenue: 120, OperatingCost: 66, NetProfit: 12.5, ParentEquity: 220},
+	}
+	events := []models.ExRightsEventEntity{
+		{ExDate: "2024-07-01", CashDividend: 0.4}, // 超过 12 个月，不计入
+		{ExDate: "2025-07-01", CashDividend: 0.3},
+	}
+	stock := &models.StockData{Price: 12, TotalMV: 3.5e10, CircMV: 2e10}
+	now := time.Date(2025, 8, 1, 10, 0, 0, 0, time.Local)
+
+	got, err := ComputeFinancialSummary(statements, events, stock, now)
+	if err != nil {
+		t.Fatalf("ComputeFinancialSummary: %v", err)
+	}
+	want := models.FinancialSummary{
+		ROE:                    5.95, // 12.5 / ((200 + 220) / 2)
+		NetProfitGrowthRate:    25,   // 12.5 vs 10
+		GrossProfitMargin:      45,   // (120 - 66) / 120
+		TotalMarketValue:       350,  // 亿元
+		CirculatingMarketValue: 200,  // 亿元
+		DividendYield:          2.5,  // 0.3 / 12
+		ReportDate:             time.Date(2025, 6, 30, 0, 0, 0, 0, time.Local),
+	}
+	if *got != want {
+		t.Fatalf("unexpected summary:\n got  %+v\n want %+v", *got, want)
+	}
+
+	if _, err := ComputeFinancialSummary(nil, nil, stock, now); err == nil {
+		t.Fatalf("expected error without statements")
+	}
+}
+
+func TestStockService_FinancialSummary_Replay(t *testing.T) {
+	t.Setenv(EnvHTTPMode, HTTPModeReplay)
+	t.Setenv(EnvFixtureDir, "testdata/fixtures")
+
+	db := newTestDB(t)
+
+	s := NewStockService()
+	s.SetDBService(db)
+
+	// 行情与分红没有夹具：财务指标照常计算，市值与股息率为 0
+	summary, err := s.getFinancialSummary("600519")
+	if err != nil {
+		t.Fatalf("getFinancialSummary: %v", err)
+	}
+	if summary.ROE != 28.71 || summary.NetProfitGrowthRate != 6.25 || summary.GrossProfitMargin != 92.31 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if summary.ReportDate.Format("2006-01-02") != "2025-09-30" || summary.TotalMarketValue != 0 {
+		t.Fatalf("unexpected report date or market value: %+v", summary)
+	}
+
+	statements, err := db.GetFinancialStatements("600519")
+	if err != nil || len(statements) != 5 || statements[0].ReportDate != "2024-09-30" || statements[4].ParentEquity != 2.45e11 {
+		t.Fatalf("expected 5 statements persisted in ascending order, got %+v, err=%v", statements, err)
+	}
+}
+
+func TestStockService_LoadDividendEvents_RefreshesStale(t *testing.T) {
+	t.Setenv(EnvHTTPMode, HTTPModeReplay)
+	t.Setenv(EnvFixtureDir, "testdata/fixtures")
+
+	db := newTestDB(t)
+
+	s := NewStockService()
+	s.SetDBService(db)
+
+	// 两天前同步的旧事件：超过刷新间隔，重新同步后补上新实施的分红
+	stale := time.Now().Add(-2 * financialStatementsMaxAge)
+	events := []models.ExRightsEventEntity{
+		{Code: "600686", ExDate: "2023-07-12", CashDividend: 0.02, UpdatedAt: stale},
+		{Code: "000001", ExDate: "2024-06-14", CashDividend: 0.719, UpdatedAt: stale},
+	}
+	if err := db.SaveExRightsEvents(events); err != nil {
+		t.Fatalf("SaveExRightsEvents: %v", err)
+	}
+
+	got := s.loadDividendEvents("600686")
+	if len(got) != 2 || got[1].ExDate != "2025-07-10" || got[1].CashDividend != 0.05 {
+		t.Fatalf("expected stale events to be refreshed, got %+v", got)
+	}
+	if time.Since(got[1].UpdatedAt) > time.Minute {
+		t.Fatalf("expected refreshed events to be marked fresh, got %v", got[1].UpdatedAt)
+	}
+
+	// 刷新失败（没有夹具）时继续使用本地数据
+	if got := s.loadDividendEvents("000001"); len(got) != 1 || got[0].CashDividend != 0.719 {
+		t.Fatalf("expected local events when refresh fails, got %+v", got)
+	}
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"fmt"
+	"stock-analyzer-wails/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 交易所（对应 stocks.market）
+const (
+	MarketSH = "SH"
+	MarketSZ = "SZ"
+	MarketBJ = "BJ"
+)
+
+// 证券类型（对应 stocks.type）
+const (
+	InstrumentTypeMain        = "主板"
+	InstrumentTypeChiNext     = "创业板"
+	InstrumentTypeSTAR        = "科创板"
+	InstrumentTypeBJ          = "北交所"
+	InstrumentTypeIndex       = "指数"
+	InstrumentTypeETF         = "ETF"
+	InstrumentTypeConvertible = "可转债"
+)
+
+// EquityInstrumentTypes A 股股票的证券类型。指数、ETF、可转债也在 stocks 表中（用于解析与行情），
+// 但资金流同步、K 线同步与策略扫描只处理股票。
+var EquityInstrumentTypes = []string{InstrumentTypeMain, InstrumentTypeChiNext, InstrumentTypeSTAR, InstrumentTypeBJ}
+
+// ActiveEquities 返回 stocks 表中活跃 A 股股票的查询（不含指数、ETF、可转债）
+func ActiveEquities(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.StockEntity{}).Where("is_active = 1 AND type IN ?", EquityInstrumentTypes)
+}
+
+// Instrument 证券标识：代码 + 交易所 + 类型。
+// 同一个 6 位代码在不同交易所可能代表不同证券（如 SH000001 上证指数与 SZ000001 平安银行），
+// 因此凡是需要访问行情接口的地方都应先解析为 Instrument。
+type Instrument struct {
+	Code   string `json:"code"`
+	Market string `json:"market"`
+	Type   string `json:"type"`
+}
+
+// FullCode 返回带交易所前缀的代码，如 SH600000
+func (i Instrument) FullCode() string {
+	return i.Market + i.Code
+}
+
+// SecID 返回东方财富 secid（1=沪市，0=深市/北交所）
+func (i Instrument) SecID() string {
+	if i.Code == "" {
+		return ""
+	}
+	if i.Market == MarketSH {
+		return "1." + i.Code
+	}
+	return "0." + i.Code
+}
+
+// IsIndex 是否为指数
+func (i Instrument) IsIndex() bool {
+	return i.Type == InstrumentTypeIndex
+}
+
+// Key 返回在自选股、预警、K 线缓存等处使用的标识：
+// 指数使用带交易所前缀的代码（避免与同号股票冲突），其余沿用 6 位代码
+func (i Instrument) Key() string {
+	if i.IsIndex() {
+		return i.FullCode()
+	}
+	return i.Code
+}
+
+// ParseInstrument 解析证券代码，支持 600000、SH600000、sh600000、600000.SH、1.600000 等写法。
+// explicit 表示输入是否显式指定了交易所；未指定时按代码前缀推断。
+func ParseInstrument(code string) (inst Instrument, explicit bool, err error) {
+	c := strings.ToUpper(strings.TrimSpace(code))
+	market := ""
+	switch {
+	case len(c) == 8 && (strings.HasPrefix(c, MarketSH) || strings.HasPrefix(c, MarketSZ) || strings.HasPrefix(c, MarketBJ)):
+		market, c = c[:2], c[2:]
+	case len(c) == 9 && c[6] == '.':
+		market, c = c[7:], c[:6]
+	case len(c) == 8 && c[1] == '.':
+		switch c[0] {
+		case '1':
+			market = MarketSH
+		case '0':
+			market = ""
+		default:
+			return Instrument{}, false, fmt.Errorf("无效的证券代码: %s", code)
+		}
+		c = c[2:]
+	}
+	if len(c) != 6 || strings.Trim(c, "0123456789") != "" {
+		return Instrument{}, false, fmt.Errorf("无效的证券代码: %s", code)
+	}
+	if market != "" && market != MarketSH && market != MarketSZ && market != MarketBJ {
+		return Instrument{}, false, fmt.Errorf("无效的交易所: %s", code)
+	}
+	if market == "" {
+		return InferInstrument(c), false, nil
+	}
+	return Instrument{Code: c, Market: market, Type: inferInstrumentType(market, c)}, true, nil
+}
+
+// InferInstrument 仅根据 6 位代码推断证券（无法区分时按股票处理，如 000001 视为平安银行）
+func InferInstrument(code string) Instrument {
+	market := MarketSZ
+	switch {
+	case isBJCode(code):
+		market = MarketBJ
+	case code[0] == '6' || code[0] == '5' || code[0] == '9' || strings.HasPrefix(code, "11"):
+		market = MarketSH
+	}
+	return Instrument{Code: code, Market: market, Type: inferInstrumentType(market, code)}
+}
+
+// instrumentFromMarketID 根据东方财富市场编号（1=沪市，0=深市/北交所）构造证券标识
+func instrumentFromMarketID(marketID int, code string) Instrument {
+	market := MarketSZ
+	switch {
+	case marketID == 1:
+		market = MarketSH
+	case isBJCode(code):
+		market = MarketBJ
+	}
+	return Instrument{Code: code, Market: market, Type: inferInstrumentType(market, code)}
+}
+
+// isBJCode 是否为北交所代码（920 新代码段与 8、4 开头的存量代码）
+func isBJCode(code string) bool {
+	return strings.HasPrefix(code, "92") || code[0] == '8' || code[0] == '4'
+}
+
+// inferInstrumentType 根据交易所与代码前缀推断证券类型
+func inferInstrumentType(market, code string) string {
+	switch market {
+	case MarketSH:
+		switch {
+		case strings.HasPrefix(code, "000") || strings.HasPrefix(code, "880") || strings.HasPrefix(code, "999"):
+			return InstrumentTypeIndex
+		case strings.HasPrefix(code, "688") || strings.HasPrefix(code, "689"):
+			return InstrumentTypeSTAR
+		case code[0] == '5':
+			return InstrumentTypeETF
+		case strings.HasPrefix(code, "11"):
+			return InstrumentTypeConvertible
+		}
+	case MarketSZ:
+		switch {
+		case strings.HasPrefix(code, "399"):
+			return InstrumentTypeIndex
+		case strings.HasPrefix(code, "15") || strings.HasPrefix(code, "16"):
+			return InstrumentTypeETF
+		case strings.HasPrefix(code, "12"):
+			return InstrumentTypeConvertible
+		case code[0] == '3':
+			return InstrumentTypeChiNext
+		}
+	case MarketBJ:
+		return InstrumentTypeBJ
+	}
+	return InstrumentTypeMain
+}
+
+// clauseTypeIndexLast 排序条件：指数排在最后
+const clauseTypeIndexLast = "CASE WHEN type = '" + InstrumentTypeIndex + "' THEN 1 ELSE 0 END"
+
+// InstrumentResolver 以 stocks 表（Market、FullCode、Type）为准解析证券标识，
+// 表中没有记录时退化为按代码前缀推断。零值与 nil 均可用（只做推断）。
+type InstrumentResolver struct {
+	db *gorm.DB
+}
+
+// NewInstrumentResolver 创建证券标识解析器，db 可为 nil
+func NewInstrumentResolver(db *gorm.DB) *InstrumentResolver {
+	return &InstrumentResolver{db: db}
+}
+
+// Resolve 解析证券代码
+func (r *InstrumentResolver) Resolve(code string) (Instrument, error) {
+	inst, explicit, err := ParseInstrument(code)
+	if err != nil {
+		return Instrument{}, err
+	}
+	if r == nil || r.db == nil {
+		return inst, nil
+	}
+
+	var entity models.StockEntity
+	tx := r.db.Model(&models.StockEntity{}).Select("code", "market", "type")
+	if explicit {
+		tx = tx.Where("full_code = ?", inst.FullCode())
+	} else {
+		// 只给出 6 位代码时优先匹配仍在交易的记录，其次优先股票，指数需显式带交易所前缀
+		tx = tx.Where("code = ?", inst.Code).Order("is_active DESC").Order(clauseTypeIndexLast)
+	}
+	if err := tx.Limit(1).Find(&entity).Error; err != nil || entity.Code == "" {
+		return inst, nil
+	}
+
+	inst.Market = entity.Market
+	if entity.Type != "" {
+		inst.Type = entity.Type
+	}
+	return inst, nil
+}
+
+// SecID 解析并返回东方财富 secid，代码无效时返回空字符串
+func (r *InstrumentResolver) SecID(code string) string {
+	inst, err := r.Resolve(code)
+	if err != nil {
+		return ""
+	}
+	return inst.SecID()
+}
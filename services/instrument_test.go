@@ -0,0 +1,207 @@
+package services
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"stock-analyzer-wails/models"
+	"strings"
+	"testing"
+)
+
+func TestParseInstrument(t *testing.T) {
+	cases := []struct {
+		in       string
+		secid    string
+		typ      string
+		explicit bool
+	}{
+		{"600519", "1.600519", InstrumentTypeMain, false},
+		{"688981", "1.688981", InstrumentTypeSTAR, false},
+		{"000001", "0.000001", InstrumentTypeMain, false},
+		{"SH000001", "1.000001", InstrumentTypeIndex, true},
+		{"000300.sh", "1.000300", InstrumentTypeIndex, true},
+		{"399006", "0.399006", InstrumentTypeIndex, false},
+		{"510300", "1.510300", InstrumentTypeETF, false},
+		{"159915", "0.159915", InstrumentTypeETF, false},
+		{"830799", "0.830799", InstrumentTypeBJ, false},
+		{"920002", "0.920002", InstrumentTypeBJ, false},
+		{"113050", "1.113050", InstrumentTypeConvertible, false},
+		{"123107", "0.123107", InstrumentTypeConvertible, false},
+		{"300750", "0.300750", InstrumentTypeChiNext, false},
+		{"1.000016", "1.000016", InstrumentTypeIndex, true},
+	}
+	for _, c := range cases {
+		inst, explicit, err := ParseInstrument(c.in)
+		if err != nil {
+			t.Fatalf("ParseInstrument(%q): %v", c.in, err)
+		}
+		if inst.SecID() != c.secid || inst.Type != c.typ || explicit != c.explicit {
+			t.Fatalf("ParseInstrument(%q) = %+v (secid %s, explicit %v); want secid %s type %s explicit %v",
+				c.in, inst, inst.SecID(), explicit, c.secid, c.typ, c.explicit)
+		}
+	}
+
+	for _, bad := range []string{"", "60051", "HK000001", "abcdef", "2.600519"} {
+		if _, _, err := ParseInstrument(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+
+	if key := (Instrument{Code: "000001", Market: MarketSH, Type: InstrumentTypeIndex}).Key(); key != "SH000001" {
+		t.Fatalf("index key = %s, want SH000001", key)
+	}
+}
+
+func TestInstrumentResolver_UsesStocksTable(t *testing.T) {
+	db := newTestDB(t)
+
+	// 指数与股票同号，stocks.code 不能再是唯一索引
+	rows := []models.StockEntity{
+		{Code: "000001", Name: "上证指数", Market: MarketSH, FullCode: "SH000001", Type: InstrumentTypeIndex},
+		{Code: "000001", Name: "平安银行", Market: MarketSZ, FullCode: "SZ000001", Type: InstrumentTypeMain},
+		{Code: "501018", Name: "南方原油", Market: MarketSH, FullCode: "SH501018", Type: InstrumentTypeETF},
+	}
+	if err := db.GetDB().Create(&rows).Error; err != nil {
+		t.Fatalf("insert stocks: %v", err)
+	}
+
+	r := NewInstrumentResolver(db.GetDB())
+	if got := r.SecID("000001"); got != "0.000001" {
+		t.Fatalf("bare 000001 should resolve to the stock, got %s", got)
+	}
+	inst, err := r.Resolve("SH000001")
+	if err != nil || inst.SecID() != "1.000001" || !inst.IsIndex() {
+		t.Fatalf("SH000001 should resolve to the index, got %+v, err=%v", inst, err)
+	}
+	if inst, _ := r.Resolve("501018"); inst.Type != InstrumentTypeETF {
+		t.Fatalf("expected ETF type from stocks table, got %+v", inst)
+	}
+
+	var nilResolver *InstrumentResolver
+	if got := nilResolver.SecID("399001"); got != "0.399001" {
+		t.Fatalf("nil resolver should fall back to inference, got %s", got)
+	}
+}
+
+func TestDBService_RelaxStockCodeIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := NewDBServiceWithPath(path)
+	if err != nil {
+		t.Fatalf("NewDBServiceWithPath: %v", err)
+	}
+	// 模拟旧版数据库：stocks.code 为唯一索引，且没有迁移记录
+	if err := db.GetDB().Exec("DROP TABLE schema_version").Error; err != nil {
+		t.Fatalf("drop schema_version: %v", err)
+	}
+	if err := db.GetDB().Exec("DROP INDEX idx_stocks_code").Error; err != nil {
+		t.Fatalf("drop index: %v", err)
+	}
+	if err := db.GetDB().Exec("CREATE UNIQUE INDEX idx_stocks_code ON stocks(code)").Error; err != nil {
+		t.Fatalf("create unique index: %v", err)
+	}
+	db.Close()
+
+	db, err = NewDBServiceWithPath(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	rows := []models.StockEntity{
+		{Code: "000001", Market: MarketSH, FullCode: "SH000001", Type: InstrumentTypeIndex},
+		{Code: "000001", Market: MarketSZ, FullCode: "SZ000001", Type: InstrumentTypeMain},
+	}
+	if err := db.GetDB().Create(&rows).Error; err != nil {
+		t.Fatalf("same code on different markets should be allowed after migration: %v", err)
+	}
+}
+
+func TestActiveEquities_ExcludesIndicesETFsAndBonds(t *testing.T) {
+	db := newTestDB(t)
+
+	rows := []models.StockEntity{
+		{Code: "000001", Name: "上证指数", Market: MarketSH, FullCode: "SH000001", Type: InstrumentTypeIndex, IsActive: 1},
+		{Code: "000001", Name: "平安银行", Market: MarketSZ, FullCode: "SZ000001", Type: InstrumentTypeMain, IsActive: 1},
+		{Code: "510300", Name: "沪深300ETF", Market: MarketSH, FullCode: "SH510300", Type: InstrumentTypeETF, IsActive: 1},
+		{Code: "113050", Name: "南银转债", Market: MarketSH, FullCode: "SH113050", Type: InstrumentTypeConvertible, IsActive: 1},
+		{Code: "688981", Name: "中芯国际", Market: MarketSH, FullCode: "SH688981", Type: InstrumentTypeSTAR, IsActive: 1},
+		{Code: "830799", Name: "艾融软件", Market: MarketBJ, FullCode: "BJ830799", Type: InstrumentTypeBJ, IsActive: 1},
+	}
+	if err := db.GetDB().Create(&rows).Error; err != nil {
+		t.Fatalf("insert stocks: %v", err)
+	}
+	want := "000001,688981,830799"
+
+	// 全市场资金流同步
+	codes, err := NewStockMarketService(db).GetAllStockCodes()
+	if err != nil || strings.Join(codes, ",") != want {
+		t.Fatalf("GetAllStockCodes: expected %s, got %v, err=%v", want, codes, err)
+	}
+
+	// K 线同步：000001 只能是平安银行
+	tasks, err := NewKLineSyncService(db).getActiveStocks()
+	if err != nil {
+		t.Fatalf("getActiveStocks: %v", err)
+	}
+	var got []string
+	for _, task := range tasks {
+		got = append(got, task.Code)
+		if task.Code == "000001" && task.Market != MarketSZ {
+			t.Fatalf("expected 000001 to be the SZ stock, got %+v", task)
+		}
+	}
+	if strings.Join(got, ",") != want {
+		t.Fatalf("getActiveStocks: expected %s, got %v", want, got)
+	}
+
+	// 全市场策略扫描
+	codes = nil
+	if err := ActiveEquities(db.GetDB()).Order("code ASC").Pluck("code", &codes).Error; err != nil || strings.Join(codes, ",") != want {
+		t.Fatalf("ActiveEquities: expected %s, got %v, err=%v", want, codes, err)
+	}
+}
+
+func TestStockMarketService_SyncAllStocks_DeactivatesMissing(t *testing.T) {
+	db := newTestDB(t)
+
+	// SH920001 为早期版本写错交易所的旧记录，600001 已退市
+	rows := []models.StockEntity{
+		{Code: "920001", Name: "北交所一", Market: MarketSH, FullCode: "SH920001", Type: InstrumentTypeMain, IsActive: 1},
+		{Code: "600001", Name: "邯郸钢铁", Market: MarketSH, FullCode: "SH600001", Type: InstrumentTypeMain, IsActive: 1},
+		{Code: "000001", Name: "平安银行", Market: MarketSZ, FullCode: "SZ000001", Type: InstrumentTypeMain, IsActive: 1},
+	}
+	if err := db.GetDB().Create(&rows).Error; err != nil {
+		t.Fatalf("insert stocks: %v", err)
+	}
+
+	m := NewStockMarketService(db)
+	m.client.SetTransport(roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		body := `{"rc":0,"data":{"total":2,"diff":{"0":{"f12":"920001","f13":0,"f14":"北交所一","f2":1000},"1":{"f12":"000001","f13":0,"f14":"平安银行","f2":1100}}}}`
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	}))
+	if _, err := m.SyncAllStocks(); err != nil {
+		t.Fatalf("SyncAllStocks: %v", err)
+	}
+
+	active := map[string]int{}
+	var all []models.StockEntity
+	if err := db.GetDB().Find(&all).Error; err != nil {
+		t.Fatalf("query stocks: %v", err)
+	}
+	for _, s := range all {
+		active[s.FullCode] = s.IsActive
+	}
+	want := map[string]int{"SH920001": 0, "SH600001": 0, "SZ000001": 1, "BJ920001": 1}
+	if !reflect.DeepEqual(active, want) {
+		t.Fatalf("is_active = %v, want %v", active, want)
+	}
+
+	var codes []string
+	if err := ActiveEquities(db.GetDB()).Order("full_code ASC").Pluck("full_code", &codes).Error; err != nil || strings.Join(codes, ",") != "BJ920001,SZ000001" {
+		t.Fatalf("ActiveEquities = %v, %v", codes, err)
+	}
+	if got := NewInstrumentResolver(db.GetDB()).SecID("920001"); got != "0.920001" {
+		t.Fatalf("expected 920001 to resolve to the active BJ row, got %s", got)
+	}
+}
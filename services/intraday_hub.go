@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// IntradayUpstream 上游分时推送：持续推送直到 ctx 取消（内部负责重连）
+type IntradayUpstream func(ctx context.Context, code string, publish func(trends []string))
+
+// IntradayHub 分时推送中心：每个代码只保持一条上游连接，推送分发给所有订阅者，
+// 最后一个订阅者退出时关闭上游。前端、预警、持仓监控等均可订阅同一代码而互不影响。
+type IntradayHub struct {
+	mu       sync.Mutex
+	parent   func() context.Context
+	upstream IntradayUpstream
+	streams  map[string]*hubStream
+	nextID   uint64
+}
+
+// hubStream 单个代码的上游连接与订阅者
+type hubStream struct {
+	cancel    context.CancelFunc
+	subs      map[uint64]func(trends []string)
+	latest    map[string]string // 分钟 -> 最新推送，用于给后加入的订阅者补发当日快照
+	day       string            // latest 对应的交易日
+	deliverMu sync.Mutex        // 保证推送与快照补发按顺序送达
+}
+
+// NewIntradayHub 创建分时推送中心，parent 返回上游连接的父 context
+func NewIntradayHub(parent func() context.Context, upstream IntradayUpstream) *IntradayHub {
+	return &IntradayHub{
+		parent:   parent,
+		upstream: upstream,
+		streams:  make(map[string]*hubStream),
+	}
+}
+
+// Subscribe 订阅指定代码的分时推送，返回取消订阅函数（可重复调用）。
+// 该代码已有上游连接时，会先向新订阅者补发当日已收到的分时快照。
+// 回调在推送 goroutine 中同步执行，不应阻塞，也不能在回调中订阅同一代码。
+func (h *IntradayHub) Subscribe(code string, fn func(trends []string)) func() {
+	for {
+		st := h.stream(code)
+
+		st.deliverMu.Lock()
+		h.mu.Lock()
+		// 上游可能在两次加锁之间被最后一个订阅者关闭，此时重新建立
+		if h.streams[code] != st {
+			h.mu.Unlock()
+			st.deliverMu.Unlock()
+			continue
+		}
+		h.nextID++
+		id := h.nextID
+		st.subs[id] = fn
+		snapshot := st.snapshot()
+		h.mu.Unlock()
+		if len(snapshot) > 0 {
+			fn(snapshot)
+		}
+		st.deliverMu.Unlock()
+
+		var once sync.Once
+		return func() {
+			once.Do(func() { h.unsubscribe(code, st, id) })
+		}
+	}
+}
+
+// stream 返回指定代码的上游连接，不存在时建立
+func (h *IntradayHub) stream(code string) *hubStream {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if st, ok := h.streams[code]; ok {
+		return st
+	}
+	ctx, cancel := context.WithCancel(h.parent())
+	st := &hubStream{
+		cancel: cancel,
+		subs:   make(map[uint64]func(trends []string)),
+		latest: make(map[string]string),
+	}
+	h.streams[code] = st
+	go h.run(ctx, code, st)
+	return st
+}
+
+// SubscriberCount 返回指定代码的订阅者数量
+func (h *IntradayHub) SubscriberCount(code string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if st, ok := h.streams[code]; ok {
+		return len(st.subs)
+	}
+	return 0
+}
+
+// ActiveCodes 返回当前保持上游连接的代码（升序）
+func (h *IntradayHub) ActiveCodes() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	codes := make([]string, 0, len(h.streams))
+	for code := range h.streams {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// unsubscribe 移除订阅者，最后一个订阅者退出时关闭上游
+func (h *IntradayHub) unsubscribe(code string, st *hubStream, id uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(st.subs, id)
+	if len(st.subs) > 0 {
+		return
+	}
+	if h.streams[code] == st {
+		delete(h.streams, code)
+	}
+	st.cancel()
+}
+
+// run 运行上游连接，结束后（ctx 取消）清理
+func (h *IntradayHub) run(ctx context.Context, code string, st *hubStream) {
+	h.upstream(ctx, code, func(trends []string) { h.publish(st, trends) })
+
+	h.mu.Lock()
+	if h.streams[code] == st {
+		delete(h.streams, code)
+	}
+	h.mu.Unlock()
+}
+
+// publish 记录快照并分发给当前所有订阅者
+func (h *IntradayHub) publish(st *hubStream, trends []string) {
+	st.deliverMu.Lock()
+	defer st.deliverMu.Unlock()
+
+	h.mu.Lock()
+	for _, line := range trends {
+		if len(line) < len("2006-01-02 15:04") {
+			continue
+		}
+		minute := line[:len("2006-01-02 15:04")]
+		// 跨日时丢弃前一交易日的快照
+		if minute[:10] != st.day {
+			st.day = minute[:10]
+			st.latest = make(map[string]string)
+		}
+		st.latest[minute] = line
+	}
+	subs := make([]func(trends []string), 0, len(st.subs))
+	for _, fn := range st.subs {
+		subs = append(subs, fn)
+	}
+	h.mu.Unlock()
+
+	for _, fn := range subs {
+		fn(trends)
+	}
+}
+
+// snapshot 返回当日已收到的分时（按分钟升序），调用方需持有 h.mu
+func (st *hubStream) snapshot() []string {
+	minutes := make([]string, 0, len(st.latest))
+	for m := range st.latest {
+		minutes = append(minutes, m)
+	}
+	sort.Strings(minutes)
+	lines := make([]string, 0, len(minutes))
+	for _, m := range minutes {
+		lines = append(lines, st.latest[m])
+	}
+	return lines
+}
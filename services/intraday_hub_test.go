@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeUpstream 可控的上游：记录连接次数，publish 由测试驱动
+type fakeUpstream struct {
+	mu       sync.Mutex
+	started  int
+	publish  func(trends []string)
+	stopped  chan string
+	ready    chan struct{}
+	readyOne sync.Once
+}
+
+func (u *fakeUpstream) run(ctx context.Context, code string, publish func(trends []string)) {
+	u.mu.Lock()
+	u.started++
+	u.publish = publish
+	u.mu.Unlock()
+	u.readyOne.Do(func() { close(u.ready) })
+	<-ctx.Done()
+	u.stopped <- code
+}
+
+func (u *fakeUpstream) send(trends ...string) {
+	u.mu.Lock()
+	publish := u.publish
+	u.mu.Unlock()
+	publish(trends)
+}
+
+func TestIntradayHub_SharedUpstream(t *testing.T) {
+	u := &fakeUpstream{stopped: make(chan string, 4), ready: make(chan struct{})}
+	hub := NewIntradayHub(context.Background, u.run)
+
+	var mu sync.Mutex
+	got := map[string][]string{}
+	collect := func(name string) func([]string) {
+		return func(trends []string) {
+			mu.Lock()
+			defer mu.Unlock()
+			got[name] = append(got[name], trends...)
+		}
+	}
+
+	unsubA := hub.Subscribe("600519", collect("a"))
+	<-u.ready
+	u.send("2024-01-02 09:30,1700,1701,1702,1699,100,170000,1700.5")
+	u.send("2024-01-02 09:30,1700,1703,1703,1699,150,255000,1701.0")
+
+	// 后加入的订阅者先收到当日快照（同一分钟只保留最新一条）
+	unsubB := hub.Subscribe("600519", collect("b"))
+	u.send("2024-01-02 09:31,1703,1704,1705,1702,80,136000,1701.2")
+
+	if n := hub.SubscriberCount("600519"); n != 2 {
+		t.Fatalf("expected 2 subscribers, got %d", n)
+	}
+	u.mu.Lock()
+	started := u.started
+	u.mu.Unlock()
+	if started != 1 {
+		t.Fatalf("expected 1 upstream connection, got %d", started)
+	}
+
+	mu.Lock()
+	if len(got["a"]) != 3 {
+		t.Fatalf("subscriber a: expected 3 frames, got %v", got["a"])
+	}
+	if len(got["b"]) != 2 || got["b"][0] != "2024-01-02 09:30,1700,1703,1703,1699,150,255000,1701.0" {
+		t.Fatalf("subscriber b: expected snapshot + live frame, got %v", got["b"])
+	}
+	mu.Unlock()
+
+	unsubA()
+	unsubA() // 重复调用无副作用
+	select {
+	case <-u.stopped:
+		t.Fatalf("upstream closed while a subscriber remains")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unsubB()
+	select {
+	case code := <-u.stopped:
+		if code != "600519" {
+			t.Fatalf("unexpected code stopped: %s", code)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("upstream not closed after last subscriber left")
+	}
+	if codes := hub.ActiveCodes(); len(codes) != 0 {
+		t.Fatalf("expected no active codes, got %v", codes)
+	}
+}
+
+func TestStreamIntradayData_RefCounted(t *testing.T) {
+	s := NewStockService()
+	s.Startup(context.Background())
+	u := &fakeUpstream{stopped: make(chan string, 4), ready: make(chan struct{})}
+	s.hub = NewIntradayHub(func() context.Context { return s.ctx }, u.run)
+	s.emitIntraday = func(ctx context.Context, code string, trends []string) {}
+
+	// 自选列表与详情页同时订阅，另有预警在进程内订阅
+	s.StreamIntradayData("600519")
+	s.StreamIntradayData("600519")
+	alerts := make(chan []string, 1)
+	unsub := s.SubscribeIntraday("600519", func(trends []string) { alerts <- trends })
+	<-u.ready
+
+	if n := s.IntradaySubscribers("600519"); n != 2 {
+		t.Fatalf("expected 2 hub subscribers (frontend + alert), got %d", n)
+	}
+
+	// 一个页面离开不影响另一个页面与预警
+	s.StopIntradayStream("600519")
+	u.send("2024-01-02 09:30,1700,1701,1702,1699,100,170000,1700.5")
+	select {
+	case <-alerts:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("in-process subscriber did not receive frame")
+	}
+
+	s.StopIntradayStream("600519")
+	if n := s.IntradaySubscribers("600519"); n != 1 {
+		t.Fatalf("expected alert subscriber to remain, got %d", n)
+	}
+	unsub()
+	select {
+	case <-u.stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("upstream not closed after last subscriber left")
+	}
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"sort"
+	"stock-analyzer-wails/models"
+	"strings"
+	"time"
+)
+
+// intradayPoint 解析后的分时推送（东方财富 trends：时间,开,收,高,低,量,额,均价）
+type intradayPoint struct {
+	minute   string // 2006-01-02 15:04
+	open     float64
+	close    float64
+	high     float64
+	low      float64
+	volume   int64
+	amount   float64
+	avgPrice float64
+}
+
+// parseIntradayTrend 解析一条分时推送，格式不符时返回 false
+func parseIntradayTrend(line string) (intradayPoint, bool) {
+	parts := strings.Split(line, ",")
+	if len(parts) < 8 || len(parts[0]) != len("2006-01-02 15:04") {
+		return intradayPoint{}, false
+	}
+	p := intradayPoint{
+		minute:   parts[0],
+		open:     parsePrice(parts[1]),
+		close:    parsePrice(parts[2]),
+		high:     parsePrice(parts[3]),
+		low:      parsePrice(parts[4]),
+		volume:   int64(parsePrice(parts[5])),
+		amount:   parsePrice(parts[6]),
+		avgPrice: parsePrice(parts[7]),
+	}
+	if p.close <= 0 {
+		return intradayPoint{}, false
+	}
+	return p, true
+}
+
+// intradayRecorder 将单只股票的分时推送保存为分时点，并实时聚合为 1 分钟 K 线写入 K 线缓存。
+// 同一分钟会多次推送（该分钟内的累计值），聚合规则：开盘取首次、最高/最低取极值、收盘与成交量取最新。
+// 仅在单个 SSE goroutine 内使用，无需加锁。
+type intradayRecorder struct {
+	db   *DBService
+	code string
+	bars map[string]*models.KLineData // key: 分钟
+}
+
+// newIntradayRecorder 创建分时记录器
+func newIntradayRecorder(db *DBService, code string) *intradayRecorder {
+	return &intradayRecorder{db: db, code: code, bars: make(map[string]*models.KLineData)}
+}
+
+// Record 处理一次推送：保存分时点并更新对应分钟的 K 线
+func (r *intradayRecorder) Record(trends []string) error {
+	now := time.Now()
+	points := make([]models.IntradayPointEntity, 0, len(trends))
+	changed := make(map[string]*models.KLineData, len(trends))
+	for _, line := range trends {
+		p, ok := parseIntradayTrend(line)
+		if !ok {
+			continue
+		}
+		points = append(points, models.IntradayPointEntity{
+			Code:      r.code,
+			TradeDate: p.minute[:10],
+			Time:      p.minute[11:],
+			Price:     p.close,
+			AvgPrice:  p.avgPrice,
+			Volume:    p.volume,
+			Amount:    p.amount,
+			UpdatedAt: now,
+		})
+		changed[p.minute] = r.merge(p)
+	}
+	if len(points) == 0 {
+		return nil
+	}
+	if err := r.db.SaveIntradayPoints(points); err != nil {
+		return err
+	}
+
+	minutes := make([]string, 0, len(changed))
+	for m := range changed {
+		minutes = append(minutes, m)
+	}
+	sort.Strings(minutes)
+	records := make([]map[string]interface{}, 0, len(minutes))
+	for _, m := range minutes {
+		b := changed[m]
+		records = append(records, map[string]interface{}{
+			"date":   b.Time,
+			"open":   b.Open,
+			"high":   b.High,
+			"low":    b.Low,
+			"close":  b.Close,
+			"volume": b.Volume,
+		})
+	}
+	// 实时推送为不复权价格。前复权以最新价格为基准，当日价格与不复权一致，两种缓存都写入；
+	// 后复权价格需要乘以复权因子，不写入，后复权分钟线只来自行情源
+	for _, adjust := range []string{KLineAdjustNone, KLineAdjustForward} {
+		if _, _, err := r.db.InsertOrUpdateKLinePeriodData(r.code, KLinePeriod1Min, adjust, records); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// merge 将推送合并到对应分钟的 K 线
+func (r *intradayRecorder) merge(p intradayPoint) *models.KLineData {
+	b, ok := r.bars[p.minute]
+	if !ok {
+		open := p.open
+		if open <= 0 {
+			open = p.close
+		}
+		b = &models.KLineData{Time: p.minute, Open: open, High: p.close, Low: p.close}
+		r.bars[p.minute] = b
+		// 只保留当天的分钟线，跨日时清理
+		for m := range r.bars {
+			if m[:10] != p.minute[:10] {
+				delete(r.bars, m)
+			}
+		}
+	}
+	b.High = max(b.High, p.high, p.close)
+	if p.low > 0 {
+		b.Low = min(b.Low, p.low)
+	}
+	b.Low = min(b.Low, p.close)
+	b.Close = p.close
+	b.Volume = p.volume
+	return b
+}
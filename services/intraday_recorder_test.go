@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestIntradayRecorder_AggregatesMinuteBars(t *testing.T) {
+	db := newTestDB(t)
+
+	r := newIntradayRecorder(db, "600519")
+	// 首次推送为全量快照，之后同一分钟会多次推送累计值
+	steps := [][]string{
+		{"2024-01-02 09:30,10.00,10.00,10.00,10.00,100,1000,10.00", "2024-01-02 09:31,10.00,10.10,10.10,10.00,50,505,10.02"},
+		{"2024-01-02 09:31,10.00,10.30,10.30,10.00,80,808,10.05"},
+		{"2024-01-02 09:31,10.00,9.90,10.30,9.90,120,1200,10.01", "bad,line"},
+	}
+	for _, trends := range steps {
+		if err := r.Record(trends); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	bars, err := db.GetKLinePeriodDataFromCache("600519", KLinePeriod1Min, KLineAdjustForward, 10)
+	if err != nil || len(bars) != 2 {
+		t.Fatalf("expected 2 minute bars, got %v, err=%v", bars, err)
+	}
+	last := cacheRecordsToKLines(bars)[1]
+	if last.Time != "2024-01-02 09:31" || last.Open != 10 || last.High != 10.3 || last.Low != 9.9 || last.Close != 9.9 || last.Volume != 120 {
+		t.Fatalf("unexpected aggregated bar: %+v", last)
+	}
+
+	// 实时价格同时写入不复权缓存；后复权缓存价格基准不同，不写入
+	if none, _ := db.GetKLinePeriodDataFromCache("600519", KLinePeriod1Min, KLineAdjustNone, 10); len(none) != 2 {
+		t.Fatalf("expected the live bars in the unadjusted cache, got %v", none)
+	}
+	backward := []map[string]interface{}{{"date": "2024-01-02 09:30", "open": 50.0, "high": 50.0, "low": 50.0, "close": 50.0, "volume": int64(100)}}
+	if _, _, err := db.InsertOrUpdateKLinePeriodData("600519", KLinePeriod1Min, KLineAdjustBackward, backward); err != nil {
+		t.Fatalf("InsertOrUpdateKLinePeriodData: %v", err)
+	}
+	if err := r.Record([]string{"2024-01-02 09:31,10.00,9.90,10.30,9.90,120,1200,10.01"}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if got, _ := db.GetKLinePeriodDataFromCache("600519", KLinePeriod1Min, KLineAdjustBackward, 10); len(got) != 1 || got[0]["close"] != 50.0 {
+		t.Fatalf("expected the backward-adjusted cache to be left alone, got %v", got)
+	}
+	if forward, _ := db.GetKLinePeriodDataFromCache("600519", KLinePeriod1Min, KLineAdjustForward, 10); len(forward) != 2 {
+		t.Fatalf("expected the forward-adjusted minute bars to be kept, got %v", forward)
+	}
+
+	stored, err := (&StockService{dbService: db}).GetStoredIntradayData("600519", "")
+	if err != nil || len(stored.Data) != 2 || stored.Data[1].Price != 9.9 || stored.Data[1].AvgPrice != 10.01 {
+		t.Fatalf("unexpected stored intraday data: %+v, err=%v", stored, err)
+	}
+
+	// 下一交易日的昨收取上一交易日最后一个分时点
+	if err := r.Record([]string{"2024-01-03 09:30,9.95,9.95,9.95,9.95,10,99.5,9.95"}); err != nil {
+		t.Fatalf("Record next day: %v", err)
+	}
+	next, err := (&StockService{dbService: db}).GetStoredIntradayData("600519", "2024-01-03")
+	if err != nil || len(next.Data) != 1 || next.PreClose != 9.9 {
+		t.Fatalf("unexpected next day data: %+v, err=%v", next, err)
+	}
+}
+
+func TestStreamIntradayData_PersistsWhenEnabled(t *testing.T) {
+	db := newTestDB(t)
+
+	s := NewStockService()
+	s.SetProvider(&fakeProvider{})
+	s.SetDBService(db)
+	s.SetIntradayPersistence(true)
+	s.emitIntraday = func(ctx context.Context, code string, trends []string) {}
+	s.emitStatus = func(ctx context.Context, h StreamHealth) {}
+	defer s.StopIntradayStream("600519")
+
+	s.StreamIntradayData("600519")
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if data, err := s.GetStoredIntradayData("600519", "2024-01-02"); err == nil && len(data.Data) == 1 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected streamed point to be persisted")
+}
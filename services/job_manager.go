@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"stock-analyzer-wails/internal/logger"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"go.uber.org/zap"
+)
+
+// JobStatusEvent 任务状态变化事件（开始、暂停、继续、结束）
+const JobStatusEvent = "jobStatus"
+
+// 任务类型
+const (
+	JobKLineSync     = "kline_sync"      // K线同步
+	JobMoneyFlowSync = "money_flow_sync" // 全市场资金流同步
+	JobBatchSync     = "batch_sync"      // 批量同步股票历史数据
+	JobMassScan      = "mass_scan"       // 全市场策略扫描
+)
+
+// 任务状态
+const (
+	JobRunning   = "running"
+	JobPaused    = "paused"
+	JobCompleted = "completed"
+	JobCancelled = "cancelled"
+	JobFailed    = "failed"
+)
+
+// jobHistoryKeep 保留的已结束任务数量
+const jobHistoryKeep = 50
+
+// JobInfo 任务状态快照
+type JobInfo struct {
+	ID         string      `json:"id"`
+	Kind       string      `json:"kind"`
+	Status     string      `json:"status"`
+	Current    int         `json:"current"` // 已处理数量
+	Total      int         `json:"total"`   // 总数量
+	StartedAt  string      `json:"startedAt"`
+	FinishedAt string      `json:"finishedAt,omitempty"`
+	Result     interface{} `json:"result,omitempty"` // 结束时的结果（取消时为已完成部分）
+	Error      string      `json:"error,omitempty"`
+}
+
+// Job 一个长时间运行的操作：可取消的上下文加暂停闸门。
+// 执行方在处理每一项之前调用 Wait，结束时调用 JobManager.Finish。
+type Job struct {
+	id     string
+	kind   string
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu         sync.Mutex
+	status     string
+	resumeCh   chan struct{} // 暂停期间非空，继续时关闭
+	current    int
+	total      int
+	startedAt  time.Time
+	finishedAt time.Time
+	result     interface{}
+	errMsg     string
+}
+
+// ID 返回任务ID
+func (j *Job) ID() string {
+	return j.id
+}
+
+// Context 返回任务的上下文，任务被取消或程序退出时结束
+func (j *Job) Context() context.Context {
+	return j.ctx
+}
+
+// Wait 在处理下一项之前调用：暂停时阻塞直到继续；任务已取消时返回错误
+func (j *Job) Wait() error {
+	for {
+		j.mu.Lock()
+		ch := j.resumeCh
+		j.mu.Unlock()
+		if ch == nil {
+			return j.ctx.Err()
+		}
+		select {
+		case <-ch:
+		case <-j.ctx.Done():
+			return j.ctx.Err()
+		}
+	}
+}
+
+// SetProgress 更新任务进度
+func (j *Job) SetProgress(current, total int) {
+	j.mu.Lock()
+	j.current, j.total = current, total
+	j.mu.Unlock()
+}
+
+// info 返回任务状态快照
+func (j *Job) info() JobInfo {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	info := JobInfo{
+		ID:        j.id,
+		Kind:      j.kind,
+		Status:    j.status,
+		Current:   j.current,
+		Total:     j.total,
+		StartedAt: j.startedAt.Format("2006-01-02 15:04:05"),
+		Result:    j.result,
+		Error:     j.errMsg,
+	}
+	if !j.finishedAt.IsZero() {
+		info.FinishedAt = j.finishedAt.Format("2006-01-02 15:04:05")
+	}
+	return info
+}
+
+// finished 任务是否已结束
+func (j *Job) finished() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return !j.finishedAt.IsZero()
+}
+
+// JobManager 管理长时间运行的同步、扫描任务：分配任务ID，提供暂停、继续、取消
+type JobManager struct {
+	mu    sync.Mutex
+	ctx   context.Context
+	jobs  map[string]*Job
+	order []string // 按开始时间排列的任务ID
+	emit  func(ctx context.Context, info JobInfo)
+	now   func() time.Time
+}
+
+// NewJobManager 创建任务管理器
+func NewJobManager() *JobManager {
+	return &JobManager{
+		jobs: make(map[string]*Job),
+		emit: emitJobStatus,
+		now:  time.Now,
+	}
+}
+
+// emitJobStatus 向前端发送任务状态事件
+func emitJobStatus(ctx context.Context, info JobInfo) {
+	if ctx == nil {
+		return
+	}
+	runtime.EventsEmit(ctx, JobStatusEvent, info)
+}
+
+// SetContext 设置上下文（用于发送事件；程序退出时所有任务随之取消）
+func (m *JobManager) SetContext(ctx context.Context) {
+	m.mu.Lock()
+	m.ctx = ctx
+	m.mu.Unlock()
+}
+
+// Begin 登记一个新任务。id 为空时自动分配；与已结束的任务同ID（如续传的同步任务）时替换旧记录。
+func (m *JobManager) Begin(kind, id string) *Job {
+	m.mu.Lock()
+	parent := m.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	now := m.now()
+	if id == "" {
+		id = kind + "-" + strconv.FormatInt(now.UnixNano(), 36)
+	}
+	ctx, cancel := context.WithCancel(parent)
+	job := &Job{
+		id:        id,
+		kind:      kind,
+		ctx:       ctx,
+		cancel:    cancel,
+		status:    JobRunning,
+		startedAt: now,
+	}
+	if _, ok := m.jobs[id]; ok {
+		m.removeLocked(id)
+	}
+	m.jobs[id] = job
+	m.order = append(m.order, id)
+	m.pruneLocked()
+	m.mu.Unlock()
+
+	logger.Info("任务开始",
+		zap.String("module", "services.job_manager"),
+		zap.String("op", "Begin"),
+		zap.String("job_id", id),
+		zap.String("kind", kind),
+	)
+	m.publish(job)
+	return job
+}
+
+// Finish 结束任务并发送最终状态。status 为 completed/cancelled/failed，result 为（部分）结果。
+func (m *JobManager) Finish(job *Job, status string, result interface{}, errMsg string) {
+	job.mu.Lock()
+	job.status = status
+	job.result = result
+	job.errMsg = errMsg
+	job.finishedAt = m.now()
+	if job.resumeCh != nil {
+		close(job.resumeCh)
+		job.resumeCh = nil
+	}
+	job.mu.Unlock()
+	job.cancel()
+
+	logger.Info("任务结束",
+		zap.String("module", "services.job_manager"),
+		zap.String("op", "Finish"),
+		zap.String("job_id", job.id),
+		zap.String("status", status),
+		zap.String("error", errMsg),
+	)
+	m.publish(job)
+}
+
+// Pause 暂停任务：正在处理的一项完成后停在下一次 Wait
+func (m *JobManager) Pause(id string) error {
+	job, err := m.lookup(id)
+	if err != nil {
+		return err
+	}
+	job.mu.Lock()
+	if job.status != JobRunning {
+		job.mu.Unlock()
+		return fmt.Errorf("任务未在运行: %s", id)
+	}
+	job.status = JobPaused
+	job.resumeCh = make(chan struct{})
+	job.mu.Unlock()
+
+	m.publish(job)
+	return nil
+}
+
+// Resume 继续已暂停的任务
+func (m *JobManager) Resume(id string) error {
+	job, err := m.lookup(id)
+	if err != nil {
+		return err
+	}
+	job.mu.Lock()
+	if job.status != JobPaused {
+		job.mu.Unlock()
+		return fmt.Errorf("任务未暂停: %s", id)
+	}
+	job.status = JobRunning
+	close(job.resumeCh)
+	job.resumeCh = nil
+	job.mu.Unlock()
+
+	m.publish(job)
+	return nil
+}
+
+// Cancel 取消运行中或已暂停的任务。执行方在下一次 Wait 时退出，并以 cancelled 状态结束。
+func (m *JobManager) Cancel(id string) error {
+	job, err := m.lookup(id)
+	if err != nil {
+		return err
+	}
+	if job.finished() {
+		return fmt.Errorf("任务已结束: %s", id)
+	}
+	job.cancel()
+	return nil
+}
+
+// Get 获取任务状态
+func (m *JobManager) Get(id string) (JobInfo, error) {
+	job, err := m.lookup(id)
+	if err != nil {
+		return JobInfo{}, err
+	}
+	return job.info(), nil
+}
+
+// List 列出任务（最新的在前）
+func (m *JobManager) List() []JobInfo {
+	m.mu.Lock()
+	jobs := make([]*Job, 0, len(m.order))
+	for i := len(m.order) - 1; i >= 0; i-- {
+		jobs = append(jobs, m.jobs[m.order[i]])
+	}
+	m.mu.Unlock()
+
+	infos := make([]JobInfo, len(jobs))
+	for i, job := range jobs {
+		infos[i] = job.info()
+	}
+	return infos
+}
+
+func (m *JobManager) lookup(id string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("任务不存在: %s", id)
+	}
+	return job, nil
+}
+
+func (m *JobManager) publish(job *Job) {
+	m.mu.Lock()
+	ctx := m.ctx
+	m.mu.Unlock()
+	m.emit(ctx, job.info())
+}
+
+// removeLocked 删除任务记录（调用方持有 m.mu）
+func (m *JobManager) removeLocked(id string) {
+	delete(m.jobs, id)
+	for i, v := range m.order {
+		if v == id {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// pruneLocked 已结束的任务超过 jobHistoryKeep 个时删除最早的（调用方持有 m.mu）
+func (m *JobManager) pruneLocked() {
+	var finished []string
+	for _, id := range m.order {
+		if m.jobs[id].finished() {
+			finished = append(finished, id)
+		}
+	}
+	for i := 0; i < len(finished)-jobHistoryKeep; i++ {
+		m.removeLocked(finished[i])
+	}
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"stock-analyzer-wails/models"
+)
+
+func TestJobManager_PauseResumeCancel(t *testing.T) {
+	m := NewJobManager()
+	var mu sync.Mutex
+	var statuses []string
+	m.emit = func(_ context.Context, info JobInfo) {
+		mu.Lock()
+		statuses = append(statuses, info.Status)
+		mu.Unlock()
+	}
+
+	job := m.Begin(JobMassScan, "")
+	if err := job.Wait(); err != nil {
+		t.Fatalf("Wait on a running job = %v", err)
+	}
+	if err := m.Resume(job.ID()); err == nil {
+		t.Fatalf("expected resuming a running job to be rejected")
+	}
+
+	// 暂停期间 Wait 阻塞，继续后返回
+	if err := m.Pause(job.ID()); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- job.Wait() }()
+	select {
+	case err := <-done:
+		t.Fatalf("expected Wait to block while paused, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := m.Resume(job.ID()); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Wait after resume = %v", err)
+	}
+
+	// 暂停中的任务也可以直接取消
+	if err := m.Pause(job.ID()); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	go func() { done <- job.Wait() }()
+	if err := m.Cancel(job.ID()); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait after cancel = %v", err)
+	}
+	job.SetProgress(3, 10)
+	m.Finish(job, JobCancelled, map[string]int{"scanned": 3}, "")
+
+	info, err := m.Get(job.ID())
+	if err != nil || info.Status != JobCancelled || info.Current != 3 || info.FinishedAt == "" || info.Result == nil {
+		t.Fatalf("Get = %+v, %v", info, err)
+	}
+	if err := m.Cancel(job.ID()); err == nil {
+		t.Fatalf("expected cancelling a finished job to be rejected")
+	}
+	if err := m.Pause(job.ID()); err == nil {
+		t.Fatalf("expected pausing a finished job to be rejected")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{JobRunning, JobPaused, JobRunning, JobPaused, JobCancelled}
+	if len(statuses) != len(want) {
+		t.Fatalf("expected status events %v, got %v", want, statuses)
+	}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Fatalf("expected status events %v, got %v", want, statuses)
+		}
+	}
+}
+
+func TestJobManager_ListKeepsRecentFinishedJobs(t *testing.T) {
+	m := NewJobManager()
+	m.emit = func(context.Context, JobInfo) {}
+	now := cst("2024-06-14 20:00:00")
+	m.now = func() time.Time { now = now.Add(time.Second); return now }
+
+	running := m.Begin(JobKLineSync, "kline-1")
+	for i := 0; i < jobHistoryKeep+5; i++ {
+		m.Finish(m.Begin(JobBatchSync, ""), JobCompleted, nil, "")
+	}
+	// 同ID再次开始（续传）时替换旧记录
+	m.Finish(running, JobCancelled, nil, "")
+	m.Begin(JobKLineSync, "kline-1")
+
+	jobs := m.List()
+	if len(jobs) != jobHistoryKeep+1 {
+		t.Fatalf("expected %d jobs to be kept, got %d", jobHistoryKeep+1, len(jobs))
+	}
+	if jobs[0].ID != "kline-1" || jobs[0].Status != JobRunning {
+		t.Fatalf("expected the restarted job first, got %+v", jobs[0])
+	}
+}
+
+func TestKLineSync_CancelKeepsPartialResultsAndResumes(t *testing.T) {
+	db := newTestDB(t)
+	mustCreate(t, db,
+		&models.StockEntity{Code: "600519", Name: "贵州茅台", Market: MarketSH, FullCode: "SH600519", Type: InstrumentTypeMain, IsActive: 1},
+		&models.StockEntity{Code: "000001", Name: "平安银行", Market: MarketSZ, FullCode: "SZ000001", Type: InstrumentTypeMain, IsActive: 1},
+		&models.StockEntity{Code: "000002", Name: "万科A", Market: MarketSZ, FullCode: "SZ000002", Type: InstrumentTypeMain, IsActive: 1},
+	)
+
+	server := &fakeKLineServer{closes: map[string]float64{"600519": 1700, "000001": 11, "000002": 8.5}, requests: map[string]string{}}
+	manager := NewJobManager()
+	manager.emit = func(context.Context, JobInfo) {}
+	svc := NewKLineSyncService(db)
+	svc.SetJobManager(manager)
+	svc.now = func() time.Time { return cst("2024-06-14 20:00:00") }
+
+	// 第一只股票的请求发出时取消任务：该股票照常完成，剩余股票不再处理
+	var once sync.Once
+	svc.client.SetTransport(roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		once.Do(func() {
+			for _, job := range manager.List() {
+				if job.Kind == JobKLineSync && job.Status == JobRunning {
+					_ = manager.Cancel(job.ID)
+				}
+			}
+		})
+		return server.RoundTrip(r)
+	}))
+
+	result, err := svc.StartKLineSync(5, KLineAdjustForward)
+	if err != nil || !result.Cancelled || result.Success || result.TotalCount != 3 || result.SuccessCount != 1 {
+		t.Fatalf("StartKLineSync = %+v, %v", result, err)
+	}
+	if len(server.requests) != 1 {
+		t.Fatalf("expected only the first code to be fetched, got %v", server.requests)
+	}
+	info, err := manager.Get(result.JobID)
+	if err != nil || info.Status != JobCancelled || info.Current != 1 || info.Result == nil {
+		t.Fatalf("job info = %+v, %v", info, err)
+	}
+	persisted, err := svc.jobs.Get(result.JobID)
+	if err != nil || persisted.Status != SyncJobInterrupted || persisted.Succeeded != 1 {
+		t.Fatalf("persisted job = %+v, %v", persisted, err)
+	}
+
+	resumed, err := svc.ResumeKLineSync(result.JobID)
+	if err != nil || resumed.Cancelled || resumed.TotalCount != 2 || resumed.SuccessCount != 2 {
+		t.Fatalf("ResumeKLineSync = %+v, %v", resumed, err)
+	}
+	if info, _ := manager.Get(result.JobID); info.Status != JobCompleted {
+		t.Fatalf("expected the resumed job to complete, got %+v", info)
+	}
+}
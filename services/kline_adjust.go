@@ -0,0 +1,122 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"stock-analyzer-wails/models"
+	"strings"
+)
+
+// K 线复权方式
+const (
+	KLineAdjustNone     = "none"     // 不复权
+	KLineAdjustForward  = "forward"  // 前复权（以最新价格为基准）
+	KLineAdjustBackward = "backward" // 后复权（以上市首日价格为基准）
+)
+
+// klineAdjustAliases 兼容的复权写法（含东方财富 fqt 参数）
+var klineAdjustAliases = map[string]string{
+	"":                  KLineAdjustForward,
+	KLineAdjustForward:  KLineAdjustForward,
+	"qfq":               KLineAdjustForward,
+	"1":                 KLineAdjustForward,
+	KLineAdjustBackward: KLineAdjustBackward,
+	"hfq":               KLineAdjustBackward,
+	"2":                 KLineAdjustBackward,
+	KLineAdjustNone:     KLineAdjustNone,
+	"bfq":               KLineAdjustNone,
+	"nfq":               KLineAdjustNone,
+	"0":                 KLineAdjustNone,
+}
+
+// NormalizeKLineAdjust 将复权参数规范化（空值视为前复权），不支持的写法返回错误
+func NormalizeKLineAdjust(adjust string) (string, error) {
+	a, ok := klineAdjustAliases[strings.ToLower(strings.TrimSpace(adjust))]
+	if !ok {
+		return "", fmt.Errorf("不支持的复权方式: %s", adjust)
+	}
+	return a, nil
+}
+
+// klineAdjustFQT 返回东方财富 fqt 参数（0=不复权，1=前复权，2=后复权）
+func klineAdjustFQT(adjust string) string {
+	switch adjust {
+	case KLineAdjustNone:
+		return "0"
+	case KLineAdjustBackward:
+		return "2"
+	default:
+		return "1"
+	}
+}
+
+// exRightsRatio 计算除权除息参考价与前收盘价之比
+// 参考价 = (前收盘 - 每股派息 + 配股价 × 每股配股) / (1 + 每股送股 + 每股转增 + 每股配股)
+func exRightsRatio(prevClose float64, e models.ExRightsEventEntity) float64 {
+	if prevClose <= 0 {
+		return 1
+	}
+	ref := (prevClose - e.CashDividend + e.RightsPrice*e.RightsRatio) / (1 + e.BonusShares + e.TransferShares + e.RightsRatio)
+	if ref <= 0 {
+		return 1
+	}
+	return ref / prevClose
+}
+
+// AdjustKLines 根据除权除息事件对不复权 K 线进行本地复权（等比复权，成交量不变）
+// bars 需按时间升序；返回新切片，不修改入参
+func AdjustKLines(bars []*models.KLineData, events []models.ExRightsEventEntity, adjust string) []*models.KLineData {
+	out := make([]*models.KLineData, len(bars))
+	for i, b := range bars {
+		c := *b
+		out[i] = &c
+	}
+	if adjust == KLineAdjustNone || len(out) == 0 || len(events) == 0 {
+		return out
+	}
+
+	sorted := append([]models.ExRightsEventEntity(nil), events...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ExDate < sorted[j].ExDate })
+
+	// factors[i] 为第 i 根 K 线之后（含当天）发生的所有除权因子之积
+	// 前复权：第 i 根乘以其后所有事件的因子；后复权：第 i 根除以其前（含当天）所有事件的因子
+	ratios := make([]float64, len(out)) // ratios[i]：在第 i 根 K 线当天除权的因子
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	for _, e := range sorted {
+		// 找到除权日当天或之后的第一根 K 线（K 线时间可能带分钟）
+		idx := sort.Search(len(out), func(i int) bool { return out[i].Time[:min(len(out[i].Time), 10)] >= e.ExDate })
+		if idx == 0 || idx >= len(out) {
+			continue // 窗口之外的事件不影响窗口内的相对价格
+		}
+		ratios[idx] *= exRightsRatio(out[idx-1].Close, e)
+	}
+
+	if adjust == KLineAdjustBackward {
+		factor := 1.0
+		for i, b := range out {
+			factor /= ratios[i]
+			scaleKLine(b, factor)
+		}
+		return out
+	}
+
+	factor := 1.0
+	for i := len(out) - 1; i >= 0; i-- {
+		scaleKLine(out[i], factor)
+		factor *= ratios[i]
+	}
+	return out
+}
+
+// scaleKLine 按因子缩放价格
+func scaleKLine(b *models.KLineData, factor float64) {
+	if factor == 1 {
+		return
+	}
+	b.Open *= factor
+	b.High *= factor
+	b.Low *= factor
+	b.Close *= factor
+}
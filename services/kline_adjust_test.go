@@ -0,0 +1,124 @@
+package services
+
+import (
+	"math"
+	"stock-analyzer-wails/models"
+	"testing"
+)
+
+func TestNormalizeKLineAdjust(t *testing.T) {
+	cases := map[string]string{
+		"":         KLineAdjustForward,
+		"qfq":      KLineAdjustForward,
+		"HFQ":      KLineAdjustBackward,
+		"2":        KLineAdjustBackward,
+		" none ":   KLineAdjustNone,
+		"0":        KLineAdjustNone,
+		"backward": KLineAdjustBackward,
+	}
+	for in, want := range cases {
+		got, err := NormalizeKLineAdjust(in)
+		if err != nil || got != want {
+			t.Fatalf("NormalizeKLineAdjust(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := NormalizeKLineAdjust("split"); err == nil {
+		t.Fatalf("expected error for unsupported adjust mode")
+	}
+}
+
+func TestAdjustKLines(t *testing.T) {
+	// 2024-06-03 除权：每股派 1 元并 10 转 10，前收盘 21 → 参考价 10
+	bars := []*models.KLineData{
+		{Time: "2024-05-30", Open: 20, High: 21, Low: 19, Close: 20, Volume: 100},
+		{Time: "2024-05-31", Open: 20, High: 21.5, Low: 20, Close: 21, Volume: 100},
+		{Time: "2024-06-03", Open: 10, High: 10.5, Low: 9.8, Close: 10, Volume: 200},
+		{Time: "2024-06-04", Open: 10, High: 11, Low: 10, Close: 11, Volume: 200},
+	}
+	events := []models.ExRightsEventEntity{
+		{Code: "600519", ExDate: "2024-06-03", CashDividend: 1, TransferShares: 1},
+		{Code: "600519", ExDate: "2023-01-01", CashDividend: 5}, // 窗口之前，忽略
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+	forward := AdjustKLines(bars, events, KLineAdjustForward)
+	if !near(forward[1].Close, 10) || !near(forward[0].Close, 20*10.0/21) || !near(forward[3].Close, 11) {
+		t.Fatalf("unexpected forward closes: %v %v %v", forward[0].Close, forward[1].Close, forward[3].Close)
+	}
+	if forward[0].Volume != 100 {
+		t.Fatalf("volume must not be adjusted")
+	}
+
+	backward := AdjustKLines(bars, events, KLineAdjustBackward)
+	if !near(backward[1].Close, 21) || !near(backward[2].Close, 21) || !near(backward[3].Close, 11*2.1) {
+		t.Fatalf("unexpected backward closes: %v %v %v", backward[1].Close, backward[2].Close, backward[3].Close)
+	}
+
+	none := AdjustKLines(bars, events, KLineAdjustNone)
+	if none[0].Close != 20 || bars[0].Close != 20 {
+		t.Fatalf("none mode and input must be untouched")
+	}
+}
+
+func TestDBService_KLineCacheAdjust(t *testing.T) {
+	db := newTestDB(t)
+
+	bar := func(date string, close float64) map[string]interface{} {
+		return map[string]interface{}{"date": date, "open": close, "high": close, "low": close, "close": close, "volume": int64(100)}
+	}
+
+	if _, _, err := db.InsertOrUpdateKLinePeriodData("600519", KLinePeriodDaily, KLineAdjustForward, []map[string]interface{}{
+		bar("2024-05-30", 9.52), bar("2024-05-31", 10),
+	}); err != nil {
+		t.Fatalf("insert forward: %v", err)
+	}
+	// 切换为不复权后，旧的前复权数据应被清空，避免价格基准混杂
+	if _, _, err := db.InsertOrUpdateKLinePeriodData("600519", KLinePeriodDaily, KLineAdjustNone, []map[string]interface{}{
+		bar("2024-05-31", 21), bar("2024-06-03", 10),
+	}); err != nil {
+		t.Fatalf("insert none: %v", err)
+	}
+
+	adjust, err := db.GetKLineCacheAdjust("600519", KLinePeriodDaily)
+	if err != nil || adjust != KLineAdjustNone {
+		t.Fatalf("GetKLineCacheAdjust = %q, %v", adjust, err)
+	}
+	cached, err := db.GetKLinePeriodDataFromCache("600519", KLinePeriodDaily, KLineAdjustNone, 10)
+	if err != nil || len(cached) != 2 || cached[0]["date"] != "2024-05-31" {
+		t.Fatalf("expected only the 2 unadjusted bars, got %v, err=%v", cached, err)
+	}
+	if forward, _ := db.GetKLinePeriodDataFromCache("600519", KLinePeriodDaily, KLineAdjustForward, 10); len(forward) != 0 {
+		t.Fatalf("expected no forward bars, got %v", forward)
+	}
+
+	// 本地复权：不访问行情源
+	if err := db.SaveExRightsEvents([]models.ExRightsEventEntity{
+		{Code: "600519", ExDate: "2024-06-03", CashDividend: 1, TransferShares: 1},
+	}); err != nil {
+		t.Fatalf("SaveExRightsEvents: %v", err)
+	}
+	s := NewStockService()
+	s.SetDBService(db)
+	klines, err := s.GetLocallyAdjustedKLines("600519", 0, KLineAdjustForward)
+	if err != nil || len(klines) != 2 || math.Abs(klines[0].Close-10) > 1e-9 {
+		t.Fatalf("unexpected locally adjusted klines: %v, err=%v", klines, err)
+	}
+}
+
+func TestEastMoneyProvider_GetExRightsEvents_Replay(t *testing.T) {
+	t.Setenv(EnvHTTPMode, HTTPModeReplay)
+	t.Setenv(EnvFixtureDir, "testdata/fixtures")
+
+	s := NewStockService()
+	p, ok := s.provider.(ExRightsProvider)
+	if !ok {
+		t.Fatalf("default provider should implement ExRightsProvider")
+	}
+	events, err := p.GetExRightsEvents("600686")
+	if err != nil {
+		t.Fatalf("GetExRightsEvents: %v", err)
+	}
+	if len(events) != 1 || events[0].ExDate != "2025-07-10" || math.Abs(events[0].CashDividend-0.05) > 1e-9 || math.Abs(events[0].TransferShares-0.3) > 1e-9 {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"stock-analyzer-wails/internal/logger"
+	"stock-analyzer-wails/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// legacyKLineTable 旧版按股票分表的 K 线缓存表
+type legacyKLineTable struct {
+	name   string
+	code   string
+	period string
+}
+
+// parseLegacyKLineTable 解析旧版缓存表名：日线 kline_{code}，其他周期 kline_{code}_{period}
+func parseLegacyKLineTable(name string) (legacyKLineTable, bool) {
+	rest := strings.TrimPrefix(name, "kline_")
+	if rest == name || rest == "" || name == (models.KLineBarEntity{}).TableName() {
+		return legacyKLineTable{}, false
+	}
+	for period := range klinePeriodSpecs {
+		if period == KLinePeriodDaily {
+			continue
+		}
+		suffix := strings.TrimPrefix(klineTableName("", period), "kline_")
+		if code := strings.TrimSuffix(rest, suffix); code != rest && code != "" {
+			return legacyKLineTable{name: name, code: code, period: period}, true
+		}
+	}
+	if strings.Contains(rest, "_") {
+		return legacyKLineTable{}, false
+	}
+	return legacyKLineTable{name: name, code: rest, period: KLinePeriodDaily}, true
+}
+
+// migrateToKLineBars 结构迁移：创建 kline_bars 并迁入旧版按股票分表的 K 线缓存
+func (s *DBService) migrateToKLineBars() error {
+	if err := s.db.AutoMigrate(&models.KLineBarEntity{}); err != nil {
+		return fmt.Errorf("创建 kline_bars 表失败: %w", err)
+	}
+	return s.migrateLegacyKLineTables()
+}
+
+// migrateLegacyKLineTables 将旧版 kline_{code}[_{period}] 表的数据迁入 kline_bars 并删除旧表。
+// 每张表在独立事务中迁移，失败的表保留原样并记录日志（缓存数据可重新同步，不阻塞启动），
+// 由每次启动时的 initTables 重试。
+func (s *DBService) migrateLegacyKLineTables() error {
+	var names []string
+	if err := s.db.Raw("SELECT name FROM sqlite_master WHERE type='table' AND name LIKE 'kline\\_%' ESCAPE '\\'").Scan(&names).Error; err != nil {
+		return fmt.Errorf("查询旧版 K 线缓存表失败: %w", err)
+	}
+
+	var tables []legacyKLineTable
+	for _, name := range names {
+		if t, ok := parseLegacyKLineTable(name); ok {
+			tables = append(tables, t)
+		}
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	logger.Info("开始迁移旧版 K 线缓存表",
+		zap.String("module", "services.db"),
+		zap.String("op", "migrateLegacyKLineTables"),
+		zap.Int("tables", len(tables)),
+	)
+
+	var migrated, failed int
+	var rows int64
+	for _, t := range tables {
+		n, err := s.migrateLegacyKLineTable(t)
+		if err != nil {
+			failed++
+			logger.Warn("迁移旧版 K 线缓存表失败",
+				zap.String("module", "services.db"),
+				zap.String("op", "migrateLegacyKLineTables"),
+				zap.String("table", t.name),
+				zap.Error(err),
+			)
+			continue
+		}
+		migrated++
+		rows += n
+	}
+
+	logger.Info("旧版 K 线缓存表迁移完成",
+		zap.String("module", "services.db"),
+		zap.String("op", "migrateLegacyKLineTables"),
+		zap.Int("migrated", migrated),
+		zap.Int("failed", failed),
+		zap.Int64("rows", rows),
+		zap.Duration("elapsed", time.Since(start)),
+	)
+	return nil
+}
+
+// migrateLegacyKLineTable 迁移单张旧表，返回迁入的行数。
+// 旧表缺少 adjust 列时数据均为前复权；kline_bars 中已有的同键数据优先保留。
+func (s *DBService) migrateLegacyKLineTable(t legacyKLineTable) (int64, error) {
+	adjustExpr := fmt.Sprintf("'%s'", KLineAdjustForward)
+	if s.db.Table(t.name).Migrator().HasColumn(&models.KLineEntity{}, "adjust") {
+		adjustExpr = fmt.Sprintf("COALESCE(NULLIF(adjust, ''), '%s')", KLineAdjustForward)
+	}
+
+	var rows int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(fmt.Sprintf(
+			`INSERT OR IGNORE INTO kline_bars (code, period, adjust, date, open, high, low, close, volume, created_at, updated_at)
+			SELECT ?, ?, %s, date, open, high, low, close, volume, COALESCE(created_at, CURRENT_TIMESTAMP), COALESCE(updated_at, CURRENT_TIMESTAMP)
+			FROM %q`, adjustExpr, t.name), t.code, t.period)
+		if result.Error != nil {
+			return fmt.Errorf("复制数据失败: %w", result.Error)
+		}
+		rows = result.RowsAffected
+		if err := tx.Exec(fmt.Sprintf("DROP TABLE %q", t.name)).Error; err != nil {
+			return fmt.Errorf("删除旧表失败: %w", err)
+		}
+		return nil
+	})
+	return rows, err
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseLegacyKLineTable(t *testing.T) {
+	cases := map[string]legacyKLineTable{
+		"kline_600519":       {name: "kline_600519", code: "600519", period: KLinePeriodDaily},
+		"kline_600519_5min":  {name: "kline_600519_5min", code: "600519", period: KLinePeriod5Min},
+		"kline_600519_15min": {name: "kline_600519_15min", code: "600519", period: KLinePeriod15Min},
+		"kline_SH000001":     {name: "kline_SH000001", code: "SH000001", period: KLinePeriodDaily},
+	}
+	for name, want := range cases {
+		got, ok := parseLegacyKLineTable(name)
+		if !ok || got != want {
+			t.Fatalf("parseLegacyKLineTable(%s) = %+v, %v; want %+v", name, got, ok, want)
+		}
+	}
+	for _, name := range []string{"kline_bars", "kline_", "kline_600519_2min", "stocks"} {
+		if _, ok := parseLegacyKLineTable(name); ok {
+			t.Fatalf("expected %s to be ignored", name)
+		}
+	}
+}
+
+func TestDBService_MigrateLegacyKLineTables(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := NewDBServiceWithPath(path)
+	if err != nil {
+		t.Fatalf("NewDBServiceWithPath: %v", err)
+	}
+
+	// 旧版表：最早的日线表没有 adjust 列，分钟线表有；旧版数据库没有迁移记录
+	stmts := []string{
+		`DROP TABLE schema_version`,
+		`CREATE TABLE kline_600519 (id integer PRIMARY KEY AUTOINCREMENT, date text NOT NULL UNIQUE, open real, high real, low real, close real, volume integer, created_at datetime, updated_at datetime)`,
+		`INSERT INTO kline_600519 (date, open, high, low, close, volume) VALUES ('2024-01-02', 10, 11, 9, 10.5, 100), ('2024-01-03', 10.5, 11, 10, 10.8, 120)`,
+		`CREATE TABLE kline_600519_5min (id integer PRIMARY KEY AUTOINCREMENT, date text NOT NULL UNIQUE, open real, high real, low real, close real, volume integer, adjust text, created_at datetime, updated_at datetime)`,
+		`INSERT INTO kline_600519_5min (date, open, high, low, close, volume, adjust) VALUES ('2024-01-03 09:35', 10.5, 10.6, 10.4, 10.6, 20, 'none')`,
+		`CREATE TABLE kline_SH000001 (id integer PRIMARY KEY AUTOINCREMENT, date text NOT NULL UNIQUE, open real, high real, low real, close real, volume integer, adjust text, created_at datetime, updated_at datetime)`,
+		`INSERT INTO kline_SH000001 (date, open, high, low, close, volume, adjust) VALUES ('2024-01-03', 2960, 2970, 2950, 2967, 9000, 'forward')`,
+	}
+	for _, stmt := range stmts {
+		if err := db.GetDB().Exec(stmt).Error; err != nil {
+			t.Fatalf("exec %q: %v", stmt, err)
+		}
+	}
+	db.Close()
+
+	// 重新打开时完成迁移
+	db, err = NewDBServiceWithPath(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+
+	for _, name := range []string{"kline_600519", "kline_600519_5min", "kline_SH000001"} {
+		if db.GetDB().Migrator().HasTable(name) {
+			t.Fatalf("expected legacy table %s to be dropped", name)
+		}
+	}
+
+	stocks, err := db.GetAllSyncedStocks()
+	if err != nil || !reflect.DeepEqual(stocks, []string{"600519", "SH000001"}) {
+		t.Fatalf("GetAllSyncedStocks = %v, %v", stocks, err)
+	}
+	daily, err := db.GetKLinePeriodDataFromCache("600519", KLinePeriodDaily, KLineAdjustForward, 10)
+	if err != nil || len(daily) != 2 || daily[1]["close"] != 10.8 {
+		t.Fatalf("unexpected migrated daily bars: %v, err=%v", daily, err)
+	}
+	minute, err := db.GetKLinePeriodDataFromCache("600519", KLinePeriod5Min, KLineAdjustNone, 10)
+	if err != nil || len(minute) != 1 || minute[0]["date"] != "2024-01-03 09:35" {
+		t.Fatalf("unexpected migrated minute bars: %v, err=%v", minute, err)
+	}
+
+	// 截面查询：同一日全部股票
+	section, err := db.GetKLineCrossSection("2024-01-03", "")
+	if err != nil || len(section) != 2 || section[0].Code != "600519" || section[1].Code != "SH000001" {
+		t.Fatalf("unexpected cross section: %+v, err=%v", section, err)
+	}
+
+	if err := db.ClearKLineCacheTable("600519"); err != nil {
+		t.Fatalf("ClearKLineCacheTable: %v", err)
+	}
+	if n, _ := db.GetKLineCountByCode("600519"); n != 0 {
+		t.Fatalf("expected 600519 bars to be cleared, got %d", n)
+	}
+	if minute, _ := db.GetKLinePeriodDataFromCache("600519", KLinePeriod5Min, "", 10); len(minute) != 0 {
+		t.Fatalf("expected minute bars to be cleared, got %v", minute)
+	}
+}
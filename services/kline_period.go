@@ -0,0 +1,113 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// K 线周期（前端/接口统一使用的规范名称）
+const (
+	KLinePeriodDaily = "daily"
+	KLinePeriodWeek  = "week"
+	KLinePeriodMonth = "month"
+	KLinePeriod1Min  = "1min"
+	KLinePeriod5Min  = "5min"
+	KLinePeriod15Min = "15min"
+	KLinePeriod30Min = "30min"
+	KLinePeriod60Min = "60min"
+)
+
+// tradingMinutesDay A 股每日连续竞价分钟数
+const tradingMinutesDay = 240
+
+// klinePeriodSpec 周期元数据
+type klinePeriodSpec struct {
+	klt        string  // 东方财富 klt 参数
+	label      string  // 中文名称（用于提示词/日志）
+	barsPerDay int     // 每个交易日的 K 线根数（日线及以上为 0）
+	barsPerYr  float64 // 每年 K 线根数
+}
+
+var klinePeriodSpecs = map[string]klinePeriodSpec{
+	KLinePeriodDaily: {klt: "101", label: "日线", barsPerYr: 252},
+	KLinePeriodWeek:  {klt: "102", label: "周线", barsPerYr: 52},
+	KLinePeriodMonth: {klt: "103", label: "月线", barsPerYr: 12},
+	KLinePeriod1Min:  {klt: "1", label: "1分钟线", barsPerDay: tradingMinutesDay, barsPerYr: 252 * tradingMinutesDay},
+	KLinePeriod5Min:  {klt: "5", label: "5分钟线", barsPerDay: tradingMinutesDay / 5, barsPerYr: 252 * tradingMinutesDay / 5},
+	KLinePeriod15Min: {klt: "15", label: "15分钟线", barsPerDay: tradingMinutesDay / 15, barsPerYr: 252 * tradingMinutesDay / 15},
+	KLinePeriod30Min: {klt: "30", label: "30分钟线", barsPerDay: tradingMinutesDay / 30, barsPerYr: 252 * tradingMinutesDay / 30},
+	KLinePeriod60Min: {klt: "60", label: "60分钟线", barsPerDay: tradingMinutesDay / 60, barsPerYr: 252 * tradingMinutesDay / 60},
+}
+
+// klinePeriodAliases 兼容的周期写法
+var klinePeriodAliases = map[string]string{
+	"":        KLinePeriodDaily,
+	"day":     KLinePeriodDaily,
+	"1d":      KLinePeriodDaily,
+	"101":     KLinePeriodDaily,
+	"weekly":  KLinePeriodWeek,
+	"1w":      KLinePeriodWeek,
+	"102":     KLinePeriodWeek,
+	"monthly": KLinePeriodMonth,
+	"1mo":     KLinePeriodMonth,
+	"103":     KLinePeriodMonth,
+	"1":       KLinePeriod1Min,
+	"1m":      KLinePeriod1Min,
+	"5":       KLinePeriod5Min,
+	"5m":      KLinePeriod5Min,
+	"15":      KLinePeriod15Min,
+	"15m":     KLinePeriod15Min,
+	"30":      KLinePeriod30Min,
+	"30m":     KLinePeriod30Min,
+	"60":      KLinePeriod60Min,
+	"60m":     KLinePeriod60Min,
+	"1h":      KLinePeriod60Min,
+}
+
+// NormalizeKLinePeriod 将周期参数规范化（空值视为日线），不支持的周期返回错误
+func NormalizeKLinePeriod(period string) (string, error) {
+	p := strings.ToLower(strings.TrimSpace(period))
+	if alias, ok := klinePeriodAliases[p]; ok {
+		p = alias
+	}
+	if _, ok := klinePeriodSpecs[p]; !ok {
+		return "", fmt.Errorf("不支持的 K 线周期: %s", period)
+	}
+	return p, nil
+}
+
+// IsMinuteKLinePeriod 是否为分钟级周期
+func IsMinuteKLinePeriod(period string) bool {
+	p, err := NormalizeKLinePeriod(period)
+	if err != nil {
+		return false
+	}
+	return klinePeriodSpecs[p].barsPerDay > 0
+}
+
+// KLinePeriodLabel 返回周期中文名称，未知周期原样返回
+func KLinePeriodLabel(period string) string {
+	p, err := NormalizeKLinePeriod(period)
+	if err != nil {
+		return period
+	}
+	return klinePeriodSpecs[p].label
+}
+
+// klinePeriodBarsPerYear 每年 K 线根数（用于年化收益）
+func klinePeriodBarsPerYear(period string) float64 {
+	p, err := NormalizeKLinePeriod(period)
+	if err != nil {
+		return 252
+	}
+	return klinePeriodSpecs[p].barsPerYr
+}
+
+// klineTableName 返回旧版 K 线缓存表名（日线 kline_{code}，其他周期 kline_{code}_{period}），用于迁移识别旧表
+func klineTableName(code, period string) string {
+	p, err := NormalizeKLinePeriod(period)
+	if err != nil || p == KLinePeriodDaily {
+		return fmt.Sprintf("kline_%s", code)
+	}
+	return fmt.Sprintf("kline_%s_%s", code, p)
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"stock-analyzer-wails/models"
+	"testing"
+	"time"
+)
+
+func TestNormalizeKLinePeriod(t *testing.T) {
+	cases := map[string]string{
+		"":      KLinePeriodDaily,
+		"daily": KLinePeriodDaily,
+		"week":  KLinePeriodWeek,
+		"5":     KLinePeriod5Min,
+		"15m":   KLinePeriod15Min,
+		"1h":    KLinePeriod60Min,
+		" 1MIN": KLinePeriod1Min,
+	}
+	for in, want := range cases {
+		got, err := NormalizeKLinePeriod(in)
+		if err != nil || got != want {
+			t.Fatalf("NormalizeKLinePeriod(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := NormalizeKLinePeriod("2min"); err == nil {
+		t.Fatalf("expected error for unsupported period")
+	}
+	if klineTableName("600519", "daily") != "kline_600519" || klineTableName("600519", "5") != "kline_600519_5min" {
+		t.Fatalf("unexpected table names: %s %s", klineTableName("600519", "daily"), klineTableName("600519", "5"))
+	}
+}
+
+// minuteProvider 返回间隔 5 分钟的固定分钟线（自 10:00 起第 start 根开始），可模拟行情源故障
+type minuteProvider struct {
+	fakeProvider
+	start int
+	fail  bool
+}
+
+func (m *minuteProvider) GetKLineData(code string, limit int, period string, adjust string) ([]*models.KLineData, error) {
+	if m.fail {
+		return nil, errors.New("offline")
+	}
+	klines := make([]*models.KLineData, 0, 10)
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.Local)
+	for i := m.start; i < m.start+10; i++ {
+		klines = append(klines, &models.KLineData{
+			Time:  base.Add(time.Duration(i*5) * time.Minute).Format("2006-01-02 15:04"),
+			Open:  10,
+			Close: 10 + float64(i)/100,
+			High:  11,
+			Low:   9,
+		})
+	}
+	return klines, nil
+}
+
+func TestStockService_GetKLineData_MinuteCache(t *testing.T) {
+	db := newTestDB(t)
+
+	mp := &minuteProvider{}
+	s := NewStockService()
+	s.SetDBService(db)
+	s.SetProvider(mp)
+
+	// 第一次拉取 10:00-10:45，第二次 10:25-11:10，缓存应合并为 15 根
+	if _, err := s.GetKLineData("600519", 100, "5min", ""); err != nil {
+		t.Fatalf("first fetch: %v", err)
+	}
+	mp.start = 5
+	klines, err := s.GetKLineData("600519", 100, "5min", "")
+	if err != nil {
+		t.Fatalf("second fetch: %v", err)
+	}
+	if len(klines) != 15 || klines[0].Time != "2024-01-02 10:00" || klines[14].Time != "2024-01-02 11:10" {
+		t.Fatalf("expected 15 merged bars, got %d", len(klines))
+	}
+	if klines[14].MACD == nil {
+		t.Fatalf("expected indicators on cached minute bars")
+	}
+
+	// 行情源故障时降级为缓存
+	mp.fail = true
+	klines, err = s.GetKLineData("600519", 5, "5min", "")
+	if err != nil || len(klines) != 5 {
+		t.Fatalf("expected 5 cached bars when provider fails, got %d, err=%v", len(klines), err)
+	}
+
+	// 切换复权方式只写入该方式的缓存，前复权缓存中行情源已不再返回的历史分钟线仍保留
+	mp.fail = false
+	mp.start = 10
+	if _, err := s.GetKLineData("600519", 100, "5min", KLineAdjustNone); err != nil {
+		t.Fatalf("unadjusted fetch: %v", err)
+	}
+	mp.fail = true
+	klines, err = s.GetKLineData("600519", 100, "5min", KLineAdjustForward)
+	if err != nil || len(klines) != 15 || klines[0].Time != "2024-01-02 10:00" {
+		t.Fatalf("expected 15 cached forward bars after switching adjust, got %d, err=%v", len(klines), err)
+	}
+	none, err := db.GetKLinePeriodDataFromCache("600519", KLinePeriod5Min, KLineAdjustNone, 100)
+	if err != nil || len(none) != 10 {
+		t.Fatalf("expected 10 unadjusted bars, got %d, err=%v", len(none), err)
+	}
+
+	// 分钟线缓存表不计入已同步股票
+	stocks, err := db.GetAllSyncedStocks()
+	if err != nil || len(stocks) != 0 {
+		t.Fatalf("expected no daily synced stocks, got %v, err=%v", stocks, err)
+	}
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"stock-analyzer-wails/models"
+)
+
+// fakeKLineServer 按请求的 beg/end/lmt 返回日K线，收盘价由 closes 按代码指定
+type fakeKLineServer struct {
+	mu        sync.Mutex
+	closes    map[string]float64
+	requests  map[string]string // secid → 最近一次请求的查询串
+	suspended map[string]string // 代码 → 停牌日期，该日不返回K线
+}
+
+func (f *fakeKLineServer) RoundTrip(r *http.Request) (*http.Response, error) {
+	q := r.URL.Query()
+	secid := q.Get("secid")
+	code := secid[strings.Index(secid, ".")+1:]
+
+	f.mu.Lock()
+	f.requests[secid] = r.URL.RawQuery
+	closePrice := f.closes[code]
+	suspended := f.suspended[code]
+	f.mu.Unlock()
+
+	cal := GetTradingCalendar()
+	end, _ := time.ParseInLocation("20060102", q.Get("end"), chinaLocation)
+	var start time.Time
+	if beg := q.Get("beg"); beg != "" {
+		start, _ = time.ParseInLocation("20060102", beg, chinaLocation)
+	} else {
+		var lmt int
+		fmt.Sscan(q.Get("lmt"), &lmt)
+		start = cal.AddTradingDays(end, -(lmt - 1))
+	}
+	var lines []string
+	for d := start; !d.After(end); d = cal.NextTradingDay(d) {
+		if cal.IsTradingDay(d) && d.Format("2006-01-02") != suspended {
+			lines = append(lines, fmt.Sprintf(`"%s,%.2f,%.2f,%.2f,%.2f,1000"`, d.Format("2006-01-02"), closePrice, closePrice, closePrice, closePrice))
+		}
+	}
+	body := `{"rc":0,"data":{"klines":[` + strings.Join(lines, ",") + `]}}`
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    r,
+	}, nil
+}
+
+// cacheBars 写入 [start, end] 内的前复权日K线缓存，skip 中的日期不写入
+func cacheBars(t *testing.T, db *DBService, code string, start, end time.Time, closePrice float64, skip ...string) {
+	t.Helper()
+	cacheAdjustedBars(t, db, code, KLineAdjustForward, start, end, closePrice, skip...)
+}
+
+// cacheAdjustedBars 同 cacheBars，按 adjust 指定的复权方式写入
+func cacheAdjustedBars(t *testing.T, db *DBService, code, adjust string, start, end time.Time, closePrice float64, skip ...string) {
+	t.Helper()
+	cal := GetTradingCalendar()
+	var klines []map[string]interface{}
+	for d := start; !d.After(end); d = cal.NextTradingDay(d) {
+		date := d.Format("2006-01-02")
+		if !cal.IsTradingDay(d) || strings.Contains(strings.Join(skip, ","), date) {
+			continue
+		}
+		klines = append(klines, map[string]interface{}{"date": date, "open": closePrice, "high": closePrice, "low": closePrice, "close": closePrice, "volume": int64(1000)})
+	}
+	if _, _, err := db.InsertOrUpdateKLinePeriodData(code, KLinePeriodDaily, adjust, klines); err != nil {
+		t.Fatalf("cache bars: %v", err)
+	}
+}
+
+func TestKLineSync_IncrementalAndFallbacks(t *testing.T) {
+	db := newTestDB(t)
+	mustCreate(t, db,
+		&models.StockEntity{Code: "600519", Name: "贵州茅台", Market: MarketSH, FullCode: "SH600519", Type: InstrumentTypeMain, IsActive: 1},
+		&models.StockEntity{Code: "000001", Name: "平安银行", Market: MarketSZ, FullCode: "SZ000001", Type: InstrumentTypeMain, IsActive: 1},
+		&models.StockEntity{Code: "000002", Name: "万科A", Market: MarketSZ, FullCode: "SZ000002", Type: InstrumentTypeMain, IsActive: 1},
+	)
+
+	now := cst("2024-06-14 20:00:00")
+	days := 10
+	windowStart, windowEnd := klineSyncRange(now, days)
+	cal := GetTradingCalendar()
+	lastCached := cal.AddTradingDays(windowEnd, -2)
+
+	// 600519：缓存完整且价格一致 → 增量
+	cacheBars(t, db, "600519", windowStart, lastCached, 1700)
+	// 000001：无缓存 → 全量
+	// 000002：前复权价格与接口不一致（新除权）→ 全量并替换，窗口外的旧数据一并清除
+	cacheBars(t, db, "000002", cal.AddTradingDays(windowStart, -5), lastCached, 9)
+
+	server := &fakeKLineServer{closes: map[string]float64{"600519": 1700, "000001": 11, "000002": 8.5}, requests: map[string]string{}}
+	svc := NewKLineSyncService(db)
+	svc.client.SetTransport(server)
+	svc.now = func() time.Time { return now }
+
+	result, err := svc.StartKLineSync(days, KLineAdjustForward)
+	if err != nil || result.SuccessCount != 3 || result.IncrementalCount != 1 || result.FullCount != 2 {
+		t.Fatalf("StartKLineSync = %+v, %v", result, err)
+	}
+
+	overlapStart := cal.AddTradingDays(lastCached, -(klineSyncOverlap - 1)).Format("20060102")
+	if q := server.requests["1.600519"]; !strings.Contains(q, "beg="+overlapStart) {
+		t.Fatalf("expected incremental request from %s, got %s", overlapStart, q)
+	}
+	if q := server.requests["0.000001"]; !strings.Contains(q, fmt.Sprintf("lmt=%d", days)) {
+		t.Fatalf("expected full request, got %s", q)
+	}
+
+	var history []models.SyncHistoryEntity
+	db.GetDB().Where("sync_type = ?", "kline").Order("stock_code").Find(&history)
+	modes := map[string]string{}
+	for _, h := range history {
+		modes[h.StockCode] = h.SyncMode
+		if h.StockCode == "600519" && h.StartDate != cal.AddTradingDays(lastCached, -(klineSyncOverlap-1)).Format("2006-01-02") {
+			t.Fatalf("unexpected incremental range: %+v", h)
+		}
+	}
+	if modes["600519"] != KLineSyncIncremental || modes["000001"] != KLineSyncFull || modes["000002"] != KLineSyncFull {
+		t.Fatalf("unexpected sync modes: %v", modes)
+	}
+
+	for code, want := range map[string]int{"600519": days, "000001": days, "000002": days} {
+		if n, _ := db.GetKLineCountByCode(code); n != want {
+			t.Fatalf("%s: expected %d cached bars, got %d", code, want, n)
+		}
+	}
+	if latest, _ := db.GetLatestKLineDate("600519"); latest != windowEnd.Format("2006-01-02") {
+		t.Fatalf("expected 600519 to be synced up to %s, got %s", windowEnd.Format("2006-01-02"), latest)
+	}
+	bars, _, _ := db.GetKLineDailyBarsBetween("000002", "", "", 1)
+	if len(bars) != 1 || bars[0].Close != 8.5 {
+		t.Fatalf("expected 000002 to be rebased, got %+v", bars)
+	}
+}
+
+func TestKLineSync_KeepingCachedAdjust(t *testing.T) {
+	db := newTestDB(t)
+	mustCreate(t, db,
+		&models.StockEntity{Code: "600519", Name: "贵州茅台", Market: MarketSH, FullCode: "SH600519", Type: InstrumentTypeMain, IsActive: 1},
+		&models.StockEntity{Code: "000001", Name: "平安银行", Market: MarketSZ, FullCode: "SZ000001", Type: InstrumentTypeMain, IsActive: 1},
+		&models.StockEntity{Code: "000002", Name: "万科A", Market: MarketSZ, FullCode: "SZ000002", Type: InstrumentTypeMain, IsActive: 1},
+	)
+
+	now := cst("2024-06-14 20:00:00")
+	days := 10
+	windowStart, windowEnd := klineSyncRange(now, days)
+	lastCached := GetTradingCalendar().AddTradingDays(windowEnd, -2)
+
+	// 600519 以不复权、000001 以后复权同步过；000002 没有缓存
+	cacheAdjustedBars(t, db, "600519", KLineAdjustNone, windowStart, lastCached, 1700)
+	cacheAdjustedBars(t, db, "000001", KLineAdjustBackward, windowStart, lastCached, 11)
+
+	server := &fakeKLineServer{closes: map[string]float64{"600519": 1700, "000001": 11, "000002": 8.5}, requests: map[string]string{}}
+	svc := NewKLineSyncService(db)
+	svc.client.SetTransport(server)
+	svc.now = func() time.Time { return now }
+
+	result, err := svc.StartKLineSyncKeepingAdjust(days, KLineAdjustForward)
+	if err != nil || result.SuccessCount != 3 || result.IncrementalCount != 2 || result.FullCount != 1 {
+		t.Fatalf("StartKLineSyncKeepingAdjust = %+v, %v", result, err)
+	}
+
+	for secid, fqt := range map[string]string{"1.600519": "fqt=0", "0.000001": "fqt=2", "0.000002": "fqt=1"} {
+		if q := server.requests[secid]; !strings.Contains(q, fqt) {
+			t.Fatalf("%s: expected %s, got %s", secid, fqt, q)
+		}
+	}
+	for code, want := range map[string]string{"600519": KLineAdjustNone, "000001": KLineAdjustBackward, "000002": KLineAdjustForward} {
+		if adjust, _ := db.GetKLineCacheAdjust(code, KLinePeriodDaily); adjust != want {
+			t.Fatalf("%s: expected cache adjust %s, got %s", code, want, adjust)
+		}
+		if n, _ := db.GetKLineCountByCode(code); n != days {
+			t.Fatalf("%s: expected %d cached bars, got %d", code, days, n)
+		}
+	}
+}
+
+func TestKLineSync_PlanFallsBackToFull(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewKLineSyncService(db)
+	windowStart, windowEnd := klineSyncRange(cst("2024-06-14 20:00:00"), 10)
+	cal := GetTradingCalendar()
+	gap := cal.AddTradingDays(windowStart, 3).Format("2006-01-02")
+
+	// 缺口（多为停牌）不直接全量，从缺口前的缓存K线开始增量拉取，由重叠区间校验决定
+	cacheBars(t, db, "600519", windowStart, windowEnd, 1700, gap)
+	gapPlan := svc.planKLineSync("600519", KLineAdjustForward, windowStart, windowEnd, false)
+	if gapPlan.mode != KLineSyncIncremental || !gapPlan.start.Equal(cal.AddTradingDays(windowStart, 2)) || len(gapPlan.overlap) != 7 {
+		t.Fatalf("expected an incremental plan from before the gap, got %+v", gapPlan)
+	}
+
+	cacheBars(t, db, "000001", windowStart, windowEnd, 11)
+	if plan := svc.planKLineSync("000001", KLineAdjustNone, windowStart, windowEnd, false); plan.mode != KLineSyncFull || plan.reason != "复权方式变更" {
+		t.Fatalf("expected an adjust change to force a full sync, got %+v", plan)
+	}
+	plan := svc.planKLineSync("000001", KLineAdjustForward, windowStart, windowEnd, false)
+	if plan.mode != KLineSyncIncremental || len(plan.overlap) != klineSyncOverlap {
+		t.Fatalf("expected an incremental plan, got %+v", plan)
+	}
+	if plan := svc.planKLineSync("000001", KLineAdjustForward, windowStart, windowEnd, true); plan.mode != KLineSyncFull {
+		t.Fatalf("expected forced full sync, got %+v", plan)
+	}
+
+	// 不复权数据的价格差异只是修订，不需要替换缓存
+	fetched := []map[string]interface{}{{"date": plan.overlap[0].Date, "close": 12.0}}
+	if reason, rebase := klineOverlapMismatch(plan.overlap[:1], fetched, KLineAdjustNone); reason != "" || rebase {
+		t.Fatalf("unexpected mismatch for unadjusted revision: %q %v", reason, rebase)
+	}
+	if _, rebase := klineOverlapMismatch(plan.overlap[:1], fetched, KLineAdjustForward); !rebase {
+		t.Fatalf("expected a forward-adjusted price change to rebase the cache")
+	}
+	fetched[0]["close"] = 11.0
+	if reason, rebase := klineOverlapMismatch(plan.overlap, fetched, KLineAdjustForward); reason == "" || rebase {
+		t.Fatalf("expected missing overlap dates to be reported, got %q %v", reason, rebase)
+	}
+}
+
+func TestKLineSync_SuspendedAndLateListedStocks(t *testing.T) {
+	db := newTestDB(t)
+	mustCreate(t, db,
+		&models.StockEntity{Code: "600519", Name: "贵州茅台", Market: MarketSH, FullCode: "SH600519", Type: InstrumentTypeMain, IsActive: 1},
+		&models.StockEntity{Code: "000001", Name: "平安银行", Market: MarketSZ, FullCode: "SZ000001", Type: InstrumentTypeMain, IsActive: 1},
+		&models.StockEntity{Code: "000002", Name: "万科A", Market: MarketSZ, FullCode: "SZ000002", Type: InstrumentTypeMain, IsActive: 1},
+	)
+
+	now := cst("2024-06-14 20:00:00")
+	days := 10
+	windowStart, windowEnd := klineSyncRange(now, days)
+	cal := GetTradingCalendar()
+	lastCached := cal.AddTradingDays(windowEnd, -2)
+	suspended := cal.AddTradingDays(windowStart, 3).Format("2006-01-02")
+	listed := cal.AddTradingDays(windowStart, 4)
+
+	// 600519：窗口内停牌一天
+	cacheBars(t, db, "600519", windowStart, lastCached, 1700, suspended)
+	// 000001：窗口起点之后上市，此前曾从更早的日期拉取过
+	cacheBars(t, db, "000001", listed, lastCached, 11)
+	mustCreate(t, db, &models.SyncHistoryEntity{StockCode: "000001", SyncType: "kline", SyncMode: KLineSyncFull,
+		StartDate: cal.AddTradingDays(windowStart, -3).Format("2006-01-02"), EndDate: lastCached.Format("2006-01-02"), Status: "success"})
+	// 000002：缓存来自较短的同步窗口，没有从窗口起点拉取过
+	cacheBars(t, db, "000002", listed, lastCached, 8.5)
+
+	server := &fakeKLineServer{
+		closes:    map[string]float64{"600519": 1700, "000001": 11, "000002": 8.5},
+		requests:  map[string]string{},
+		suspended: map[string]string{"600519": suspended},
+	}
+	svc := NewKLineSyncService(db)
+	svc.client.SetTransport(server)
+	svc.now = func() time.Time { return now }
+
+	result, err := svc.StartKLineSync(days, KLineAdjustForward)
+	if err != nil || result.SuccessCount != 3 || result.IncrementalCount != 2 || result.FullCount != 1 {
+		t.Fatalf("StartKLineSync = %+v, %v", result, err)
+	}
+	if q := server.requests["1.600519"]; !strings.Contains(q, "beg="+cal.AddTradingDays(windowStart, 2).Format("20060102")) {
+		t.Fatalf("expected 600519 to be fetched from before the suspension, got %s", q)
+	}
+	if q := server.requests["0.000001"]; !strings.Contains(q, "beg="+cal.AddTradingDays(lastCached, -(klineSyncOverlap-1)).Format("20060102")) {
+		t.Fatalf("expected 000001 to be fetched incrementally, got %s", q)
+	}
+	if q := server.requests["0.000002"]; !strings.Contains(q, fmt.Sprintf("lmt=%d", days)) {
+		t.Fatalf("expected 000002 to be fetched in full, got %s", q)
+	}
+
+	// 停牌和晚上市的股票记为增量同步
+	var history []models.SyncHistoryEntity
+	db.GetDB().Where("sync_type = ?", "kline").Order("id").Find(&history)
+	modes := map[string]string{}
+	for _, h := range history {
+		modes[h.StockCode] = h.SyncMode
+	}
+	if modes["600519"] != KLineSyncIncremental || modes["000001"] != KLineSyncIncremental || modes["000002"] != KLineSyncFull {
+		t.Fatalf("unexpected sync modes: %v", modes)
+	}
+	for code, want := range map[string]int{"600519": days - 1, "000001": days - 4, "000002": days} {
+		if n, _ := db.GetKLineCountByCode(code); n != want {
+			t.Fatalf("%s: expected %d cached bars, got %d", code, want, n)
+		}
+	}
+}
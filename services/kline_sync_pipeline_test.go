@@ -0,0 +1,126 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"stock-analyzer-wails/models"
+)
+
+func TestDBService_KLineWriteCounts(t *testing.T) {
+	db := newTestDB(t)
+	bar := func(date string, close float64) map[string]interface{} {
+		return map[string]interface{}{"date": date, "open": close, "high": close, "low": close, "close": close, "volume": int64(100)}
+	}
+
+	added, updated, err := db.InsertOrUpdateKLinePeriodData("600519", KLinePeriodDaily, KLineAdjustForward, []map[string]interface{}{
+		bar("2024-06-11", 10), bar("2024-06-12", 11), bar("2024-06-13", 12),
+	})
+	if err != nil || added != 3 || updated != 0 {
+		t.Fatalf("first insert = %d, %d, %v", added, updated, err)
+	}
+
+	// 相同的K线不计数；修订的计为更新；重复日期以最后一条为准
+	added, updated, err = db.InsertOrUpdateKLinePeriodData("600519", KLinePeriodDaily, KLineAdjustForward, []map[string]interface{}{
+		bar("2024-06-12", 11), bar("2024-06-13", 12.5), bar("2024-06-14", 13), bar("2024-06-14", 13.5),
+	})
+	if err != nil || added != 1 || updated != 1 {
+		t.Fatalf("second insert = %d, %d, %v", added, updated, err)
+	}
+	cached, _ := db.GetKLinePeriodDataFromCache("600519", KLinePeriodDaily, KLineAdjustForward, 10)
+	if len(cached) != 4 || cached[2]["close"] != 12.5 || cached[3]["close"] != 13.5 {
+		t.Fatalf("unexpected cache %v", cached)
+	}
+
+	// 复权方式变更时整段替换，原有日期计为更新
+	added, updated, err = db.InsertOrUpdateKLinePeriodData("600519", KLinePeriodDaily, KLineAdjustNone, []map[string]interface{}{
+		bar("2024-06-14", 20), bar("2024-06-17", 21),
+	})
+	if err != nil || added != 1 || updated != 1 {
+		t.Fatalf("adjust change = %d, %d, %v", added, updated, err)
+	}
+	if n, _ := db.GetKLineCountByCode("600519"); n != 2 {
+		t.Fatalf("expected the old bars to be replaced, got %d", n)
+	}
+}
+
+func TestKLineSync_ConcurrentFetchSingleWriter(t *testing.T) {
+	db := newTestDB(t)
+	closes := map[string]float64{}
+	for i := 0; i < 25; i++ {
+		code := fmt.Sprintf("6000%02d", i)
+		closes[code] = 10 + float64(i)
+		mustCreate(t, db, &models.StockEntity{Code: code, Name: "股票" + code, Market: MarketSH, FullCode: "SH" + code, Type: InstrumentTypeMain, IsActive: 1})
+	}
+
+	server := &fakeKLineServer{closes: closes, requests: map[string]string{}}
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	svc := NewKLineSyncService(db)
+	svc.SetConcurrency(4)
+	svc.limiter = newRateLimiter(1000, 4)
+	svc.now = func() time.Time { return cst("2024-06-14 20:00:00") }
+	svc.client.SetTransport(roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return server.RoundTrip(r)
+	}))
+
+	days := 5
+	result, err := svc.StartKLineSync(days, KLineAdjustForward)
+	if err != nil || !result.Success || result.SuccessCount != 25 || result.FullCount != 25 || result.TotalRecords != 25*days {
+		t.Fatalf("StartKLineSync = %+v, %v", result, err)
+	}
+	if maxInFlight < 2 || maxInFlight > 4 {
+		t.Fatalf("expected 2-4 concurrent requests, got %d", maxInFlight)
+	}
+	for code := range closes {
+		if n, _ := db.GetKLineCountByCode(code); n != days {
+			t.Fatalf("%s: expected %d cached bars, got %d", code, days, n)
+		}
+	}
+	job, err := svc.jobs.Get(result.JobID)
+	if err != nil || job.Status != SyncJobCompleted || job.Succeeded != 25 {
+		t.Fatalf("persisted job = %+v, %v", job, err)
+	}
+
+	// 再次同步：只拉取重叠区间，数据没有变化，不计新增和更新
+	result, err = svc.StartKLineSync(days, KLineAdjustForward)
+	if err != nil || result.IncrementalCount != 25 || result.TotalRecords != 0 {
+		t.Fatalf("second StartKLineSync = %+v, %v", result, err)
+	}
+	var history []models.SyncHistoryEntity
+	db.GetDB().Where("sync_type = ? AND sync_mode = ?", "kline", KLineSyncFull).Find(&history)
+	if len(history) != 25 || history[0].RecordsAdded != days || history[0].RecordsUpdated != 0 {
+		t.Fatalf("unexpected full sync history %+v", history)
+	}
+}
+
+func TestRateLimiter_SharedTokenBucket(t *testing.T) {
+	now := cst("2024-06-14 09:30:00")
+	l := newRateLimiter(2, 2)
+	l.now = func() time.Time { return now }
+
+	for i, want := range []time.Duration{0, 0, 500 * time.Millisecond, time.Second} {
+		if got := l.reserve(); got != want {
+			t.Fatalf("reservation %d: expected %v, got %v", i, want, got)
+		}
+	}
+	// 归还未使用的预占后，等待时间相应缩短
+	l.cancelReservation()
+	now = now.Add(time.Second)
+	if got := l.reserve(); got != 0 {
+		t.Fatalf("expected no wait after refilling, got %v", got)
+	}
+}
@@ -3,7 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
-	"math/rand"
+	"math"
 	"strconv"
 	"strings"
 	"sync"
@@ -25,8 +25,26 @@ type KLineSyncService struct {
 	ctx       context.Context
 	running   bool
 	mu        sync.Mutex
+	jobs      *SyncJobStore
+	manager   *JobManager
+	now       func() time.Time
+
+	concurrency int          // 抓取并发数
+	limiter     *rateLimiter // 所有抓取协程共用的限速器
 }
 
+const (
+	// DefaultKLineSyncConcurrency 默认的K线抓取并发数
+	DefaultKLineSyncConcurrency = 3
+	// MaxKLineSyncConcurrency K线抓取并发数上限（并发过高容易触发行情源的反爬虫限制）
+	MaxKLineSyncConcurrency = 8
+
+	// klineSyncRequestRate 所有抓取协程合计每秒最多发起的股票请求数
+	klineSyncRequestRate = 5
+	// klineWriteBatchSize 写入协程每累积多少只股票的数据提交一次事务
+	klineWriteBatchSize = 10
+)
+
 // NewKLineSyncService 创建K线同步服务
 func NewKLineSyncService(dbService *DBService) *KLineSyncService {
 	client := resty.New()
@@ -36,10 +54,19 @@ func NewKLineSyncService(dbService *DBService) *KLineSyncService {
 	client.SetRetryCount(3)
 	client.SetCloseConnection(true) // 短连接
 
+	applyHTTPMode(client) // 录制/回放（离线运行）
+	useGateway(client, PriorityBackground)
+
 	return &KLineSyncService{
 		dbService: dbService,
 		client:    client,
 		running:   false,
+		jobs:      NewSyncJobStore(dbService),
+		manager:   NewJobManager(),
+		now:       time.Now,
+
+		concurrency: DefaultKLineSyncConcurrency,
+		limiter:     newRateLimiter(klineSyncRequestRate, 1),
 	}
 }
 
@@ -48,8 +75,34 @@ func (s *KLineSyncService) SetContext(ctx context.Context) {
 	s.ctx = ctx
 }
 
+// SetJobManager 设置任务管理器（与其他长任务共用，以便统一暂停、取消）
+func (s *KLineSyncService) SetJobManager(m *JobManager) {
+	s.manager = m
+}
+
+// SetConcurrency 设置抓取并发数（对之后开始的同步生效），超出 1~MaxKLineSyncConcurrency 时取边界值
+func (s *KLineSyncService) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	if n > MaxKLineSyncConcurrency {
+		n = MaxKLineSyncConcurrency
+	}
+	s.mu.Lock()
+	s.concurrency = n
+	s.mu.Unlock()
+}
+
+// Concurrency 返回当前的抓取并发数
+func (s *KLineSyncService) Concurrency() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.concurrency
+}
+
 // KLineSyncProgress K线同步进度
 type KLineSyncProgress struct {
+	JobID            string  `json:"jobId"`            // 同步任务ID
 	IsRunning        bool    `json:"isRunning"`        // 是否正在运行
 	CurrentIndex     int     `json:"currentIndex"`     // 当前处理索引
 	TotalCount       int     `json:"totalCount"`       // 总数
@@ -66,13 +119,47 @@ type KLineSyncProgress struct {
 
 // KLineSyncResult K线同步结果
 type KLineSyncResult struct {
-	Success      bool   `json:"success"`
-	TotalCount   int    `json:"totalCount"`
-	SuccessCount int    `json:"successCount"`
-	FailedCount  int    `json:"failedCount"`
-	TotalRecords int    `json:"totalRecords"`
-	Duration     int    `json:"duration"` // 耗时（秒）
-	Message      string `json:"message"`
+	JobID            string `json:"jobId"`     // 同步任务ID（可用于续传）
+	Cancelled        bool   `json:"cancelled"` // 是否被取消（结果为已完成部分）
+	Success          bool   `json:"success"`
+	TotalCount       int    `json:"totalCount"`
+	SuccessCount     int    `json:"successCount"`
+	FailedCount      int    `json:"failedCount"`
+	IncrementalCount int    `json:"incrementalCount"` // 增量同步成功的股票数
+	FullCount        int    `json:"fullCount"`        // 全量同步成功的股票数
+	TotalRecords     int    `json:"totalRecords"`
+	Duration         int    `json:"duration"` // 耗时（秒）
+	Message          string `json:"message"`
+}
+
+// K线同步方式（记录在 sync_history.sync_mode）
+const (
+	KLineSyncIncremental = "incremental" // 只拉取缓存最新日期之后的数据
+	KLineSyncFull        = "full"        // 重新拉取整个同步窗口
+)
+
+const (
+	// klineSyncOverlap 增量同步时与缓存重叠的交易日数，用于发现数据修订和复权基准变化
+	klineSyncOverlap = 3
+	// klineOverlapTolerance 重叠K线收盘价允许的误差（接口价格保留两位小数）
+	klineOverlapTolerance = 0.005
+)
+
+// klineSyncPlan 单只股票的同步计划
+type klineSyncPlan struct {
+	mode        string
+	start       time.Time // 拉取起始日期
+	end         time.Time // 拉取截止日期
+	windowStart time.Time // 同步窗口起始日期（全量同步的起点）
+	reason      string    // 全量同步的原因
+	replace     bool      // 写入前清空缓存（复权基准已变，旧数据整体失效）
+
+	overlap []models.KLineBarEntity // 增量同步时与拉取范围重叠的缓存K线
+}
+
+// full 返回同一窗口的全量同步计划
+func (p klineSyncPlan) full(reason string) klineSyncPlan {
+	return klineSyncPlan{mode: KLineSyncFull, start: p.windowStart, end: p.end, windowStart: p.windowStart, reason: reason}
 }
 
 // KLineSyncTask K线同步任务
@@ -80,26 +167,58 @@ type KLineSyncTask struct {
 	Code   string
 	Name   string
 	Market string
+	Type   string
 }
 
-// StartKLineSync 开始K线数据同步
-func (s *KLineSyncService) StartKLineSync(days int) (*KLineSyncResult, error) {
-	s.mu.Lock()
-	if s.running {
-		s.mu.Unlock()
+// instrument 返回任务对应的证券标识（stocks 表缺少交易所时按代码推断）
+func (t *KLineSyncTask) instrument() Instrument {
+	if t.Market == "" {
+		return InferInstrument(t.Code)
+	}
+	typ := t.Type
+	if typ == "" {
+		typ = inferInstrumentType(t.Market, t.Code)
+	}
+	return Instrument{Code: t.Code, Market: t.Market, Type: typ}
+}
+
+// StartKLineSync 开始K线数据同步（增量）
+// 已有完整缓存的股票只拉取最新缓存日期之后的数据，其余股票全量拉取最近 days 个交易日。
+// adjust 为复权方式（none/forward/backward，空值为前复权）
+func (s *KLineSyncService) StartKLineSync(days int, adjust string) (*KLineSyncResult, error) {
+	return s.startKLineSync(klineSyncParams{Days: days, Adjust: adjust})
+}
+
+// StartKLineSyncKeepingAdjust 增量同步，每只股票沿用其日线缓存的复权方式，没有缓存的股票使用 adjust。
+// 用于定时同步：按统一的复权方式同步会把用户以其他复权方式同步的缓存整体重新拉取并替换。
+func (s *KLineSyncService) StartKLineSyncKeepingAdjust(days int, adjust string) (*KLineSyncResult, error) {
+	return s.startKLineSync(klineSyncParams{Days: days, Adjust: adjust, KeepAdjust: true})
+}
+
+// StartFullKLineSync 忽略本地缓存，全量重新同步所有活跃股票最近 days 个交易日的K线
+func (s *KLineSyncService) StartFullKLineSync(days int, adjust string) (*KLineSyncResult, error) {
+	return s.startKLineSync(klineSyncParams{Days: days, Adjust: adjust, ForceFull: true})
+}
+
+// startKLineSync 创建并执行K线同步任务
+func (s *KLineSyncService) startKLineSync(params klineSyncParams) (*KLineSyncResult, error) {
+	days := params.Days
+	adjust, err := NormalizeKLineAdjust(params.Adjust)
+	if err != nil {
+		return &KLineSyncResult{
+			Success: false,
+			Message: err.Error(),
+		}, err
+	}
+	params.Adjust = adjust
+
+	if !s.beginSync() {
 		return &KLineSyncResult{
 			Success: false,
 			Message: "同步任务已在运行中",
 		}, fmt.Errorf("同步任务已在运行中")
 	}
-	s.running = true
-	s.mu.Unlock()
-
-	defer func() {
-		s.mu.Lock()
-		s.running = false
-		s.mu.Unlock()
-	}()
+	defer s.endSync()
 
 	// 验证日期范围
 	if days <= 0 || days > 2000 {
@@ -109,8 +228,6 @@ func (s *KLineSyncService) StartKLineSync(days int) (*KLineSyncResult, error) {
 		}, fmt.Errorf("日期范围无效")
 	}
 
-	startTime := time.Now()
-
 	// 1. 获取所有活跃股票
 	tasks, err := s.getActiveStocks()
 	if err != nil {
@@ -128,93 +245,291 @@ func (s *KLineSyncService) StartKLineSync(days int) (*KLineSyncResult, error) {
 		}, fmt.Errorf("没有需要同步的股票")
 	}
 
+	// 持久化任务和每只股票的检查点，程序中途退出后可以续传
+	job, err := s.jobs.Create(SyncJobKLine, params, klineSyncItems(tasks))
+	if err != nil {
+		return &KLineSyncResult{
+			Success: false,
+			Message: err.Error(),
+		}, err
+	}
+	return s.runKLineJob(job, params, tasks)
+}
+
+// ResumeKLineSync 续传中断的K线同步任务，只处理尚未成功（未处理或失败）的股票，沿用原任务的参数
+func (s *KLineSyncService) ResumeKLineSync(jobID string) (*KLineSyncResult, error) {
+	if !s.beginSync() {
+		return &KLineSyncResult{
+			Success: false,
+			Message: "同步任务已在运行中",
+		}, fmt.Errorf("同步任务已在运行中")
+	}
+	defer s.endSync()
+
+	job, items, err := s.jobs.Reopen(jobID, SyncJobKLine)
+	if err != nil {
+		return &KLineSyncResult{
+			Success: false,
+			Message: err.Error(),
+		}, err
+	}
+	var params klineSyncParams
+	if err := decodeSyncJobParams(job, &params); err != nil {
+		if finishErr := s.jobs.Finish(job.ID, SyncJobFailed, err.Error()); finishErr != nil {
+			logger.Error("更新同步任务状态失败", zap.String("job_id", job.ID), zap.Error(finishErr))
+		}
+		return &KLineSyncResult{
+			Success: false,
+			Message: err.Error(),
+		}, err
+	}
+
+	tasks := make([]*KLineSyncTask, len(items))
+	for i, item := range items {
+		tasks[i] = &KLineSyncTask{Code: item.Code, Name: item.Name, Market: item.Market, Type: item.Type}
+	}
+	return s.runKLineJob(job, params, tasks)
+}
+
+// klineSyncParams K线同步任务参数（保存在 sync_jobs.params，续传时沿用）
+type klineSyncParams struct {
+	Days       int    `json:"days"`
+	Adjust     string `json:"adjust"`
+	ForceFull  bool   `json:"forceFull"`
+	KeepAdjust bool   `json:"keepAdjust"` // 已有缓存的股票沿用缓存的复权方式，Adjust 只用于没有缓存的股票
+}
+
+// taskAdjust 返回单只股票同步使用的复权方式
+func (s *KLineSyncService) taskAdjust(code string, params klineSyncParams) string {
+	if !params.KeepAdjust {
+		return params.Adjust
+	}
+	cached, err := s.dbService.GetKLineCacheAdjust(code, KLinePeriodDaily)
+	if err != nil || cached == "" {
+		return params.Adjust
+	}
+	return cached
+}
+
+// klineSyncItems 将同步任务转换为检查点
+func klineSyncItems(tasks []*KLineSyncTask) []models.SyncJobItemEntity {
+	items := make([]models.SyncJobItemEntity, len(tasks))
+	for i, task := range tasks {
+		items[i] = models.SyncJobItemEntity{
+			ItemKey: task.instrument().Key(),
+			Code:    task.Code,
+			Name:    task.Name,
+			Market:  task.Market,
+			Type:    task.Type,
+		}
+	}
+	return items
+}
+
+// beginSync 标记同步开始，已有同步在运行时返回 false
+func (s *KLineSyncService) beginSync() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return false
+	}
+	s.running = true
+	return true
+}
+
+// endSync 标记同步结束
+func (s *KLineSyncService) endSync() {
+	s.mu.Lock()
+	s.running = false
+	s.mu.Unlock()
+}
+
+// klineFetchResult 抓取协程交给写入协程的单只股票数据
+type klineFetchResult struct {
+	task   *KLineSyncTask
+	code   string
+	adjust string
+	plan   klineSyncPlan
+	klines []map[string]interface{}
+	err    error
+}
+
+// runKLineJob 并发抓取任务中的股票，由单一写入协程按批提交事务，每批提交后记录检查点。
+// 抓取并发数由 SetConcurrency 配置，所有抓取协程共用同一个限速器；写入跟不上时抓取协程阻塞等待。
+// 任务在任务管理器中以同步任务ID登记，可暂停、取消；取消后同步任务标记为中断，可以续传。
+func (s *KLineSyncService) runKLineJob(job *models.SyncJobEntity, params klineSyncParams, tasks []*KLineSyncTask) (*KLineSyncResult, error) {
+	days, adjust, forceFull := params.Days, params.Adjust, params.ForceFull
+	startTime := time.Now()
+	ctl := s.manager.Begin(JobKLineSync, job.ID)
+	concurrency := s.Concurrency()
+
 	logger.Info("开始K线数据同步",
+		zap.String("job_id", job.ID),
 		zap.Int("stock_count", len(tasks)),
 		zap.Int("days", days),
+		zap.String("adjust", adjust),
+		zap.Bool("keep_adjust", params.KeepAdjust),
+		zap.Bool("force_full", forceFull),
+		zap.Int("concurrency", concurrency),
 	)
 
-	// 2. 顺序同步每只股票，避免SQLite并发锁库问题
-	// 统计结果
+	// 统计结果（只在写入协程中修改）
 	var successCount, failedCount, totalRecords int
+	var incrementalCount, fullCount int
+	windowStart, windowEnd := klineSyncRange(s.now(), days)
 
 	// 初始化进度
 	progress := &KLineSyncProgress{
+		JobID:      job.ID,
 		IsRunning:  true,
 		TotalCount: len(tasks),
 		StartTime:  startTime.Format("2006-01-02 15:04:05"),
 	}
 
-	// 顺序处理每只股票
-	for i, task := range tasks {
-		// 随机延迟模拟真人行为（200-500ms）
-		// 延迟可以防止被反爬虫机制识别
-		delay := time.Duration(rand.Intn(300)+200) * time.Millisecond
-		time.Sleep(delay)
-
-		// 获取K线数据
-		klines, err := s.fetchKLineData(task, days)
+	// record 记录单只股票的结果：同步历史、检查点和进度
+	record := func(r klineFetchResult, count KLineWriteCount, err error) {
 		if err != nil {
 			failedCount++
-			logger.Error("获取K线数据失败",
-				zap.String("code", task.Code),
-				zap.Error(err),
+			total := len(r.klines)
+			if r.err != nil {
+				total = 0
+				logger.Error("获取K线数据失败", zap.String("code", r.task.Code), zap.Error(err))
+			} else {
+				logger.Error("保存K线数据失败", zap.String("code", r.task.Code), zap.Error(err))
+			}
+			if recordErr := s.recordSyncHistory(r.task, r.plan, total, 0, 0, false, err.Error()); recordErr != nil {
+				logger.Error("记录同步历史失败", zap.String("code", r.task.Code), zap.Error(recordErr))
+			}
+			s.checkpoint(job.ID, r.code, false, err.Error())
+		} else {
+			successCount++
+			totalRecords += int(count.Added + count.Updated)
+			if r.plan.mode == KLineSyncIncremental {
+				incrementalCount++
+			} else {
+				fullCount++
+			}
+			if recordErr := s.recordSyncHistory(r.task, r.plan, len(r.klines), int(count.Added), int(count.Updated), true, ""); recordErr != nil {
+				logger.Error("记录同步历史失败", zap.String("code", r.task.Code), zap.Error(recordErr))
+			}
+			s.checkpoint(job.ID, r.code, true, "")
+
+			logger.Debug("K线数据同步成功",
+				zap.String("code", r.task.Code),
+				zap.String("mode", r.plan.mode),
+				zap.String("reason", r.plan.reason),
+				zap.Int("records", len(r.klines)),
+				zap.Int64("added", count.Added),
+				zap.Int64("updated", count.Updated),
 			)
+		}
+		processed := successCount + failedCount
+		ctl.SetProgress(processed, len(tasks))
+		s.updateProgress(progress, processed, len(tasks), r.task.Code, r.task.Name, successCount, failedCount, totalRecords, startTime)
+	}
 
-			// 更新进度
-			s.updateProgress(progress, i+1, len(tasks), task.Code, task.Name, successCount, failedCount, totalRecords, startTime)
-
-			// 记录同步历史
-			if recordErr := s.recordSyncHistory(task, days, 0, 0, 0, false, err.Error()); recordErr != nil {
-				logger.Error("记录同步历史失败",
-					zap.String("code", task.Code),
-					zap.Error(recordErr),
-				)
+	// 数据通道（抓取 -> 写入），缓冲满时抓取协程阻塞，避免内存中堆积过多未写入的数据
+	dataChan := make(chan klineFetchResult, concurrency*2)
+
+	// 单一写入协程：每累积 klineWriteBatchSize 只股票提交一次事务，检查点在提交后记录，
+	// 中途退出时未提交的股票会在续传时重新抓取
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		var batch []klineFetchResult
+
+		flush := func() {
+			writes := make([]KLineWrite, len(batch))
+			for i, r := range batch {
+				// 没有拉到数据时不替换，避免清空缓存
+				writes[i] = KLineWrite{Code: r.code, Adjust: r.adjust, Replace: r.plan.replace && len(r.klines) > 0, KLines: r.klines}
 			}
-			continue
+			counts := make([]KLineWriteCount, len(batch))
+			errs := make([]error, len(batch))
+			if saved, err := s.dbService.SaveKLineBatch(KLinePeriodDaily, writes); err == nil {
+				copy(counts, saved)
+			} else {
+				// 整批回滚后逐只重写，避免一只股票的问题连累同批的其他股票
+				logger.Warn("批量保存K线失败，改为逐只保存", zap.String("job_id", job.ID), zap.Error(err))
+				for i := range writes {
+					saved, err := s.dbService.SaveKLineBatch(KLinePeriodDaily, writes[i:i+1])
+					if err != nil {
+						errs[i] = err
+						continue
+					}
+					counts[i] = saved[0]
+				}
+			}
+			for i, r := range batch {
+				record(r, counts[i], errs[i])
+			}
+			batch = nil
 		}
 
-		// 存储K线数据
-		added, updated, err := s.saveKLineData(task.Code, klines)
-		if err != nil {
-			failedCount++
-			logger.Error("保存K线数据失败",
-				zap.String("code", task.Code),
-				zap.Error(err),
-			)
-
-			// 更新进度
-			s.updateProgress(progress, i+1, len(tasks), task.Code, task.Name, successCount, failedCount, totalRecords, startTime)
-
-			// 记录同步历史
-			if recordErr := s.recordSyncHistory(task, days, len(klines), 0, 0, false, err.Error()); recordErr != nil {
-				logger.Error("记录同步历史失败",
-					zap.String("code", task.Code),
-					zap.Error(recordErr),
-				)
+		for r := range dataChan {
+			if r.err != nil {
+				record(r, KLineWriteCount{}, r.err)
+				continue
+			}
+			batch = append(batch, r)
+			if len(batch) >= klineWriteBatchSize {
+				flush()
 			}
-			continue
 		}
 
-		successCount++
-		totalRecords += int(added + updated)
+		// 处理剩余数据
+		if len(batch) > 0 {
+			flush()
+		}
+	}()
 
-		// 记录同步历史
-		if err := s.recordSyncHistory(task, days, len(klines), int(added), int(updated), true, ""); err != nil {
-			logger.Error("记录同步历史失败",
-				zap.String("code", task.Code),
-				zap.Error(err),
-			)
+	// 抓取协程池：sem 限制并发数，limiter 限制整体请求速率
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	cancelled := false
+	for _, task := range tasks {
+		// 暂停时在这里等待，取消时停止派发剩余股票
+		if ctl.Wait() != nil {
+			cancelled = true
+			break
 		}
 
-		logger.Debug("K线数据同步成功",
-			zap.String("code", task.Code),
-			zap.Int("records", len(klines)),
-			zap.Int("added", int(added)),
-			zap.Int("updated", int(updated)),
-		)
+		wg.Add(1)
+		sem <- struct{}{} // 获取信号量
+
+		go func(task *KLineSyncTask) {
+			defer wg.Done()
+			defer func() { <-sem }() // 释放信号量
+
+			// 等待期间任务被取消时不再抓取，该股票留给续传处理
+			if s.limiter.Wait(ctl.Context()) != nil {
+				return
+			}
 
-		// 更新进度
-		s.updateProgress(progress, i+1, len(tasks), task.Code, task.Name, successCount, failedCount, totalRecords, startTime)
+			// 指数以带交易所前缀的代码写入 kline_bars，避免与同号股票（如 000001）共用缓存
+			code := task.instrument().Key()
+			adjust := s.taskAdjust(code, params)
+			plan := s.planKLineSync(code, adjust, windowStart, windowEnd, forceFull)
+			klines, plan, err := s.fetchPlannedKLines(task, plan, days, adjust)
+			dataChan <- klineFetchResult{task: task, code: code, adjust: adjust, plan: plan, klines: klines, err: err}
+		}(task)
+	}
+
+	// 等待抓取完成后关闭数据通道，再等待写入协程提交剩余数据
+	wg.Wait()
+	close(dataChan)
+	<-writerDone
+	// 派发完后才取消时，等待限速的股票也不会被处理
+	if ctl.Context().Err() != nil && successCount+failedCount < len(tasks) {
+		cancelled = true
+	}
+
+	syncStatus, jobStatus := SyncJobCompleted, JobCompleted
+	if cancelled {
+		syncStatus, jobStatus = SyncJobInterrupted, JobCancelled
+	}
+	if err := s.jobs.Finish(job.ID, syncStatus, ""); err != nil {
+		logger.Error("更新同步任务状态失败", zap.String("job_id", job.ID), zap.Error(err))
 	}
 
 	// 发送最终进度
@@ -223,33 +538,59 @@ func (s *KLineSyncService) StartKLineSync(days int) (*KLineSyncResult, error) {
 
 	duration := int(time.Since(startTime).Seconds())
 
-	logger.Info("K线数据同步完成",
+	logger.Info("K线数据同步结束",
+		zap.String("job_id", job.ID),
+		zap.Bool("cancelled", cancelled),
 		zap.Int("total_count", len(tasks)),
 		zap.Int("success_count", successCount),
 		zap.Int("failed_count", failedCount),
+		zap.Int("incremental_count", incrementalCount),
+		zap.Int("full_count", fullCount),
 		zap.Int("total_records", totalRecords),
 		zap.Int("duration", duration),
 	)
 
-	return &KLineSyncResult{
-		Success:      true,
-		TotalCount:   len(tasks),
-		SuccessCount: successCount,
-		FailedCount:  failedCount,
-		TotalRecords: totalRecords,
-		Duration:     duration,
-		Message:      fmt.Sprintf("同步完成：成功 %d 只，失败 %d 只，总记录数 %d 条", successCount, failedCount, totalRecords),
-	}, nil
+	result := &KLineSyncResult{
+		JobID:            job.ID,
+		Success:          !cancelled,
+		Cancelled:        cancelled,
+		TotalCount:       len(tasks),
+		SuccessCount:     successCount,
+		FailedCount:      failedCount,
+		IncrementalCount: incrementalCount,
+		FullCount:        fullCount,
+		TotalRecords:     totalRecords,
+		Duration:         duration,
+		Message: fmt.Sprintf("同步完成：成功 %d 只（增量 %d 只，全量 %d 只），失败 %d 只，总记录数 %d 条",
+			successCount, incrementalCount, fullCount, failedCount, totalRecords),
+	}
+	if cancelled {
+		result.Message = fmt.Sprintf("同步已取消：已处理 %d/%d 只，成功 %d 只，失败 %d 只，可续传剩余股票",
+			successCount+failedCount, len(tasks), successCount, failedCount)
+	}
+	ctl.SetProgress(successCount+failedCount, len(tasks))
+	s.manager.Finish(ctl, jobStatus, result, "")
+	return result, nil
 }
 
-// getActiveStocks 获取所有活跃股票
+// checkpoint 记录单只股票的检查点，失败只记日志（不影响本次同步，续传时该股票会被重新处理）
+func (s *KLineSyncService) checkpoint(jobID, key string, success bool, errMsg string) {
+	if err := s.jobs.Checkpoint(jobID, []string{key}, success, errMsg); err != nil {
+		logger.Error("记录同步检查点失败",
+			zap.String("job_id", jobID),
+			zap.String("code", key),
+			zap.Error(err),
+		)
+	}
+}
+
+// getActiveStocks 获取所有活跃 A 股股票（不含指数、ETF、可转债）
 func (s *KLineSyncService) getActiveStocks() ([]*KLineSyncTask, error) {
 	db := s.dbService.GetDB()
 	var stocks []models.StockEntity
 
-	err := db.Model(&models.StockEntity{}).
-		Select("code, name, market").
-		Where("is_active = 1").
+	err := ActiveEquities(db).
+		Select("code, name, market, type").
 		Order("code").
 		Find(&stocks).Error
 
@@ -263,6 +604,7 @@ func (s *KLineSyncService) getActiveStocks() ([]*KLineSyncTask, error) {
 			Code:   stock.Code,
 			Name:   stock.Name,
 			Market: stock.Market,
+			Type:   stock.Type,
 		}
 	}
 
@@ -270,40 +612,40 @@ func (s *KLineSyncService) getActiveStocks() ([]*KLineSyncTask, error) {
 }
 
 // fetchKLineData 获取K线数据
-func (s *KLineSyncService) fetchKLineData(task *KLineSyncTask, days int) ([]map[string]interface{}, error) {
-	// 构造secid
-	secid := ""
-	if task.Market == "SH" {
-		secid = "1." + task.Code
-	} else if task.Market == "SZ" {
-		secid = "0." + task.Code
-	} else if task.Market == "BJ" {
-		secid = "2." + task.Code
-	} else {
-		// 默认根据代码判断
-		if task.Code[0] == '6' {
-			secid = "1." + task.Code
-		} else if task.Code[0] == '0' || task.Code[0] == '3' {
-			secid = "0." + task.Code
-		} else {
-			secid = "2." + task.Code
-		}
-	}
+func (s *KLineSyncService) fetchKLineData(task *KLineSyncTask, days int, adjust string) ([]map[string]interface{}, error) {
+	// 构造secid（以 stocks 表的交易所为准）
+	secid := task.instrument().SecID()
 
-	// 计算开始日期
-	endDate := time.Now()
-	startDate := endDate.AddDate(0, 0, -days)
+	// 计算日期范围（days 为交易日数）
+	startDate, endDate := klineSyncRange(s.now(), days)
 
 	// 构造请求URL
 	// klt=101: 日K
-	// fqt=1: 前复权
+	// fqt: 0=不复权，1=前复权，2=后复权
 	url := fmt.Sprintf(
-		"https://push2his.eastmoney.com/api/qt/stock/kline/get?secid=%s&fields1=f1,f2,f3,f4,f5,f6&fields2=f51,f52,f53,f54,f55,f56&klt=101&fqt=1&end=%s&lmt=%d",
+		"https://push2his.eastmoney.com/api/qt/stock/kline/get?secid=%s&fields1=f1,f2,f3,f4,f5,f6&fields2=f51,f52,f53,f54,f55,f56&klt=101&fqt=%s&end=%s&lmt=%d",
 		secid,
+		klineAdjustFQT(adjust),
 		endDate.Format("20060102"),
 		days,
 	)
+	return s.requestKLines(url, startDate, endDate)
+}
+
+// fetchKLineRange 获取指定日期范围（含首尾）的日K线
+func (s *KLineSyncService) fetchKLineRange(task *KLineSyncTask, startDate, endDate time.Time, adjust string) ([]map[string]interface{}, error) {
+	url := fmt.Sprintf(
+		"https://push2his.eastmoney.com/api/qt/stock/kline/get?secid=%s&fields1=f1,f2,f3,f4,f5,f6&fields2=f51,f52,f53,f54,f55,f56&klt=101&fqt=%s&beg=%s&end=%s",
+		task.instrument().SecID(),
+		klineAdjustFQT(adjust),
+		startDate.Format("20060102"),
+		endDate.Format("20060102"),
+	)
+	return s.requestKLines(url, startDate, endDate)
+}
 
+// requestKLines 请求东方财富日K线接口，只保留 [startDate, endDate] 范围内的数据
+func (s *KLineSyncService) requestKLines(url string, startDate, endDate time.Time) ([]map[string]interface{}, error) {
 	// 发送请求
 	var result struct {
 		RC   int `json:"rc"`
@@ -339,7 +681,7 @@ func (s *KLineSyncService) fetchKLineData(task *KLineSyncTask, days int) ([]map[
 
 		date := parts[0]
 		// 过滤日期范围
-		klineDate, err := time.Parse("2006-01-02", date)
+		klineDate, err := time.ParseInLocation("2006-01-02", date, chinaLocation)
 		if err != nil {
 			continue
 		}
@@ -348,12 +690,13 @@ func (s *KLineSyncService) fetchKLineData(task *KLineSyncTask, days int) ([]map[
 			continue
 		}
 
+		// 字段顺序：f51 日期, f52 开盘, f53 收盘, f54 最高, f55 最低, f56 成交量
 		klines = append(klines, map[string]interface{}{
 			"date":   date,
 			"open":   parsePrice(parts[1]),
-			"high":   parsePrice(parts[2]),
-			"low":    parsePrice(parts[3]),
-			"close":  parsePrice(parts[4]),
+			"close":  parsePrice(parts[2]),
+			"high":   parsePrice(parts[3]),
+			"low":    parsePrice(parts[4]),
 			"volume": int64(parsePrice(parts[5])),
 		})
 	}
@@ -361,6 +704,203 @@ func (s *KLineSyncService) fetchKLineData(task *KLineSyncTask, days int) ([]map[
 	return klines, nil
 }
 
+// SyncKLineRange 重新同步单只股票指定日期范围（YYYY-MM-DD，含首尾）的日K线，用于修复缓存中的缺失或异常数据。
+// 沿用缓存已有的复权方式（没有缓存时为前复权），避免因复权方式不同而清空整张缓存表。返回写入的记录数。
+func (s *KLineSyncService) SyncKLineRange(code, startDate, endDate string) (int, error) {
+	start, err := time.ParseInLocation("2006-01-02", startDate, chinaLocation)
+	if err != nil {
+		return 0, fmt.Errorf("开始日期无效: %s", startDate)
+	}
+	end, err := time.ParseInLocation("2006-01-02", endDate, chinaLocation)
+	if err != nil || end.Before(start) {
+		return 0, fmt.Errorf("结束日期无效: %s", endDate)
+	}
+
+	inst, err := NewInstrumentResolver(s.dbService.GetDB()).Resolve(code)
+	if err != nil {
+		return 0, err
+	}
+	task := &KLineSyncTask{Code: inst.Code, Market: inst.Market, Type: inst.Type}
+
+	adjust, err := s.dbService.GetKLineCacheAdjust(inst.Key(), KLinePeriodDaily)
+	if err != nil {
+		return 0, err
+	}
+	if adjust == "" {
+		adjust = KLineAdjustForward
+	}
+
+	klines, err := s.fetchKLineRange(task, start, end, adjust)
+	if err != nil {
+		return 0, err
+	}
+	added, updated, err := s.saveKLineData(inst.Key(), adjust, klines)
+	if err != nil {
+		return 0, err
+	}
+	logger.Info("重新同步K线范围完成",
+		zap.String("module", "services.kline_sync"),
+		zap.String("op", "SyncKLineRange"),
+		zap.String("code", inst.Key()),
+		zap.String("start", startDate),
+		zap.String("end", endDate),
+		zap.Int("records", len(klines)),
+	)
+	return int(added + updated), nil
+}
+
+// klineSyncRange 返回截至 now 最近 days 个交易日的起止日期（截止到最近一个交易日）
+func klineSyncRange(now time.Time, days int) (time.Time, time.Time) {
+	cal := GetTradingCalendar()
+	end := cal.LatestTradingDay(now)
+	return cal.AddTradingDays(end, -(days - 1)), end
+}
+
+// planKLineSync 根据本地缓存决定单只股票的同步方式。
+// 缓存完整且复权方式一致时增量拉取：从最新缓存日期向前重叠 klineSyncOverlap 个交易日到窗口截止日；
+// 没有缓存、复权方式不同、缓存早于同步窗口或未覆盖窗口起点时全量拉取整个窗口。
+// 上市晚于窗口起点的股票从最早缓存日期起算应有的交易日。
+// 缓存中缺少交易日（多为停牌）时从第一个缺失日之前的缓存K线开始增量拉取，行情源有这些日期的数据时顺带补齐，
+// 重叠区间校验不通过时才改为全量拉取。
+func (s *KLineSyncService) planKLineSync(code, adjust string, windowStart, windowEnd time.Time, forceFull bool) klineSyncPlan {
+	base := klineSyncPlan{start: windowStart, end: windowEnd, windowStart: windowStart}
+	if forceFull {
+		return base.full("手动全量同步")
+	}
+
+	latest, err := s.dbService.GetLatestKLineDate(code)
+	if err != nil {
+		return base.full(err.Error())
+	}
+	if latest == "" {
+		return base.full("无本地缓存")
+	}
+	cachedAdjust, err := s.dbService.GetKLineCacheAdjust(code, KLinePeriodDaily)
+	if err != nil {
+		return base.full(err.Error())
+	}
+	if cachedAdjust != adjust {
+		return base.full("复权方式变更")
+	}
+	latestDate, err := time.ParseInLocation("2006-01-02", latest, chinaLocation)
+	if err != nil {
+		return base.full("最新缓存日期无效")
+	}
+	if latestDate.Before(windowStart) {
+		return base.full("缓存早于同步窗口")
+	}
+
+	// 最早缓存晚于窗口起点时，只有曾从窗口起点或更早拉取过（说明上市晚于窗口）才从最早缓存起算，
+	// 否则是较短窗口留下的缓存，需要全量补齐
+	from := windowStart
+	earliest, err := s.dbService.GetEarliestKLineDate(code)
+	if err != nil {
+		return base.full(err.Error())
+	}
+	if earliestDate, err := time.ParseInLocation("2006-01-02", earliest, chinaLocation); err == nil && earliestDate.After(windowStart) {
+		if !s.fetchedSince(code, windowStart) {
+			return base.full("缓存未覆盖同步窗口")
+		}
+		from = earliestDate
+	}
+
+	cal := GetTradingCalendar()
+	expected := cal.TradingDaysBetween(cal.PrevTradingDay(from), latestDate)
+	cachedBars, cached, err := s.dbService.GetKLineDailyBarsBetween(code, from.Format("2006-01-02"), latest, expected)
+	if err != nil {
+		return base.full(err.Error())
+	}
+
+	plan := base
+	plan.mode = KLineSyncIncremental
+	plan.start = cal.AddTradingDays(latestDate, -(klineSyncOverlap - 1))
+	if int(cached) < expected {
+		plan.start = firstKLineGap(cal, cachedBars, from, latestDate)
+	}
+	if plan.start.Before(from) {
+		plan.start = from
+	}
+	startDate := plan.start.Format("2006-01-02")
+	for _, bar := range cachedBars {
+		if bar.Date >= startDate {
+			plan.overlap = append(plan.overlap, bar)
+		}
+	}
+	return plan
+}
+
+// firstKLineGap 返回 from 之后第一个缺失交易日之前的最后一个交易日（即缺口前的缓存K线日期），没有缺口时返回 latest
+func firstKLineGap(cal *TradingCalendar, bars []models.KLineBarEntity, from, latest time.Time) time.Time {
+	dates := make(map[string]bool, len(bars))
+	for _, bar := range bars {
+		dates[bar.Date] = true
+	}
+	prev := from
+	for d := from; !d.After(latest); d = cal.NextTradingDay(d) {
+		if !dates[d.Format("2006-01-02")] {
+			return prev
+		}
+		prev = d
+	}
+	return latest
+}
+
+// fetchedSince 是否有从 date 或更早开始成功拉取该股票K线的同步记录
+func (s *KLineSyncService) fetchedSince(code string, date time.Time) bool {
+	var n int64
+	err := s.dbService.GetDB().Model(&models.SyncHistoryEntity{}).
+		Where("stock_code = ? AND sync_type = ? AND status = ? AND start_date <= ?", code, "kline", "success", date.Format("2006-01-02")).
+		Count(&n).Error
+	return err == nil && n > 0
+}
+
+// fetchPlannedKLines 按同步计划拉取K线，返回实际执行的计划。
+// 增量拉取后用重叠部分校验缓存：缺少已缓存的交易日时改为全量拉取；
+// 复权价格发生变化（如前复权遇到新的除权除息）说明旧缓存的价格基准已失效，全量拉取并替换缓存。
+func (s *KLineSyncService) fetchPlannedKLines(task *KLineSyncTask, plan klineSyncPlan, days int, adjust string) ([]map[string]interface{}, klineSyncPlan, error) {
+	if plan.mode == KLineSyncIncremental {
+		klines, err := s.fetchKLineRange(task, plan.start, plan.end, adjust)
+		if err != nil {
+			return nil, plan, err
+		}
+		reason, rebase := klineOverlapMismatch(plan.overlap, klines, adjust)
+		if reason == "" {
+			return klines, plan, nil
+		}
+		logger.Info("增量同步校验未通过，改为全量同步",
+			zap.String("module", "services.kline_sync"),
+			zap.String("op", "fetchPlannedKLines"),
+			zap.String("code", task.Code),
+			zap.String("reason", reason),
+		)
+		plan = plan.full(reason)
+		plan.replace = rebase
+	}
+
+	klines, err := s.fetchKLineData(task, days, adjust)
+	return klines, plan, err
+}
+
+// klineOverlapMismatch 比较重叠区间的缓存K线与新拉取的K线。
+// 返回不一致的原因；rebase 为 true 表示复权基准已变，需要替换整段缓存。
+// 不复权数据的价格差异视为修订，直接覆盖即可。
+func klineOverlapMismatch(cached []models.KLineBarEntity, klines []map[string]interface{}, adjust string) (reason string, rebase bool) {
+	fetched := make(map[string]float64, len(klines))
+	for _, k := range klines {
+		fetched[k["date"].(string)] = k["close"].(float64)
+	}
+	for _, bar := range cached {
+		closePrice, ok := fetched[bar.Date]
+		if !ok {
+			return "重叠区间缺少已缓存的交易日 " + bar.Date, false
+		}
+		if adjust != KLineAdjustNone && math.Abs(closePrice-bar.Close) > klineOverlapTolerance {
+			return "复权价格变化 " + bar.Date, true
+		}
+	}
+	return "", false
+}
+
 // parsePrice 解析价格
 func parsePrice(s string) float64 {
 	if s == "" || s == "-" {
@@ -374,16 +914,15 @@ func parsePrice(s string) float64 {
 }
 
 // saveKLineData 保存K线数据
-func (s *KLineSyncService) saveKLineData(code string, klines []map[string]interface{}) (int64, int64, error) {
-	return s.dbService.InsertOrUpdateKLineData(code, klines)
+func (s *KLineSyncService) saveKLineData(code string, adjust string, klines []map[string]interface{}) (int64, int64, error) {
+	return s.dbService.InsertOrUpdateKLinePeriodData(code, KLinePeriodDaily, adjust, klines)
 }
 
-// recordSyncHistory 记录同步历史
-func (s *KLineSyncService) recordSyncHistory(task *KLineSyncTask, days int, totalRecords, added, updated int, success bool, errorMsg string) error {
+// recordSyncHistory 记录同步历史（日期范围为实际拉取的范围）
+func (s *KLineSyncService) recordSyncHistory(task *KLineSyncTask, plan klineSyncPlan, totalRecords, added, updated int, success bool, errorMsg string) error {
 	db := s.dbService.GetDB()
 
-	endDate := time.Now().Format("2006-01-02")
-	startDate := time.Now().AddDate(0, 0, -days).Format("2006-01-02")
+	startDate, endDate := plan.start.Format("2006-01-02"), plan.end.Format("2006-01-02")
 	status := "success"
 	if !success {
 		status = "failed"
@@ -393,6 +932,7 @@ func (s *KLineSyncService) recordSyncHistory(task *KLineSyncTask, days int, tota
 		StockCode:      task.Code,
 		StockName:      task.Name,
 		SyncType:       "kline",
+		SyncMode:       plan.mode,
 		StartDate:      startDate,
 		EndDate:        endDate,
 		Status:         status,
@@ -479,6 +1019,7 @@ func (s *KLineSyncService) GetKLineSyncHistory(limit int) ([]map[string]interfac
 			"stockCode":      e.StockCode,
 			"stockName":      e.StockName,
 			"syncType":       e.SyncType,
+			"syncMode":       e.SyncMode,
 			"startDate":      e.StartDate,
 			"endDate":        e.EndDate,
 			"status":         e.Status,
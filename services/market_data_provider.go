@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"stock-analyzer-wails/models"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// 行情数据源名称（对应配置项 market_data_provider）
+const (
+	MarketDataProviderEastMoney = "eastmoney"
+)
+
+// MarketDataProvider 行情数据源抽象。
+//
+// StockService 只负责业务编排（指标计算、健康检查、同步入库等），
+// 具体的行情接口（URL、字段映射、推送协议）由各数据源实现，
+// 新增数据源或离线夹具时无需改动 StockService。
+type MarketDataProvider interface {
+	// Name 返回数据源名称（用于日志与配置）
+	Name() string
+	// GetStockByCode 获取单只股票实时行情
+	GetStockByCode(code string) (*models.StockData, error)
+	// GetQuotes 批量获取实时行情，返回以入参代码为 key 的结果；取不到行情的代码不出现在结果中
+	GetQuotes(codes []string) (map[string]*models.StockData, error)
+	// GetKLineData 获取原始 K 线（按时间升序，不含技术指标），limit 为最多返回的根数，adjust 为复权方式
+	GetKLineData(code string, limit int, period string, adjust string) ([]*models.KLineData, error)
+	// GetIntradayData 获取当日分时快照
+	GetIntradayData(code string) (*models.IntradayResponse, error)
+	// GetMoneyFlowData 获取日级资金流向
+	GetMoneyFlowData(code string) (*models.MoneyFlowResponse, error)
+	// GetOrderBook 获取五档盘口
+	GetOrderBook(code string) (*models.OrderBook, error)
+	// StreamIntraday 建立一次分时推送连接，每收到一批分时数据调用 onTrends。
+	// 连接正常结束返回 nil，异常返回 error；重连与退避由调用方负责。
+	StreamIntraday(ctx context.Context, code string, onTrends func(trends []string)) error
+}
+
+// instrumentAware 可选能力：接收证券标识解析器，使 secid 以 stocks 表为准
+type instrumentAware interface {
+	SetInstrumentResolver(r *InstrumentResolver)
+}
+
+// contextAware 可选能力：返回常规请求携带指定上下文的数据源副本
+type contextAware interface {
+	WithContext(ctx context.Context) MarketDataProvider
+}
+
+// ExRightsProvider 可选能力：提供除权除息事件，用于在本地对不复权 K 线进行复权
+type ExRightsProvider interface {
+	GetExRightsEvents(code string) ([]models.ExRightsEventEntity, error)
+}
+
+// FinancialStatementProvider 可选能力：提供按报告期的财务报表，用于计算 ROE、毛利率等财务摘要
+type FinancialStatementProvider interface {
+	GetFinancialStatements(code string) ([]models.FinancialStatementEntity, error)
+}
+
+// NewMarketDataProvider 根据名称创建行情数据源，名称为空时使用东方财富。
+// client 用于常规请求，sseClient 用于长连接推送（不超时）。
+func NewMarketDataProvider(name string, client, sseClient *resty.Client) (MarketDataProvider, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", MarketDataProviderEastMoney:
+		return NewEastMoneyProvider(client, sseClient), nil
+	default:
+		return nil, fmt.Errorf("不支持的行情数据源: %s", name)
+	}
+}
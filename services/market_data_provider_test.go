@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"stock-analyzer-wails/models"
+	"testing"
+)
+
+// fakeProvider 用于替换真实行情源的测试数据源
+type fakeProvider struct {
+	bars      int
+	lastLimit int
+}
+
+func (f *fakeProvider) Name() string { return "fake" }
+
+func (f *fakeProvider) GetStockByCode(code string) (*models.StockData, error) {
+	return &models.StockData{Code: code, Name: "测试", Price: 10}, nil
+}
+
+func (f *fakeProvider) GetQuotes(codes []string) (map[string]*models.StockData, error) {
+	quotes := make(map[string]*models.StockData, len(codes))
+	for _, code := range codes {
+		quotes[code] = &models.StockData{Code: code, Name: "测试", Price: 10}
+	}
+	return quotes, nil
+}
+
+func (f *fakeProvider) GetKLineData(code string, limit int, period string, adjust string) ([]*models.KLineData, error) {
+	f.lastLimit = limit
+	klines := make([]*models.KLineData, 0, f.bars)
+	for i := 0; i < f.bars; i++ {
+		price := 10 + float64(i%7)
+		klines = append(klines, &models.KLineData{
+			Time:  fmt.Sprintf("2024-01-%02d", i%28+1),
+			Open:  price,
+			Close: price + 0.5,
+			High:  price + 1,
+			Low:   price - 1,
+		})
+	}
+	return klines, nil
+}
+
+func (f *fakeProvider) GetIntradayData(code string) (*models.IntradayResponse, error) {
+	return &models.IntradayResponse{}, nil
+}
+
+func (f *fakeProvider) GetMoneyFlowData(code string) (*models.MoneyFlowResponse, error) {
+	return &models.MoneyFlowResponse{}, nil
+}
+
+func (f *fakeProvider) GetOrderBook(code string) (*models.OrderBook, error) {
+	return &models.OrderBook{}, nil
+}
+
+func (f *fakeProvider) StreamIntraday(ctx context.Context, code string, onTrends func(trends []string)) error {
+	onTrends([]string{"2024-01-02 09:30,10,10,10,10,100,1000,10"})
+	return nil
+}
+
+func TestStockService_SetProvider(t *testing.T) {
+	s := NewStockService()
+	if s.ProviderName() != MarketDataProviderEastMoney {
+		t.Fatalf("expected default provider %q, got %q", MarketDataProviderEastMoney, s.ProviderName())
+	}
+
+	fp := &fakeProvider{bars: 80}
+	s.SetProvider(fp)
+
+	stock, err := s.GetStockByCode("600519")
+	if err != nil || stock.Name != "测试" {
+		t.Fatalf("expected stock from fake provider, got %+v, err=%v", stock, err)
+	}
+
+	klines, err := s.GetKLineData("600519", 20, "daily", "")
+	if err != nil {
+		t.Fatalf("GetKLineData error: %v", err)
+	}
+	if fp.lastLimit != 70 {
+		t.Fatalf("expected provider to be asked for limit+50=70 bars, got %d", fp.lastLimit)
+	}
+	if len(klines) != 20 {
+		t.Fatalf("expected 20 bars after trimming, got %d", len(klines))
+	}
+	if klines[len(klines)-1].MACD == nil || klines[len(klines)-1].KDJ == nil {
+		t.Fatalf("expected indicators to be calculated on provider bars")
+	}
+}
+
+func TestNewMarketDataProvider(t *testing.T) {
+	s := NewStockService()
+	for _, name := range []string{"", "eastmoney", " EastMoney "} {
+		p, err := NewMarketDataProvider(name, s.client, s.sseClient)
+		if err != nil || p.Name() != MarketDataProviderEastMoney {
+			t.Fatalf("name %q: expected eastmoney provider, got %v, err=%v", name, p, err)
+		}
+	}
+	if err := s.UseProvider("unknown-vendor"); err == nil {
+		t.Fatalf("expected error for unknown provider")
+	}
+	if s.ProviderName() != MarketDataProviderEastMoney {
+		t.Fatalf("unknown provider must not replace current provider")
+	}
+}
@@ -15,8 +15,9 @@ import (
 
 // MoneyFlowService 资金流向服务
 type MoneyFlowService struct {
-	repo   *repositories.MoneyFlowRepository
-	client *resty.Client
+	repo        *repositories.MoneyFlowRepository
+	client      *resty.Client
+	instruments *InstrumentResolver
 }
 
 // NewMoneyFlowService 创建资金流向服务
@@ -27,6 +28,9 @@ func NewMoneyFlowService(repo *repositories.MoneyFlowRepository) *MoneyFlowServi
 	client.SetHeader("Referer", "https://quote.eastmoney.com/")
 	client.SetRetryCount(3)
 
+	applyHTTPMode(client) // 录制/回放（离线运行）
+	useGateway(client, PriorityInteractive)
+
 	return &MoneyFlowService{
 		repo:   repo,
 		client: client,
@@ -105,15 +109,13 @@ func (s *MoneyFlowService) FetchAndSaveHistory(code string) error {
 	return nil
 }
 
+// SetInstrumentResolver 注入证券标识解析器（以 stocks 表为准）
+func (s *MoneyFlowService) SetInstrumentResolver(r *InstrumentResolver) {
+	s.instruments = r
+}
+
 func (s *MoneyFlowService) generateSecid(code string) string {
-	if strings.HasPrefix(code, "6") {
-		return "1." + code
-	} else if strings.HasPrefix(code, "0") || strings.HasPrefix(code, "3") {
-		return "0." + code
-	} else if strings.HasPrefix(code, "8") || strings.HasPrefix(code, "4") {
-		return "2." + code
-	}
-	return "0." + code // Default fallback
+	return s.instruments.SecID(code)
 }
 
 func (s *MoneyFlowService) parseFloat(val string) float64 {
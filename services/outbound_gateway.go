@@ -0,0 +1,504 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"stock-analyzer-wails/internal/logger"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"go.uber.org/zap"
+)
+
+// RequestPriority 出站请求的优先级
+type RequestPriority int
+
+const (
+	PriorityInteractive RequestPriority = iota // 前端交互、行情刷新、预警、分时推送：排队时优先放行
+	PriorityBackground                         // 批量同步、扫描等后台任务：没有交互请求排队时才放行
+)
+
+// 熔断器状态
+const (
+	BreakerClosed   = "closed"    // 正常放行
+	BreakerOpen     = "open"      // 熔断中，请求直接失败
+	BreakerHalfOpen = "half_open" // 熔断到期，放行一个试探请求
+)
+
+// CircuitBreakerEvent 熔断状态变化事件名（前端监听）
+const CircuitBreakerEvent = "circuitBreaker"
+
+const (
+	// gatewayDefaultRate 每个主机默认每秒放行的请求数
+	gatewayDefaultRate = 10
+	// gatewayDefaultBurst 每个主机默认允许的突发请求数
+	gatewayDefaultBurst = 10
+	// breakerFailureThreshold 连续失败多少次后熔断
+	breakerFailureThreshold = 5
+	// breakerOpenDuration 熔断后多久放行试探请求
+	breakerOpenDuration = 30 * time.Second
+	// breakerMaxOpenDuration 试探失败时熔断时长加倍，最长不超过该值
+	breakerMaxOpenDuration = 5 * time.Minute
+)
+
+// gatewayHostLimits 按主机配置的限速，未配置的主机使用默认值
+var gatewayHostLimits = map[string]struct {
+	rate  float64
+	burst int
+}{
+	"push2his.eastmoney.com": {rate: 5, burst: 5}, // 历史K线、资金流：批量同步的主要来源，反爬虫更严格
+}
+
+// ErrCircuitOpen 数据源熔断期间请求被直接拒绝
+var ErrCircuitOpen = errors.New("数据源暂时不可用（已熔断）")
+
+// GatewayHostStatus 单个主机的限速与熔断状态，时间均为毫秒时间戳（0 表示尚无）
+type GatewayHostStatus struct {
+	Host                string  `json:"host"`
+	State               string  `json:"state"`
+	Rate                float64 `json:"rate"`  // 每秒放行的请求数
+	Burst               int     `json:"burst"` // 允许的突发请求数
+	Requests            int64   `json:"requests"`
+	Failures            int64   `json:"failures"`
+	Rejected            int64   `json:"rejected"`            // 熔断期间被拒绝的请求数
+	ConsecutiveFailures int     `json:"consecutiveFailures"` // 连续失败次数，成功后清零
+	Waiting             int     `json:"waiting"`             // 排队等待放行的请求数
+	LastError           string  `json:"lastError"`
+	LastErrorAt         int64   `json:"lastErrorAt"`
+	OpenedAt            int64   `json:"openedAt"`
+	RetryAt             int64   `json:"retryAt"` // 熔断中：放行试探请求的时间
+}
+
+// gatewayWaiter 排队等待放行的请求
+type gatewayWaiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+// gatewayHost 单个主机的令牌桶、等待队列与熔断器
+type gatewayHost struct {
+	status  GatewayHostStatus
+	tokens  float64
+	last    time.Time
+	queues  [2][]*gatewayWaiter // 按优先级排队
+	timer   *time.Timer
+	openFor time.Duration // 本次熔断时长
+	retryAt time.Time     // 放行试探请求的时间
+	probing bool          // 半开状态下试探请求是否已发出
+}
+
+// OutboundGateway 所有数据源客户端共用的出站网关：按主机令牌桶限速（交互请求优先），
+// 连续失败时熔断，避免数据源出错时各个监控、推送、同步仍不断请求。
+type OutboundGateway struct {
+	mu    sync.Mutex
+	hosts map[string]*gatewayHost
+
+	ctx  context.Context
+	emit func(ctx context.Context, status GatewayHostStatus)
+	now  func() time.Time
+}
+
+// NewOutboundGateway 创建出站网关
+func NewOutboundGateway() *OutboundGateway {
+	return &OutboundGateway{
+		hosts: make(map[string]*gatewayHost),
+		emit: func(ctx context.Context, status GatewayHostStatus) {
+			if ctx == nil {
+				return
+			}
+			runtime.EventsEmit(ctx, CircuitBreakerEvent, status)
+		},
+		now: time.Now,
+	}
+}
+
+var outboundGateway = NewOutboundGateway()
+
+// GetOutboundGateway 返回进程内共用的出站网关
+func GetOutboundGateway() *OutboundGateway {
+	return outboundGateway
+}
+
+// SetContext 设置上下文（用于发送熔断事件）
+func (g *OutboundGateway) SetContext(ctx context.Context) {
+	g.mu.Lock()
+	g.ctx = ctx
+	g.mu.Unlock()
+}
+
+// SetHostLimit 设置主机的限速，rate <= 0 表示不限速
+func (g *OutboundGateway) SetHostLimit(host string, rate float64, burst int) {
+	if burst < 1 {
+		burst = 1
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	h := g.host(host)
+	h.status.Rate, h.status.Burst = rate, burst
+	if h.tokens > float64(burst) {
+		h.tokens = float64(burst)
+	}
+	if h.timer != nil && h.timer.Stop() {
+		// 按新的速率重新计算下一次放行时间
+		h.timer = nil
+		g.schedule(h)
+	}
+}
+
+// Status 返回所有请求过的主机的状态（按主机名升序）
+func (g *OutboundGateway) Status() []GatewayHostStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	list := make([]GatewayHostStatus, 0, len(g.hosts))
+	for _, h := range g.hosts {
+		list = append(list, g.snapshot(h))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Host < list[j].Host })
+	return list
+}
+
+// ResetBreaker 手动恢复主机的熔断器
+func (g *OutboundGateway) ResetBreaker(host string) error {
+	g.mu.Lock()
+	h, ok := g.hosts[host]
+	if !ok {
+		g.mu.Unlock()
+		return fmt.Errorf("没有该主机的请求记录: %s", host)
+	}
+	changed := h.status.State != BreakerClosed
+	g.close(h)
+	snapshot := g.snapshot(h)
+	g.mu.Unlock()
+
+	if changed {
+		g.notify(snapshot)
+	}
+	return nil
+}
+
+// host 返回主机的记录，不存在时按配置创建。调用方需持有锁。
+func (g *OutboundGateway) host(name string) *gatewayHost {
+	h, ok := g.hosts[name]
+	if ok {
+		return h
+	}
+	rate, burst := float64(gatewayDefaultRate), gatewayDefaultBurst
+	if limit, ok := gatewayHostLimits[name]; ok {
+		rate, burst = limit.rate, limit.burst
+	}
+	h = &gatewayHost{
+		status: GatewayHostStatus{Host: name, State: BreakerClosed, Rate: rate, Burst: burst},
+		tokens: float64(burst),
+	}
+	g.hosts[name] = h
+	return h
+}
+
+func (g *OutboundGateway) snapshot(h *gatewayHost) GatewayHostStatus {
+	s := h.status
+	s.Waiting = len(h.queues[PriorityInteractive]) + len(h.queues[PriorityBackground])
+	return s
+}
+
+func (g *OutboundGateway) notify(status GatewayHostStatus) {
+	g.mu.Lock()
+	ctx := g.ctx
+	g.mu.Unlock()
+	g.emit(ctx, status)
+}
+
+// refill 按经过的时间补充令牌。调用方需持有锁。
+func (g *OutboundGateway) refill(h *gatewayHost, now time.Time) {
+	if !h.last.IsZero() {
+		h.tokens += now.Sub(h.last).Seconds() * h.status.Rate
+		if burst := float64(h.status.Burst); h.tokens > burst {
+			h.tokens = burst
+		}
+	}
+	h.last = now
+}
+
+// acquire 等待主机放行一次请求。熔断中直接返回 ErrCircuitOpen；
+// 熔断到期后只放行一个试探请求（probe 为 true），其余请求仍被拒绝。
+func (g *OutboundGateway) acquire(ctx context.Context, host string, priority RequestPriority) (probe bool, err error) {
+	if priority != PriorityInteractive {
+		priority = PriorityBackground
+	}
+	g.mu.Lock()
+	h := g.host(host)
+	now := g.now()
+
+	var changed bool
+	switch h.status.State {
+	case BreakerOpen:
+		if now.Before(h.retryAt) {
+			h.status.Rejected++
+			g.mu.Unlock()
+			return false, g.openError(host, h.retryAt)
+		}
+		h.status.State, h.probing, probe, changed = BreakerHalfOpen, true, true, true
+	case BreakerHalfOpen:
+		if h.probing {
+			h.status.Rejected++
+			g.mu.Unlock()
+			return false, g.openError(host, h.retryAt)
+		}
+		h.probing, probe = true, true
+	}
+	snapshot := g.snapshot(h)
+
+	// 不限速，或同等及更高优先级没有请求排队且有令牌时直接放行
+	g.refill(h, now)
+	queued := len(h.queues[PriorityInteractive])
+	if priority == PriorityBackground {
+		queued += len(h.queues[PriorityBackground])
+	}
+	if h.status.Rate <= 0 || (queued == 0 && h.tokens >= 1) {
+		if h.status.Rate > 0 {
+			h.tokens--
+		}
+		h.status.Requests++
+		g.mu.Unlock()
+		if changed {
+			g.notify(snapshot)
+		}
+		return probe, nil
+	}
+
+	w := &gatewayWaiter{ready: make(chan struct{})}
+	h.queues[priority] = append(h.queues[priority], w)
+	g.schedule(h)
+	g.mu.Unlock()
+	if changed {
+		g.notify(snapshot)
+	}
+
+	select {
+	case <-w.ready:
+		return probe, nil
+	case <-ctx.Done():
+		g.mu.Lock()
+		if w.granted {
+			// 已放行但不再使用，归还令牌
+			h.tokens++
+			h.status.Requests--
+		} else {
+			q := h.queues[priority]
+			for i := range q {
+				if q[i] == w {
+					h.queues[priority] = append(q[:i:i], q[i+1:]...)
+					break
+				}
+			}
+		}
+		if probe {
+			h.probing = false
+		}
+		g.mu.Unlock()
+		return false, ctx.Err()
+	}
+}
+
+// schedule 在下一个令牌可用时放行排队的请求。调用方需持有锁。
+func (g *OutboundGateway) schedule(h *gatewayHost) {
+	if h.timer != nil {
+		return
+	}
+	var wait time.Duration
+	if h.status.Rate > 0 && h.tokens < 1 {
+		wait = time.Duration((1 - h.tokens) / h.status.Rate * float64(time.Second))
+	}
+	h.timer = time.AfterFunc(wait, func() { g.dispatch(h) })
+}
+
+// dispatch 按优先级放行排队的请求，令牌不足时等待下一个令牌
+func (g *OutboundGateway) dispatch(h *gatewayHost) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	h.timer = nil
+	g.refill(h, g.now())
+	unlimited := h.status.Rate <= 0 // 排队期间改为不限速时全部放行
+	for unlimited || h.tokens >= 1 {
+		var w *gatewayWaiter
+		for p := range h.queues {
+			if len(h.queues[p]) > 0 {
+				w, h.queues[p] = h.queues[p][0], h.queues[p][1:]
+				break
+			}
+		}
+		if w == nil {
+			return
+		}
+		if !unlimited {
+			h.tokens--
+		}
+		h.status.Requests++
+		w.granted = true
+		close(w.ready)
+	}
+	if len(h.queues[PriorityInteractive])+len(h.queues[PriorityBackground]) > 0 {
+		g.schedule(h)
+	}
+}
+
+func (g *OutboundGateway) openError(host string, retryAt time.Time) error {
+	return fmt.Errorf("%w: %s，%s 后重试", ErrCircuitOpen, host, retryAt.In(chinaLocation).Format("15:04:05"))
+}
+
+// record 记录请求结果：连续失败达到阈值或试探请求失败时熔断，成功时清零并关闭熔断。
+// 请求被调用方取消时不计入。
+func (g *OutboundGateway) record(ctx context.Context, host string, probe bool, resp *http.Response, err error) {
+	failed := err != nil
+	if err == nil && (resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests) {
+		failed, err = true, fmt.Errorf("HTTP %s", resp.Status)
+	}
+
+	g.mu.Lock()
+	h := g.host(host)
+	if failed && ctx.Err() != nil {
+		if probe {
+			h.probing = false
+		}
+		g.mu.Unlock()
+		return
+	}
+
+	now := g.now()
+	before := h.status.State
+	if failed {
+		h.status.Failures++
+		h.status.ConsecutiveFailures++
+		h.status.LastError, h.status.LastErrorAt = err.Error(), now.UnixMilli()
+		switch {
+		case probe:
+			g.open(h, now, h.openFor*2)
+		case h.status.State == BreakerClosed && h.status.ConsecutiveFailures >= breakerFailureThreshold:
+			g.open(h, now, breakerOpenDuration)
+		}
+	} else {
+		h.status.ConsecutiveFailures = 0
+		if probe {
+			g.close(h)
+		}
+	}
+	changed := h.status.State != before
+	snapshot := g.snapshot(h)
+	g.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if snapshot.State == BreakerOpen {
+		logger.Warn("数据源连续失败，已熔断",
+			zap.String("module", "services.gateway"),
+			zap.String("op", "record"),
+			zap.String("host", host),
+			zap.Int("consecutive_failures", snapshot.ConsecutiveFailures),
+			zap.Int64("retry_at", snapshot.RetryAt),
+			zap.String("last_error", snapshot.LastError),
+		)
+	} else {
+		logger.Info("数据源恢复，熔断关闭",
+			zap.String("module", "services.gateway"),
+			zap.String("op", "record"),
+			zap.String("host", host),
+		)
+	}
+	g.notify(snapshot)
+}
+
+// open 熔断 d（不超过 breakerMaxOpenDuration）。调用方需持有锁。
+func (g *OutboundGateway) open(h *gatewayHost, now time.Time, d time.Duration) {
+	if d < breakerOpenDuration {
+		d = breakerOpenDuration
+	}
+	if d > breakerMaxOpenDuration {
+		d = breakerMaxOpenDuration
+	}
+	h.openFor, h.probing = d, false
+	h.retryAt = now.Add(d)
+	h.status.State = BreakerOpen
+	h.status.OpenedAt, h.status.RetryAt = now.UnixMilli(), h.retryAt.UnixMilli()
+}
+
+// close 关闭熔断。调用方需持有锁。
+func (g *OutboundGateway) close(h *gatewayHost) {
+	h.openFor, h.probing = 0, false
+	h.status.State = BreakerClosed
+	h.status.ConsecutiveFailures = 0
+	h.status.OpenedAt, h.status.RetryAt = 0, 0
+}
+
+// Transport 返回经过网关的 Transport，priority 为请求的默认优先级（可用 withRequestPriority 按请求指定）
+func (g *OutboundGateway) Transport(base http.RoundTripper, priority RequestPriority) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &gatewayTransport{gateway: g, base: base, priority: priority}
+}
+
+type gatewayTransport struct {
+	gateway  *OutboundGateway
+	base     http.RoundTripper
+	priority RequestPriority
+}
+
+func (t *gatewayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	if isLoopbackHost(host) {
+		// 本地地址（本地代理、测试服务器）不是外部数据源，不限速也不熔断
+		return t.base.RoundTrip(req)
+	}
+	priority := t.priority
+	if p, ok := req.Context().Value(requestPriorityKey{}).(RequestPriority); ok {
+		priority = p
+	}
+	probe, err := t.gateway.acquire(req.Context(), host, priority)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	t.gateway.record(req.Context(), host, probe, resp, err)
+	return resp, err
+}
+
+// isLoopbackHost 是否为本机地址
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+type requestPriorityKey struct{}
+
+// withRequestPriority 为单个请求指定优先级（配合 resty 的 SetContext 使用），覆盖客户端的默认优先级。
+// 交互客户端上的批量任务用它降为后台优先级，避免与行情、预警、推送争抢。
+func withRequestPriority(ctx context.Context, priority RequestPriority) context.Context {
+	return context.WithValue(ctx, requestPriorityKey{}, priority)
+}
+
+// Attach 让客户端的请求经过网关，priority 为该客户端请求的默认优先级。熔断拒绝的请求不再重试。
+func (g *OutboundGateway) Attach(client *resty.Client, priority RequestPriority) *resty.Client {
+	client.SetTransport(g.Transport(client.GetClient().Transport, priority))
+	client.AddRetryCondition(func(_ *resty.Response, err error) bool {
+		return err != nil && !errors.Is(err, ErrCircuitOpen)
+	})
+	return client
+}
+
+// useGateway 让客户端的请求经过共享出站网关。在 applyHTTPMode 之后调用；回放模式不访问网络，不经过网关。
+func useGateway(client *resty.Client, priority RequestPriority) *resty.Client {
+	if mode, _ := httpModeFromEnv(); mode == HTTPModeReplay {
+		return client
+	}
+	return GetOutboundGateway().Attach(client, priority)
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// statusRoundTripper 按 status 返回响应并统计调用次数
+type statusRoundTripper struct {
+	mu     sync.Mutex
+	status int
+	calls  int
+}
+
+func (f *statusRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return &http.Response{
+		StatusCode: f.status,
+		Status:     http.StatusText(f.status),
+		Body:       io.NopCloser(strings.NewReader("{}")),
+		Request:    r,
+	}, nil
+}
+
+func newTestGateway() (*OutboundGateway, *[]string) {
+	g := NewOutboundGateway()
+	var states []string
+	var mu sync.Mutex
+	g.emit = func(_ context.Context, status GatewayHostStatus) {
+		mu.Lock()
+		states = append(states, status.State)
+		mu.Unlock()
+	}
+	return g, &states
+}
+
+func TestOutboundGateway_CircuitBreaker(t *testing.T) {
+	g, states := newTestGateway()
+	now := cst("2024-06-14 10:00:00")
+	g.now = func() time.Time { return now }
+	g.SetHostLimit("push2his.eastmoney.com", 0, 1) // 时钟固定不动，不限速
+	base := &statusRoundTripper{status: http.StatusBadGateway}
+
+	// 熔断拒绝的请求不重试；其他失败照常重试
+	client := resty.New().SetRetryCount(2).SetRetryWaitTime(time.Millisecond)
+	client.SetTransport(base)
+	g.Attach(client, PriorityBackground)
+	client.AddRetryCondition(func(r *resty.Response, err error) bool {
+		return err == nil && r.StatusCode() >= http.StatusInternalServerError
+	})
+	get := func() error {
+		_, err := client.R().Get("http://push2his.eastmoney.com/api/qt/stock/kline/get")
+		return err
+	}
+
+	// 第 5 次连续失败后熔断
+	if err := get(); err != nil || base.calls != 3 {
+		t.Fatalf("expected 3 attempts before opening, got %d, err=%v", base.calls, err)
+	}
+	// 第二次请求的第 3 次尝试已被熔断拒绝
+	if err := get(); !errors.Is(err, ErrCircuitOpen) || base.calls != 5 {
+		t.Fatalf("expected the breaker to open on the 5th failure, got %d calls, err=%v", base.calls, err)
+	}
+	if err := get(); !errors.Is(err, ErrCircuitOpen) || base.calls != 5 {
+		t.Fatalf("expected a fast failure without retries, got %d calls, err=%v", base.calls, err)
+	}
+	status := g.Status()
+	if len(status) != 1 || status[0].State != BreakerOpen || status[0].Rejected != 2 || status[0].RetryAt != now.Add(breakerOpenDuration).UnixMilli() {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	// 到期后放行一个试探请求，失败则熔断时长加倍
+	now = now.Add(breakerOpenDuration)
+	if err := get(); !errors.Is(err, ErrCircuitOpen) || base.calls != 6 {
+		t.Fatalf("expected one probe, got %d calls, err=%v", base.calls, err)
+	}
+	if s := g.Status()[0]; s.State != BreakerOpen || s.RetryAt != now.Add(2*breakerOpenDuration).UnixMilli() {
+		t.Fatalf("expected the breaker to reopen for longer, got %+v", s)
+	}
+
+	// 试探成功后关闭
+	now = now.Add(2 * breakerOpenDuration)
+	base.status = http.StatusOK
+	if err := get(); err != nil || base.calls != 7 {
+		t.Fatalf("expected the probe to succeed, got %d calls, err=%v", base.calls, err)
+	}
+	if s := g.Status()[0]; s.State != BreakerClosed || s.ConsecutiveFailures != 0 || s.Failures != 6 {
+		t.Fatalf("expected the breaker to close, got %+v", s)
+	}
+
+	want := []string{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	if strings.Join(*states, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected events %v, got %v", want, *states)
+	}
+
+	// 手动恢复；本机地址不经过网关
+	base.status = http.StatusBadGateway
+	for i := 0; i < breakerFailureThreshold; i++ {
+		_ = get()
+	}
+	if err := g.ResetBreaker("push2his.eastmoney.com"); err != nil || g.Status()[0].State != BreakerClosed {
+		t.Fatalf("ResetBreaker: %v, %+v", err, g.Status())
+	}
+	if _, err := client.R().Get("http://127.0.0.1:1/"); err != nil || len(g.Status()) != 1 {
+		t.Fatalf("expected loopback requests to bypass the gateway, got %v, %+v", err, g.Status())
+	}
+}
+
+func TestOutboundGateway_InteractiveBeforeBackground(t *testing.T) {
+	g, _ := newTestGateway()
+	g.SetHostLimit("push2.eastmoney.com", 20, 1)
+	base := &statusRoundTripper{status: http.StatusOK}
+	background := g.Transport(base, PriorityBackground)
+	interactive := g.Transport(base, PriorityInteractive)
+
+	send := func(rt http.RoundTripper, ctx context.Context) error {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://push2.eastmoney.com/api/qt/stock/get", nil)
+		_, err := rt.RoundTrip(req)
+		return err
+	}
+	waitQueued := func(n int) {
+		for i := 0; i < 200; i++ {
+			if s := g.Status(); len(s) == 1 && s[0].Waiting == n {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Fatalf("expected %d waiting requests, got %+v", n, g.Status())
+	}
+
+	// 用掉唯一的令牌，之后的请求排队
+	if err := send(background, context.Background()); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+
+	// 排队中取消的请求移出队列
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() { cancelled <- send(background, ctx) }()
+	waitQueued(1)
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the queued request to be cancelled, got %v", err)
+	}
+	waitQueued(0)
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	run := func(name string, rt http.RoundTripper) {
+		defer wg.Done()
+		if err := send(rt, context.Background()); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		mu.Lock()
+		order = append(order, name)
+		mu.Unlock()
+	}
+	wg.Add(3)
+	go run("background-1", background)
+	waitQueued(1)
+	go run("background-2", background)
+	waitQueued(2)
+	go run("interactive", interactive)
+	wg.Wait()
+
+	if strings.Join(order, ",") != "interactive,background-1,background-2" {
+		t.Fatalf("expected the interactive request to go first, got %v", order)
+	}
+	if s := g.Status()[0]; s.Requests != 4 || base.calls != 4 {
+		t.Fatalf("unexpected status %+v (calls %d)", s, base.calls)
+	}
+}
+
+// routeRoundTripper 按路径返回 JSON 响应并记录请求顺序
+type routeRoundTripper struct {
+	mu     sync.Mutex
+	bodies map[string]string
+	paths  []string
+}
+
+func (f *routeRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paths = append(f.paths, r.URL.Path)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     http.StatusText(http.StatusOK),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(f.bodies[r.URL.Path])),
+		Request:    r,
+	}, nil
+}
+
+func TestStockService_BatchSyncYieldsToInteractiveQuotes(t *testing.T) {
+	db := newTestDB(t)
+
+	g, _ := newTestGateway()
+	g.SetHostLimit("push2.eastmoney.com", 20, 1)
+	base := &routeRoundTripper{bodies: map[string]string{
+		"/api/qt/stock/kline/get": `{"data":{"klines":["2024-06-13,10.00,10.20,10.30,9.90,1000","2024-06-14,10.20,10.50,10.60,10.10,1200"]}}`,
+		"/api/qt/ulist.np/get":    `{"data":{"diff":[{"f12":"000001","f13":0,"f14":"平安银行","f2":10.5}]}}`,
+		"/warmup":                 `{}`,
+	}}
+
+	// 与 NewStockService 一样，行情客户端整体为交互优先级；K 线与行情放在同一主机上排队
+	client := resty.New()
+	client.SetTransport(g.Transport(base, PriorityInteractive))
+	p := NewEastMoneyProvider(client, client)
+	p.klineURL = "https://push2.eastmoney.com/api/qt/stock/kline/get"
+	s := NewStockService()
+	s.ctx = nil // 不发送 Wails 事件
+	s.SetDBService(db)
+	s.SetProvider(p)
+
+	waitQueued := func(n int) {
+		for i := 0; i < 200; i++ {
+			if st := g.Status(); len(st) == 1 && st[0].Waiting == n {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Fatalf("expected %d waiting requests, got %+v", n, g.Status())
+	}
+
+	// 用掉唯一的令牌，之后的请求排队
+	if _, err := client.R().Get("https://push2.eastmoney.com/warmup"); err != nil {
+		t.Fatalf("warmup: %v", err)
+	}
+
+	synced := make(chan error, 1)
+	go func() {
+		synced <- s.BatchSyncStockData([]string{"000001"}, "2024-01-01", "2024-12-31", KLineAdjustForward)
+	}()
+	waitQueued(1)
+
+	quoted := make(chan error, 1)
+	go func() {
+		quotes, err := s.GetQuotes([]string{"000001"})
+		if err == nil && quotes["000001"] == nil {
+			err = errors.New("missing quote")
+		}
+		quoted <- err
+	}()
+	waitQueued(2)
+
+	if err := <-quoted; err != nil {
+		t.Fatalf("GetQuotes: %v", err)
+	}
+	if err := <-synced; err != nil {
+		t.Fatalf("BatchSyncStockData: %v", err)
+	}
+	if got := strings.Join(base.paths, ","); got != "/warmup,/api/qt/ulist.np/get,/api/qt/stock/kline/get" {
+		t.Fatalf("expected the interactive quote request to go before the queued batch sync, got %s", got)
+	}
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"errors"
+	"stock-analyzer-wails/models"
+	"sync"
+	"time"
+)
+
+// DefaultQuoteCacheTTL 行情缓存默认有效期：预警（10s）、持仓（30s）与前端请求在此窗口内共享同一份行情
+const DefaultQuoteCacheTTL = 3 * time.Second
+
+// QuoteCacheStats 行情缓存统计，用于调整 TTL
+type QuoteCacheStats struct {
+	TTLMs   int64   `json:"ttlMs"`   // 当前有效期（毫秒）
+	Entries int     `json:"entries"` // 缓存条目数
+	Hits    uint64  `json:"hits"`    // 命中次数（按代码计）
+	Misses  uint64  `json:"misses"`  // 未命中次数（按代码计）
+	Shared  uint64  `json:"shared"`  // 未命中但复用了进行中请求的次数
+	Fetches uint64  `json:"fetches"` // 实际向行情源发起的请求次数
+	HitRate float64 `json:"hitRate"` // 命中率
+}
+
+// 行情类型：不同接口返回的字段不同，按类型分别缓存，互不复用
+const (
+	quoteKindDetail = "detail" // stock/get 单只完整行情（GetStockByCode）
+	quoteKindBatch  = "batch"  // ulist 批量精简行情（GetQuotes）
+)
+
+// errQuoteFetchAborted fetch 异常中止（panic）时，等待同一请求的调用方收到的错误
+var errQuoteFetchAborted = errors.New("获取行情异常中止")
+
+// quoteKey 缓存与进行中请求的 key
+func quoteKey(kind, code string) string {
+	return kind + ":" + code
+}
+
+// quoteEntry 缓存条目
+type quoteEntry struct {
+	stock     *models.StockData
+	fetchedAt time.Time
+}
+
+// quoteCall 进行中的请求，同一代码的并发请求只发起一次
+type quoteCall struct {
+	done  chan struct{}
+	stock *models.StockData
+	err   error
+}
+
+// QuoteCache 短时行情缓存：在 TTL 内复用行情，并对同一代码的并发请求去重（single-flight）。
+// TTL <= 0 时不缓存，但仍会合并并发请求。
+type QuoteCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	entries  map[string]quoteEntry
+	inflight map[string]*quoteCall
+	hits     uint64
+	misses   uint64
+	shared   uint64
+	fetches  uint64
+	now      func() time.Time
+}
+
+// NewQuoteCache 创建行情缓存
+func NewQuoteCache(ttl time.Duration) *QuoteCache {
+	return &QuoteCache{
+		ttl:      ttl,
+		entries:  make(map[string]quoteEntry),
+		inflight: make(map[string]*quoteCall),
+		now:      time.Now,
+	}
+}
+
+// SetTTL 调整缓存有效期，已缓存的条目按新的有效期判断是否过期
+func (c *QuoteCache) SetTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
+}
+
+// TTL 返回当前缓存有效期
+func (c *QuoteCache) TTL() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ttl
+}
+
+// Clear 清空缓存（切换行情源时调用），不影响统计
+func (c *QuoteCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]quoteEntry)
+}
+
+// Stats 返回缓存统计
+func (c *QuoteCache) Stats() QuoteCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := QuoteCacheStats{
+		TTLMs:   c.ttl.Milliseconds(),
+		Entries: len(c.entries),
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Shared:  c.shared,
+		Fetches: c.fetches,
+	}
+	if total := c.hits + c.misses; total > 0 {
+		stats.HitRate = float64(c.hits) / float64(total)
+	}
+	return stats
+}
+
+// Get 批量读取 kind 类型的行情：未过期的直接返回，其余中已有进行中请求的等待其结果，剩下的合并为一次 fetch。
+// 返回的行情为副本，FetchedAt 为实际从行情源获取的时间；fetch 未返回的代码不出现在结果中。
+// codes 需已去重、去空白；c 为 nil 时直接调用 fetch。
+func (c *QuoteCache) Get(kind string, codes []string, fetch func(codes []string) (map[string]*models.StockData, error)) (map[string]*models.StockData, error) {
+	if c == nil {
+		return fetch(codes)
+	}
+
+	result := make(map[string]*models.StockData, len(codes))
+	waits := make(map[string]*quoteCall)
+	owned := make(map[string]*quoteCall)
+	missing := make([]string, 0, len(codes))
+
+	c.mu.Lock()
+	now := c.now()
+	for _, code := range codes {
+		key := quoteKey(kind, code)
+		if e, ok := c.entries[key]; ok && c.ttl > 0 && now.Sub(e.fetchedAt) < c.ttl {
+			c.hits++
+			result[code] = copyQuote(e.stock)
+			continue
+		}
+		c.misses++
+		if call, ok := c.inflight[key]; ok {
+			c.shared++
+			waits[code] = call
+			continue
+		}
+		call := &quoteCall{done: make(chan struct{})}
+		c.inflight[key] = call
+		owned[code] = call
+		missing = append(missing, code)
+	}
+	if len(missing) > 0 {
+		c.fetches++
+	}
+	c.mu.Unlock()
+
+	var fetchErr error
+	if len(missing) > 0 {
+		fetchErr = c.fetchOwned(kind, missing, owned, fetch)
+		for code, call := range owned {
+			if call.stock != nil {
+				result[code] = copyQuote(call.stock)
+			}
+		}
+	}
+
+	for code, call := range waits {
+		<-call.done
+		if call.err != nil {
+			fetchErr = call.err
+			continue
+		}
+		if call.stock != nil {
+			result[code] = copyQuote(call.stock)
+		}
+	}
+
+	// 部分成功时返回已有结果，全部失败才返回错误
+	if fetchErr != nil && len(result) == 0 {
+		return nil, fetchErr
+	}
+	return result, nil
+}
+
+// fetchOwned 为本次负责的代码调用 fetch，写入缓存并结束这些进行中的请求。
+// 结束请求放在 defer 中：fetch panic 时等待方收到 errQuoteFetchAborted 而不是永远阻塞，panic 继续向上传递。
+func (c *QuoteCache) fetchOwned(kind string, missing []string, owned map[string]*quoteCall, fetch func(codes []string) (map[string]*models.StockData, error)) error {
+	var quotes map[string]*models.StockData
+	err := errQuoteFetchAborted
+	defer func() {
+		fetchedAt := c.now()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for _, code := range missing {
+			call := owned[code]
+			if err != nil {
+				call.err = err
+			} else if stock := quotes[code]; stock != nil {
+				stock = copyQuote(stock)
+				stock.FetchedAt = fetchedAt.UnixMilli()
+				call.stock = stock
+				c.entries[quoteKey(kind, code)] = quoteEntry{stock: stock, fetchedAt: fetchedAt}
+			}
+			delete(c.inflight, quoteKey(kind, code))
+			close(call.done)
+		}
+	}()
+
+	quotes, err = fetch(missing)
+	return err
+}
+
+// copyQuote 复制行情，避免调用方修改缓存中的数据
+func copyQuote(stock *models.StockData) *models.StockData {
+	c := *stock
+	return &c
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"errors"
+	"stock-analyzer-wails/models"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEastMoneyProvider_GetQuotes_Replay(t *testing.T) {
+	t.Setenv(EnvHTTPMode, HTTPModeReplay)
+	t.Setenv(EnvFixtureDir, "testdata/fixtures")
+
+	s := NewStockService()
+	// 600519.SH 与 600519 为同一证券，只请求一次；SH000001 为指数，000001 为平安银行
+	quotes, err := s.GetQuotes([]string{"600519", "000001", "SH000001", "600519.SH", " 600519 "})
+	if err != nil {
+		t.Fatalf("GetQuotes: %v", err)
+	}
+	if len(quotes) != 4 {
+		t.Fatalf("expected 4 requested codes to be mapped, got %d: %v", len(quotes), quotes)
+	}
+	if q := quotes["600519"]; q == nil || q.Name != "贵州茅台" || q.Price != 1520 || q.Code != "600519" {
+		t.Fatalf("unexpected 600519 quote: %+v", q)
+	}
+	if q := quotes["600519.SH"]; q == nil || q.Name != "贵州茅台" {
+		t.Fatalf("aliases of the same instrument should share one quote")
+	}
+	if q := quotes["000001"]; q == nil || q.Name != "平安银行" {
+		t.Fatalf("bare 000001 should map to the stock, got %+v", q)
+	}
+	if q := quotes["SH000001"]; q == nil || q.Name != "上证指数" || q.Code != "SH000001" || q.PE != 0 {
+		t.Fatalf("unexpected index quote: %+v", q)
+	}
+}
+
+func TestQuoteCache_TTLAndSingleFlight(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local)
+	c := NewQuoteCache(3 * time.Second)
+	c.now = func() time.Time { return now }
+
+	var calls int32
+	release := make(chan struct{})
+	fetch := func(codes []string) (map[string]*models.StockData, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		quotes := make(map[string]*models.StockData, len(codes))
+		for _, code := range codes {
+			quotes[code] = &models.StockData{Code: code, Price: 10}
+		}
+		return quotes, nil
+	}
+
+	// 并发请求同一代码只发起一次 fetch
+	var wg sync.WaitGroup
+	results := make([]map[string]*models.StockData, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = c.Get(quoteKindBatch, []string{"600519"}, fetch)
+		}(i)
+	}
+	for c.Stats().Misses < 5 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("expected 1 fetch for concurrent requests, got %d", calls)
+	}
+	for _, r := range results {
+		if q := r["600519"]; q == nil || q.FetchedAt != now.UnixMilli() {
+			t.Fatalf("expected shared quote with fetch timestamp, got %+v", q)
+		}
+	}
+
+	// TTL 内命中缓存，且返回副本
+	results[0]["600519"].Price = 99
+	now = now.Add(2 * time.Second)
+	got, _ := c.Get(quoteKindBatch, []string{"600519"}, fetch)
+	if calls != 1 || got["600519"].Price != 10 {
+		t.Fatalf("expected cached copy within TTL, calls=%d quote=%+v", calls, got["600519"])
+	}
+
+	// 过期后重新获取
+	now = now.Add(2 * time.Second)
+	if _, err := c.Get(quoteKindBatch, []string{"600519"}, fetch); err != nil || calls != 2 {
+		t.Fatalf("expected refetch after TTL, calls=%d err=%v", calls, err)
+	}
+
+	stats := c.Stats()
+	if stats.Hits != 1 || stats.Misses != 6 || stats.Shared != 4 || stats.Fetches != 2 || stats.Entries != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestQuoteCache_KindsAreCachedSeparately(t *testing.T) {
+	c := NewQuoteCache(time.Minute)
+	fetchWith := func(name string) func(codes []string) (map[string]*models.StockData, error) {
+		return func(codes []string) (map[string]*models.StockData, error) {
+			return map[string]*models.StockData{codes[0]: {Code: codes[0], Name: name}}, nil
+		}
+	}
+
+	// 批量精简行情先入缓存，单只完整行情不能复用它
+	if _, err := c.Get(quoteKindBatch, []string{"600519"}, fetchWith("batch")); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	detail, err := c.Get(quoteKindDetail, []string{"600519"}, fetchWith("detail"))
+	if err != nil || detail["600519"].Name != "detail" {
+		t.Fatalf("expected a separate detail fetch, got %+v, err=%v", detail["600519"], err)
+	}
+	batch, _ := c.Get(quoteKindBatch, []string{"600519"}, fetchWith("refetched"))
+	if batch["600519"].Name != "batch" {
+		t.Fatalf("expected the cached batch quote, got %+v", batch["600519"])
+	}
+}
+
+func TestQuoteCache_FetchPanicReleasesWaiters(t *testing.T) {
+	c := NewQuoteCache(time.Minute)
+	release := make(chan struct{})
+	panicky := func(codes []string) (map[string]*models.StockData, error) {
+		<-release
+		panic("boom")
+	}
+
+	panicked := make(chan interface{}, 1)
+	go func() {
+		defer func() { panicked <- recover() }()
+		c.Get(quoteKindBatch, []string{"600519"}, panicky)
+	}()
+	for c.Stats().Fetches < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// 等待同一请求的调用方收到错误，而不是永远阻塞
+	waited := make(chan error, 1)
+	go func() {
+		_, err := c.Get(quoteKindBatch, []string{"600519"}, panicky)
+		waited <- err
+	}()
+	for c.Stats().Shared < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	if r := <-panicked; r != "boom" {
+		t.Fatalf("expected the panic to propagate to the fetching caller, got %v", r)
+	}
+	select {
+	case err := <-waited:
+		if !errors.Is(err, errQuoteFetchAborted) {
+			t.Fatalf("expected errQuoteFetchAborted, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("waiter blocked after the fetch panicked")
+	}
+
+	// 之后的请求重新获取
+	got, err := c.Get(quoteKindBatch, []string{"600519"}, func(codes []string) (map[string]*models.StockData, error) {
+		return map[string]*models.StockData{"600519": {Code: "600519", Price: 10}}, nil
+	})
+	if err != nil || got["600519"] == nil {
+		t.Fatalf("expected a fresh fetch after the panic, got %v, err=%v", got, err)
+	}
+}
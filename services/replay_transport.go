@@ -0,0 +1,287 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"stock-analyzer-wails/internal/logger"
+	"strings"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+)
+
+// HTTP 流量模式：
+// - live（默认）：直连行情接口
+// - record：直连并把响应（含 SSE 帧）写入夹具目录
+// - replay：只从夹具目录回放，不发起任何网络请求
+const (
+	HTTPModeLive   = "live"
+	HTTPModeRecord = "record"
+	HTTPModeReplay = "replay"
+)
+
+// 环境变量：用于在不改代码的情况下让整个应用/测试离线运行
+const (
+	EnvHTTPMode   = "STOCK_ANALYZER_HTTP_MODE"
+	EnvFixtureDir = "STOCK_ANALYZER_FIXTURE_DIR"
+)
+
+// httpFixture 单个请求的录制结果
+type httpFixture struct {
+	Note   string      `json:"note,omitempty"` // 说明（如手写的合成夹具），不参与匹配
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// ReplayTransport 录制/回放 http.RoundTripper。
+//
+// 夹具按 “方法 + 规范化 URL（查询参数排序）” 匹配：
+// - 录制时写入 {dir}/{host}/{sha1}.json，同一请求多次录制以最后一次为准；
+// - 回放时递归加载目录下所有 *.json，文件名不参与匹配，便于手写夹具。
+// SSE 响应体按原始文本保存，回放时逐帧读出后 EOF，由调用方自行重连。
+type ReplayTransport struct {
+	mode string
+	dir  string
+	base http.RoundTripper
+
+	mu       sync.RWMutex
+	fixtures map[string]*httpFixture
+}
+
+// NewReplayTransport 创建录制/回放 Transport；base 为空时使用 http.DefaultTransport
+func NewReplayTransport(mode, dir string, base http.RoundTripper) (*ReplayTransport, error) {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &ReplayTransport{
+		mode:     mode,
+		dir:      dir,
+		base:     base,
+		fixtures: make(map[string]*httpFixture),
+	}
+
+	switch mode {
+	case HTTPModeRecord:
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("创建夹具目录失败: %w", err)
+		}
+	case HTTPModeReplay:
+		if err := t.loadFixtures(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("不支持的 HTTP 模式: %s", mode)
+	}
+	return t, nil
+}
+
+// fixtureKey 生成请求匹配键：方法 + 规范化 URL
+func fixtureKey(method, rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return strings.ToUpper(method) + " " + rawURL
+	}
+	// url.Values.Encode 会按 key 排序，保证参数顺序不影响匹配
+	u.RawQuery = u.Query().Encode()
+	u.Fragment = ""
+	return strings.ToUpper(method) + " " + u.String()
+}
+
+func (t *ReplayTransport) loadFixtures() error {
+	if _, err := os.Stat(t.dir); errors.Is(err, fs.ErrNotExist) {
+		// 目录不存在视为没有夹具：所有请求都会返回“夹具不存在”，而不是回退到网络
+		return nil
+	}
+	return filepath.WalkDir(t.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("读取夹具目录失败: %w", err)
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("读取夹具 %s 失败: %w", path, err)
+		}
+		var fx httpFixture
+		if err := json.Unmarshal(b, &fx); err != nil {
+			return fmt.Errorf("解析夹具 %s 失败: %w", path, err)
+		}
+		if fx.Method == "" {
+			fx.Method = http.MethodGet
+		}
+		if fx.Status == 0 {
+			fx.Status = http.StatusOK
+		}
+		t.fixtures[fixtureKey(fx.Method, fx.URL)] = &fx
+		return nil
+	})
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.mode == HTTPModeReplay {
+		return t.replay(req)
+	}
+	return t.record(req)
+}
+
+func (t *ReplayTransport) replay(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	key := fixtureKey(req.Method, req.URL.String())
+	t.mu.RLock()
+	fx := t.fixtures[key]
+	t.mu.RUnlock()
+	if fx == nil {
+		return nil, fmt.Errorf("回放夹具不存在: %s", key)
+	}
+
+	header := fx.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fx.Status, http.StatusText(fx.Status)),
+		StatusCode:    fx.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(fx.Body)),
+		ContentLength: int64(len(fx.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (t *ReplayTransport) record(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	fx := &httpFixture{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Status: resp.StatusCode,
+		Header: resp.Header.Clone(),
+	}
+	// 边读边录：SSE 长连接在 Close（取消/EOF）时落盘已收到的帧
+	resp.Body = &recordingBody{
+		ReadCloser: resp.Body,
+		onClose: func(body []byte) {
+			fx.Body = string(body)
+			t.save(fx)
+		},
+	}
+	return resp, nil
+}
+
+func (t *ReplayTransport) save(fx *httpFixture) {
+	key := fixtureKey(fx.Method, fx.URL)
+	sum := sha1.Sum([]byte(key))
+	host := "unknown"
+	if u, err := url.Parse(fx.URL); err == nil && u.Host != "" {
+		host = strings.ReplaceAll(u.Host, ":", "_")
+	}
+	path := filepath.Join(t.dir, host, hex.EncodeToString(sum[:])[:16]+".json")
+
+	b, err := json.MarshalIndent(fx, "", "  ")
+	if err == nil {
+		t.mu.Lock()
+		t.fixtures[key] = fx
+		if err = os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+			err = os.WriteFile(path, b, 0o644)
+		}
+		t.mu.Unlock()
+	}
+	if err != nil {
+		logger.Warn("写入录制夹具失败",
+			zap.String("module", "services.replay"),
+			zap.String("op", "save"),
+			zap.String("url", fx.URL),
+			zap.String("path", path),
+			zap.Error(err),
+		)
+	}
+}
+
+// recordingBody 透传读取并缓存响应体，关闭时回调一次
+type recordingBody struct {
+	io.ReadCloser
+	buf     bytes.Buffer
+	once    sync.Once
+	onClose func(body []byte)
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.onClose(b.buf.Bytes()) })
+	return err
+}
+
+// httpModeFromEnv 读取 HTTP 模式与夹具目录，未设置目录时使用应用数据目录下的 fixtures
+func httpModeFromEnv() (mode, dir string) {
+	mode = strings.ToLower(strings.TrimSpace(os.Getenv(EnvHTTPMode)))
+	if mode == "" {
+		mode = HTTPModeLive
+	}
+	dir = strings.TrimSpace(os.Getenv(EnvFixtureDir))
+	if dir == "" && mode != HTTPModeLive {
+		dir = filepath.Join(GetAppDataDir(), "fixtures")
+	}
+	return mode, dir
+}
+
+// applyHTTPMode 按环境变量为 resty 客户端挂载录制/回放 Transport。
+// 回放模式下关闭重试：夹具缺失是确定性错误，重试只会拖慢测试。
+func applyHTTPMode(client *resty.Client) *resty.Client {
+	mode, dir := httpModeFromEnv()
+	if mode == HTTPModeLive {
+		return client
+	}
+
+	t, err := NewReplayTransport(mode, dir, nil)
+	if err != nil {
+		logger.Error("初始化录制/回放 Transport 失败",
+			zap.String("module", "services.replay"),
+			zap.String("op", "applyHTTPMode"),
+			zap.String("mode", mode),
+			zap.String("dir", dir),
+			zap.Error(err),
+		)
+		if mode != HTTPModeReplay {
+			return client
+		}
+		// 回放模式绝不回退到网络：使用空夹具集，所有请求确定性失败
+		t = &ReplayTransport{mode: HTTPModeReplay, dir: dir, fixtures: make(map[string]*httpFixture)}
+	}
+
+	client.SetTransport(t)
+	if mode == HTTPModeReplay {
+		client.SetRetryCount(0)
+	}
+	return client
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// writeFixture 在夹具目录写入一个手工夹具
+func writeFixture(t *testing.T, dir, name string, fx httpFixture) {
+	t.Helper()
+	b, err := json.Marshal(fx)
+	if err != nil {
+		t.Fatalf("marshal fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), b, 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+}
+
+func TestReplayTransport_RecordThenReplay(t *testing.T) {
+	hits := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"data":{"f43":171050,"q":"`+r.URL.Query().Get("secid")+`"}}`)
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	recorder, err := NewReplayTransport(HTTPModeRecord, dir, nil)
+	if err != nil {
+		t.Fatalf("NewReplayTransport(record): %v", err)
+	}
+	client := resty.New().SetTransport(recorder)
+	resp, err := client.R().Get(ts.URL + "/api/qt/stock/get?secid=1.600519&fields=f43")
+	if err != nil {
+		t.Fatalf("record request: %v", err)
+	}
+	recorded := resp.String()
+	ts.Close()
+
+	player, err := NewReplayTransport(HTTPModeReplay, dir, roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		t.Fatalf("replay must not reach the network: %s", r.URL)
+		return nil, nil
+	}))
+	if err != nil {
+		t.Fatalf("NewReplayTransport(replay): %v", err)
+	}
+	client = resty.New().SetTransport(player)
+
+	// 参数顺序不同也应命中同一夹具
+	resp, err = client.R().Get(ts.URL + "/api/qt/stock/get?fields=f43&secid=1.600519")
+	if err != nil {
+		t.Fatalf("replay request: %v", err)
+	}
+	if resp.String() != recorded || resp.StatusCode() != http.StatusOK {
+		t.Fatalf("replayed %d %q, want 200 %q", resp.StatusCode(), resp.String(), recorded)
+	}
+	if hits != 1 {
+		t.Fatalf("expected exactly 1 live hit, got %d", hits)
+	}
+
+	if _, err := client.R().Get(ts.URL + "/api/qt/stock/get?secid=0.000001"); err == nil || !strings.Contains(err.Error(), "回放夹具不存在") {
+		t.Fatalf("expected missing fixture error, got %v", err)
+	}
+}
+
+func TestApplyHTTPMode_ReplayMissingDir(t *testing.T) {
+	t.Setenv(EnvHTTPMode, HTTPModeReplay)
+	t.Setenv(EnvFixtureDir, filepath.Join(t.TempDir(), "missing"))
+
+	s := NewStockService()
+	if _, err := s.GetStockByCode("600519"); err == nil || !strings.Contains(err.Error(), "回放夹具不存在") {
+		t.Fatalf("expected replay miss instead of network access, got %v", err)
+	}
+}
@@ -0,0 +1,518 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"stock-analyzer-wails/internal/logger"
+	"stock-analyzer-wails/internal/parquet"
+	"stock-analyzer-wails/models"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 导出文件格式
+const (
+	ResearchFormatCSV     = "csv"
+	ResearchFormatParquet = "parquet"
+)
+
+// 可导出的数据集
+const (
+	ResearchDatasetKLine     = "kline"
+	ResearchDatasetMoneyFlow = "money_flow"
+	ResearchDatasetSignals   = "signals"
+)
+
+// ResearchExportProgressEvent 导出进度事件名（前端监听）
+const ResearchExportProgressEvent = "researchExportProgress"
+
+// researchExportChunk 每批读取与写入的行数（Parquet 中每批为一个行组）
+const researchExportChunk = 5000
+
+// ResearchExportRequest 研究数据导出参数
+type ResearchExportRequest struct {
+	Codes     []string `json:"codes"`     // 股票代码，为空表示全部
+	StartDate string   `json:"startDate"` // YYYY-MM-DD，为空表示不限
+	EndDate   string   `json:"endDate"`   // YYYY-MM-DD，为空表示不限
+	Datasets  []string `json:"datasets"`  // kline / money_flow / signals，为空表示全部
+	Format    string   `json:"format"`    // csv / parquet，默认 csv
+	Period    string   `json:"period"`    // K 线周期，默认日线
+	Adjust    string   `json:"adjust"`    // K 线复权方式，为空表示不限
+	OutputDir string   `json:"outputDir"` // 输出目录，为空时写入数据目录下的 exports
+}
+
+// ResearchExportFile 一个导出文件
+type ResearchExportFile struct {
+	Dataset string `json:"dataset"`
+	Path    string `json:"path"`
+	Rows    int64  `json:"rows"`
+	Size    int64  `json:"size"`
+}
+
+// ResearchExportResult 导出结果
+type ResearchExportResult struct {
+	Dir      string               `json:"dir"`
+	Format   string               `json:"format"`
+	Files    []ResearchExportFile `json:"files"`
+	Duration int                  `json:"duration"` // 耗时（秒）
+}
+
+// ResearchExportProgress 导出进度
+type ResearchExportProgress struct {
+	Dataset   string `json:"dataset"`
+	Rows      int64  `json:"rows"`      // 当前数据集已写入行数
+	TotalRows int64  `json:"totalRows"` // 当前数据集总行数
+	Done      bool   `json:"done"`      // 全部数据集已完成
+}
+
+// researchDataset 一个数据集的列定义与分批读取方式
+type researchDataset struct {
+	name    string
+	file    string
+	columns []parquet.Column
+	// query 返回带过滤条件的查询（不含排序和游标）
+	query func(db *gorm.DB) *gorm.DB
+	// next 读取游标之后的一批数据，返回行与新游标；没有更多数据时返回空
+	next func(q *gorm.DB, cursor []interface{}, limit int) ([][]interface{}, []interface{}, error)
+}
+
+// researchSink 导出文件写入器
+type researchSink interface {
+	WriteRows(rows [][]interface{}) error
+	Close() error
+}
+
+// ResearchExportService 将缓存的 K 线、资金流与策略信号导出为 CSV / Parquet
+type ResearchExportService struct {
+	dbService *DBService
+	ctx       context.Context
+	emit      func(ctx context.Context, p ResearchExportProgress)
+	chunk     int // 每批行数
+
+	mu      sync.Mutex
+	running bool
+	cancel  bool
+}
+
+// NewResearchExportService 创建研究数据导出服务
+func NewResearchExportService(db *DBService) *ResearchExportService {
+	return &ResearchExportService{
+		dbService: db,
+		chunk:     researchExportChunk,
+		emit: func(ctx context.Context, p ResearchExportProgress) {
+			runtime.EventsEmit(ctx, ResearchExportProgressEvent, p)
+		},
+	}
+}
+
+// SetContext 设置上下文（用于发送事件）
+func (s *ResearchExportService) SetContext(ctx context.Context) {
+	s.ctx = ctx
+}
+
+// Cancel 取消正在进行的导出：已导出完的数据集文件保留，正在写入的文件删除（未写完的 Parquet 文件没有 footer，无法读取）
+func (s *ResearchExportService) Cancel() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		s.cancel = true
+	}
+}
+
+func (s *ResearchExportService) cancelled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cancel
+}
+
+func (s *ResearchExportService) emitProgress(p ResearchExportProgress) {
+	if s.ctx == nil {
+		return
+	}
+	s.emit(s.ctx, p)
+}
+
+// Export 按请求导出数据。每个数据集分批读取并流式写入，内存占用与总数据量无关。
+func (s *ResearchExportService) Export(req ResearchExportRequest) (*ResearchExportResult, error) {
+	datasets, err := s.resolveDatasets(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("导出任务已在运行中")
+	}
+	s.running, s.cancel = true, false
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	dir := req.OutputDir
+	if dir == "" {
+		dir = filepath.Join(GetAppDataDir(), "exports", "research-"+time.Now().Format("20060102-150405"))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建导出目录失败: %w", err)
+	}
+
+	start := time.Now()
+	result := &ResearchExportResult{Dir: dir, Format: req.Format}
+	for _, ds := range datasets {
+		file, err := s.exportDataset(ds, dir, req.Format)
+		if err != nil {
+			logger.Error("研究数据导出失败",
+				zap.String("module", "services.research_export"),
+				zap.String("op", "Export"),
+				zap.String("dataset", ds.name),
+				zap.Error(err),
+			)
+			return nil, fmt.Errorf("导出 %s 失败: %w", ds.name, err)
+		}
+		result.Files = append(result.Files, *file)
+	}
+	result.Duration = int(time.Since(start).Seconds())
+	s.emitProgress(ResearchExportProgress{Done: true})
+
+	logger.Info("研究数据导出完成",
+		zap.String("module", "services.research_export"),
+		zap.String("op", "Export"),
+		zap.String("dir", dir),
+		zap.String("format", req.Format),
+		zap.Int("codes", len(req.Codes)),
+		zap.Duration("elapsed", time.Since(start)),
+	)
+	return result, nil
+}
+
+// resolveDatasets 校验并补全请求参数，返回要导出的数据集
+func (s *ResearchExportService) resolveDatasets(req *ResearchExportRequest) ([]researchDataset, error) {
+	req.Format = strings.ToLower(strings.TrimSpace(req.Format))
+	if req.Format == "" {
+		req.Format = ResearchFormatCSV
+	}
+	if req.Format != ResearchFormatCSV && req.Format != ResearchFormatParquet {
+		return nil, fmt.Errorf("不支持的导出格式: %s", req.Format)
+	}
+	if req.StartDate != "" && req.EndDate != "" && req.StartDate > req.EndDate {
+		return nil, fmt.Errorf("开始日期不能晚于结束日期")
+	}
+	period, err := NormalizeKLinePeriod(req.Period)
+	if err != nil {
+		return nil, err
+	}
+	req.Period = klineStorePeriod(period)
+	if req.Adjust != "" {
+		if req.Adjust, err = NormalizeKLineAdjust(req.Adjust); err != nil {
+			return nil, err
+		}
+	}
+	codes := req.Codes[:0:0]
+	for _, code := range req.Codes {
+		if code = strings.TrimSpace(code); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	req.Codes = codes
+
+	names := req.Datasets
+	if len(names) == 0 {
+		names = []string{ResearchDatasetKLine, ResearchDatasetMoneyFlow, ResearchDatasetSignals}
+	}
+	var datasets []researchDataset
+	for _, name := range names {
+		switch name {
+		case ResearchDatasetKLine:
+			datasets = append(datasets, klineDataset(*req))
+		case ResearchDatasetMoneyFlow:
+			datasets = append(datasets, moneyFlowDataset(*req))
+		case ResearchDatasetSignals:
+			datasets = append(datasets, signalsDataset(*req))
+		default:
+			return nil, fmt.Errorf("未知的数据集: %s", name)
+		}
+	}
+	return datasets, nil
+}
+
+// exportDataset 分批读取一个数据集并写入文件，取消或失败时删除未写完的文件
+func (s *ResearchExportService) exportDataset(ds researchDataset, dir, format string) (*ResearchExportFile, error) {
+	db := s.dbService.GetDB()
+	var total int64
+	if err := ds.query(db).Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("统计数据量失败: %w", err)
+	}
+
+	path := filepath.Join(dir, ds.file+"."+format)
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("创建导出文件失败: %w", err)
+	}
+	complete := false
+	defer func() {
+		f.Close()
+		if !complete {
+			os.Remove(path)
+		}
+	}()
+	buf := bufio.NewWriterSize(f, 1<<20)
+
+	sink, err := newResearchSink(buf, format, ds.columns)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows int64
+	var cursor []interface{}
+	for {
+		if s.cancelled() {
+			return nil, fmt.Errorf("导出已取消")
+		}
+		batch, next, err := ds.next(ds.query(db), cursor, s.chunk)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		if err := sink.WriteRows(batch); err != nil {
+			return nil, fmt.Errorf("写入导出文件失败: %w", err)
+		}
+		rows += int64(len(batch))
+		cursor = next
+		s.emitProgress(ResearchExportProgress{Dataset: ds.name, Rows: rows, TotalRows: total})
+	}
+
+	if err := sink.Close(); err != nil {
+		return nil, fmt.Errorf("写入导出文件失败: %w", err)
+	}
+	if err := buf.Flush(); err != nil {
+		return nil, fmt.Errorf("写入导出文件失败: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("读取导出文件失败: %w", err)
+	}
+	complete = true
+	return &ResearchExportFile{Dataset: ds.name, Path: path, Rows: rows, Size: info.Size()}, nil
+}
+
+// filterCodesAndDates 按代码与日期范围过滤；dateCol 为日期列名
+func filterCodesAndDates(q *gorm.DB, req ResearchExportRequest, dateCol string) *gorm.DB {
+	if len(req.Codes) > 0 {
+		q = q.Where("code IN ?", req.Codes)
+	}
+	if req.StartDate != "" {
+		q = q.Where(dateCol+" >= ?", req.StartDate)
+	}
+	if req.EndDate != "" {
+		// 分钟线日期带时间，结束日当天的数据也应包含
+		q = q.Where(dateCol+" <= ?", req.EndDate+" 23:59")
+	}
+	return q
+}
+
+// klineDataset K 线（kline_bars），按 (code, date, adjust) 游标分页（不限复权方式时同一日期可能有多行）
+func klineDataset(req ResearchExportRequest) researchDataset {
+	return researchDataset{
+		name: ResearchDatasetKLine,
+		file: "kline_" + req.Period,
+		columns: []parquet.Column{
+			{Name: "code", Type: parquet.String},
+			{Name: "date", Type: parquet.String},
+			{Name: "period", Type: parquet.String},
+			{Name: "adjust", Type: parquet.String},
+			{Name: "open", Type: parquet.Double},
+			{Name: "high", Type: parquet.Double},
+			{Name: "low", Type: parquet.Double},
+			{Name: "close", Type: parquet.Double},
+			{Name: "volume", Type: parquet.Int64},
+		},
+		query: func(db *gorm.DB) *gorm.DB {
+			q := db.Model(&models.KLineBarEntity{}).Where("period = ?", req.Period)
+			if req.Adjust != "" {
+				q = q.Where("adjust = ?", req.Adjust)
+			}
+			return filterCodesAndDates(q, req, "date")
+		},
+		next: func(q *gorm.DB, cursor []interface{}, limit int) ([][]interface{}, []interface{}, error) {
+			if cursor != nil {
+				q = q.Where("(code > ? OR (code = ? AND (date > ? OR (date = ? AND adjust > ?))))",
+					cursor[0], cursor[0], cursor[1], cursor[1], cursor[2])
+			}
+			var bars []models.KLineBarEntity
+			if err := q.Order("code ASC, date ASC, adjust ASC").Limit(limit).Find(&bars).Error; err != nil {
+				return nil, nil, fmt.Errorf("查询 K 线数据失败: %w", err)
+			}
+			if len(bars) == 0 {
+				return nil, nil, nil
+			}
+			rows := make([][]interface{}, len(bars))
+			for i, b := range bars {
+				rows[i] = []interface{}{b.Code, b.Date, b.Period, b.Adjust, b.Open, b.High, b.Low, b.Close, b.Volume}
+			}
+			last := bars[len(bars)-1]
+			return rows, []interface{}{last.Code, last.Date, last.Adjust}, nil
+		},
+	}
+}
+
+// moneyFlowDataset 资金流历史（stock_money_flow_hist），按 (code, trade_date) 游标分页
+func moneyFlowDataset(req ResearchExportRequest) researchDataset {
+	return researchDataset{
+		name: ResearchDatasetMoneyFlow,
+		file: "money_flow",
+		columns: []parquet.Column{
+			{Name: "code", Type: parquet.String},
+			{Name: "trade_date", Type: parquet.String},
+			{Name: "main_net", Type: parquet.Double},
+			{Name: "super_net", Type: parquet.Double},
+			{Name: "big_net", Type: parquet.Double},
+			{Name: "mid_net", Type: parquet.Double},
+			{Name: "small_net", Type: parquet.Double},
+			{Name: "close_price", Type: parquet.Double},
+			{Name: "chg_pct", Type: parquet.Double},
+			{Name: "amount", Type: parquet.Double},
+			{Name: "main_rate", Type: parquet.Double},
+			{Name: "turnover", Type: parquet.Double},
+		},
+		query: func(db *gorm.DB) *gorm.DB {
+			return filterCodesAndDates(db.Model(&models.StockMoneyFlowHistEntity{}), req, "trade_date")
+		},
+		next: func(q *gorm.DB, cursor []interface{}, limit int) ([][]interface{}, []interface{}, error) {
+			if cursor != nil {
+				q = q.Where("(code > ? OR (code = ? AND trade_date > ?))", cursor[0], cursor[0], cursor[1])
+			}
+			var flows []models.StockMoneyFlowHistEntity
+			if err := q.Order("code ASC, trade_date ASC").Limit(limit).Find(&flows).Error; err != nil {
+				return nil, nil, fmt.Errorf("查询资金流数据失败: %w", err)
+			}
+			if len(flows) == 0 {
+				return nil, nil, nil
+			}
+			rows := make([][]interface{}, len(flows))
+			for i, f := range flows {
+				rows[i] = []interface{}{f.Code, f.TradeDate, f.MainNet, f.SuperNet, f.BigNet, f.MidNet, f.SmallNet, f.ClosePrice, f.ChgPct, f.Amount, f.MainRate, f.Turnover}
+			}
+			last := flows[len(flows)-1]
+			return rows, []interface{}{last.Code, last.TradeDate}, nil
+		},
+	}
+}
+
+// signalsDataset 策略信号（stock_strategy_signals），按 id 游标分页
+func signalsDataset(req ResearchExportRequest) researchDataset {
+	return researchDataset{
+		name: ResearchDatasetSignals,
+		file: "signals",
+		columns: []parquet.Column{
+			{Name: "id", Type: parquet.Int64},
+			{Name: "code", Type: parquet.String},
+			{Name: "trade_date", Type: parquet.String},
+			{Name: "signal_type", Type: parquet.String},
+			{Name: "strategy_name", Type: parquet.String},
+			{Name: "score", Type: parquet.Double},
+			{Name: "details", Type: parquet.String},
+			{Name: "ai_score", Type: parquet.Int64},
+			{Name: "ai_reason", Type: parquet.String},
+			{Name: "created_at", Type: parquet.String},
+		},
+		query: func(db *gorm.DB) *gorm.DB {
+			return filterCodesAndDates(db.Model(&models.StockStrategySignalEntity{}), req, "trade_date")
+		},
+		next: func(q *gorm.DB, cursor []interface{}, limit int) ([][]interface{}, []interface{}, error) {
+			if cursor != nil {
+				q = q.Where("id > ?", cursor[0])
+			}
+			var signals []models.StockStrategySignalEntity
+			if err := q.Order("id ASC").Limit(limit).Find(&signals).Error; err != nil {
+				return nil, nil, fmt.Errorf("查询策略信号失败: %w", err)
+			}
+			if len(signals) == 0 {
+				return nil, nil, nil
+			}
+			rows := make([][]interface{}, len(signals))
+			for i, sig := range signals {
+				rows[i] = []interface{}{int64(sig.ID), sig.Code, sig.TradeDate, sig.SignalType, sig.StrategyName, sig.Score,
+					sig.Details, int64(sig.AIScore), sig.AIReason, sig.CreatedAt.Format("2006-01-02 15:04:05")}
+			}
+			return rows, []interface{}{signals[len(signals)-1].ID}, nil
+		},
+	}
+}
+
+// newResearchSink 按格式创建写入器
+func newResearchSink(w io.Writer, format string, columns []parquet.Column) (researchSink, error) {
+	if format == ResearchFormatParquet {
+		pw, err := parquet.NewWriter(w, columns, "stock-analyzer")
+		if err != nil {
+			return nil, err
+		}
+		return parquetSink{pw}, nil
+	}
+
+	cw := csv.NewWriter(w)
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.Name
+	}
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+	return &csvSink{w: cw, record: make([]string, len(columns))}, nil
+}
+
+type parquetSink struct {
+	w *parquet.Writer
+}
+
+func (s parquetSink) WriteRows(rows [][]interface{}) error { return s.w.WriteRowGroup(rows) }
+func (s parquetSink) Close() error                         { return s.w.Close() }
+
+type csvSink struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (s *csvSink) WriteRows(rows [][]interface{}) error {
+	for _, row := range rows {
+		for i, v := range row {
+			switch v := v.(type) {
+			case string:
+				s.record[i] = v
+			case int64:
+				s.record[i] = strconv.FormatInt(v, 10)
+			case float64:
+				s.record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				s.record[i] = fmt.Sprint(v)
+			}
+		}
+		if err := s.w.Write(s.record); err != nil {
+			return err
+		}
+	}
+	s.w.Flush()
+	return s.w.Error()
+}
+
+func (s *csvSink) Close() error {
+	s.w.Flush()
+	return s.w.Error()
+}
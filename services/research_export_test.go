@@ -0,0 +1,393 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"stock-analyzer-wails/models"
+)
+
+func newResearchExportTestDB(t *testing.T) *DBService {
+	t.Helper()
+	db := newTestDB(t)
+	var bars []models.KLineBarEntity
+	for _, code := range []string{"000001", "600519"} {
+		for _, date := range []string{"2024-01-02", "2024-01-03", "2024-01-04", "2024-01-05"} {
+			bars = append(bars, models.KLineBarEntity{Code: code, Period: KLinePeriodDaily, Adjust: KLineAdjustForward, Date: date, Open: 10, High: 11, Low: 9, Close: 10.5, Volume: 1000})
+		}
+	}
+	bars = append(bars, models.KLineBarEntity{Code: "600519", Period: KLinePeriod5Min, Adjust: KLineAdjustNone, Date: "2024-01-05 09:35", Open: 10, High: 11, Low: 9, Close: 10.5, Volume: 10})
+	mustCreate(t, db, &bars,
+		&models.StockMoneyFlowHistEntity{Code: "600519", TradeDate: "2024-01-03", MainNet: 1.5e8, ClosePrice: 1700},
+		&models.StockMoneyFlowHistEntity{Code: "600519", TradeDate: "2024-01-04", MainNet: -2e7, ClosePrice: 1690},
+		&models.StockStrategySignalEntity{Code: "600519", TradeDate: "2024-01-04", SignalType: "B", StrategyName: "主力建仓", Score: 82.5, Details: "连续净流入, \"放量\""},
+	)
+	return db
+}
+
+func readCSV(t *testing.T, path string) [][]string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv %s: %v", path, err)
+	}
+	return records
+}
+
+func TestResearchExport_CSVChunkedWithFilters(t *testing.T) {
+	db := newResearchExportTestDB(t)
+	svc := NewResearchExportService(db)
+	svc.chunk = 3 // 强制多批，覆盖游标分页
+
+	var progress []ResearchExportProgress
+	svc.SetContext(context.Background())
+	svc.emit = func(_ context.Context, p ResearchExportProgress) { progress = append(progress, p) }
+
+	dir := t.TempDir()
+	result, err := svc.Export(ResearchExportRequest{StartDate: "2024-01-03", EndDate: "2024-01-05", OutputDir: dir})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if len(result.Files) != 3 {
+		t.Fatalf("expected 3 files, got %+v", result.Files)
+	}
+
+	kline := readCSV(t, filepath.Join(dir, "kline_daily.csv"))
+	if len(kline) != 7 || kline[0][0] != "code" || kline[1][0] != "000001" || kline[1][1] != "2024-01-03" || kline[6][0] != "600519" || kline[6][1] != "2024-01-05" {
+		t.Fatalf("unexpected kline csv: %v", kline)
+	}
+	if kline[1][7] != "10.5" || kline[1][8] != "1000" {
+		t.Fatalf("unexpected kline values: %v", kline[1])
+	}
+	flows := readCSV(t, filepath.Join(dir, "money_flow.csv"))
+	if len(flows) != 3 || flows[1][2] != "150000000" {
+		t.Fatalf("unexpected money flow csv: %v", flows)
+	}
+	signals := readCSV(t, filepath.Join(dir, "signals.csv"))
+	if len(signals) != 2 || signals[1][6] != "连续净流入, \"放量\"" {
+		t.Fatalf("unexpected signals csv: %v", signals)
+	}
+
+	// K 线 6 行按每批 3 行写两次
+	var klineEvents int
+	for _, p := range progress {
+		if p.Dataset == ResearchDatasetKLine {
+			klineEvents++
+			if p.TotalRows != 6 {
+				t.Fatalf("unexpected total rows: %+v", p)
+			}
+		}
+	}
+	if klineEvents != 2 || !progress[len(progress)-1].Done {
+		t.Fatalf("unexpected progress events: %+v", progress)
+	}
+}
+
+func TestResearchExport_CodesAndMinutePeriod(t *testing.T) {
+	db := newResearchExportTestDB(t)
+	dir := t.TempDir()
+	_, err := NewResearchExportService(db).Export(ResearchExportRequest{
+		Codes:     []string{"600519"},
+		Datasets:  []string{ResearchDatasetKLine},
+		Period:    "5m",
+		EndDate:   "2024-01-05",
+		OutputDir: dir,
+	})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	kline := readCSV(t, filepath.Join(dir, "kline_5min.csv"))
+	if len(kline) != 2 || kline[1][1] != "2024-01-05 09:35" {
+		t.Fatalf("expected the minute bar on the end date, got %v", kline)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "money_flow.csv")); !os.IsNotExist(err) {
+		t.Fatalf("expected only the requested dataset")
+	}
+}
+
+func TestResearchExport_KLineCursorAcrossAdjustModes(t *testing.T) {
+	db := newResearchExportTestDB(t)
+	mustCreate(t, db, &[]models.KLineBarEntity{
+		{Code: "000001", Period: KLinePeriodDaily, Adjust: KLineAdjustBackward, Date: "2024-01-02", Close: 20},
+		{Code: "000001", Period: KLinePeriodDaily, Adjust: KLineAdjustNone, Date: "2024-01-02", Close: 5},
+	})
+	svc := NewResearchExportService(db)
+	svc.chunk = 2 // 批次在同一日期的不同复权方式之间结束
+	dir := t.TempDir()
+	if _, err := svc.Export(ResearchExportRequest{Datasets: []string{ResearchDatasetKLine}, OutputDir: dir}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	kline := readCSV(t, filepath.Join(dir, "kline_daily.csv"))
+	if len(kline) != 11 {
+		t.Fatalf("expected 10 bars, got %v", kline)
+	}
+	var adjusts []string
+	for _, row := range kline[1:4] {
+		adjusts = append(adjusts, row[3])
+	}
+	if strings.Join(adjusts, ",") != "backward,forward,none" {
+		t.Fatalf("expected every adjust mode of 2024-01-02, got %v", kline[1:4])
+	}
+}
+
+func TestResearchExport_Parquet(t *testing.T) {
+	db := newResearchExportTestDB(t)
+	svc := NewResearchExportService(db)
+	svc.chunk = 3
+	dir := t.TempDir()
+	result, err := svc.Export(ResearchExportRequest{Format: "parquet", Datasets: []string{ResearchDatasetKLine}, OutputDir: dir})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if result.Files[0].Rows != 8 {
+		t.Fatalf("unexpected rows: %+v", result.Files)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "kline_daily.parquet"))
+	if err != nil {
+		t.Fatalf("read parquet: %v", err)
+	}
+	file := readParquet(t, data)
+
+	wantColumns := []string{"code:6", "date:6", "period:6", "adjust:6", "open:5", "high:5", "low:5", "close:5", "volume:2"}
+	if !reflect.DeepEqual(file.columns, wantColumns) {
+		t.Fatalf("unexpected schema %v", file.columns)
+	}
+	// 每批 3 行为一个行组
+	if file.numRows != 8 || !reflect.DeepEqual(file.groupRows, []int64{3, 3, 2}) {
+		t.Fatalf("unexpected row groups: %d rows, %v", file.numRows, file.groupRows)
+	}
+	var want [][]interface{}
+	for _, code := range []string{"000001", "600519"} {
+		for _, date := range []string{"2024-01-02", "2024-01-03", "2024-01-04", "2024-01-05"} {
+			want = append(want, []interface{}{code, date, KLinePeriodDaily, KLineAdjustForward, 10.0, 11.0, 9.0, 10.5, int64(1000)})
+		}
+	}
+	if !reflect.DeepEqual(file.rows, want) {
+		t.Fatalf("unexpected parquet rows:\n got %v\nwant %v", file.rows, want)
+	}
+}
+
+// parquetFile 解码后的 Parquet 文件：列为 "名称:物理类型"
+type parquetFile struct {
+	columns   []string
+	numRows   int64
+	groupRows []int64
+	rows      [][]interface{}
+}
+
+// readParquet 按 Parquet 格式解码文件尾的 FileMetaData、各列的数据页头与 PLAIN 编码的值，
+// 并校验元数据中的行数、偏移与大小与实际内容一致
+func readParquet(t *testing.T, data []byte) parquetFile {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Fatalf("missing parquet magic")
+	}
+	size := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if size <= 0 || size > len(data)-12 {
+		t.Fatalf("invalid footer length %d", size)
+	}
+	footer := &thriftReader{t: t, data: data[len(data)-8-size : len(data)-8]}
+	meta := footer.structure()
+	if footer.pos != size {
+		t.Fatalf("footer has %d trailing bytes", size-footer.pos)
+	}
+
+	var file parquetFile
+	schema := meta[2].([]interface{})
+	if root := schema[0].(map[int16]interface{}); root[5] != int64(len(schema)-1) {
+		t.Fatalf("unexpected schema root %v", root)
+	}
+	types := make([]int64, 0, len(schema)-1)
+	for _, el := range schema[1:] {
+		leaf := el.(map[int16]interface{})
+		if leaf[3] != int64(0) {
+			t.Fatalf("expected required columns, got %v", leaf)
+		}
+		types = append(types, leaf[1].(int64))
+		file.columns = append(file.columns, fmt.Sprintf("%s:%d", leaf[4], leaf[1]))
+	}
+	file.numRows = meta[3].(int64)
+
+	for _, g := range meta[4].([]interface{}) {
+		group := g.(map[int16]interface{})
+		n := group[3].(int64)
+		file.groupRows = append(file.groupRows, n)
+		rows := make([][]interface{}, n)
+		var groupSize int64
+		for i, c := range group[1].([]interface{}) {
+			chunk := c.(map[int16]interface{})[3].(map[int16]interface{})
+			offset, total := chunk[9].(int64), chunk[7].(int64)
+			if chunk[1] != types[i] || chunk[5] != n || chunk[4] != int64(0) {
+				t.Fatalf("unexpected column chunk metadata %v", chunk)
+			}
+			groupSize += total
+
+			page := &thriftReader{t: t, data: data[offset : offset+total]}
+			header := page.structure()
+			dataPage := header[5].(map[int16]interface{})
+			pageSize := header[3].(int64)
+			if header[1] != int64(0) || header[2] != pageSize || dataPage[1] != n || dataPage[2] != int64(0) {
+				t.Fatalf("unexpected page header %v", header)
+			}
+			if int64(page.pos)+pageSize != total {
+				t.Fatalf("page size %d does not match column chunk size %d", int64(page.pos)+pageSize, total)
+			}
+			values := page.data[page.pos:]
+			for r := range rows {
+				switch types[i] {
+				case 2: // INT64
+					rows[r] = append(rows[r], int64(binary.LittleEndian.Uint64(values)))
+					values = values[8:]
+				case 5: // DOUBLE
+					rows[r] = append(rows[r], math.Float64frombits(binary.LittleEndian.Uint64(values)))
+					values = values[8:]
+				case 6: // BYTE_ARRAY
+					l := binary.LittleEndian.Uint32(values)
+					rows[r] = append(rows[r], string(values[4:4+l]))
+					values = values[4+l:]
+				default:
+					t.Fatalf("unexpected physical type %d", types[i])
+				}
+			}
+			if len(values) != 0 {
+				t.Fatalf("column %s has %d trailing bytes", file.columns[i], len(values))
+			}
+		}
+		if group[2] != groupSize {
+			t.Fatalf("row group size %v does not match column chunks %d", group[2], groupSize)
+		}
+		file.rows = append(file.rows, rows...)
+	}
+	return file
+}
+
+// thriftReader 解码 Thrift compact protocol：结构体为 字段编号 -> 值，整数统一为 int64
+type thriftReader struct {
+	t    *testing.T
+	data []byte
+	pos  int
+}
+
+func (r *thriftReader) byte() byte {
+	if r.pos >= len(r.data) {
+		r.t.Fatalf("thrift: unexpected end of data")
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *thriftReader) varint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		r.t.Fatalf("thrift: invalid varint at %d", r.pos)
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) structure() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var id int16
+	for {
+		b := r.byte()
+		if b == 0 {
+			return fields
+		}
+		if delta := int16(b >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(r.zigzag())
+		}
+		fields[id] = r.value(b & 0x0F)
+	}
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case 5, 6: // i32, i64
+		return r.zigzag()
+	case 8: // binary
+		n := int(r.varint())
+		if r.pos+n > len(r.data) {
+			r.t.Fatalf("thrift: binary of %d bytes exceeds data", n)
+		}
+		v := string(r.data[r.pos : r.pos+n])
+		r.pos += n
+		return v
+	case 9: // list
+		h := r.byte()
+		n := int(h >> 4)
+		if n == 15 {
+			n = int(r.varint())
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = r.value(h & 0x0F)
+		}
+		return list
+	case 12: // struct
+		return r.structure()
+	}
+	r.t.Fatalf("thrift: unsupported type %d at %d", typ, r.pos)
+	return nil
+}
+
+func TestResearchExport_CancelRemovesPartialFile(t *testing.T) {
+	db := newResearchExportTestDB(t)
+	svc := NewResearchExportService(db)
+	svc.chunk = 1
+	svc.SetContext(context.Background())
+	svc.emit = func(_ context.Context, p ResearchExportProgress) {
+		if p.Dataset == ResearchDatasetMoneyFlow {
+			svc.Cancel() // K 线已导出完，资金流写入一批后取消
+		}
+	}
+
+	dir := t.TempDir()
+	if _, err := svc.Export(ResearchExportRequest{Format: ResearchFormatParquet, OutputDir: dir}); err == nil {
+		t.Fatalf("expected the cancelled export to fail")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "kline_daily.parquet")); err != nil {
+		t.Fatalf("expected the completed kline file to be kept: %v", err)
+	}
+	for _, name := range []string{"money_flow.parquet", "signals.parquet"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Fatalf("expected no %s after cancel, got %v", name, err)
+		}
+	}
+}
+
+func TestResearchExport_RejectsInvalidRequest(t *testing.T) {
+	svc := NewResearchExportService(newTestDB(t))
+	for _, req := range []ResearchExportRequest{
+		{Format: "xlsx"},
+		{Datasets: []string{"ticks"}},
+		{StartDate: "2024-02-01", EndDate: "2024-01-01"},
+		{Period: "2m"},
+	} {
+		if _, err := svc.Export(req); err == nil {
+			t.Fatalf("expected %+v to be rejected", req)
+		}
+	}
+}
@@ -0,0 +1,507 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"stock-analyzer-wails/internal/logger"
+	"stock-analyzer-wails/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 定时任务可用的步骤，按收盘后的日常流程排列
+const (
+	ScheduleStepStockList     = "stock_list"      // 同步股票列表
+	ScheduleStepKLineSync     = "kline_sync"      // K线同步（增量）
+	ScheduleStepMoneyFlowSync = "money_flow_sync" // 全市场资金流同步
+	ScheduleStepStrategyScan  = "strategy_scan"   // 全市场策略扫描
+	ScheduleStepAIVerify      = "ai_verify"       // AI 验证当日信号
+)
+
+// ScheduleSteps 全部可用步骤
+var ScheduleSteps = []string{
+	ScheduleStepStockList,
+	ScheduleStepKLineSync,
+	ScheduleStepMoneyFlowSync,
+	ScheduleStepStrategyScan,
+	ScheduleStepAIVerify,
+}
+
+// 触发方式
+const (
+	ScheduleTriggerScheduled = "scheduled" // 按计划时间触发
+	ScheduleTriggerCatchUp   = "catchup"   // 启动时补跑错过的计划
+	ScheduleTriggerManual    = "manual"    // 手动执行
+)
+
+// ScheduleRunInterrupted 程序退出时仍在执行的记录，启动时标记
+const ScheduleRunInterrupted = "interrupted"
+
+// JobSchedule 定时任务在任务管理器中的类型
+const JobSchedule = "schedule"
+
+// schedulerCheckInterval 检查到期计划的间隔
+const schedulerCheckInterval = time.Minute
+
+// scheduleCatchUpAfter 计划时间已过去超过该时长才被视为补跑（正常触发最多延迟一个检查间隔）
+const scheduleCatchUpAfter = 5 * time.Minute
+
+// Schedule 定时任务（前端展示与编辑）
+type Schedule struct {
+	ID              uint     `json:"id"`
+	Name            string   `json:"name"`
+	Cron            string   `json:"cron"`            // 分 时 日 月 周，如 "30 15 * * 1-5"
+	TradingDaysOnly bool     `json:"tradingDaysOnly"` // 只在交易日执行
+	Steps           []string `json:"steps"`           // 依次执行，前一步失败时后续步骤不再执行
+	Enabled         bool     `json:"enabled"`
+	LastFireAt      string   `json:"lastFireAt,omitempty"`
+	NextFireAt      string   `json:"nextFireAt,omitempty"`
+}
+
+// ScheduleStepResult 单个步骤的执行结果
+type ScheduleStepResult struct {
+	Step       string `json:"step"`
+	Status     string `json:"status"` // completed/failed
+	Summary    string `json:"summary"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// ScheduleStepFunc 步骤的执行函数，scheduledAt 为本次执行的计划时间（补跑时早于当前时间），返回结果摘要
+type ScheduleStepFunc func(scheduledAt time.Time) (string, error)
+
+// SchedulerService 按 cron 规则在交易日执行同步、扫描等步骤链，持久化计划与执行记录，启动时补跑错过的计划
+type SchedulerService struct {
+	dbService *DBService
+	manager   *JobManager
+	now       func() time.Time
+
+	stepsMu sync.RWMutex
+	steps   map[string]ScheduleStepFunc
+
+	runMu   sync.Mutex // 同一时间只执行一个定时任务
+	running bool
+
+	stateMu sync.Mutex
+	stop    chan struct{}
+}
+
+// NewSchedulerService 创建定时任务服务
+func NewSchedulerService(db *DBService) *SchedulerService {
+	return &SchedulerService{
+		dbService: db,
+		manager:   NewJobManager(),
+		now:       time.Now,
+		steps:     make(map[string]ScheduleStepFunc),
+	}
+}
+
+// SetJobManager 设置任务管理器
+func (s *SchedulerService) SetJobManager(m *JobManager) {
+	s.manager = m
+}
+
+// RegisterStep 注册步骤的执行函数
+func (s *SchedulerService) RegisterStep(step string, fn ScheduleStepFunc) {
+	s.stepsMu.Lock()
+	s.steps[step] = fn
+	s.stepsMu.Unlock()
+}
+
+func (s *SchedulerService) stepFunc(step string) ScheduleStepFunc {
+	s.stepsMu.RLock()
+	defer s.stepsMu.RUnlock()
+	return s.steps[step]
+}
+
+// ListSchedules 列出全部定时任务及下一次执行时间
+func (s *SchedulerService) ListSchedules() ([]Schedule, error) {
+	var entities []models.ScheduleEntity
+	if err := s.dbService.GetDB().Order("id ASC").Find(&entities).Error; err != nil {
+		return nil, fmt.Errorf("查询定时任务失败: %w", err)
+	}
+	now := s.now()
+	schedules := make([]Schedule, len(entities))
+	for i, e := range entities {
+		schedules[i] = scheduleFromEntity(e, now)
+	}
+	return schedules, nil
+}
+
+// scheduleFromEntity 转换为前端结构并计算下一次执行时间
+func scheduleFromEntity(e models.ScheduleEntity, now time.Time) Schedule {
+	sched := Schedule{
+		ID:              e.ID,
+		Name:            e.Name,
+		Cron:            e.Cron,
+		TradingDaysOnly: e.TradingDaysOnly,
+		Steps:           splitScheduleSteps(e.Steps),
+		Enabled:         e.Enabled,
+	}
+	if e.LastFireAt != nil {
+		sched.LastFireAt = e.LastFireAt.In(chinaLocation).Format("2006-01-02 15:04")
+	}
+	if rule, err := parseCron(e.Cron, e.TradingDaysOnly); err == nil && e.Enabled {
+		if next := rule.next(now); !next.IsZero() {
+			sched.NextFireAt = next.Format("2006-01-02 15:04")
+		}
+	}
+	return sched
+}
+
+func splitScheduleSteps(steps string) []string {
+	if steps == "" {
+		return []string{}
+	}
+	return strings.Split(steps, ",")
+}
+
+// SaveSchedule 校验并保存定时任务，ID 为 0 时新建。
+// 保存后从当前时间起计算计划，不会补跑保存之前的计划时间。
+func (s *SchedulerService) SaveSchedule(sched Schedule) (*Schedule, error) {
+	sched.Name = strings.TrimSpace(sched.Name)
+	sched.Cron = strings.Join(strings.Fields(sched.Cron), " ")
+	if sched.Name == "" {
+		return nil, fmt.Errorf("定时任务名称不能为空")
+	}
+	if _, err := parseCron(sched.Cron, sched.TradingDaysOnly); err != nil {
+		return nil, err
+	}
+	if len(sched.Steps) == 0 {
+		return nil, fmt.Errorf("定时任务至少需要一个步骤")
+	}
+	for _, step := range sched.Steps {
+		if !isScheduleStep(step) {
+			return nil, fmt.Errorf("不支持的步骤: %s", step)
+		}
+	}
+
+	now := s.now()
+	entity := models.ScheduleEntity{
+		ID:              sched.ID,
+		Name:            sched.Name,
+		Cron:            sched.Cron,
+		TradingDaysOnly: sched.TradingDaysOnly,
+		Steps:           strings.Join(sched.Steps, ","),
+		Enabled:         sched.Enabled,
+		LastFireAt:      &now,
+		UpdatedAt:       now,
+	}
+	db := s.dbService.GetDB()
+	if sched.ID == 0 {
+		entity.CreatedAt = now
+		if err := db.Create(&entity).Error; err != nil {
+			return nil, fmt.Errorf("创建定时任务失败: %w", err)
+		}
+	} else {
+		result := db.Model(&models.ScheduleEntity{}).Where("id = ?", sched.ID).Updates(map[string]interface{}{
+			"name":              entity.Name,
+			"cron":              entity.Cron,
+			"trading_days_only": entity.TradingDaysOnly,
+			"steps":             entity.Steps,
+			"enabled":           entity.Enabled,
+			"last_fire_at":      now,
+			"updated_at":        now,
+		})
+		if result.Error != nil {
+			return nil, fmt.Errorf("更新定时任务失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil, fmt.Errorf("定时任务不存在: %d", sched.ID)
+		}
+	}
+
+	saved := scheduleFromEntity(entity, now)
+	return &saved, nil
+}
+
+func isScheduleStep(step string) bool {
+	for _, s := range ScheduleSteps {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteSchedule 删除定时任务及其执行记录
+func (s *SchedulerService) DeleteSchedule(id uint) error {
+	return s.dbService.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("schedule_id = ?", id).Delete(&models.ScheduleRunEntity{}).Error; err != nil {
+			return fmt.Errorf("删除定时任务执行记录失败: %w", err)
+		}
+		if err := tx.Delete(&models.ScheduleEntity{}, id).Error; err != nil {
+			return fmt.Errorf("删除定时任务失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListRuns 按开始时间倒序列出执行记录，scheduleID 为 0 时不限定时任务
+func (s *SchedulerService) ListRuns(scheduleID uint, limit int) ([]models.ScheduleRunEntity, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	tx := s.dbService.GetDB().Order("started_at DESC, id DESC").Limit(limit)
+	if scheduleID != 0 {
+		tx = tx.Where("schedule_id = ?", scheduleID)
+	}
+	var runs []models.ScheduleRunEntity
+	if err := tx.Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("查询定时任务执行记录失败: %w", err)
+	}
+	return runs, nil
+}
+
+// RunNow 立即在后台执行定时任务（不影响计划时间）
+func (s *SchedulerService) RunNow(id uint) error {
+	var entity models.ScheduleEntity
+	if err := s.dbService.GetDB().Where("id = ?", id).Take(&entity).Error; err != nil {
+		return fmt.Errorf("定时任务不存在: %d", id)
+	}
+	if !s.beginRun() {
+		return fmt.Errorf("已有定时任务在执行")
+	}
+	go func() {
+		defer s.endRun()
+		if _, err := s.execute(entity, ScheduleTriggerManual, s.now()); err != nil {
+			logger.Error("手动执行定时任务失败",
+				zap.String("module", "services.scheduler"),
+				zap.String("op", "RunNow"),
+				zap.Uint("schedule_id", id),
+				zap.Error(err),
+			)
+		}
+	}()
+	return nil
+}
+
+func (s *SchedulerService) beginRun() bool {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	if s.running {
+		return false
+	}
+	s.running = true
+	return true
+}
+
+func (s *SchedulerService) endRun() {
+	s.runMu.Lock()
+	s.running = false
+	s.runMu.Unlock()
+}
+
+// Start 启动调度：先标记上次退出时中断的执行并补跑错过的计划，之后每分钟检查一次。ctx 结束或调用 Stop 时退出。
+func (s *SchedulerService) Start(ctx context.Context) {
+	s.stateMu.Lock()
+	if s.stop != nil {
+		s.stateMu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	s.stop = stop
+	s.stateMu.Unlock()
+
+	if err := s.markInterrupted(); err != nil {
+		logger.Warn("标记中断的定时任务执行失败",
+			zap.String("module", "services.scheduler"),
+			zap.String("op", "Start"),
+			zap.Error(err),
+		)
+	}
+
+	go func() {
+		s.runDue()
+		ticker := time.NewTicker(schedulerCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.runDue()
+			}
+		}
+	}()
+	logger.Info("定时任务调度已启动",
+		zap.String("module", "services.scheduler"),
+		zap.String("op", "Start"),
+	)
+}
+
+// Stop 停止调度（正在执行的步骤不受影响）
+func (s *SchedulerService) Stop() {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// markInterrupted 将上次退出时仍在执行的记录标记为 interrupted
+func (s *SchedulerService) markInterrupted() error {
+	now := s.now()
+	return s.dbService.GetDB().Model(&models.ScheduleRunEntity{}).
+		Where("status = ?", JobRunning).
+		Updates(map[string]interface{}{"status": ScheduleRunInterrupted, "finished_at": now}).Error
+}
+
+// runDue 执行到期的定时任务。每个任务只执行最近一次错过的计划，更早的不再补跑。
+// 有任务正在执行时跳过，下次检查时计划仍然到期。
+func (s *SchedulerService) runDue() {
+	var entities []models.ScheduleEntity
+	if err := s.dbService.GetDB().Where("enabled = ?", true).Order("id ASC").Find(&entities).Error; err != nil {
+		logger.Error("查询定时任务失败",
+			zap.String("module", "services.scheduler"),
+			zap.String("op", "runDue"),
+			zap.Error(err),
+		)
+		return
+	}
+
+	for _, e := range entities {
+		rule, err := parseCron(e.Cron, e.TradingDaysOnly)
+		if err != nil {
+			continue
+		}
+		now := s.now()
+		fireAt := rule.prev(now)
+		if fireAt.IsZero() || (e.LastFireAt != nil && !fireAt.After(*e.LastFireAt)) {
+			continue
+		}
+		trigger := ScheduleTriggerScheduled
+		if now.Sub(fireAt) > scheduleCatchUpAfter {
+			trigger = ScheduleTriggerCatchUp
+		}
+		if !s.beginRun() {
+			return
+		}
+		_, err = s.execute(e, trigger, fireAt)
+		s.endRun()
+		if err != nil {
+			logger.Error("执行定时任务失败",
+				zap.String("module", "services.scheduler"),
+				zap.String("op", "runDue"),
+				zap.Uint("schedule_id", e.ID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// execute 依次执行定时任务的步骤并记录结果。调用方需先 beginRun。
+// 执行在任务管理器中登记，暂停、取消在步骤之间生效；步骤失败时后续步骤不再执行。
+func (s *SchedulerService) execute(e models.ScheduleEntity, trigger string, scheduledAt time.Time) (*models.ScheduleRunEntity, error) {
+	db := s.dbService.GetDB()
+	if trigger != ScheduleTriggerManual {
+		// 先记录计划时间，避免同一次计划被重复触发
+		if err := db.Model(&models.ScheduleEntity{}).Where("id = ?", e.ID).Update("last_fire_at", scheduledAt).Error; err != nil {
+			return nil, fmt.Errorf("更新定时任务计划时间失败: %w", err)
+		}
+	}
+
+	run := &models.ScheduleRunEntity{
+		ScheduleID:   e.ID,
+		ScheduleName: e.Name,
+		Trigger:      trigger,
+		Status:       JobRunning,
+		ScheduledAt:  scheduledAt,
+		StartedAt:    s.now(),
+	}
+	if err := db.Create(run).Error; err != nil {
+		return nil, fmt.Errorf("创建定时任务执行记录失败: %w", err)
+	}
+
+	logger.Info("开始执行定时任务",
+		zap.String("module", "services.scheduler"),
+		zap.String("op", "execute"),
+		zap.Uint("schedule_id", e.ID),
+		zap.String("name", e.Name),
+		zap.String("trigger", trigger),
+		zap.Time("scheduled_at", scheduledAt),
+	)
+
+	job := s.manager.Begin(JobSchedule, "schedule-run-"+strconv.FormatUint(uint64(run.ID), 10))
+	steps := splitScheduleSteps(e.Steps)
+	results := make([]ScheduleStepResult, 0, len(steps))
+	status, errMsg := JobCompleted, ""
+	for i, step := range steps {
+		if job.Wait() != nil {
+			status = JobCancelled
+			break
+		}
+		job.SetProgress(i, len(steps))
+
+		res := ScheduleStepResult{Step: step, Status: JobCompleted}
+		started := time.Now()
+		fn := s.stepFunc(step)
+		var err error
+		if fn == nil {
+			err = fmt.Errorf("步骤未注册")
+		} else {
+			res.Summary, err = fn(scheduledAt)
+		}
+		res.DurationMs = time.Since(started).Milliseconds()
+		if err != nil {
+			res.Status, res.Error = JobFailed, err.Error()
+			status, errMsg = JobFailed, fmt.Sprintf("%s: %v", step, err)
+		}
+		results = append(results, res)
+		s.saveRunSteps(run.ID, results)
+		if err != nil {
+			break
+		}
+	}
+	job.SetProgress(len(results), len(steps))
+
+	finishedAt := s.now()
+	run.Status, run.Error, run.FinishedAt = status, errMsg, &finishedAt
+	run.Steps = encodeScheduleSteps(results)
+	if err := db.Model(&models.ScheduleRunEntity{}).Where("id = ?", run.ID).Updates(map[string]interface{}{
+		"status":      run.Status,
+		"error":       run.Error,
+		"steps":       run.Steps,
+		"finished_at": finishedAt,
+	}).Error; err != nil {
+		logger.Error("更新定时任务执行记录失败", zap.Uint("run_id", run.ID), zap.Error(err))
+	}
+	s.manager.Finish(job, status, results, errMsg)
+
+	logger.Info("定时任务执行结束",
+		zap.String("module", "services.scheduler"),
+		zap.String("op", "execute"),
+		zap.Uint("schedule_id", e.ID),
+		zap.Uint("run_id", run.ID),
+		zap.String("status", status),
+		zap.String("error", errMsg),
+	)
+	return run, nil
+}
+
+// saveRunSteps 保存已完成步骤的结果，执行中途也能看到进度
+func (s *SchedulerService) saveRunSteps(runID uint, results []ScheduleStepResult) {
+	err := s.dbService.GetDB().Model(&models.ScheduleRunEntity{}).Where("id = ?", runID).
+		Update("steps", encodeScheduleSteps(results)).Error
+	if err != nil {
+		logger.Error("保存定时任务步骤结果失败", zap.Uint("run_id", runID), zap.Error(err))
+	}
+}
+
+func encodeScheduleSteps(results []ScheduleStepResult) string {
+	data, err := json.Marshal(results)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronLookbackDays 查找上一次计划时间时最多回溯的天数（覆盖春节等长假）
+const cronLookbackDays = 16
+
+// cronLookaheadDays 查找下一次计划时间时最多向后查找的天数
+const cronLookaheadDays = 400
+
+// cronRule 解析后的 cron 规则（分 时 日 月 周），按交易所时区计算
+type cronRule struct {
+	minute  [60]bool
+	hour    [24]bool
+	dom     [32]bool
+	month   [13]bool
+	dow     [7]bool
+	domStar bool
+	dowStar bool
+
+	tradingDaysOnly bool // 只在交易日触发
+}
+
+// parseCron 解析 5 段 cron 表达式，支持 *、列表（1,3）、范围（1-5）和步长（*/15、9-15/2），周日可写 0 或 7
+func parseCron(expr string, tradingDaysOnly bool) (*cronRule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron 表达式需要 5 段（分 时 日 月 周）: %q", expr)
+	}
+	r := &cronRule{tradingDaysOnly: tradingDaysOnly}
+	specs := []struct {
+		name     string
+		min, max int
+		set      func(int)
+	}{
+		{"分钟", 0, 59, func(v int) { r.minute[v] = true }},
+		{"小时", 0, 23, func(v int) { r.hour[v] = true }},
+		{"日期", 1, 31, func(v int) { r.dom[v] = true }},
+		{"月份", 1, 12, func(v int) { r.month[v] = true }},
+		{"星期", 0, 7, func(v int) { r.dow[v%7] = true }},
+	}
+	for i, spec := range specs {
+		if err := parseCronField(fields[i], spec.min, spec.max, spec.set); err != nil {
+			return nil, fmt.Errorf("cron %s字段无效: %w", spec.name, err)
+		}
+	}
+	r.domStar = fields[2] == "*"
+	r.dowStar = fields[4] == "*"
+	return r, nil
+}
+
+// parseCronField 解析单个字段，将命中的值交给 set
+func parseCronField(field string, min, max int, set func(int)) error {
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("步长无效: %q", part)
+			}
+			rangePart, step = part[:i], n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			a, errA := strconv.Atoi(bounds[0])
+			b, errB := strconv.Atoi(bounds[1])
+			if errA != nil || errB != nil || a > b {
+				return fmt.Errorf("范围无效: %q", part)
+			}
+			lo, hi = a, b
+		default:
+			v, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return fmt.Errorf("取值无效: %q", part)
+			}
+			lo, hi = v, v
+			if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max {
+			return fmt.Errorf("取值超出范围 %d-%d: %q", min, max, part)
+		}
+		for v := lo; v <= hi; v += step {
+			set(v)
+		}
+	}
+	return nil
+}
+
+// matchDay 日期是否命中规则。与标准 cron 一致：日期和星期都有限定时满足其一即可。
+func (r *cronRule) matchDay(day time.Time) bool {
+	if !r.month[int(day.Month())] {
+		return false
+	}
+	domOK, dowOK := r.dom[day.Day()], r.dow[int(day.Weekday())]
+	var ok bool
+	switch {
+	case r.domStar && r.dowStar:
+		ok = true
+	case r.domStar:
+		ok = dowOK
+	case r.dowStar:
+		ok = domOK
+	default:
+		ok = domOK || dowOK
+	}
+	if ok && r.tradingDaysOnly {
+		return GetTradingCalendar().IsTradingDay(day)
+	}
+	return ok
+}
+
+// prev 返回不晚于 t 的最近一次计划时间，回溯 cronLookbackDays 天仍没有时返回零值
+func (r *cronRule) prev(t time.Time) time.Time {
+	t = t.In(chinaLocation)
+	today := startOfChinaDay(t)
+	for d := 0; d <= cronLookbackDays; d++ {
+		day := today.AddDate(0, 0, -d)
+		if !r.matchDay(day) {
+			continue
+		}
+		last := 24*60 - 1
+		if d == 0 {
+			last = t.Hour()*60 + t.Minute()
+		}
+		for m := last; m >= 0; m-- {
+			if r.hour[m/60] && r.minute[m%60] {
+				return day.Add(time.Duration(m) * time.Minute)
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// next 返回晚于 t 的下一次计划时间，找不到时返回零值
+func (r *cronRule) next(t time.Time) time.Time {
+	t = t.In(chinaLocation)
+	today := startOfChinaDay(t)
+	for d := 0; d <= cronLookaheadDays; d++ {
+		day := today.AddDate(0, 0, d)
+		if !r.matchDay(day) {
+			continue
+		}
+		first := 0
+		if d == 0 {
+			first = t.Hour()*60 + t.Minute() + 1
+		}
+		for m := first; m < 24*60; m++ {
+			if r.hour[m/60] && r.minute[m%60] {
+				return day.Add(time.Duration(m) * time.Minute)
+			}
+		}
+	}
+	return time.Time{}
+}
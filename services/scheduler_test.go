@@ -0,0 +1,161 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"stock-analyzer-wails/models"
+)
+
+func TestCron_TradingDaysSkipHolidays(t *testing.T) {
+	for _, expr := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "0 9 * 13 *", "a * * * *"} {
+		if _, err := parseCron(expr, false); err == nil {
+			t.Fatalf("expected %q to be rejected", expr)
+		}
+	}
+
+	rule, err := parseCron("30 15 * * *", true)
+	if err != nil {
+		t.Fatalf("parseCron: %v", err)
+	}
+	// 2024-10-01 ~ 10-07 国庆休市
+	if got := rule.prev(cst("2024-10-08 10:00:00")); !got.Equal(cst("2024-09-30 15:30:00")) {
+		t.Fatalf("prev = %v", got)
+	}
+	if got := rule.prev(cst("2024-10-08 15:30:00")); !got.Equal(cst("2024-10-08 15:30:00")) {
+		t.Fatalf("prev at the fire time = %v", got)
+	}
+	if got := rule.next(cst("2024-09-30 15:30:00")); !got.Equal(cst("2024-10-08 15:30:00")) {
+		t.Fatalf("next = %v", got)
+	}
+
+	// 日期和星期都有限定时满足其一即可；步长与范围
+	rule, _ = parseCron("0,30 9-10/1 1 * 1", false)
+	if got := rule.next(cst("2024-06-01 10:30:00")); !got.Equal(cst("2024-06-03 09:00:00")) {
+		t.Fatalf("next Monday = %v", got)
+	}
+	if got := rule.next(cst("2024-06-28 12:00:00")); !got.Equal(cst("2024-07-01 09:00:00")) {
+		t.Fatalf("next first day = %v", got)
+	}
+}
+
+// fakeScheduleSteps 注册记录调用顺序的步骤，摘要中带上计划时间，fail 中的步骤返回错误
+func fakeScheduleSteps(svc *SchedulerService, calls *[]string, fail ...string) {
+	for _, step := range ScheduleSteps {
+		step := step
+		svc.RegisterStep(step, func(scheduledAt time.Time) (string, error) {
+			*calls = append(*calls, step)
+			for _, f := range fail {
+				if f == step {
+					return "", fmt.Errorf("%s failed", step)
+				}
+			}
+			return step + " ok " + scheduledAt.Format("2006-01-02 15:04"), nil
+		})
+	}
+}
+
+func TestScheduler_CatchUpThenScheduled(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewSchedulerService(db)
+	var calls []string
+	fakeScheduleSteps(svc, &calls)
+
+	now := cst("2024-06-13 10:00:00") // 周四
+	svc.now = func() time.Time { return now }
+	sched, err := svc.SaveSchedule(Schedule{
+		Name:            "收盘后更新",
+		Cron:            "30  15 * * *",
+		TradingDaysOnly: true,
+		Steps:           []string{ScheduleStepStockList, ScheduleStepKLineSync, ScheduleStepStrategyScan},
+		Enabled:         true,
+	})
+	if err != nil || sched.Cron != "30 15 * * *" || sched.NextFireAt != "2024-06-13 15:30" {
+		t.Fatalf("SaveSchedule = %+v, %v", sched, err)
+	}
+
+	// 周四、周五错过，周末跳过；周一启动时只补跑最近一次
+	now = cst("2024-06-17 09:00:00")
+	svc.runDue()
+	svc.runDue()
+	runs, err := svc.ListRuns(sched.ID, 10)
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("ListRuns = %+v, %v", runs, err)
+	}
+	run := runs[0]
+	if run.Trigger != ScheduleTriggerCatchUp || run.Status != JobCompleted || !run.ScheduledAt.Equal(cst("2024-06-14 15:30:00")) {
+		t.Fatalf("unexpected catch-up run: %+v", run)
+	}
+	// 补跑的步骤拿到的是计划时间而不是当前时间
+	var results []ScheduleStepResult
+	if err := json.Unmarshal([]byte(run.Steps), &results); err != nil || len(results) != 3 || results[2].Summary != "strategy_scan ok 2024-06-14 15:30" {
+		t.Fatalf("unexpected step results %s: %v", run.Steps, err)
+	}
+
+	now = cst("2024-06-17 15:31:00")
+	svc.runDue()
+	runs, _ = svc.ListRuns(sched.ID, 10)
+	if len(runs) != 2 || runs[0].Trigger != ScheduleTriggerScheduled || len(calls) != 6 {
+		t.Fatalf("expected a scheduled run, got %+v (calls %v)", runs, calls)
+	}
+	list, _ := svc.ListSchedules()
+	if len(list) != 1 || list[0].LastFireAt != "2024-06-17 15:30" || list[0].NextFireAt != "2024-06-18 15:30" {
+		t.Fatalf("ListSchedules = %+v", list)
+	}
+}
+
+func TestScheduler_FailedStepStopsChain(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewSchedulerService(db)
+	var calls []string
+	fakeScheduleSteps(svc, &calls, ScheduleStepKLineSync)
+	svc.now = func() time.Time { return cst("2024-06-17 16:00:00") }
+
+	sched, err := svc.SaveSchedule(Schedule{Name: "每日", Cron: "0 16 * * 1-5", Steps: []string{ScheduleStepStockList, ScheduleStepKLineSync, ScheduleStepStrategyScan}})
+	if err != nil {
+		t.Fatalf("SaveSchedule: %v", err)
+	}
+	entity := models.ScheduleEntity{ID: sched.ID, Name: sched.Name, Steps: "stock_list,kline_sync,strategy_scan"}
+	if !svc.beginRun() {
+		t.Fatalf("beginRun")
+	}
+	run, err := svc.execute(entity, ScheduleTriggerManual, svc.now())
+	svc.endRun()
+	if err != nil || run.Status != JobFailed || run.Error != "kline_sync: kline_sync failed" {
+		t.Fatalf("execute = %+v, %v", run, err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected the scan to be skipped after a failure, got %v", calls)
+	}
+
+	// 未启用的任务不会被调度；启动时遗留的执行记录标记为中断
+	svc.runDue()
+	mustCreate(t, db, &models.ScheduleRunEntity{ScheduleID: sched.ID, Status: JobRunning, StartedAt: svc.now()})
+	if err := svc.markInterrupted(); err != nil {
+		t.Fatalf("markInterrupted: %v", err)
+	}
+	runs, _ := svc.ListRuns(0, 10)
+	if len(runs) != 2 || runs[0].Status != ScheduleRunInterrupted {
+		t.Fatalf("ListRuns = %+v", runs)
+	}
+
+	for _, bad := range []Schedule{
+		{Name: "", Cron: "0 16 * * *", Steps: []string{ScheduleStepStockList}},
+		{Name: "x", Cron: "0 16 * *", Steps: []string{ScheduleStepStockList}},
+		{Name: "x", Cron: "0 16 * * *"},
+		{Name: "x", Cron: "0 16 * * *", Steps: []string{"backup"}},
+		{ID: 999, Name: "x", Cron: "0 16 * * *", Steps: []string{ScheduleStepStockList}},
+	} {
+		if _, err := svc.SaveSchedule(bad); err == nil {
+			t.Fatalf("expected %+v to be rejected", bad)
+		}
+	}
+	if err := svc.DeleteSchedule(sched.ID); err != nil {
+		t.Fatalf("DeleteSchedule: %v", err)
+	}
+	if runs, _ := svc.ListRuns(0, 10); len(runs) != 0 {
+		t.Fatalf("expected runs to be deleted with the schedule, got %d", len(runs))
+	}
+}
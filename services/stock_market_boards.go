@@ -0,0 +1,267 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"stock-analyzer-wails/internal/logger"
+	"stock-analyzer-wails/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 板块类型（对应 boards.type）
+const (
+	BoardTypeIndustry = "industry" // 行业板块
+	BoardTypeConcept  = "concept"  // 概念板块
+)
+
+// boardListFS 东方财富板块列表的 fs 参数
+var boardListFS = map[string]string{
+	BoardTypeIndustry: "m:90+t:2",
+	BoardTypeConcept:  "m:90+t:3",
+}
+
+// boardCodePattern 东方财富板块代码，如 BK0477
+var boardCodePattern = regexp.MustCompile(`^BK\d{4}$`)
+
+// boardPageSize 板块列表与成分股的分页大小
+const boardPageSize = 500
+
+// isBoardCode 判断是否为板块代码
+func isBoardCode(code string) bool {
+	return boardCodePattern.MatchString(code)
+}
+
+// NormalizeBoardType 规范化板块类型，空值视为行业
+func NormalizeBoardType(boardType string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(boardType)) {
+	case "", BoardTypeIndustry:
+		return BoardTypeIndustry, nil
+	case BoardTypeConcept:
+		return BoardTypeConcept, nil
+	default:
+		return "", fmt.Errorf("不支持的板块类型: %s", boardType)
+	}
+}
+
+// SyncBoardsResult 板块同步结果
+type SyncBoardsResult struct {
+	Industries int     `json:"industries"` // 行业板块数
+	Concepts   int     `json:"concepts"`   // 概念板块数
+	Members    int     `json:"members"`    // 成分股记录数
+	Failed     int     `json:"failed"`     // 成分股获取失败的板块数
+	Duration   float64 `json:"duration"`   // 耗时（秒）
+	Message    string  `json:"message"`    // 消息
+}
+
+// SyncBoards 同步行业、概念板块及其成分股到本地。
+// 单个板块的成分股获取失败不影响其他板块，旧的成分股保留到下次同步。
+func (s *StockMarketService) SyncBoards() (*SyncBoardsResult, error) {
+	if s.dbService == nil {
+		return nil, fmt.Errorf("数据库服务未初始化")
+	}
+	startTime := time.Now()
+	result := &SyncBoardsResult{}
+
+	for _, boardType := range []string{BoardTypeIndustry, BoardTypeConcept} {
+		boards, err := s.fetchBoardList(boardType)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range boards {
+			members, err := s.fetchBoardMembers(b.Code)
+			if err != nil {
+				result.Failed++
+				logger.Warn("获取板块成分股失败",
+					zap.String("module", "services.stock_market"),
+					zap.String("op", "SyncBoards"),
+					zap.String("board", b.Code),
+					zap.Error(err),
+				)
+				continue
+			}
+			board := models.BoardEntity{Code: b.Code, Name: b.Name, Type: boardType}
+			if err := s.dbService.ReplaceBoardMembers(board, members); err != nil {
+				return nil, err
+			}
+			result.Members += len(members)
+		}
+		if boardType == BoardTypeIndustry {
+			result.Industries = len(boards)
+		} else {
+			result.Concepts = len(boards)
+		}
+	}
+
+	result.Duration = time.Since(startTime).Seconds()
+	result.Message = fmt.Sprintf("同步完成：行业 %d 个，概念 %d 个，成分股 %d 条", result.Industries, result.Concepts, result.Members)
+	if result.Failed > 0 {
+		result.Message += fmt.Sprintf("，%d 个板块获取成分股失败", result.Failed)
+	}
+
+	logger.Info("同步板块完成",
+		zap.String("module", "services.stock_market"),
+		zap.String("op", "SyncBoards"),
+		zap.Int("industries", result.Industries),
+		zap.Int("concepts", result.Concepts),
+		zap.Int("members", result.Members),
+		zap.Int("failed", result.Failed),
+		zap.Float64("duration", result.Duration),
+	)
+	return result, nil
+}
+
+// GetBoardMembers 获取板块成分股（来自本地已同步的数据，附带 stocks 表中的行情）
+func (s *StockMarketService) GetBoardMembers(boardCode string) ([]StockMarketData, error) {
+	if s.dbService == nil {
+		return nil, fmt.Errorf("数据库服务未初始化")
+	}
+	boardCode = strings.ToUpper(strings.TrimSpace(boardCode))
+	if !isBoardCode(boardCode) {
+		return nil, fmt.Errorf("无效的板块代码: %s", boardCode)
+	}
+
+	db := s.dbService.GetDB()
+	var members []models.BoardMemberEntity
+	if err := db.Where("board_code = ?", boardCode).Order("stock_code ASC").Find(&members).Error; err != nil {
+		return nil, fmt.Errorf("查询板块成分股失败: %w", err)
+	}
+
+	codes := make([]string, 0, len(members))
+	for _, m := range members {
+		codes = append(codes, m.StockCode)
+	}
+	var entities []models.StockEntity
+	if len(codes) > 0 {
+		// 成分股均为股票，排除同号指数
+		if err := db.Where("code IN ? AND type <> ?", codes, InstrumentTypeIndex).Find(&entities).Error; err != nil {
+			return nil, fmt.Errorf("查询成分股行情失败: %w", err)
+		}
+	}
+	byCode := make(map[string]models.StockEntity, len(entities))
+	for _, e := range entities {
+		byCode[e.Code] = e
+	}
+
+	stocks := make([]StockMarketData, 0, len(members))
+	for _, m := range members {
+		e, ok := byCode[m.StockCode]
+		if !ok {
+			// stocks 表尚未同步该股票时只返回代码与名称
+			stocks = append(stocks, StockMarketData{Code: m.StockCode, Name: m.StockName})
+			continue
+		}
+		stocks = append(stocks, StockMarketData{
+			ID:         int64(e.ID),
+			Code:       e.Code,
+			Name:       e.Name,
+			Market:     e.Market,
+			FullCode:   e.FullCode,
+			Type:       e.Type,
+			IsActive:   e.IsActive,
+			Price:      e.Price,
+			ChangeRate: e.ChangeRate,
+			Turnover:   e.Turnover,
+			PE:         e.PE,
+			Industry:   e.Industry,
+			TotalMV:    e.TotalMV,
+			CircMV:     e.CircMV,
+			UpdatedAt:  e.UpdatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	return stocks, nil
+}
+
+// fetchBoardList 从东方财富获取板块列表
+func (s *StockMarketService) fetchBoardList(boardType string) ([]IndustryInfo, error) {
+	rows, err := s.fetchClist(boardListFS[boardType], "f12,f14")
+	if err != nil {
+		logger.Error("请求板块列表失败", zap.String("type", boardType), zap.Error(err))
+		return nil, fmt.Errorf("请求板块列表失败: %w", err)
+	}
+
+	boards := make([]IndustryInfo, 0, len(rows))
+	for _, row := range rows {
+		code := getString(row["f12"])
+		if code == "" {
+			continue
+		}
+		boards = append(boards, IndustryInfo{Code: code, Name: getString(row["f14"]), Type: boardType})
+	}
+	return boards, nil
+}
+
+// fetchBoardMembers 从东方财富获取板块成分股
+func (s *StockMarketService) fetchBoardMembers(boardCode string) ([]models.BoardMemberEntity, error) {
+	rows, err := s.fetchClist("b:"+boardCode+"+f:!50", "f12,f14")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool, len(rows))
+	members := make([]models.BoardMemberEntity, 0, len(rows))
+	for _, row := range rows {
+		code := getString(row["f12"])
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		members = append(members, models.BoardMemberEntity{
+			BoardCode: boardCode,
+			StockCode: code,
+			StockName: getString(row["f14"]),
+			UpdatedAt: now,
+		})
+	}
+	return members, nil
+}
+
+// fetchClist 分页请求东方财富列表接口（np=1 返回数组），返回全部数据行
+func (s *StockMarketService) fetchClist(fs, fields string) ([]map[string]interface{}, error) {
+	var rows []map[string]interface{}
+	for pn := 1; ; pn++ {
+		var apiResp struct {
+			RC   int `json:"rc"`
+			Data *struct {
+				Total int                      `json:"total"`
+				Diff  []map[string]interface{} `json:"diff"`
+			} `json:"data"`
+		}
+		resp, err := s.client.R().
+			SetQueryParams(map[string]string{
+				"pn":     strconv.Itoa(pn),
+				"pz":     strconv.Itoa(boardPageSize),
+				"po":     "1",
+				"np":     "1",
+				"fltt":   "2",
+				"invt":   "2",
+				"fid":    "f12",
+				"fs":     fs,
+				"fields": fields,
+			}).
+			SetResult(&apiResp).
+			Get(s.clistURL)
+		if err != nil {
+			return nil, err
+		}
+		if resp.IsError() {
+			return nil, fmt.Errorf("HTTP error: %s", resp.Status())
+		}
+		if apiResp.RC != 0 {
+			return nil, fmt.Errorf("API返回错误: rc=%d", apiResp.RC)
+		}
+		// 没有数据时 data 为 null
+		if apiResp.Data == nil || len(apiResp.Data.Diff) == 0 {
+			break
+		}
+		rows = append(rows, apiResp.Data.Diff...)
+		if len(rows) >= apiResp.Data.Total {
+			break
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"reflect"
+	"stock-analyzer-wails/models"
+	"testing"
+)
+
+func TestStockMarketService_SyncBoards_Replay(t *testing.T) {
+	t.Setenv(EnvHTTPMode, HTTPModeReplay)
+	t.Setenv(EnvFixtureDir, "testdata/fixtures")
+
+	db := newTestDB(t)
+
+	stocks := []models.StockEntity{
+		{Code: "600519", Name: "贵州茅台", Market: MarketSH, FullCode: "SH600519", Type: InstrumentTypeMain, Industry: "酿酒行业", PE: 20},
+		{Code: "000858", Name: "五粮液", Market: MarketSZ, FullCode: "SZ000858", Type: InstrumentTypeMain, Industry: "酿酒行业", PE: 15},
+		{Code: "000568", Name: "泸州老窖", Market: MarketSZ, FullCode: "SZ000568", Type: InstrumentTypeMain, PE: -5}, // 亏损不计入
+		{Code: "600809", Name: "山西汾酒", Market: MarketSH, FullCode: "SH600809", Type: InstrumentTypeMain, Industry: "酿酒行业", PE: 25},
+		{Code: "000001", Name: "上证指数", Market: MarketSH, FullCode: "SH000001", Type: InstrumentTypeIndex, Industry: "酿酒行业", PE: 13},
+	}
+	if err := db.GetDB().Create(&stocks).Error; err != nil {
+		t.Fatalf("insert stocks: %v", err)
+	}
+
+	m := NewStockMarketService(db)
+	result, err := m.SyncBoards()
+	if err != nil {
+		t.Fatalf("SyncBoards: %v", err)
+	}
+	if result.Industries != 1 || result.Concepts != 2 || result.Members != 6 || result.Failed != 0 {
+		t.Fatalf("unexpected sync result: %+v", result)
+	}
+
+	concepts, err := m.GetIndustries("concept")
+	if err != nil || len(concepts) != 2 || concepts[0].Name != "沪股通" || concepts[1].MemberCount != 2 {
+		t.Fatalf("unexpected concept boards: %+v, err=%v", concepts, err)
+	}
+	if _, err := m.GetIndustries("region"); err == nil {
+		t.Fatalf("expected error for unsupported board type")
+	}
+
+	members, err := m.GetBoardMembers("BK0477")
+	if err != nil || len(members) != 3 || members[2].FullCode != "SH600519" {
+		t.Fatalf("unexpected board members: %+v, err=%v", members, err)
+	}
+
+	filtered, total, err := m.GetStocksList(1, 20, "", "BK0896")
+	if err != nil || total != 2 || len(filtered) != 2 {
+		t.Fatalf("expected 2 stocks in concept board, got %d (%+v), err=%v", total, filtered, err)
+	}
+
+	s := NewStockService()
+	s.SetDBService(db)
+	info, err := s.getIndustryInfo("600519")
+	if err != nil {
+		t.Fatalf("getIndustryInfo: %v", err)
+	}
+	want := models.IndustryInfo{
+		IndustryName: "酿酒行业",
+		ConceptNames: []string{"沪股通", "白酒"},
+		IndustryPE:   20, // (20 + 15 + 25) / 3
+	}
+	if !reflect.DeepEqual(*info, want) {
+		t.Fatalf("unexpected industry info: %+v", *info)
+	}
+}
@@ -1,8 +1,8 @@
 package services
 
 import (
-	"encoding/json"
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
@@ -119,6 +119,7 @@ const (
 type StockMarketService struct {
 	dbService *DBService
 	client    *resty.Client
+	clistURL  string // 东方财富列表接口（板块列表、板块成分股）
 }
 
 // NewStockMarketService 创建市场股票服务
@@ -129,58 +130,52 @@ func NewStockMarketService(dbService *DBService) *StockMarketService {
 	client.SetHeader("Referer", "https://quote.eastmoney.com/")
 	client.SetRetryCount(3)
 
+	applyHTTPMode(client) // 录制/回放（离线运行）
+	useGateway(client, PriorityBackground)
+
 	return &StockMarketService{
 		dbService: dbService,
 		client:    client,
+		clistURL:  "https://push2.eastmoney.com/api/qt/clist/get",
 	}
 }
 
-// IndustryInfo 行业信息
+// IndustryInfo 板块信息（行业或概念）
 type IndustryInfo struct {
-	Code string `json:"code"`
-	Name string `json:"name"`
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`        // industry/concept
+	MemberCount int    `json:"memberCount,omitempty"` // 成分股数量（同步后才有）
 }
 
-// GetIndustries 获取行业列表
-func (s *StockMarketService) GetIndustries() ([]IndustryInfo, error) {
-	url := "https://push2.eastmoney.com/api/qt/clist/get?pn=1&pz=200&po=1&np=1&ut=bd1d9ddb04089700cf9c27f6f7426281&fltt=2&invt=2&fid=f3&fs=m:90+t:2&fields=f12,f14"
-
-	resp, err := s.client.R().Get(url)
+// GetIndustries 获取板块列表，boardType 为 industry（默认）或 concept。
+// 优先使用本地已同步的板块，尚未同步时直接请求东方财富。
+func (s *StockMarketService) GetIndustries(boardType string) ([]IndustryInfo, error) {
+	boardType, err := NormalizeBoardType(boardType)
 	if err != nil {
-		logger.Error("请求行业列表失败", zap.Error(err))
-		return nil, fmt.Errorf("请求行业列表失败: %w", err)
-	}
-
-	// 定义专用响应结构体
-	type IndustryListResponse struct {
-		RC   int `json:"rc"`
-		Data struct {
-			Diff []struct {
-				F12 string `json:"f12"` // 代码
-				F14 string `json:"f14"` // 名称
-			} `json:"diff"`
-		} `json:"data"`
-	}
-
-	var apiResp IndustryListResponse
-	if err := json.Unmarshal(resp.Body(), &apiResp); err != nil {
-		logger.Error("解析行业列表失败", zap.Error(err))
-		return nil, fmt.Errorf("解析行业列表失败: %w", err)
-	}
-
-	if apiResp.RC != 0 {
-		return nil, fmt.Errorf("API返回错误: rc=%d", apiResp.RC)
+		return nil, err
 	}
 
-	industries := make([]IndustryInfo, 0)
-	for _, item := range apiResp.Data.Diff {
-		industries = append(industries, IndustryInfo{
-			Code: item.F12,
-			Name: item.F14,
-		})
+	if s.dbService != nil {
+		boards, err := s.dbService.GetBoards(boardType)
+		if err != nil {
+			return nil, err
+		}
+		if len(boards) > 0 {
+			industries := make([]IndustryInfo, 0, len(boards))
+			for _, b := range boards {
+				industries = append(industries, IndustryInfo{
+					Code:        b.Code,
+					Name:        b.Name,
+					Type:        b.Type,
+					MemberCount: b.MemberCount,
+				})
+			}
+			return industries, nil
+		}
 	}
 
-	return industries, nil
+	return s.fetchBoardList(boardType)
 }
 
 // StockMarketData 市场股票数据
@@ -190,7 +185,7 @@ type StockMarketData struct {
 	Name     string `json:"name"`
 	Market   string `json:"market"`   // SH, SZ, BJ
 	FullCode string `json:"fullCode"` // 市场代码 + 股票代码，如 SH600000
-	Type     string `json:"type"`     // 主板, 创业板, 科创板, 北交所
+	Type     string `json:"type"`     // 主板, 创业板, 科创板, 北交所, 指数, ETF, 可转债
 	// 实时行情数据
 	Price        float64 `json:"price"`        // 最新价
 	ChangeRate   float64 `json:"changeRate"`   // 涨跌幅(%)
@@ -245,9 +240,10 @@ func (s *StockMarketService) SyncAllStocks() (*SyncStocksResult, error) {
 	// 默认参数
 	pn := 1
 	pz := 5000 // 每页5000条
-	fs := "m:0+t:6,m:0+t:80,m:1+t:2,m:1+t:23,m:0+t:81+s:2048"
-	// 只请求数据库表需要的字段
-	fields := "f12,f13,f14,f2,f3,f4,f5,f6,f7,f8,f9,f10,f15,f16,f17,f18,f33,f100,f102,f103,f20,f21"
+	// 沪深京 A 股 + 沪深指数 + ETF + 可转债
+	fs := "m:0+t:6,m:0+t:80,m:1+t:2,m:1+t:23,m:0+t:81+s:2048,m:1+s:2,m:0+t:5,b:MK0021,b:MK0022,b:MK0023,b:MK0024,b:MK0354"
+	// 只请求数据库表需要的字段（f1 为价格小数位数）
+	fields := "f1,f12,f13,f14,f2,f3,f4,f5,f6,f7,f8,f9,f10,f15,f16,f17,f18,f33,f100,f102,f103,f20,f21"
 
 	// 获取数据库连接
 	db := s.dbService.GetDB()
@@ -257,6 +253,7 @@ func (s *StockMarketService) SyncAllStocks() (*SyncStocksResult, error) {
 	totalInserted := 0
 	totalUpdated := 0
 	totalCount := 0
+	seen := make([]string, 0, 8192) // 本次返回的完整代码，用于停用已不在列表中的记录
 
 	err := db.Transaction(func(tx *gorm.DB) error {
 		for {
@@ -309,12 +306,13 @@ func (s *StockMarketService) SyncAllStocks() (*SyncStocksResult, error) {
 					continue
 				}
 				stocks = append(stocks, *stockEntity)
+				seen = append(seen, stockEntity.FullCode)
 			}
 
 			if len(stocks) > 0 {
 				// 使用 CreateInBatches 进行批量插入/更新
 				result := tx.Clauses(clause.OnConflict{
-					Columns:   []clause.Column{{Name: "code"}},
+					Columns:   []clause.Column{{Name: "full_code"}},
 					UpdateAll: true,
 				}).CreateInBatches(stocks, 100)
 
@@ -348,6 +346,17 @@ func (s *StockMarketService) SyncAllStocks() (*SyncStocksResult, error) {
 			// 下一页
 			pn++
 		}
+
+		// 本次全量同步未返回的记录（已退市、或早期版本写错交易所的旧记录）标记为停止交易
+		if len(seen) > 0 {
+			result := tx.Model(&models.StockEntity{}).Where("is_active = 1 AND full_code NOT IN ?", seen).Update("is_active", 0)
+			if result.Error != nil {
+				return fmt.Errorf("停用已下线股票失败: %w", result.Error)
+			}
+			if result.RowsAffected > 0 {
+				logger.Info("已停用不在本次同步结果中的股票", zap.Int64("count", result.RowsAffected))
+			}
+		}
 		return nil
 	})
 
@@ -394,33 +403,28 @@ func (s *StockMarketService) parseStockItemToEntity(item interface{}, updatedAt
 		name = ""
 	}
 
-	// 获取market (f13: 市场)
-	market := "SH"
-	if marketCode, ok := data["f13"].(string); ok && marketCode != "" {
-		market = marketCode
-	} else {
-		// 根据代码前缀判断市场（兜底逻辑）
-		if strings.HasPrefix(code, "6") {
-			market = "SH"
-		} else if strings.HasPrefix(code, "0") || strings.HasPrefix(code, "3") {
-			market = "SZ"
-		} else if strings.HasPrefix(code, "8") || strings.HasPrefix(code, "4") {
-			market = "BJ"
-		}
+	// 交易所与证券类型（f13: 市场编号，1=沪市，0=深市/北交所）
+	inst := InferInstrument(code)
+	if marketID, ok := data[FieldMarket].(float64); ok {
+		inst = instrumentFromMarketID(int(marketID), code)
 	}
+	market := inst.Market
+	stockType := inst.Type
 
-	// 判断板块类型
-	stockType := "主板"
-	if strings.HasPrefix(code, "688") {
-		stockType = "科创板"
-	} else if strings.HasPrefix(code, "6") {
-		stockType = "主板"
-	} else if strings.HasPrefix(code, "0") {
-		stockType = "主板"
-	} else if strings.HasPrefix(code, "3") {
-		stockType = "创业板"
-	} else if strings.HasPrefix(code, "8") || strings.HasPrefix(code, "4") {
-		stockType = "北交所"
+	// 价格字段按 f1（小数位数）缩放：股票/指数为 2 位，ETF、可转债为 3 位
+	priceScale := 100.0
+	if digits, ok := data["f1"].(float64); ok && digits > 0 {
+		priceScale = math.Pow(10, digits)
+	}
+	parsePriceField := func(key string) float64 {
+		switch v := data[key].(type) {
+		case float64:
+			return v / priceScale
+		case string:
+			f, _ := strconv.ParseFloat(v, 64)
+			return f / priceScale
+		}
+		return 0
 	}
 
 	// 解析价格相关字段（接口返回的值通常是×100）
@@ -468,16 +472,16 @@ func (s *StockMarketService) parseStockItemToEntity(item interface{}, updatedAt
 		FullCode:     market + code,
 		Type:         stockType,
 		IsActive:     1,
-		Price:        parseFloat("f2"),          // f2: 最新价
+		Price:        parsePriceField("f2"),     // f2: 最新价
 		ChangeRate:   parseFloat("f3"),          // f3: 涨跌幅
-		ChangeAmount: parseFloat("f4"),          // f4: 涨跌额
+		ChangeAmount: parsePriceField("f4"),     // f4: 涨跌额
 		Volume:       parseInt("f5"),            // f5: 总手（VOL）/成交量
 		Amount:       parseInt("f6"),            // f6: 成交额
 		Amplitude:    parseFloat("f7"),          // f7: 振幅
-		High:         parseFloat("f15"),         // f15: 今日最高
-		Low:          parseFloat("f16"),         // f16: 今日最低
-		Open:         parseFloat("f17"),         // f17: 今开
-		PreClose:     parseFloat("f18"),         // f18: 昨收
+		High:         parsePriceField("f15"),    // f15: 今日最高
+		Low:          parsePriceField("f16"),    // f16: 今日最低
+		Open:         parsePriceField("f17"),    // f17: 今开
+		PreClose:     parsePriceField("f18"),    // f18: 昨收
 		Turnover:     parseFloat("f8"),          // f8: 换手率
 		VolumeRatio:  parseFloat("f10"),         // f10: 量比
 		PE:           parseFloat("f9"),          // f9: 市盈率(动态)
@@ -507,11 +511,16 @@ func (s *StockMarketService) GetStocksList(page int, pageSize int, search string
 	tx := db.Model(&models.StockEntity{})
 
 	if search != "" {
-		tx = tx.Where("code LIKE ? OR name LIKE ?", "%"+search+"%", "%"+search+"%")
+		tx = tx.Where("code LIKE ? OR name LIKE ? OR full_code LIKE ?", "%"+search+"%", "%"+search+"%", "%"+strings.ToUpper(search)+"%")
 	}
 
 	if industry != "" {
-		tx = tx.Where("industry = ?", industry)
+		if isBoardCode(industry) {
+			// 板块代码（如概念板块 BK0800）：按成分股筛选
+			tx = tx.Where("code IN (?)", db.Model(&models.BoardMemberEntity{}).Select("stock_code").Where("board_code = ?", industry))
+		} else {
+			tx = tx.Where("industry = ?", industry)
+		}
 	}
 
 	if err := tx.Count(&total).Error; err != nil {
@@ -560,20 +569,18 @@ func (s *StockMarketService) GetStocksList(page int, pageSize int, search string
 	return stocks, int(total), nil
 }
 
-// GetAllStockCodes 获取所有活跃股票代码
+// GetAllStockCodes 获取所有活跃 A 股股票代码（不含指数、ETF、可转债）
 func (s *StockMarketService) GetAllStockCodes() ([]string, error) {
 	db := s.dbService.GetDB()
 	var codes []string
 
-	err := db.Model(&models.StockEntity{}).
-		Where("is_active = 1").
+	err := ActiveEquities(db).
 		Order("code ASC").
 		Pluck("code", &codes).Error
 
 	if err != nil {
 		return nil, fmt.Errorf("查询股票代码失败: %w", err)
 	}
-
 	return codes, nil
 }
 
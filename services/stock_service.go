@@ -1,15 +1,10 @@
 package services
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
 	"math"
 	"math/rand"
-	"net/http"
 	"stock-analyzer-wails/internal/logger"
 	"stock-analyzer-wails/models"
 	"strconv"
@@ -25,19 +20,26 @@ import (
 
 // StockService 处理股票相关的业务逻辑
 type StockService struct {
-	client       *resty.Client
-	sseClient    *resty.Client // 专门用于 SSE 的客户端（不超时）
-	ctx          context.Context
-	cancel       context.CancelFunc
-	streamMu     sync.Mutex
-	streams      map[string]context.CancelFunc
-	emitIntraday func(ctx context.Context, code string, trends []string)
-	dbService    *DBService // 数据库服务
-	warnMu       sync.Mutex
-	lastWarnAt   map[string]time.Time
-	exactURL     string
-	listURL      string
-	klineURL     string
+	client          *resty.Client
+	sseClient       *resty.Client // 专门用于 SSE 的客户端（不超时）
+	ctx             context.Context
+	cancel          context.CancelFunc
+	streamMu        sync.Mutex
+	streams         map[string]context.CancelFunc // 前端订阅（code -> 取消订阅）
+	streamRefs      map[string]int                // 前端订阅计数
+	hub             *IntradayHub                  // 分时推送中心（每个 code 一条上游连接）
+	health          *streamHealthTracker          // 上游连接健康状况
+	emitStatus      func(ctx context.Context, h StreamHealth)
+	emitIntraday    func(ctx context.Context, code string, trends []string)
+	dbService       *DBService // 数据库服务
+	warnMu          sync.Mutex
+	lastWarnAt      map[string]time.Time
+	listURL         string
+	provider        MarketDataProvider  // 行情数据源（默认东方财富）
+	instruments     *InstrumentResolver // 证券标识解析（注入数据库后以 stocks 表为准）
+	quotes          *QuoteCache         // 短时行情缓存（预警、持仓、前端请求共享）
+	persistIntraday atomic.Bool         // 是否将分时推送保存到本地并聚合为 1 分钟 K 线
+	manager         *JobManager         // 长任务管理（批量同步的暂停、取消）
 }
 
 // NewStockService 创建股票服务实例
@@ -59,16 +61,35 @@ func NewStockService() *StockService {
 	sseClient.SetHeader("Cache-Control", "no-cache")
 	sseClient.SetCloseConnection(false) // 保持长连接
 
+	// 录制/回放（离线运行），同时覆盖常规请求与 SSE
+	applyHTTPMode(client)
+	applyHTTPMode(sseClient)
+
+	// 与其他数据源客户端共用限速和熔断；行情、推送都是前端交互请求，优先于后台同步
+	useGateway(client, PriorityInteractive)
+	useGateway(sseClient, PriorityInteractive)
+
 	s := &StockService{
 		client:     client,
 		sseClient:  sseClient,
 		ctx:        ctx,
 		cancel:     cancel,
-		exactURL:   "https://push2.eastmoney.com/api/qt/stock/get",
 		listURL:    "http://78.push2.eastmoney.com/api/qt/clist/get",
-		klineURL:   "https://push2his.eastmoney.com/api/qt/stock/kline/get",
+		provider:   NewEastMoneyProvider(client, sseClient),
 		streams:    make(map[string]context.CancelFunc),
+		streamRefs: make(map[string]int),
 		lastWarnAt: make(map[string]time.Time),
+		quotes:     NewQuoteCache(DefaultQuoteCacheTTL),
+		manager:    NewJobManager(),
+	}
+
+	s.hub = NewIntradayHub(func() context.Context { return s.ctx }, s.runIntradayUpstream)
+	s.health = newStreamHealthTracker(func(h StreamHealth) {
+		h.Subscribers = s.hub.SubscriberCount(h.Code)
+		s.emitStatus(s.ctx, h)
+	})
+	s.emitStatus = func(ctx context.Context, h StreamHealth) {
+		runtime.EventsEmit(ctx, StreamStatusEvent, h)
 	}
 
 	// 默认事件推送实现（生产环境）
@@ -79,9 +100,78 @@ func NewStockService() *StockService {
 	return s
 }
 
-// SetDBService 注入数据库服务。
+// SetDBService 注入数据库服务，同时让证券标识解析以 stocks 表为准。
 func (s *StockService) SetDBService(db *DBService) {
 	s.dbService = db
+	if db != nil {
+		s.instruments = NewInstrumentResolver(db.GetDB())
+		s.bindInstrumentResolver()
+	}
+}
+
+// SetJobManager 设置任务管理器
+func (s *StockService) SetJobManager(m *JobManager) {
+	s.manager = m
+}
+
+// bindInstrumentResolver 将证券标识解析器传给支持的行情数据源
+func (s *StockService) bindInstrumentResolver() {
+	if p, ok := s.provider.(instrumentAware); ok && s.instruments != nil {
+		p.SetInstrumentResolver(s.instruments)
+	}
+}
+
+// providerWith 返回常规请求携带 ctx 的行情数据源；数据源不支持时原样返回
+func (s *StockService) providerWith(ctx context.Context) MarketDataProvider {
+	if p, ok := s.provider.(contextAware); ok {
+		return p.WithContext(ctx)
+	}
+	return s.provider
+}
+
+// SetProvider 替换行情数据源（如其他厂商或离线夹具）。
+// 应在 Startup 之前调用；传入 nil 时保持原数据源不变。
+func (s *StockService) SetProvider(p MarketDataProvider) {
+	if p == nil {
+		return
+	}
+	s.provider = p
+	s.bindInstrumentResolver()
+	if s.quotes != nil {
+		s.quotes.Clear()
+	}
+}
+
+// UseProvider 按名称切换行情数据源，复用 StockService 的 HTTP/SSE 客户端。
+func (s *StockService) UseProvider(name string) error {
+	p, err := NewMarketDataProvider(name, s.client, s.sseClient)
+	if err != nil {
+		return err
+	}
+	s.SetProvider(p)
+	return nil
+}
+
+// SetQuoteCacheTTL 设置行情缓存有效期，<= 0 表示不缓存（仍合并并发请求）
+func (s *StockService) SetQuoteCacheTTL(ttl time.Duration) {
+	if s.quotes == nil {
+		s.quotes = NewQuoteCache(ttl)
+		return
+	}
+	s.quotes.SetTTL(ttl)
+}
+
+// QuoteCacheStats 返回行情缓存命中统计
+func (s *StockService) QuoteCacheStats() QuoteCacheStats {
+	if s.quotes == nil {
+		return QuoteCacheStats{}
+	}
+	return s.quotes.Stats()
+}
+
+// ProviderName 返回当前行情数据源名称
+func (s *StockService) ProviderName() string {
+	return s.provider.Name()
 }
 
 func (s *StockService) logWarnThrottled(code string, msg string, fields ...zap.Field) {
@@ -156,86 +246,69 @@ func (s *StockService) GetStockDetail(code string) (*models.StockDetail, error)
 
 // getOrderBook 获取五档盘口数据
 func (s *StockService) getOrderBook(code string) (*models.OrderBook, error) {
-	secid := s.getSecID(code)
-	if secid == "" {
-		return nil, fmt.Errorf("无效的股票代码")
-	}
-
-	fields := "f68,f69,f70,f71,f72,f73,f74,f75,f76,f77,f78,f79,f80,f81,f82,f83,f84,f85"
-	fullURL := fmt.Sprintf("%s?secid=%s&fields=%s", s.exactURL, secid, fields)
+	return s.provider.GetOrderBook(code)
+}
 
-	var result struct {
-		Data map[string]interface{} `json:"data"`
+// getFinancialSummary 获取核心财务数据：基于本地财务报表（按需同步）、分红记录与最新行情计算
+func (s *StockService) getFinancialSummary(code string) (*models.FinancialSummary, error) {
+	statements, err := s.loadFinancialStatements(code)
+	if err != nil {
+		return nil, err
 	}
-
-	resp, err := s.client.R().
-		SetResult(&result).
-		Get(fullURL)
-
+	// 行情获取失败时仍返回报表指标，市值与股息率为 0
+	stock, err := s.GetStockByCode(code)
 	if err != nil {
-		return nil, fmt.Errorf("请求盘口数据失败: %w", err)
+		stock = nil
 	}
+	return ComputeFinancialSummary(statements, s.loadDividendEvents(code), stock, time.Now())
+}
 
-	if resp.IsError() {
-		return nil, fmt.Errorf("HTTP error: %s", resp.Status())
+// getIndustryInfo 获取所属行业、概念板块与行业平均市盈率（基于本地已同步的板块与 stocks 表）
+func (s *StockService) getIndustryInfo(code string) (*models.IndustryInfo, error) {
+	db := s.dbService
+	if db == nil {
+		return nil, fmt.Errorf("数据库服务未初始化")
 	}
-
-	if result.Data == nil {
-		return nil, fmt.Errorf("未找到盘口数据: %s", code)
+	inst, err := s.instruments.Resolve(code)
+	if err != nil {
+		return nil, err
 	}
 
-	data := result.Data
-
-	orderBook := &models.OrderBook{
-		Buy5:  make([]models.OrderBookEntry, 5),
-		Sell5: make([]models.OrderBookEntry, 5),
+	boards, err := db.GetStockBoards(inst.Code)
+	if err != nil {
+		return nil, err
 	}
-
-	for i := 0; i < 5; i++ {
-		priceField := fmt.Sprintf("f%d", 73+i*2)
-		volumeField := fmt.Sprintf("f%d", 74+i*2)
-		orderBook.Sell5[i] = models.OrderBookEntry{
-			Price:  getFloat(data[priceField]) / 100,
-			Volume: getInt64(data[volumeField]),
+	info := &models.IndustryInfo{ConceptNames: []string{}}
+	industryBoard := ""
+	for _, b := range boards {
+		switch b.Type {
+		case BoardTypeIndustry:
+			if info.IndustryName == "" {
+				info.IndustryName, industryBoard = b.Name, b.Code
+			}
+		case BoardTypeConcept:
+			info.ConceptNames = append(info.ConceptNames, b.Name)
 		}
 	}
 
-	for i := 0; i < 5; i++ {
-		priceField := fmt.Sprintf("f%d", 68+i*2)
-		volumeField := fmt.Sprintf("f%d", 69+i*2)
-		orderBook.Buy5[i] = models.OrderBookEntry{
-			Price:  getFloat(data[priceField]) / 100,
-			Volume: getInt64(data[volumeField]),
+	// 板块尚未同步时退化为 stocks 表中的行业字段
+	if info.IndustryName == "" {
+		var stock models.StockEntity
+		if err := db.GetDB().Select("industry").Where("full_code = ?", inst.FullCode()).Limit(1).Find(&stock).Error; err != nil {
+			return nil, fmt.Errorf("查询所属行业失败: %w", err)
 		}
+		info.IndustryName = stock.Industry
+	}
+	if info.IndustryName == "" {
+		return info, nil
 	}
 
-	return orderBook, nil
-}
-
-// getFinancialSummary 获取核心财务数据 (Mock 数据)
-func (s *StockService) getFinancialSummary(code string) (*models.FinancialSummary, error) {
-	_ = code // mock data: keep signature for future real implementation
-	// MOCK DATA
-	return &models.FinancialSummary{
-		ROE:                    15.8,
-		NetProfitGrowthRate:    22.5,
-		GrossProfitMargin:      45.1,
-		TotalMarketValue:       12000.5, // 亿元
-		CirculatingMarketValue: 8000.2,  // 亿元
-		DividendYield:          1.5,
-		ReportDate:             time.Now().AddDate(0, -3, 0),
-	}, nil
-}
-
-// getIndustryInfo 获取行业与宏观信息 (Mock 数据)
-func (s *StockService) getIndustryInfo(code string) (*models.IndustryInfo, error) {
-	_ = code // mock data: keep signature for future real implementation
-	// MOCK DATA
-	return &models.IndustryInfo{
-		IndustryName: "软件开发",
-		ConceptNames: []string{"人工智能", "云计算", "数字经济"},
-		IndustryPE:   45.8,
-	}, nil
+	pe, err := db.GetIndustryAveragePE(info.IndustryName, industryBoard)
+	if err != nil {
+		return nil, err
+	}
+	info.IndustryPE = round2(pe)
+	return info, nil
 }
 
 // GetStockByCode 根据股票代码获取股票数据（精确查询）
@@ -246,116 +319,87 @@ func (s *StockService) GetStockByCode(code string) (*models.StockData, error) {
 		return nil, fmt.Errorf("股票代码不能为空")
 	}
 
-	secid := s.getSecID(code)
-	if secid == "" {
-		return nil, fmt.Errorf("无法识别的股票代码格式: %s", code)
-	}
-
-	fields := "f58,f43,f169,f170,f47,f48,f44,f45,f46,f60,f171,f168,f162,f167,f116,f117,f12,f14,f19,f20"
-	url := fmt.Sprintf("%s?secid=%s&fields=%s", s.exactURL, secid, fields)
-
-	var result struct {
-		Data map[string]interface{} `json:"data"`
-	}
-
-	resp, err := s.client.R().
-		SetResult(&result).
-		Get(url)
-
+	quotes, err := s.quotes.Get(quoteKindDetail, []string{code}, func(codes []string) (map[string]*models.StockData, error) {
+		stock, err := s.provider.GetStockByCode(codes[0])
+		if err != nil {
+			return nil, err
+		}
+		return map[string]*models.StockData{codes[0]: stock}, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("请求失败: %w", err)
+		return nil, err
 	}
-
-	if resp.IsError() {
-		return nil, fmt.Errorf("HTTP error: %s", resp.Status())
+	stock := quotes[code]
+	if stock == nil {
+		return nil, fmt.Errorf("未获取到股票数据: %s", code)
 	}
 
-	if result.Data == nil {
-		return nil, fmt.Errorf("未找到股票数据: %s", code)
-	}
+	logger.Info("精确获取股票数据成功", zap.String("code", code), zap.String("provider", s.provider.Name()), zap.Int64("ms", time.Since(start).Milliseconds()))
+	return stock, nil
+}
 
-	data := result.Data
-	stockCode := getString(data["f12"])
-	if stockCode == "" {
-		stockCode = code
+// GetQuotes 批量获取实时行情（去重后按批请求），返回以入参代码为 key 的结果。
+// 用于预警、持仓、策略扫描等需要轮询多只证券的场景，避免逐个调用 GetStockByCode。
+func (s *StockService) GetQuotes(codes []string) (map[string]*models.StockData, error) {
+	start := time.Now()
+	seen := make(map[string]bool, len(codes))
+	unique := make([]string, 0, len(codes))
+	for _, code := range codes {
+		code = strings.TrimSpace(code)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		unique = append(unique, code)
+	}
+	if len(unique) == 0 {
+		return map[string]*models.StockData{}, nil
 	}
 
-	stock := &models.StockData{
-		Code:         stockCode,
-		Name:         getString(data["f58"]),
-		Price:        getFloat(data["f43"]) / 100,
-		Change:       getFloat(data["f169"]) / 100,
-		ChangeRate:   getFloat(data["f170"]) / 100,
-		Volume:       getInt64(data["f47"]),
-		Amount:       getFloat(data["f48"]),
-		High:         getFloat(data["f44"]) / 100,
-		Low:          getFloat(data["f45"]) / 100,
-		Open:         getFloat(data["f46"]) / 100,
-		PreClose:     getFloat(data["f60"]) / 100,
-		Amplitude:    getFloat(data["f171"]) / 100,
-		Turnover:     getFloat(data["f168"]) / 100,
-		PE:           getFloat(data["f162"]) / 100,
-		PB:           getFloat(data["f167"]) / 100,
-		TotalMV:      getFloat(data["f116"]),
-		CircMV:       getFloat(data["f117"]),
-		VolumeRatio:  getFloat(data["f20"]) / 100,
-		WarrantRatio: getFloat(data["f19"]) / 100,
+	quotes, err := s.quotes.Get(quoteKindBatch, unique, s.provider.GetQuotes)
+	if err != nil {
+		return nil, err
 	}
 
-	logger.Info("精确获取股票数据成功", zap.String("code", code), zap.Int64("ms", time.Since(start).Milliseconds()))
-	return stock, nil
+	logger.Debug("批量获取行情成功",
+		zap.String("module", "services.stock"),
+		zap.String("op", "GetQuotes"),
+		zap.String("provider", s.provider.Name()),
+		zap.Int("requested", len(unique)),
+		zap.Int("returned", len(quotes)),
+		zap.Int64("ms", time.Since(start).Milliseconds()),
+	)
+	return quotes, nil
 }
 
 // GetKLineData 获取历史K线数据并计算技术指标
-func (s *StockService) GetKLineData(code string, limit int, period string) ([]*models.KLineData, error) {
-	secid := s.getSecID(code)
-	if secid == "" {
-		return nil, fmt.Errorf("无效的股票代码")
-	}
-
-	klt := "101"
-	switch period {
-	case "week":
-		klt = "102"
-	case "month":
-		klt = "103"
-	}
-
-	fetchLimit := limit + 50
-	url := fmt.Sprintf("%s?secid=%s&fields1=f1,f2,f3,f4,f5,f6&fields2=f51,f52,f53,f54,f55,f56&klt=%s&fqt=1&end=20500101&lmt=%d", s.klineURL, secid, klt, fetchLimit)
+// period 支持 daily/week/month 以及 1min/5min/15min/30min/60min；
+// adjust 支持 none/forward/backward（空值为前复权）。
+func (s *StockService) GetKLineData(code string, limit int, period string, adjust string) ([]*models.KLineData, error) {
+	return s.getKLineData(context.Background(), code, limit, period, adjust)
+}
 
-	var result struct {
-		Data struct {
-			Klines []string `json:"klines"`
-		} `json:"data"`
+// getKLineData 同 GetKLineData，行情请求携带 ctx（可取消、可指定网关优先级）
+func (s *StockService) getKLineData(ctx context.Context, code string, limit int, period string, adjust string) ([]*models.KLineData, error) {
+	period, err := NormalizeKLinePeriod(period)
+	if err != nil {
+		return nil, err
 	}
-
-	resp, err := s.client.R().
-		SetResult(&result).
-		Get(url)
-
+	adjust, err = NormalizeKLineAdjust(adjust)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.IsError() {
-		return nil, fmt.Errorf("HTTP error: %s", resp.Status())
+	// 多取 50 根用于指标预热，返回前再截断
+	fetchLimit := limit + 50
+	var klines []*models.KLineData
+	if IsMinuteKLinePeriod(period) {
+		klines, err = s.getMinuteKLines(ctx, code, period, adjust, fetchLimit)
+	} else {
+		klines, err = s.providerWith(ctx).GetKLineData(code, fetchLimit, period, adjust)
 	}
-
-	klines := make([]*models.KLineData, 0)
-	for _, line := range result.Data.Klines {
-		parts := strings.Split(line, ",")
-		if len(parts) < 6 {
-			continue
-		}
-		klines = append(klines, &models.KLineData{
-			Time:   parts[0],
-			Open:   s.parsePrice(parts[1]),
-			Close:  s.parsePrice(parts[2]),
-			High:   s.parsePrice(parts[3]),
-			Low:    s.parsePrice(parts[4]),
-			Volume: int64(s.parsePrice(parts[5])),
-		})
+	if err != nil {
+		return nil, err
 	}
 
 	s.calculateIndicators(klines)
@@ -366,6 +410,76 @@ func (s *StockService) GetKLineData(code string, limit int, period string) ([]*m
 	return klines, nil
 }
 
+// getMinuteKLines 获取分钟线并写入本地周期缓存。
+// 行情源只保留最近若干交易日的分钟线，因此以缓存为准：
+// 拉取成功后先合并入库，再从缓存读取；拉取失败时降级为只读缓存。
+// 各复权方式分别缓存，切换复权方式不会清掉其他方式积累的分钟线。
+func (s *StockService) getMinuteKLines(ctx context.Context, code string, period string, adjust string, limit int) ([]*models.KLineData, error) {
+	klines, fetchErr := s.providerWith(ctx).GetKLineData(code, limit, period, adjust)
+	db := s.dbService
+	if db == nil {
+		return klines, fetchErr
+	}
+
+	if fetchErr == nil && len(klines) > 0 {
+		records := make([]map[string]interface{}, 0, len(klines))
+		for _, k := range klines {
+			records = append(records, map[string]interface{}{
+				"date":   k.Time,
+				"open":   k.Open,
+				"high":   k.High,
+				"low":    k.Low,
+				"close":  k.Close,
+				"volume": k.Volume,
+			})
+		}
+		if _, _, err := db.InsertOrUpdateKLinePeriodData(code, period, adjust, records); err != nil {
+			logger.Warn("写入分钟线缓存失败",
+				zap.String("module", "services.stock"),
+				zap.String("op", "getMinuteKLines"),
+				zap.String("code", code),
+				zap.String("period", period),
+				zap.Error(err),
+			)
+		}
+	}
+
+	cached, err := db.GetKLinePeriodDataFromCache(code, period, adjust, limit)
+	if err != nil || len(cached) < len(klines) {
+		return klines, fetchErr
+	}
+	if fetchErr != nil {
+		if len(cached) == 0 {
+			return nil, fetchErr
+		}
+		logger.Warn("拉取分钟线失败，使用本地缓存",
+			zap.String("module", "services.stock"),
+			zap.String("op", "getMinuteKLines"),
+			zap.String("code", code),
+			zap.String("period", period),
+			zap.Int("cached", len(cached)),
+			zap.Error(fetchErr),
+		)
+	}
+	return cacheRecordsToKLines(cached), nil
+}
+
+// cacheRecordsToKLines 将缓存记录（GetKLine*FromCache 的返回值）转换为 KLineData
+func cacheRecordsToKLines(records []map[string]interface{}) []*models.KLineData {
+	klines := make([]*models.KLineData, 0, len(records))
+	for _, r := range records {
+		k := &models.KLineData{}
+		k.Time, _ = r["date"].(string)
+		k.Open, _ = r["open"].(float64)
+		k.High, _ = r["high"].(float64)
+		k.Low, _ = r["low"].(float64)
+		k.Close, _ = r["close"].(float64)
+		k.Volume, _ = r["volume"].(int64)
+		klines = append(klines, k)
+	}
+	return klines
+}
+
 func (s *StockService) calculateIndicators(klines []*models.KLineData) {
 	if len(klines) == 0 {
 		return
@@ -439,78 +553,39 @@ func (s *StockService) calculateIndicators(klines []*models.KLineData) {
 
 // GetIntradayData 获取分时数据 (非实时快照，用于初始化)
 func (s *StockService) GetIntradayData(code string) (*models.IntradayResponse, error) {
-	secid := s.getSecID(code)
-	if secid == "" {
-		return nil, fmt.Errorf("无效的股票代码")
-	}
-
-	url := fmt.Sprintf("https://push2.eastmoney.com/api/qt/stock/trends2/get?secid=%s&fields1=f1,f2,f3,f4,f5,f6,f7,f8,f9,f10,f11,f12,f13&fields2=f51,f52,f53,f54,f55,f56,f57,f58&ndays=1&iscr=0&ut=fa5fd1943c7b386f172d6893dbfba10b", secid)
-
-	var result struct {
-		Data struct {
-			PreClose float64  `json:"preClose"`
-			Trends   []string `json:"trends"`
-		} `json:"data"`
-	}
-
-	resp, err := s.client.R().
-		SetResult(&result).
-		Get(url)
-
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.IsError() {
-		return nil, fmt.Errorf("HTTP error: %s", resp.Status())
-	}
-
-	intradayData := make([]models.IntradayData, 0)
-	for _, line := range result.Data.Trends {
-		parts := strings.Split(line, ",")
-		if len(parts) < 8 {
-			continue
-		}
-		intradayData = append(intradayData, models.IntradayData{
-			Time:     parts[0],
-			Price:    s.parsePrice(parts[2]),
-			AvgPrice: s.parsePrice(parts[7]),
-			Volume:   int64(s.parsePrice(parts[5])),
-			PreClose: result.Data.PreClose,
-		})
-	}
-
-	return &models.IntradayResponse{
-		Data:     intradayData,
-		PreClose: result.Data.PreClose,
-	}, nil
+	return s.provider.GetIntradayData(code)
 }
 
-// StopIntradayStream 停止指定股票的分时 SSE 流。
+// StopIntradayStream 释放前端对指定股票分时推送的一次订阅。
 //
 // 设计说明：
-// - 前端可能会重复调用 StreamIntradayData（例如切换 Tab/刷新页面/重复进入详情页）。
-// - SSE 是长连接，如果不显式 stop，会造成 goroutine 泄漏、重复推送、网络连接占用。
-// - 这里按股票 code 维度管理 cancel func：同一 code 只允许存在一个活跃 SSE 流。
+// - 前端多个页面（自选列表、详情页）可能同时订阅同一 code，这里按 code 计数。
+// - 计数归零时才取消前端订阅；上游 SSE 连接由 IntradayHub 在最后一个订阅者（含预警等进程内订阅）退出时关闭。
 func (s *StockService) StopIntradayStream(code string) {
 	code = strings.TrimSpace(code)
 	if code == "" {
 		return
 	}
 	s.streamMu.Lock()
-	cancel := s.streams[code]
+	if s.streamRefs[code] > 1 {
+		s.streamRefs[code]--
+		s.streamMu.Unlock()
+		return
+	}
+	unsubscribe := s.streams[code]
 	delete(s.streams, code)
+	delete(s.streamRefs, code)
 	s.streamMu.Unlock()
-	if cancel != nil {
-		cancel()
+	if unsubscribe != nil {
+		unsubscribe()
 	}
 }
 
-// StreamIntradayData 实时流式获取分时数据 (SSE 代理)。
+// StreamIntradayData 订阅分时推送并通过 Wails Events 转发给前端 (SSE 代理)。
 //
 // 核心特性：
-// 1) 使用专用 sseClient（Timeout=0）避免默认 10s 超时导致的 "context deadline exceeded"。
-// 2) 同一 code 重复启动会先 Stop 旧流，避免重复 goroutine & 重复推送。
+// 1) 同一 code 只保持一条上游连接（IntradayHub），前端重复订阅只增加计数，不会重复推送。
+// 2) 连接由 MarketDataProvider 建立（默认东方财富使用 Timeout=0 的 sseClient，避免 "context deadline exceeded"）。
 // 3) 自动重连：连接失败/非 200/读流错误/EOF 会进入重试（指数退避 + 抖动）。
 func (s *StockService) StreamIntradayData(code string) {
 	code = strings.TrimSpace(code)
@@ -518,199 +593,158 @@ func (s *StockService) StreamIntradayData(code string) {
 		return
 	}
 
-	// 如果同一个 code 已经在流式推送，先停掉旧的，避免重复推送/泄漏
-	s.StopIntradayStream(code)
-
-	// 子 context：用于单个 code 的生命周期控制（StopIntradayStream 会 cancel）
-	sseCtx, cancel := context.WithCancel(s.ctx)
 	s.streamMu.Lock()
-	s.streams[code] = cancel
-	s.streamMu.Unlock()
+	defer s.streamMu.Unlock()
+	s.streamRefs[code]++
+	if _, ok := s.streams[code]; ok {
+		return
+	}
+	s.streams[code] = s.hub.Subscribe(code, func(trends []string) {
+		s.emitIntraday(s.ctx, code, trends)
+	})
+}
 
-	go func() {
-		defer func() {
-			// goroutine 退出时清理 map，避免残留
-			s.streamMu.Lock()
-			delete(s.streams, code)
-			s.streamMu.Unlock()
-		}()
+// SubscribeIntraday 在进程内订阅分时推送（预警、持仓监控等），返回取消订阅函数。
+// 与前端共享同一条上游连接；回调需尽快返回。
+func (s *StockService) SubscribeIntraday(code string, fn func(trends []string)) func() {
+	return s.hub.Subscribe(strings.TrimSpace(code), fn)
+}
 
-		secid := s.getSecID(code)
-		if secid == "" {
-			logger.Error("无效的股票代码（无法推送 SSE）",
+// IntradaySubscribers 返回指定股票分时推送的订阅者数量（含前端与进程内订阅）
+func (s *StockService) IntradaySubscribers(code string) int {
+	return s.hub.SubscriberCount(strings.TrimSpace(code))
+}
+
+// GetStreamHealth 返回所有活跃分时推送连接的健康状况（按代码升序）
+func (s *StockService) GetStreamHealth() []StreamHealth {
+	list := s.health.List()
+	for i := range list {
+		list[i].Subscribers = s.hub.SubscriberCount(list[i].Code)
+	}
+	return list
+}
+
+// runIntradayUpstream 维持单个 code 的上游 SSE 连接直到 ctx 取消，推送交给 IntradayHub 分发。
+// 连接状态变化（连接中/推送中/等待重连/停止）记录到 health 并通过 intradayStreamStatus 事件通知前端。
+func (s *StockService) runIntradayUpstream(ctx context.Context, code string, publish func(trends []string)) {
+	health := s.health.Start(code, s.provider.Name())
+	defer s.health.Stop(health)
+
+	var recorder *intradayRecorder
+	if s.persistIntraday.Load() && s.dbService != nil {
+		recorder = newIntradayRecorder(s.dbService, code)
+	}
+	onTrends := func(trends []string) {
+		s.health.Frame(health)
+		publish(trends)
+		if recorder == nil {
+			return
+		}
+		if err := recorder.Record(trends); err != nil {
+			s.logWarnThrottled(code, "保存分时数据失败",
 				zap.String("module", "services.stock"),
 				zap.String("op", "StreamIntradayData"),
 				zap.String("code", code),
+				zap.Error(err),
+			)
+		}
+	}
+
+	retry := 0
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("SSE 流已停止",
+				zap.String("module", "services.stock"),
+				zap.String("op", "StreamIntradayData"),
+				zap.String("code", code),
+				zap.Error(ctx.Err()),
 			)
 			return
+		default:
 		}
 
-		sseURL := fmt.Sprintf("https://push2.eastmoney.com/api/qt/stock/trends2/sse?secid=%s&fields1=f1,f2,f3,f4,f5,f6,f7,f8,f9,f10,f11,f12,f13&fields2=f51,f52,f53,f54,f55,f56,f57,f58&ndays=1&iscr=0&ut=fa5fd1943c7b386f172d6893dbfba10b", secid)
-
-		retry := 0
-		for {
-			select {
-			case <-sseCtx.Done():
-				logger.Info("SSE 流已停止",
-					zap.String("module", "services.stock"),
-					zap.String("op", "StreamIntradayData"),
-					zap.String("code", code),
-					zap.Error(sseCtx.Err()),
-				)
-				return
-			default:
-			}
+		attemptStart := time.Now()
+		err := s.provider.StreamIntraday(ctx, code, onTrends)
 
-			// 2. 使用 Resty 的 sseClient 发起请求
-			// DoNotParseResponse: true -> 拿到原始 Response，自己处理流
-			attemptStart := time.Now()
-			resp, err := s.sseClient.R().
-				SetContext(sseCtx).
-				SetDoNotParseResponse(true).
-				Get(sseURL)
-
-			if err != nil {
-				// 取消（页面离开、应用退出等）属于正常退出，不需要报错
-				if sseCtx.Err() != nil {
-					logger.Info("SSE 连接结束（已取消）",
-						zap.String("module", "services.stock"),
-						zap.String("op", "StreamIntradayData"),
-						zap.String("code", code),
-						zap.Error(sseCtx.Err()),
-						zap.Int64("duration_ms", time.Since(attemptStart).Milliseconds()),
-					)
-					return
-				}
-				s.logWarnThrottled(code, "连接 SSE 接口失败，准备重试",
-					zap.String("module", "services.stock"),
-					zap.String("op", "StreamIntradayData"),
-					zap.String("code", code),
-					zap.String("url", sseURL),
-					zap.Int("retry", retry),
-					zap.Error(err),
-					zap.Int64("duration_ms", time.Since(attemptStart).Milliseconds()),
-				)
-				if !s.sleepBackoff(sseCtx, retry) {
-					return
-				}
-				retry++
-				continue
-			}
+		// 取消（页面离开、应用退出等）属于正常退出，不需要报错
+		if ctx.Err() != nil {
+			logger.Info("SSE 连接结束（已取消）",
+				zap.String("module", "services.stock"),
+				zap.String("op", "StreamIntradayData"),
+				zap.String("code", code),
+				zap.Error(ctx.Err()),
+				zap.Int64("duration_ms", time.Since(attemptStart).Milliseconds()),
+			)
+			return
+		}
 
-			// 3. 处理响应体 (RawBody)
-			func() {
-				rawBody := resp.RawBody()
-				defer func() { _ = rawBody.Close() }()
-
-				if resp.StatusCode() != http.StatusOK {
-					// 尽量读一点 body 帮助定位（例如被限流、被 WAF 拦截、返回错误 JSON 等）
-					b, _ := io.ReadAll(io.LimitReader(rawBody, 2048))
-					s.logWarnThrottled(code, "SSE 接口返回非 200，准备重试",
-						zap.String("module", "services.stock"),
-						zap.String("op", "StreamIntradayData"),
-						zap.String("code", code),
-						zap.String("url", sseURL),
-						zap.Int("status", resp.StatusCode()),
-						zap.ByteString("body", b),
-						zap.Int("retry", retry),
-						zap.Int64("duration_ms", time.Since(attemptStart).Milliseconds()),
-					)
-					return
-				}
+		s.health.Disconnected(health, err)
+		if err != nil {
+			s.logWarnThrottled(code, "SSE 推送异常，准备重试",
+				zap.String("module", "services.stock"),
+				zap.String("op", "StreamIntradayData"),
+				zap.String("code", code),
+				zap.String("provider", s.provider.Name()),
+				zap.Int("retry", retry),
+				zap.Error(err),
+				zap.Int64("duration_ms", time.Since(attemptStart).Milliseconds()),
+			)
+		} else {
+			logger.Info("SSE 读取结束（远端关闭/EOF），准备重试",
+				zap.String("module", "services.stock"),
+				zap.String("op", "StreamIntradayData"),
+				zap.String("code", code),
+				zap.String("provider", s.provider.Name()),
+				zap.Int("retry", retry),
+				zap.Int64("duration_ms", time.Since(attemptStart).Milliseconds()),
+			)
+		}
 
-				scanner := bufio.NewScanner(rawBody)
-				// SSE 的单行 data 可能超过默认 64KB，必须放大 buffer，否则会 ErrTooLong
-				buf := make([]byte, 0, 64*1024)
-				scanner.Buffer(buf, 2*1024*1024)
-
-				for scanner.Scan() {
-					select {
-					case <-sseCtx.Done():
-						logger.Info("SSE 流被取消",
-							zap.String("module", "services.stock"),
-							zap.String("op", "StreamIntradayData"),
-							zap.String("code", code),
-							zap.Int64("duration_ms", time.Since(attemptStart).Milliseconds()),
-						)
-						return
-					default:
-					}
-
-					line := scanner.Text()
-					// SSE 允许空行/注释/心跳行（例如 ": keep-alive"）
-					if line == "" || strings.HasPrefix(line, ":") {
-						continue
-					}
-					if strings.HasPrefix(line, "data:") {
-						jsonStr := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
-						var sseData struct {
-							Data struct {
-								Trends []string `json:"trends"`
-							} `json:"data"`
-						}
-						if err := json.Unmarshal([]byte(jsonStr), &sseData); err != nil {
-							// 解析失败通常是服务端偶发推送了非预期格式；这里用 throttled warn 避免刷屏。
-							s.logWarnThrottled(code, "SSE data JSON 解析失败（将忽略该条）",
-								zap.String("module", "services.stock"),
-								zap.String("op", "StreamIntradayData"),
-								zap.String("code", code),
-								zap.Int("json_len", len(jsonStr)),
-								zap.Error(err),
-							)
-							continue
-						}
-						if sseData.Data.Trends != nil {
-							s.emitIntraday(s.ctx, code, sseData.Data.Trends)
-						}
-					}
-				}
+		// 正常结束（EOF）与异常均进入重试，除非已取消
+		if !s.sleepBackoff(ctx, retry) {
+			return
+		}
+		retry++
+		s.health.Reconnect(health)
+	}
+}
 
-				if err := scanner.Err(); err != nil {
-					if sseCtx.Err() != nil {
-						logger.Info("SSE 读取结束（已取消）",
-							zap.String("module", "services.stock"),
-							zap.String("op", "StreamIntradayData"),
-							zap.String("code", code),
-							zap.Error(sseCtx.Err()),
-							zap.Int64("duration_ms", time.Since(attemptStart).Milliseconds()),
-						)
-						return
-					}
-					if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
-						logger.Info("SSE 读取结束（远端关闭/EOF），准备重试",
-							zap.String("module", "services.stock"),
-							zap.String("op", "StreamIntradayData"),
-							zap.String("code", code),
-							zap.String("url", sseURL),
-							zap.Int("retry", retry),
-							zap.Error(err),
-							zap.Int64("duration_ms", time.Since(attemptStart).Milliseconds()),
-						)
-						return
-					}
-					s.logWarnThrottled(code, "读取 SSE 流发生错误，准备重试",
-						zap.String("module", "services.stock"),
-						zap.String("op", "StreamIntradayData"),
-						zap.String("code", code),
-						zap.String("url", sseURL),
-						zap.Int("retry", retry),
-						zap.Error(err),
-						zap.Int64("duration_ms", time.Since(attemptStart).Milliseconds()),
-					)
-					return
-				}
-			}()
+// SetIntradayPersistence 设置是否保存分时推送（对之后启动的推送生效）
+func (s *StockService) SetIntradayPersistence(enabled bool) {
+	s.persistIntraday.Store(enabled)
+}
 
-			// 正常结束（EOF/非200）也进入重试，除非已取消
-			if sseCtx.Err() != nil {
-				return
-			}
-			if !s.sleepBackoff(sseCtx, retry) {
-				return
-			}
-			retry++
-		}
-	}()
+// IntradayPersistenceEnabled 是否保存分时推送
+func (s *StockService) IntradayPersistenceEnabled() bool {
+	return s.persistIntraday.Load()
+}
+
+// GetStoredIntradayData 读取本地保存的分时数据（用于回看历史交易日），tradeDate 为空时取最近一个交易日
+func (s *StockService) GetStoredIntradayData(code string, tradeDate string) (*models.IntradayResponse, error) {
+	db := s.dbService
+	if db == nil {
+		return nil, fmt.Errorf("数据库服务未初始化")
+	}
+	points, preClose, err := db.GetIntradayPoints(strings.TrimSpace(code), strings.TrimSpace(tradeDate))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.IntradayResponse{
+		Data:     make([]models.IntradayData, 0, len(points)),
+		PreClose: preClose,
+	}
+	for _, p := range points {
+		resp.Data = append(resp.Data, models.IntradayData{
+			Time:     p.TradeDate + " " + p.Time,
+			Price:    p.Price,
+			AvgPrice: p.AvgPrice,
+			Volume:   p.Volume,
+			PreClose: preClose,
+		})
+	}
+	return resp, nil
 }
 
 // sleepBackoff sleeps with exponential backoff + jitter. Returns false if ctx cancelled.
@@ -846,7 +880,7 @@ func (s *StockService) BatchAnalyzeStocks(ctx context.Context, codes []string, r
 				if err != nil {
 					continue
 				}
-				klines, err := s.GetKLineData(code, 100, "daily")
+				klines, err := s.GetKLineData(code, 100, KLinePeriodDaily, KLineAdjustForward)
 				if err != nil {
 					continue
 				}
@@ -900,65 +934,12 @@ func (s *StockService) parsePrice(p string) float64 {
 }
 
 func (s *StockService) getSecID(code string) string {
-	if len(code) != 6 {
-		return ""
-	}
-	if code[0] == '6' {
-		return "1." + code
-	} else if code[0] == '0' || code[0] == '3' || code[0] == '8' || code[0] == '4' {
-		return "0." + code
-	}
-	return ""
+	return s.instruments.SecID(code)
 }
 
 // GetMoneyFlowData 获取资金流数据
 func (s *StockService) GetMoneyFlowData(code string) (*models.MoneyFlowResponse, error) {
-	secid := s.getSecID(code)
-	if secid == "" {
-		return nil, fmt.Errorf("无效的股票代码")
-	}
-
-	// 使用东方财富的资金流接口
-	url := fmt.Sprintf("http://push2.eastmoney.com/api/qt/stock/fflow/daykline/get?secid=%s&fields1=f1,f2,f3,f7&fields2=f51,f52,f53,f54,f55,f56,f57,f58,f59,f60,f61,f62,f63,f64,f65&lmt=0&klt=101&fqt=1", secid)
-
-	var result struct {
-		Data struct {
-			Flows []string `json:"klines"`
-		} `json:"data"`
-	}
-
-	resp, err := s.client.R().
-		SetResult(&result).
-		Get(url)
-
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.IsError() {
-		return nil, fmt.Errorf("HTTP error: %s", resp.Status())
-	}
-
-	flowData := make([]models.MoneyFlowData2, 0)
-	for _, line := range result.Data.Flows {
-		parts := strings.Split(line, ",")
-		if len(parts) < 11 {
-			continue
-		}
-		flowData = append(flowData, models.MoneyFlowData2{
-			Time:   parts[0],
-			Main:   s.parsePrice(parts[1]),
-			Retail: s.parsePrice(parts[4]),
-			Super:  s.parsePrice(parts[7]),
-			Big:    s.parsePrice(parts[8]),
-			Medium: s.parsePrice(parts[9]),
-			Small:  s.parsePrice(parts[10]),
-		})
-	}
-
-	return &models.MoneyFlowResponse{
-		Data: flowData,
-	}, nil
+	return s.provider.GetMoneyFlowData(code)
 }
 
 // SearchStock 搜索股票
@@ -1008,13 +989,25 @@ func (s *StockService) SearchStockLegacy(keyword string) ([]*models.StockData, e
 }
 
 // SyncStockData 同步单个股票的历史数据到本地 SQLite
-// 该方法会为每个股票创建一个独立的表（如 kline_600519），并存储历史 K 线数据
-func (s *StockService) SyncStockData(code string, startDate string, endDate string) (*models.SyncResult, error) {
+// 历史 K 线写入 kline_bars 表（按代码、周期、复权方式、日期存储）。
+// adjust 为复权方式；同步不复权数据时会一并同步除权除息事件，便于本地复权。
+func (s *StockService) SyncStockData(code string, startDate string, endDate string, adjust string) (*models.SyncResult, error) {
+	return s.syncStockData(context.Background(), code, startDate, endDate, adjust)
+}
+
+// syncStockData 同 SyncStockData，行情请求携带 ctx（批量同步时为任务上下文，并降为后台优先级）
+func (s *StockService) syncStockData(ctx context.Context, code string, startDate string, endDate string, adjust string) (*models.SyncResult, error) {
 	result := &models.SyncResult{
 		StockCode: code,
 		Success:   false,
 	}
 
+	adjust, err := NormalizeKLineAdjust(adjust)
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		return result, err
+	}
+
 	// 获取数据库服务实例
 	db := s.dbService
 	if db == nil {
@@ -1033,7 +1026,7 @@ func (s *StockService) SyncStockData(code string, startDate string, endDate stri
 	}
 
 	// 1. 获取股票的历史 K 线数据
-	klines, err := s.GetKLineData(code, 5000, "daily")
+	klines, err := s.getKLineData(ctx, code, 5000, KLinePeriodDaily, adjust)
 	if err != nil {
 		result.ErrorMessage = fmt.Sprintf("获取 K 线数据失败: %v", err)
 		return result, err
@@ -1072,12 +1065,24 @@ func (s *StockService) SyncStockData(code string, startDate string, endDate stri
 	}
 
 	// 4. 批量插入或更新数据
-	addedCount, updatedCount, err := db.InsertOrUpdateKLineData(code, klineRecords)
+	addedCount, updatedCount, err := db.InsertOrUpdateKLinePeriodData(code, KLinePeriodDaily, adjust, klineRecords)
 	if err != nil {
 		result.ErrorMessage = fmt.Sprintf("插入数据失败: %v", err)
 		return result, err
 	}
 
+	// 不复权数据需要除权除息事件才能在本地复权，失败不影响 K 线同步结果
+	if adjust == KLineAdjustNone {
+		if _, err := s.syncExRightsEvents(ctx, code); err != nil {
+			logger.Warn("同步除权除息事件失败",
+				zap.String("module", "services.stock"),
+				zap.String("op", "SyncStockData"),
+				zap.String("code", code),
+				zap.Error(err),
+			)
+		}
+	}
+
 	// 5. 返回成功结果
 	result.Success = true
 	result.RecordsAdded = int(addedCount)
@@ -1087,6 +1092,64 @@ func (s *StockService) SyncStockData(code string, startDate string, endDate stri
 	return result, nil
 }
 
+// SyncExRightsEvents 从行情源同步指定股票的除权除息事件到本地，返回事件数量
+func (s *StockService) SyncExRightsEvents(code string) (int, error) {
+	return s.syncExRightsEvents(context.Background(), code)
+}
+
+// syncExRightsEvents 同 SyncExRightsEvents，行情请求携带 ctx
+func (s *StockService) syncExRightsEvents(ctx context.Context, code string) (int, error) {
+	db := s.dbService
+	if db == nil {
+		return 0, fmt.Errorf("数据库服务未初始化")
+	}
+	p, ok := s.providerWith(ctx).(ExRightsProvider)
+	if !ok {
+		return 0, fmt.Errorf("行情数据源 %s 不支持除权除息数据", s.provider.Name())
+	}
+
+	events, err := p.GetExRightsEvents(code)
+	if err != nil {
+		return 0, err
+	}
+	if err := db.SaveExRightsEvents(events); err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+// GetLocallyAdjustedKLines 基于本地不复权日线缓存与除权除息事件计算复权 K 线（含技术指标），不访问行情源
+func (s *StockService) GetLocallyAdjustedKLines(code string, limit int, adjust string) ([]*models.KLineData, error) {
+	db := s.dbService
+	if db == nil {
+		return nil, fmt.Errorf("数据库服务未初始化")
+	}
+	adjust, err := NormalizeKLineAdjust(adjust)
+	if err != nil {
+		return nil, err
+	}
+
+	// 复权因子依赖完整历史，必须基于整段不复权数据计算后再截取
+	cached, err := db.GetKLinePeriodDataFromCache(code, KLinePeriodDaily, KLineAdjustNone, math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+	if len(cached) == 0 {
+		return nil, fmt.Errorf("本地没有 %s 的不复权日线缓存，请先以不复权方式同步", code)
+	}
+	events, err := db.GetExRightsEvents(code)
+	if err != nil {
+		return nil, err
+	}
+
+	klines := AdjustKLines(cacheRecordsToKLines(cached), events, adjust)
+	s.calculateIndicators(klines)
+	if limit > 0 && len(klines) > limit {
+		return klines[len(klines)-limit:], nil
+	}
+	return klines, nil
+}
+
 // GetDataSyncStats 获取数据同步统计信息
 // 返回已同步的股票列表、总记录数等信息
 func (s *StockService) GetDataSyncStats() (*models.DataSyncStats, error) {
@@ -1137,30 +1200,34 @@ func (s *StockService) GetDataSyncStats() (*models.DataSyncStats, error) {
 // 优先从 SQLite 读取，如果数据不足则从 API 补充
 func (s *StockService) GetKLineFromCache(code string, limit int) ([]*models.KLineData, error) {
 	// TODO: 实现缓存读取逻辑
-	// 1. 从数据库表 kline_{code} 中读取最新的 limit 条记录
+	// 1. 从 kline_bars 中读取该股票最新的 limit 条记录
 	// 2. 如果记录数不足，从 API 获取补充数据
 	// 3. 将补充的数据存储到数据库
 	// 4. 返回合并后的 K 线数据
 
 	// 当前直接调用 API（后续优化为缓存优先）
-	return s.GetKLineData(code, limit, "daily")
+	return s.GetKLineData(code, limit, KLinePeriodDaily, KLineAdjustForward)
 }
 
 // ClearStockCache 清除指定股票的本地缓存数据
 func (s *StockService) ClearStockCache(code string) error {
 	// TODO: 实现缓存清除逻辑
-	// 1. 删除数据库表 kline_{code}
+	// 1. 删除 kline_bars 中该股票的记录
 	// 2. 返回删除结果
 
 	return nil
 }
 
 // BatchSyncStockData 批量同步多个股票的历史数据
-// 该方法会为每个股票创建独立的表，并通过 Wails 事件发送同步进度
-func (s *StockService) BatchSyncStockData(codes []string, startDate string, endDate string) error {
+// 该方法会将每个股票的数据写入 K 线缓存，并通过 Wails 事件发送同步进度。
+// 同步在任务管理器中登记，可暂停、取消；取消时已同步的股票保留，不返回错误。
+func (s *StockService) BatchSyncStockData(codes []string, startDate string, endDate string, adjust string) error {
 	if len(codes) == 0 {
 		return fmt.Errorf("股票代码列表为空")
 	}
+	job := s.manager.Begin(JobBatchSync, "")
+	// 行情客户端是交互优先级；批量同步随任务取消，并让位于行情刷新、预警和分时推送
+	ctx := withRequestPriority(job.Context(), PriorityBackground)
 
 	logger.Info("开始批量同步股票数据",
 		zap.String("module", "services.stock"),
@@ -1168,6 +1235,7 @@ func (s *StockService) BatchSyncStockData(codes []string, startDate string, endD
 		zap.Int("stock_count", len(codes)),
 		zap.String("start_date", startDate),
 		zap.String("end_date", endDate),
+		zap.String("adjust", adjust),
 	)
 
 	startTime := time.Now()
@@ -1177,13 +1245,25 @@ func (s *StockService) BatchSyncStockData(codes []string, startDate string, endD
 	successCodes := []string{}
 
 	// 遍历 codes 列表
+	cancelled := false
 	for i, code := range codes {
-		// 调用 SyncStockData
-		result, err := s.SyncStockData(code, startDate, endDate)
+		// 暂停时在这里等待，取消时停止同步剩余股票
+		if job.Wait() != nil {
+			cancelled = true
+			break
+		}
+
+		result, err := s.syncStockData(ctx, code, startDate, endDate, adjust)
+		if job.Context().Err() != nil {
+			// 同步中途被取消的股票不计入失败
+			cancelled = true
+			break
+		}
 
 		// 发送进度事件
 		if s.ctx != nil {
 			runtime.EventsEmit(s.ctx, "dataSyncProgress", map[string]interface{}{
+				"jobId":        job.ID(),
 				"currentIndex": i + 1,
 				"totalCount":   len(codes),
 				"currentCode":  code,
@@ -1204,6 +1284,7 @@ func (s *StockService) BatchSyncStockData(codes []string, startDate string, endD
 			totalUpdated += result.RecordsUpdated
 			successCodes = append(successCodes, code)
 		}
+		job.SetProgress(i+1, len(codes))
 
 		// 避免 API 限流，短暂延迟
 		if i < len(codes)-1 {
@@ -1213,7 +1294,9 @@ func (s *StockService) BatchSyncStockData(codes []string, startDate string, endD
 
 	duration := int(time.Since(startTime).Seconds())
 
-	logger.Info("批量同步完成",
+	logger.Info("批量同步结束",
+		zap.String("job_id", job.ID()),
+		zap.Bool("cancelled", cancelled),
 		zap.Int("success_count", len(successCodes)),
 		zap.Int("failed_count", len(failedCodes)),
 		zap.Int("total_added", totalAdded),
@@ -1221,10 +1304,25 @@ func (s *StockService) BatchSyncStockData(codes []string, startDate string, endD
 		zap.Int("duration", duration),
 	)
 
+	result := map[string]interface{}{
+		"successCodes": successCodes,
+		"failedCodes":  failedCodes,
+		"totalAdded":   totalAdded,
+		"totalUpdated": totalUpdated,
+		"duration":     duration,
+	}
+	if cancelled {
+		s.manager.Finish(job, JobCancelled, result, "")
+		return nil
+	}
+
 	// 如果所有都失败了，返回错误
 	if len(successCodes) == 0 {
-		return fmt.Errorf("批量同步失败，所有股票同步都失败了")
+		err := fmt.Errorf("批量同步失败，所有股票同步都失败了")
+		s.manager.Finish(job, JobFailed, result, err.Error())
+		return err
 	}
 
+	s.manager.Finish(job, JobCompleted, result, "")
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestSleepBackoff_Cancelled(t *testing.T) {
@@ -25,49 +26,55 @@ func TestSSEClient_NoTimeout(t *testing.T) {
 	}
 }
 
-//
-//func TestStream_StopReplacesOld(t *testing.T) {
-//	s := NewStockService()
-//	s.Startup(context.Background())
-//
-//	// Override emitter so tests don't depend on Wails runtime context.
-//	s.emitIntraday = func(ctx context.Context, code string, trends []string) {
-//		// no-op
-//	}
-//
-//	// avoid real network calls by intercepting with a test server transport
-//	var hits int32
-//	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-//		atomic.AddInt32(&hits, 1)
-//		w.Header().Set("Content-Type", "text/event-stream")
-//		w.WriteHeader(http.StatusOK)
-//		// immediately close
-//		_, _ = io.WriteString(w, "data: {\"data\": {\"trends\": [\"t,0,0,0,0,0,0,0\"]}}\n\n")
-//	}))
-//	defer ts.Close()
-//
-//	// Replace sseClient transport to route any request to our server.
-//	s.sseClient.Transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
-//		req.URL.Scheme = "http"
-//		req.URL.Host = ts.Listener.Addr().String()
-//		return http.DefaultTransport.RoundTrip(req)
-//	})
-//
-//	code := "600519"
-//	// start twice, second should cancel first
-//	s.StreamIntradayData(code)
-//	s.StreamIntradayData(code)
-//
-//	// wait a bit to allow goroutines to run
-//	time.Sleep(200 * time.Millisecond)
-//
-//	s.StopIntradayStream(code)
-//
-//	// we should have at least one attempt
-//	if atomic.LoadInt32(&hits) == 0 {
-//		t.Fatalf("expected at least one SSE connect attempt")
-//	}
-//}
+func TestStreamIntradayData_Replay(t *testing.T) {
+	dir := t.TempDir()
+	secid := InferInstrument("600519").SecID()
+	writeFixture(t, dir, "sse.json", httpFixture{
+		URL:    "https://push2.eastmoney.com/api/qt/stock/trends2/sse?" + trendsQuery(secid),
+		Status: http.StatusOK,
+		Header: http.Header{"Content-Type": []string{"text/event-stream"}},
+		Body: ": keep-alive\n\n" +
+			"data: {\"data\": {\"trends\": [\"2024-01-02 09:30,1700,1701,1702,1699,100,170000,1700.5\"]}}\n\n" +
+			"data: not-json\n\n" +
+			"data: {\"data\": {\"trends\": [\"2024-01-02 09:31,1701,1703,1704,1700,80,136000,1701.2\"]}}\n\n",
+	})
+	t.Setenv(EnvHTTPMode, HTTPModeReplay)
+	t.Setenv(EnvFixtureDir, dir)
+
+	s := NewStockService()
+	s.Startup(context.Background())
+
+	got := make(chan []string, 16)
+	s.emitIntraday = func(ctx context.Context, code string, trends []string) {
+		got <- trends
+	}
+
+	s.emitStatus = func(ctx context.Context, h StreamHealth) {}
+
+	code := "600519"
+	// 重复订阅只计数，不会建立第二条上游连接
+	s.StreamIntradayData(code)
+	s.StreamIntradayData(code)
+	defer s.StopIntradayStream(code)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case trends := <-got:
+			if len(trends) != 1 {
+				t.Fatalf("expected 1 trend per frame, got %v", trends)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for replayed SSE frame %d", i+1)
+		}
+	}
+
+	s.streamMu.Lock()
+	active := len(s.streams)
+	s.streamMu.Unlock()
+	if active != 1 {
+		t.Fatalf("expected exactly 1 active stream, got %d", active)
+	}
+}
 
 type roundTripperFunc func(*http.Request) (*http.Response, error)
 
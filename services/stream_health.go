@@ -0,0 +1,149 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// 分时推送连接状态
+const (
+	StreamStateConnecting   = "connecting"   // 正在建立连接（首次或重连）
+	StreamStateStreaming    = "streaming"    // 已收到推送
+	StreamStateReconnecting = "reconnecting" // 连接断开，等待重连
+	StreamStateStopped      = "stopped"      // 已停止（最后一个订阅者退出）
+)
+
+// StreamStatusEvent 连接状态变化事件名（前端监听）
+const StreamStatusEvent = "intradayStreamStatus"
+
+// StreamHealth 单个代码的分时推送连接健康状况，时间均为毫秒时间戳（0 表示尚无）
+type StreamHealth struct {
+	Code              string `json:"code"`
+	Provider          string `json:"provider"`
+	State             string `json:"state"`
+	Subscribers       int    `json:"subscribers"`       // 订阅者数量（前端与进程内订阅）
+	Reconnects        int    `json:"reconnects"`        // 重连次数
+	Errors            int    `json:"errors"`            // 累计错误次数
+	ConsecutiveErrors int    `json:"consecutiveErrors"` // 连续错误次数，收到推送后清零
+	Frames            int64  `json:"frames"`            // 收到的推送帧数
+	LastError         string `json:"lastError"`
+	LastErrorAt       int64  `json:"lastErrorAt"`
+	LastFrameAt       int64  `json:"lastFrameAt"`
+	StartedAt         int64  `json:"startedAt"`      // 上游连接建立时间（首次连接）
+	StateChangedAt    int64  `json:"stateChangedAt"` // 最近一次状态变化时间
+}
+
+// streamHealthTracker 记录各代码上游连接的健康状况，状态变化时通过 onChange 通知。
+// onChange 在锁外调用。
+type streamHealthTracker struct {
+	mu       sync.Mutex
+	streams  map[string]*StreamHealth
+	onChange func(h StreamHealth)
+	now      func() time.Time
+}
+
+// newStreamHealthTracker 创建连接健康记录
+func newStreamHealthTracker(onChange func(h StreamHealth)) *streamHealthTracker {
+	return &streamHealthTracker{
+		streams:  make(map[string]*StreamHealth),
+		onChange: onChange,
+		now:      time.Now,
+	}
+}
+
+// Start 上游连接开始，返回该连接的记录句柄。
+// 同一代码停止后立即重新订阅时，旧连接的后续更新不会覆盖新连接的记录。
+func (t *streamHealthTracker) Start(code, provider string) *StreamHealth {
+	now := t.now().UnixMilli()
+	h := &StreamHealth{Code: code, Provider: provider, StartedAt: now}
+	t.mu.Lock()
+	t.streams[code] = h
+	t.setState(h, StreamStateConnecting, now)
+	snapshot := *h
+	t.mu.Unlock()
+
+	if t.onChange != nil {
+		t.onChange(snapshot)
+	}
+	return h
+}
+
+// Reconnect 开始一次重连
+func (t *streamHealthTracker) Reconnect(h *StreamHealth) {
+	t.update(h, func(now int64) bool {
+		h.Reconnects++
+		return t.setState(h, StreamStateConnecting, now)
+	})
+}
+
+// Frame 收到一帧推送（只在状态变化时通知）
+func (t *streamHealthTracker) Frame(h *StreamHealth) {
+	t.update(h, func(now int64) bool {
+		h.Frames++
+		h.LastFrameAt = now
+		h.ConsecutiveErrors = 0
+		return t.setState(h, StreamStateStreaming, now)
+	})
+}
+
+// Disconnected 连接断开，err 为 nil 表示远端正常关闭（EOF）
+func (t *streamHealthTracker) Disconnected(h *StreamHealth, err error) {
+	t.update(h, func(now int64) bool {
+		changed := false
+		if err != nil {
+			h.Errors++
+			h.ConsecutiveErrors++
+			h.LastError = err.Error()
+			h.LastErrorAt = now
+			// 错误信息变化也需要通知，便于面板展示
+			changed = true
+		}
+		return t.setState(h, StreamStateReconnecting, now) || changed
+	})
+}
+
+// Stop 上游连接停止，通知后移除记录
+func (t *streamHealthTracker) Stop(h *StreamHealth) {
+	t.update(h, func(now int64) bool {
+		if t.streams[h.Code] == h {
+			delete(t.streams, h.Code)
+		}
+		return t.setState(h, StreamStateStopped, now)
+	})
+}
+
+// List 返回所有活跃连接的健康状况（按代码升序）
+func (t *streamHealthTracker) List() []StreamHealth {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	list := make([]StreamHealth, 0, len(t.streams))
+	for _, h := range t.streams {
+		list = append(list, *h)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list
+}
+
+// update 在锁内修改记录，fn 返回 true 且记录仍是该代码的当前连接时在锁外通知
+func (t *streamHealthTracker) update(h *StreamHealth, fn func(now int64) bool) {
+	t.mu.Lock()
+	current := t.streams[h.Code] == h
+	changed := fn(t.now().UnixMilli())
+	snapshot := *h
+	t.mu.Unlock()
+
+	if changed && current && t.onChange != nil {
+		t.onChange(snapshot)
+	}
+}
+
+// setState 设置状态，返回状态是否变化
+func (t *streamHealthTracker) setState(h *StreamHealth, state string, now int64) bool {
+	if h.State == state {
+		return false
+	}
+	h.State = state
+	h.StateChangedAt = now
+	return true
+}
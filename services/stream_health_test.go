@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyStreamProvider 第一次连接失败，之后推送一帧并保持连接直到取消
+type flakyStreamProvider struct {
+	fakeProvider
+	calls atomic.Int32
+}
+
+func (f *flakyStreamProvider) StreamIntraday(ctx context.Context, code string, onTrends func(trends []string)) error {
+	if f.calls.Add(1) == 1 {
+		return errors.New("connection reset")
+	}
+	onTrends([]string{"2024-01-02 09:30,10,10,10,10,100,1000,10"})
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestStreamHealth_StatusEvents(t *testing.T) {
+	s := NewStockService()
+	s.SetProvider(&flakyStreamProvider{})
+	s.emitIntraday = func(ctx context.Context, code string, trends []string) {}
+	events := make(chan StreamHealth, 16)
+	s.emitStatus = func(ctx context.Context, h StreamHealth) { events <- h }
+
+	s.StreamIntradayData("600519")
+
+	next := func() StreamHealth {
+		t.Helper()
+		select {
+		case h := <-events:
+			return h
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timed out waiting for status event")
+		}
+		return StreamHealth{}
+	}
+
+	if h := next(); h.State != StreamStateConnecting || h.Provider != "fake" {
+		t.Fatalf("expected connecting via fake provider, got %+v", h)
+	}
+	if h := next(); h.State != StreamStateReconnecting || h.Errors != 1 || h.LastError != "connection reset" {
+		t.Fatalf("expected reconnecting after error, got %+v", h)
+	}
+	if h := next(); h.State != StreamStateConnecting || h.Reconnects != 1 {
+		t.Fatalf("expected reconnect attempt, got %+v", h)
+	}
+	h := next()
+	if h.State != StreamStateStreaming || h.ConsecutiveErrors != 0 || h.LastFrameAt == 0 || h.Subscribers != 1 {
+		t.Fatalf("expected streaming with 1 subscriber, got %+v", h)
+	}
+
+	list := s.GetStreamHealth()
+	if len(list) != 1 || list[0].Code != "600519" || list[0].Frames != 1 || list[0].Errors != 1 {
+		t.Fatalf("unexpected stream health list: %+v", list)
+	}
+
+	s.StopIntradayStream("600519")
+	if h := next(); h.State != StreamStateStopped {
+		t.Fatalf("expected stopped, got %+v", h)
+	}
+	if list := s.GetStreamHealth(); len(list) != 0 {
+		t.Fatalf("expected no active streams after stop, got %+v", list)
+	}
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"stock-analyzer-wails/internal/logger"
+	"stock-analyzer-wails/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 同步任务类型
+const (
+	SyncJobKLine     = "kline"      // K线同步
+	SyncJobMoneyFlow = "money_flow" // 全市场资金流同步
+)
+
+// 同步任务状态
+const (
+	SyncJobRunning     = "running"
+	SyncJobCompleted   = "completed"
+	SyncJobInterrupted = "interrupted" // 程序退出或任务被取消时未处理完
+	SyncJobFailed      = "failed"
+)
+
+// 检查点状态
+const (
+	syncItemPending = "pending"
+	syncItemSuccess = "success"
+	syncItemFailed  = "failed"
+)
+
+// SyncJobStore 同步任务与检查点的持久化。
+// 任务开始时写入全部股票（pending），每只股票处理完后记录检查点；续传时只处理未成功的股票。
+type SyncJobStore struct {
+	dbService *DBService
+	now       func() time.Time
+}
+
+// NewSyncJobStore 创建同步任务存储
+func NewSyncJobStore(db *DBService) *SyncJobStore {
+	return &SyncJobStore{dbService: db, now: time.Now}
+}
+
+// Create 创建同步任务并写入全部待处理股票
+func (s *SyncJobStore) Create(kind string, params interface{}, items []models.SyncJobItemEntity) (*models.SyncJobEntity, error) {
+	paramsJSON := ""
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("序列化任务参数失败: %w", err)
+		}
+		paramsJSON = string(data)
+	}
+
+	now := s.now()
+	job := &models.SyncJobEntity{
+		ID:        kind + "-" + strconv.FormatInt(now.UnixNano(), 36),
+		Kind:      kind,
+		Status:    SyncJobRunning,
+		Params:    paramsJSON,
+		Total:     len(items),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for i := range items {
+		items[i].JobID = job.ID
+		items[i].Seq = i
+		items[i].Status = syncItemPending
+		items[i].UpdatedAt = now
+	}
+
+	err := s.dbService.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(items, 500).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建同步任务失败: %w", err)
+	}
+	if err := s.prune(); err != nil {
+		logger.Warn("清理历史同步任务失败",
+			zap.String("module", "services.sync_jobs"),
+			zap.String("op", "Create"),
+			zap.Error(err),
+		)
+	}
+	return job, nil
+}
+
+// prune 已结束的任务超过 jobHistoryKeep 个时删除最早的任务及其检查点
+// （中断、失败的任务会保留未完成的检查点，不清理会一直累积）
+func (s *SyncJobStore) prune() error {
+	db := s.dbService.GetDB()
+	var ids []string
+	err := db.Model(&models.SyncJobEntity{}).Where("status <> ?", SyncJobRunning).
+		Order("created_at DESC, id DESC").Pluck("id", &ids).Error
+	if err != nil {
+		return fmt.Errorf("查询同步任务失败: %w", err)
+	}
+	if len(ids) <= jobHistoryKeep {
+		return nil
+	}
+	stale := ids[jobHistoryKeep:]
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("job_id IN ?", stale).Delete(&models.SyncJobItemEntity{}).Error; err != nil {
+			return fmt.Errorf("清除同步检查点失败: %w", err)
+		}
+		if err := tx.Where("id IN ?", stale).Delete(&models.SyncJobEntity{}).Error; err != nil {
+			return fmt.Errorf("删除同步任务失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// Get 获取同步任务
+func (s *SyncJobStore) Get(id string) (*models.SyncJobEntity, error) {
+	var job models.SyncJobEntity
+	if err := s.dbService.GetDB().Where("id = ?", id).Take(&job).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("同步任务不存在: %s", id)
+		}
+		return nil, fmt.Errorf("查询同步任务失败: %w", err)
+	}
+	return &job, nil
+}
+
+// List 按创建时间倒序列出同步任务，kind 为空时不限类型
+func (s *SyncJobStore) List(kind string, limit int) ([]models.SyncJobEntity, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	tx := s.dbService.GetDB().Order("created_at DESC").Limit(limit)
+	if kind != "" {
+		tx = tx.Where("kind = ?", kind)
+	}
+	var jobs []models.SyncJobEntity
+	if err := tx.Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("查询同步任务失败: %w", err)
+	}
+	return jobs, nil
+}
+
+// Checkpoint 记录一批股票的处理结果，并按状态变化增量更新任务的成功/失败计数
+// （只读取这批股票原来的状态，不重新统计整个任务）
+func (s *SyncJobStore) Checkpoint(jobID string, keys []string, success bool, errMsg string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	status := syncItemSuccess
+	if !success {
+		status = syncItemFailed
+	}
+	now := s.now()
+	err := s.dbService.GetDB().Transaction(func(tx *gorm.DB) error {
+		var prev []struct {
+			Status string
+			Count  int64
+		}
+		err := tx.Model(&models.SyncJobItemEntity{}).Select("status, COUNT(*) AS count").
+			Where("job_id = ? AND item_key IN ?", jobID, keys).Group("status").Scan(&prev).Error
+		if err != nil {
+			return err
+		}
+		// 先减去这批股票原来的计数再按新状态加上：续传时失败转成功、重复失败都不会重复计数
+		var total, succeeded, failed int64
+		for _, p := range prev {
+			total += p.Count
+			switch p.Status {
+			case syncItemSuccess:
+				succeeded -= p.Count
+			case syncItemFailed:
+				failed -= p.Count
+			}
+		}
+		if success {
+			succeeded += total
+		} else {
+			failed += total
+		}
+
+		err = tx.Model(&models.SyncJobItemEntity{}).
+			Where("job_id = ? AND item_key IN ?", jobID, keys).
+			Updates(map[string]interface{}{
+				"status":     status,
+				"error":      errMsg,
+				"attempts":   gorm.Expr("attempts + 1"),
+				"updated_at": now,
+			}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.SyncJobEntity{}).Where("id = ?", jobID).Updates(map[string]interface{}{
+			"succeeded":  gorm.Expr("succeeded + ?", succeeded),
+			"failed":     gorm.Expr("failed + ?", failed),
+			"updated_at": now,
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("记录同步检查点失败: %w", err)
+	}
+	return nil
+}
+
+// Finish 结束同步任务。全部成功时清除检查点，只保留任务记录。
+func (s *SyncJobStore) Finish(jobID, status, errMsg string) error {
+	now := s.now()
+	return s.dbService.GetDB().Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.SyncJobEntity{}).Where("id = ?", jobID).Updates(map[string]interface{}{
+			"status":      status,
+			"error":       errMsg,
+			"updated_at":  now,
+			"finished_at": now,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("更新同步任务状态失败: %w", err)
+		}
+		if status != SyncJobCompleted {
+			return nil
+		}
+		var remaining int64
+		if err := tx.Model(&models.SyncJobItemEntity{}).Where("job_id = ? AND status <> ?", jobID, syncItemSuccess).Count(&remaining).Error; err != nil {
+			return fmt.Errorf("统计未完成检查点失败: %w", err)
+		}
+		if remaining > 0 {
+			return nil
+		}
+		if err := tx.Where("job_id = ?", jobID).Delete(&models.SyncJobItemEntity{}).Error; err != nil {
+			return fmt.Errorf("清除同步检查点失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// Reopen 将未完成的任务重新置为运行中，返回任务及尚未成功的股票（按原始顺序）。
+// 运行中的任务、以及已全部成功的任务不能续传。
+func (s *SyncJobStore) Reopen(jobID, kind string) (*models.SyncJobEntity, []models.SyncJobItemEntity, error) {
+	job, err := s.Get(jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Kind != kind {
+		return nil, nil, fmt.Errorf("任务类型不匹配: %s", job.Kind)
+	}
+	if job.Status == SyncJobRunning {
+		return nil, nil, fmt.Errorf("同步任务正在运行: %s", jobID)
+	}
+
+	var items []models.SyncJobItemEntity
+	if err := s.dbService.GetDB().Where("job_id = ? AND status <> ?", jobID, syncItemSuccess).Order("seq ASC").Find(&items).Error; err != nil {
+		return nil, nil, fmt.Errorf("查询同步检查点失败: %w", err)
+	}
+	if len(items) == 0 {
+		return nil, nil, fmt.Errorf("同步任务已全部完成，无需续传")
+	}
+
+	now := s.now()
+	err = s.dbService.GetDB().Model(&models.SyncJobEntity{}).Where("id = ?", jobID).Updates(map[string]interface{}{
+		"status":      SyncJobRunning,
+		"error":       "",
+		"updated_at":  now,
+		"finished_at": nil,
+	}).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("更新同步任务状态失败: %w", err)
+	}
+	job.Status = SyncJobRunning
+	job.Error = ""
+	job.FinishedAt = nil
+
+	logger.Info("续传同步任务",
+		zap.String("module", "services.sync_jobs"),
+		zap.String("op", "Reopen"),
+		zap.String("job_id", jobID),
+		zap.Int("remaining", len(items)),
+	)
+	return job, items, nil
+}
+
+// MarkInterrupted 将上次运行遗留的 running 任务标记为 interrupted（启动时调用，此时不会有任务在运行）
+func (s *SyncJobStore) MarkInterrupted() (int64, error) {
+	result := s.dbService.GetDB().Model(&models.SyncJobEntity{}).
+		Where("status = ?", SyncJobRunning).
+		Updates(map[string]interface{}{"status": SyncJobInterrupted, "updated_at": s.now()})
+	if result.Error != nil {
+		return 0, fmt.Errorf("标记中断的同步任务失败: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		logger.Info("发现上次未完成的同步任务",
+			zap.String("module", "services.sync_jobs"),
+			zap.String("op", "MarkInterrupted"),
+			zap.Int64("count", result.RowsAffected),
+		)
+	}
+	return result.RowsAffected, nil
+}
+
+// decodeSyncJobParams 解析任务参数
+func decodeSyncJobParams(job *models.SyncJobEntity, v interface{}) error {
+	if job.Params == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(job.Params), v); err != nil {
+		return fmt.Errorf("解析任务参数失败: %w", err)
+	}
+	return nil
+}
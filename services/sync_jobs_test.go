@@ -0,0 +1,187 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"stock-analyzer-wails/models"
+)
+
+func TestSyncJobStore_CheckpointAndReopen(t *testing.T) {
+	db := newTestDB(t)
+	store := NewSyncJobStore(db)
+
+	job, err := store.Create(SyncJobMoneyFlow, map[string]int{"limit": 120}, []models.SyncJobItemEntity{
+		{ItemKey: "600519", Code: "600519"},
+		{ItemKey: "000001", Code: "000001"},
+		{ItemKey: "000002", Code: "000002"},
+	})
+	if err != nil || job.Status != SyncJobRunning || job.Total != 3 {
+		t.Fatalf("Create = %+v, %v", job, err)
+	}
+	if err := store.Checkpoint(job.ID, []string{"600519"}, true, ""); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	if err := store.Checkpoint(job.ID, []string{"000002"}, false, "timeout"); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+
+	// 运行中的任务不能续传；程序重启后标记为中断
+	if _, _, err := store.Reopen(job.ID, SyncJobMoneyFlow); err == nil {
+		t.Fatalf("expected a running job to be rejected")
+	}
+	if n, err := store.MarkInterrupted(); err != nil || n != 1 {
+		t.Fatalf("MarkInterrupted = %d, %v", n, err)
+	}
+	got, err := store.Get(job.ID)
+	if err != nil || got.Status != SyncJobInterrupted || got.Succeeded != 1 || got.Failed != 1 {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+
+	if _, _, err := store.Reopen(job.ID, SyncJobKLine); err == nil {
+		t.Fatalf("expected a kind mismatch to be rejected")
+	}
+	reopened, items, err := store.Reopen(job.ID, SyncJobMoneyFlow)
+	if err != nil || reopened.Status != SyncJobRunning {
+		t.Fatalf("Reopen = %+v, %v", reopened, err)
+	}
+	if len(items) != 2 || items[0].Code != "000001" || items[1].Code != "000002" || items[1].Attempts != 1 {
+		t.Fatalf("expected pending and failed items in order, got %+v", items)
+	}
+
+	// 再次失败不重复计数；失败转成功时从失败计数中移出
+	if err := store.Checkpoint(job.ID, []string{"000002"}, false, "timeout"); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	if got, _ := store.Get(job.ID); got.Succeeded != 1 || got.Failed != 1 {
+		t.Fatalf("expected a repeated failure to be counted once, got %+v", got)
+	}
+	if err := store.Checkpoint(job.ID, []string{"000001", "000002"}, true, ""); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	if got, _ := store.Get(job.ID); got.Succeeded != 3 || got.Failed != 0 {
+		t.Fatalf("expected the retried items to move to succeeded, got %+v", got)
+	}
+	if err := store.Finish(job.ID, SyncJobCompleted, ""); err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+	var remaining int64
+	db.GetDB().Model(&models.SyncJobItemEntity{}).Where("job_id = ?", job.ID).Count(&remaining)
+	if remaining != 0 {
+		t.Fatalf("expected checkpoints of a fully successful job to be cleared, got %d", remaining)
+	}
+	if _, _, err := store.Reopen(job.ID, SyncJobMoneyFlow); err == nil {
+		t.Fatalf("expected a completed job to be rejected")
+	}
+
+	jobs, err := store.List(SyncJobMoneyFlow, 10)
+	if err != nil || len(jobs) != 1 || jobs[0].Succeeded != 3 || jobs[0].FinishedAt == nil {
+		t.Fatalf("List = %+v, %v", jobs, err)
+	}
+}
+
+func TestKLineSync_ResumeProcessesRemainingCodes(t *testing.T) {
+	db := newTestDB(t)
+	now := cst("2024-06-14 20:00:00")
+	server := &fakeKLineServer{closes: map[string]float64{"600519": 1700, "000001": 11, "000002": 8.5}, requests: map[string]string{}}
+	svc := NewKLineSyncService(db)
+	svc.client.SetTransport(server)
+	svc.now = func() time.Time { return now }
+
+	// 模拟上次运行：第一只股票完成后程序退出
+	params := klineSyncParams{Days: 5, Adjust: KLineAdjustForward}
+	job, err := svc.jobs.Create(SyncJobKLine, params, klineSyncItems([]*KLineSyncTask{
+		{Code: "600519", Name: "贵州茅台", Market: MarketSH},
+		{Code: "000001", Name: "平安银行", Market: MarketSZ},
+		{Code: "000002", Name: "万科A", Market: MarketSZ},
+	}))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := svc.jobs.Checkpoint(job.ID, []string{"600519"}, true, ""); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	if _, err := svc.jobs.MarkInterrupted(); err != nil {
+		t.Fatalf("MarkInterrupted: %v", err)
+	}
+
+	result, err := svc.ResumeKLineSync(job.ID)
+	if err != nil || result.JobID != job.ID || result.TotalCount != 2 || result.SuccessCount != 2 {
+		t.Fatalf("ResumeKLineSync = %+v, %v", result, err)
+	}
+	if _, ok := server.requests["1.600519"]; ok || len(server.requests) != 2 {
+		t.Fatalf("expected only the remaining codes to be fetched, got %v", server.requests)
+	}
+	if q := server.requests["0.000001"]; q == "" {
+		t.Fatalf("expected 000001 to be fetched with the original params")
+	}
+
+	got, err := svc.jobs.Get(job.ID)
+	if err != nil || got.Status != SyncJobCompleted || got.Succeeded != 3 {
+		t.Fatalf("job after resume = %+v, %v", got, err)
+	}
+	if _, err := svc.ResumeKLineSync(job.ID); err == nil {
+		t.Fatalf("expected a completed job to be rejected")
+	}
+}
+
+func TestSyncJobStore_PrunesFinishedJobsAndItems(t *testing.T) {
+	db := newTestDB(t)
+	store := NewSyncJobStore(db)
+	now := cst("2024-06-14 20:00:00")
+	store.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	// 最早的中断、失败任务各留有未完成的检查点
+	items := func() []models.SyncJobItemEntity {
+		return []models.SyncJobItemEntity{{ItemKey: "600519", Code: "600519"}, {ItemKey: "000001", Code: "000001"}}
+	}
+	var first []string
+	for i, status := range []string{SyncJobInterrupted, SyncJobFailed} {
+		job, err := store.Create(SyncJobKLine, nil, items())
+		if err != nil {
+			t.Fatalf("Create %d: %v", i, err)
+		}
+		if err := store.Finish(job.ID, status, "offline"); err != nil {
+			t.Fatalf("Finish %d: %v", i, err)
+		}
+		first = append(first, job.ID)
+	}
+	for i := 0; i < jobHistoryKeep; i++ {
+		job, err := store.Create(SyncJobMoneyFlow, nil, items())
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if err := store.Finish(job.ID, SyncJobInterrupted, ""); err != nil {
+			t.Fatalf("Finish: %v", err)
+		}
+	}
+	// 运行中的任务不计入保留数量，也不会被清理
+	running, err := store.Create(SyncJobKLine, nil, items())
+	if err != nil {
+		t.Fatalf("Create running: %v", err)
+	}
+
+	for _, id := range first {
+		if _, err := store.Get(id); err == nil {
+			t.Fatalf("expected job %s to be pruned", id)
+		}
+	}
+	var orphaned int64
+	db.GetDB().Model(&models.SyncJobItemEntity{}).Where("job_id IN ?", first).Count(&orphaned)
+	if orphaned != 0 {
+		t.Fatalf("expected checkpoints of pruned jobs to be removed, got %d", orphaned)
+	}
+
+	var jobs, itemRows int64
+	db.GetDB().Model(&models.SyncJobEntity{}).Count(&jobs)
+	db.GetDB().Model(&models.SyncJobItemEntity{}).Count(&itemRows)
+	if jobs != jobHistoryKeep+1 || itemRows != 2*(jobHistoryKeep+1) {
+		t.Fatalf("expected %d jobs with their items, got %d jobs, %d items", jobHistoryKeep+1, jobs, itemRows)
+	}
+	if got, err := store.Get(running.ID); err != nil || got.Status != SyncJobRunning {
+		t.Fatalf("running job = %+v, %v", got, err)
+	}
+}
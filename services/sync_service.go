@@ -24,6 +24,9 @@ type SyncService struct {
 	stockMarketService *StockMarketService
 	moneyFlowRepo      *repositories.MoneyFlowRepository
 	client             *resty.Client
+	instruments        *InstrumentResolver
+	jobs               *SyncJobStore
+	manager            *JobManager
 	ctx                context.Context
 	running            bool
 	mu                 sync.Mutex
@@ -31,10 +34,11 @@ type SyncService struct {
 
 // SyncProgress 同步进度结构体
 type SyncProgress struct {
+	JobID        string `json:"jobId"` // 同步任务ID
 	Total        int    `json:"total"`
 	Current      int    `json:"current"`
 	CurrentStock string `json:"currentStock"`
-	Status       string `json:"status"` // "running", "completed", "error"
+	Status       string `json:"status"` // "running", "completed", "cancelled", "error"
 	SuccessCount int    `json:"successCount"`
 	FailedCount  int    `json:"failedCount"`
 }
@@ -57,12 +61,21 @@ func NewSyncService(
 	// 这是解决 Eastmoney API EOF 问题的关键
 	client.SetCloseConnection(true)
 
-	return &SyncService{
+	applyHTTPMode(client) // 录制/回放（离线运行）
+	useGateway(client, PriorityBackground)
+
+	s := &SyncService{
 		dbService:          dbService,
 		stockMarketService: stockMarketService,
 		moneyFlowRepo:      moneyFlowRepo,
 		client:             client,
+		manager:            NewJobManager(),
+	}
+	if dbService != nil {
+		s.instruments = NewInstrumentResolver(dbService.GetDB())
+		s.jobs = NewSyncJobStore(dbService)
 	}
+	return s
 }
 
 // SetContext 设置上下文
@@ -70,21 +83,23 @@ func (s *SyncService) SetContext(ctx context.Context) {
 	s.ctx = ctx
 }
 
+// SetJobManager 设置任务管理器
+func (s *SyncService) SetJobManager(m *JobManager) {
+	s.manager = m
+}
+
 // StartFullMarketSync 启动全市场历史资金流同步
 func (s *SyncService) StartFullMarketSync() error {
-	s.mu.Lock()
-	if s.running {
-		s.mu.Unlock()
-		return fmt.Errorf("同步任务已在运行中")
-	}
-	s.running = true
-	s.mu.Unlock()
+	_, err := s.RunFullMarketSync()
+	return err
+}
 
-	defer func() {
-		s.mu.Lock()
-		s.running = false
-		s.mu.Unlock()
-	}()
+// RunFullMarketSync 执行全市场历史资金流同步并返回最终进度，Status 为 cancelled 时表示任务被取消
+func (s *SyncService) RunFullMarketSync() (*SyncProgress, error) {
+	if !s.beginSync() {
+		return nil, fmt.Errorf("同步任务已在运行中")
+	}
+	defer s.endSync()
 
 	logger.Info("开始全市场历史资金流同步任务")
 
@@ -92,14 +107,77 @@ func (s *SyncService) StartFullMarketSync() error {
 	codes, err := s.stockMarketService.GetAllStockCodes()
 	if err != nil {
 		s.emitProgress(&SyncProgress{Status: "error", CurrentStock: "获取股票列表失败"})
-		return fmt.Errorf("获取股票列表失败: %w", err)
+		return nil, fmt.Errorf("获取股票列表失败: %w", err)
+	}
+
+	// 持久化任务和每只股票的检查点，程序中途退出后可以续传
+	items := make([]models.SyncJobItemEntity, len(codes))
+	for i, code := range codes {
+		items[i] = models.SyncJobItemEntity{ItemKey: code, Code: code}
 	}
+	job, err := s.jobs.Create(SyncJobMoneyFlow, nil, items)
+	if err != nil {
+		s.emitProgress(&SyncProgress{Status: "error", CurrentStock: "创建同步任务失败"})
+		return nil, err
+	}
+	return s.runMoneyFlowJob(job, codes)
+}
+
+// ResumeFullMarketSync 续传中断的全市场资金流同步任务，只处理尚未成功（未处理或失败）的股票
+func (s *SyncService) ResumeFullMarketSync(jobID string) error {
+	if !s.beginSync() {
+		return fmt.Errorf("同步任务已在运行中")
+	}
+	defer s.endSync()
+
+	job, items, err := s.jobs.Reopen(jobID, SyncJobMoneyFlow)
+	if err != nil {
+		return err
+	}
+	codes := make([]string, len(items))
+	for i, item := range items {
+		codes[i] = item.Code
+	}
+	_, err = s.runMoneyFlowJob(job, codes)
+	return err
+}
+
+// beginSync 标记同步开始，已有同步在运行时返回 false
+func (s *SyncService) beginSync() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return false
+	}
+	s.running = true
+	return true
+}
+
+// endSync 标记同步结束
+func (s *SyncService) endSync() {
+	s.mu.Lock()
+	s.running = false
+	s.mu.Unlock()
+}
+
+// moneyFlowFetchResult 单只股票的资金流抓取结果（Worker -> Saver）
+type moneyFlowFetchResult struct {
+	code  string
+	flows []models.MoneyFlowData
+	err   error
+}
 
+// runMoneyFlowJob 并发抓取任务中的股票，由单一写入协程批量落库并记录检查点。
+// 暂停时不再派发新股票，已派发的股票照常完成；取消后任务标记为中断，可以续传。
+// 返回最终进度，取消时 Status 为 cancelled。
+func (s *SyncService) runMoneyFlowJob(job *models.SyncJobEntity, codes []string) (*SyncProgress, error) {
 	total := len(codes)
-	logger.Info("获取到待同步股票", zap.Int("total", total))
+	logger.Info("获取到待同步股票", zap.String("job_id", job.ID), zap.Int("total", total))
+	ctl := s.manager.Begin(JobMoneyFlowSync, job.ID)
 
 	// 初始化进度
 	progress := &SyncProgress{
+		JobID:  job.ID,
 		Total:  total,
 		Status: "running",
 	}
@@ -111,53 +189,63 @@ func (s *SyncService) StartFullMarketSync() error {
 
 	// 数据通道 (Worker -> Saver)
 	// 每个 worker 可能会发送 2000+ 条历史数据，所以这里的 buffer 不需要太大，只要能缓冲几个 worker 的结果即可
-	dataChan := make(chan []models.MoneyFlowData, 20)
+	dataChan := make(chan moneyFlowFetchResult, 20)
 
 	// 结果通道 (Saver -> Progress)
 	resultChan := make(chan bool, total)
 
 	// 启动单一写入协程 (Single Writer)
+	// 检查点在数据提交后才记录，中途退出时未提交的股票会在续传时重新抓取
 	go func() {
 		defer close(resultChan) // 写入完成后关闭结果通道
 
 		var batch []models.MoneyFlowData
-		// 累积 20 只股票的数据提交一次 (假设每只股票 1000 条数据，20只就是 20000 条，可能有点多)
-		// SQLite 批量插入建议 500-1000 条一次比较稳，但如果是 Transaction，可以多一些。
-		// 用户建议：每累积 10-20 只股票的数据执行一次事务提交
+		var batchCodes []string
+		// 每累积 10 只股票的数据执行一次事务提交
 		const StocksPerBatch = 10
-		stocksInBatch := 0
 
-		for flows := range dataChan {
-			if len(flows) > 0 {
-				batch = append(batch, flows...)
-				stocksInBatch++
-
-				// 达到批次大小，执行提交
-				if stocksInBatch >= StocksPerBatch {
-					err := s.moneyFlowRepo.SaveMoneyFlows(batch)
-					if err != nil {
-						logger.Error("批量保存资金流失败", zap.Error(err))
-					}
-					// 无论成功失败，都清空批次
-					batch = nil
-					stocksInBatch = 0
+		flush := func() {
+			err := s.moneyFlowRepo.SaveMoneyFlows(batch)
+			errMsg := ""
+			if err != nil {
+				logger.Error("批量保存资金流失败", zap.Error(err))
+				errMsg = err.Error()
+			}
+			s.checkpoint(job.ID, batchCodes, err == nil, errMsg)
+			for range batchCodes {
+				resultChan <- err == nil
+			}
+			batch, batchCodes = nil, nil
+		}
+
+		for r := range dataChan {
+			switch {
+			case r.err != nil:
+				s.checkpoint(job.ID, []string{r.code}, false, r.err.Error())
+				resultChan <- false
+			case len(r.flows) == 0:
+				// 爬取成功但无数据（如新股），也视为成功
+				s.checkpoint(job.ID, []string{r.code}, true, "")
+				resultChan <- true
+			default:
+				batch = append(batch, r.flows...)
+				batchCodes = append(batchCodes, r.code)
+				if len(batchCodes) >= StocksPerBatch {
+					flush()
 				}
 			}
-			// 爬取完成一个股票，发送成功信号
-			resultChan <- true
 		}
 
 		// 处理剩余数据
-		if len(batch) > 0 {
-			err := s.moneyFlowRepo.SaveMoneyFlows(batch)
-			if err != nil {
-				logger.Error("批量保存剩余资金流失败", zap.Error(err))
-			}
+		if len(batchCodes) > 0 {
+			flush()
 		}
 	}()
 
 	// 启动进度监听协程
+	progressDone := make(chan struct{})
 	go func() {
+		defer close(progressDone)
 		for success := range resultChan {
 			progress.Current++
 			if success {
@@ -165,19 +253,19 @@ func (s *SyncService) StartFullMarketSync() error {
 			} else {
 				progress.FailedCount++
 			}
+			ctl.SetProgress(progress.Current, total)
 			s.emitProgress(progress)
 		}
 	}()
 
 	// 3. 循环执行任务
-	for i, code := range codes {
-		// 检查上下文是否取消
-		select {
-		case <-s.ctx.Done():
-			logger.Warn("同步任务被取消")
-			close(dataChan) // 关闭数据通道，停止写入协程
-			return nil
-		default:
+	cancelled := false
+	for _, code := range codes {
+		// 暂停时在这里等待；任务被取消或程序退出时停止派发
+		if ctl.Wait() != nil {
+			logger.Warn("同步任务被取消", zap.String("job_id", job.ID))
+			cancelled = true
+			break
 		}
 
 		progress.CurrentStock = code
@@ -185,7 +273,7 @@ func (s *SyncService) StartFullMarketSync() error {
 		wg.Add(1)
 		sem <- struct{}{} // 获取信号量
 
-		go func(stockCode string, idx int) {
+		go func(stockCode string) {
 			defer wg.Done()
 			defer func() { <-sem }() // 释放信号量
 
@@ -194,48 +282,56 @@ func (s *SyncService) StartFullMarketSync() error {
 
 			// 仅爬取数据，不写入数据库
 			rawData, err := s.FetchHistoryFlowDataV2(stockCode, 120)
-			flows := AlignStockData2MoneyFlow(stockCode, GetSortedData(rawData))
 			if err != nil {
 				logger.Error("同步资金流失败", zap.String("code", stockCode), zap.Error(err))
-				// 失败时，发送空切片以通知 Saver 继续计数
-				dataChan <- []models.MoneyFlowData{}
-			} else {
-				if len(flows) > 0 {
-					// 实时扫描策略信号
-					s.ScanAndSaveStrategySignals(stockCode, flows)
-					dataChan <- flows
-				} else {
-					// 爬取成功但无数据（如新股），也视为成功
-					dataChan <- []models.MoneyFlowData{}
-				}
+				dataChan <- moneyFlowFetchResult{code: stockCode, err: err}
+				return
+			}
+			flows := AlignStockData2MoneyFlow(stockCode, GetSortedData(rawData))
+			if len(flows) > 0 {
+				// 实时扫描策略信号
+				s.ScanAndSaveStrategySignals(stockCode, flows)
 			}
-		}(code, i)
+			dataChan <- moneyFlowFetchResult{code: stockCode, flows: flows}
+		}(code)
 	}
 
-	// 等待所有任务完成
+	// 等待抓取完成后关闭数据通道，再等待写入协程提交剩余数据、进度协程退出
 	wg.Wait()
-	close(dataChan) // 关闭数据通道，通知 Saver 退出
-
-	// 这里不需要显式等待 resultChan，因为 emitProgress 是异步通知前端的
-	// 但为了让日志准确，我们稍微等一下进度协程（可选）
-	// 由于 StartFullMarketSync 返回 nil 后，主函数就结束了，
-	// 如果进度协程还在跑，可能会有问题。
-	// 但在这个场景下，close(dataChan) -> Saver 退出 -> close(resultChan) -> 进度协程退出
-	// 所以我们需要等待 Saver 彻底退出。
-	// 简单的办法：使用 WaitGroup 等待 Saver。
-
-	// 不过根据目前代码结构，StartFullMarketSync 阻塞在 wg.Wait()，
-	// 此时 Workers 都结束了。
-	// dataChan 关闭后，Saver 会处理完剩余数据然后退出。
-	// 我们可以在这里简单 sleep 一下或者不做处理，因为 Saver 运行很快。
-
-	// 为了严谨，我们应该等待 Saver。
-	// 但由于我无法轻易修改 Saver 的结构（在闭包里），
-	// 而且 resultChan 是无缓冲的（不，它是 buffered total），
-	// Saver 退出后 resultChan 关闭，进度协程退出。
-	// 我们可以直接返回。
+	close(dataChan)
+	<-progressDone
 
-	return nil
+	status, jobStatus := SyncJobCompleted, JobCompleted
+	progress.Status = "completed"
+	if cancelled {
+		status, jobStatus = SyncJobInterrupted, JobCancelled
+		progress.Status = "cancelled"
+		progress.CurrentStock = "同步已取消，可续传"
+	}
+	if err := s.jobs.Finish(job.ID, status, ""); err != nil {
+		logger.Error("更新同步任务状态失败", zap.String("job_id", job.ID), zap.Error(err))
+	}
+	s.emitProgress(progress)
+	s.manager.Finish(ctl, jobStatus, *progress, "")
+
+	logger.Info("全市场历史资金流同步结束",
+		zap.String("job_id", job.ID),
+		zap.String("status", status),
+		zap.Int("success_count", progress.SuccessCount),
+		zap.Int("failed_count", progress.FailedCount),
+	)
+	return progress, nil
+}
+
+// checkpoint 记录一批股票的检查点，失败只记日志（续传时这些股票会被重新处理）
+func (s *SyncService) checkpoint(jobID string, codes []string, success bool, errMsg string) {
+	if err := s.jobs.Checkpoint(jobID, codes, success, errMsg); err != nil {
+		logger.Error("记录同步检查点失败",
+			zap.String("job_id", jobID),
+			zap.Int("count", len(codes)),
+			zap.Error(err),
+		)
+	}
 }
 
 // SyncAndScanSingleStock 同步并扫描单只股票
@@ -274,11 +370,9 @@ func (s *SyncService) SyncAndScanSingleStock(code string) ([]models.StrategySign
 // FetchHistoryFlowData 仅获取历史资金流数据，不保存
 func (s *SyncService) FetchHistoryFlowData(code string) ([]models.MoneyFlowData, error) {
 	// 转换代码格式
-	secid := ""
-	if strings.HasPrefix(code, "6") {
-		secid = "1." + code
-	} else {
-		secid = "0." + code
+	secid := s.instruments.SecID(code)
+	if secid == "" {
+		return nil, fmt.Errorf("无效的证券代码: %s", code)
 	}
 
 	// 构造 URL (lmt=0 获取全部)
@@ -349,6 +443,41 @@ func (s *SyncService) FetchHistoryFlowData(code string) ([]models.MoneyFlowData,
 	return flows, nil
 }
 
+// moneyFlowHistoryMaxDays 资金流历史接口可获取的最大交易日数
+const moneyFlowHistoryMaxDays = 120
+
+// ResyncMoneyFlowRange 重新同步单只股票指定日期范围（YYYY-MM-DD，含首尾）的资金流历史，用于修复缓存中的缺失或异常数据。
+// 资金流接口只提供最近约 120 个交易日，更早的日期无法修复。返回写入的记录数。
+func (s *SyncService) ResyncMoneyFlowRange(code, startDate, endDate string) (int, error) {
+	start, err := time.ParseInLocation("2006-01-02", startDate, chinaLocation)
+	if err != nil {
+		return 0, fmt.Errorf("开始日期无效: %s", startDate)
+	}
+	if endDate < startDate {
+		return 0, fmt.Errorf("结束日期无效: %s", endDate)
+	}
+
+	limit := GetTradingCalendar().TradingDaysBetween(start, time.Now()) + 1
+	if limit > moneyFlowHistoryMaxDays {
+		return 0, fmt.Errorf("资金流接口仅提供最近 %d 个交易日的数据，无法修复 %s 起的数据", moneyFlowHistoryMaxDays, startDate)
+	}
+
+	data, err := s.FetchHistoryFlowDataV2(code, limit)
+	if err != nil {
+		return 0, err
+	}
+	flows := make([]models.MoneyFlowData, 0, len(data))
+	for _, f := range AlignStockData2MoneyFlow(code, GetSortedData(data)) {
+		if f.TradeDate >= startDate && f.TradeDate <= endDate {
+			flows = append(flows, f)
+		}
+	}
+	if err := s.moneyFlowRepo.SaveMoneyFlows(flows); err != nil {
+		return 0, fmt.Errorf("保存资金流数据失败: %w", err)
+	}
+	return len(flows), nil
+}
+
 // FetchAndSaveHistoryFlow 已废弃，保留兼容性
 func (s *SyncService) FetchAndSaveHistoryFlow(code string) error {
 	flows, err := s.FetchHistoryFlowData(code)
@@ -442,10 +571,10 @@ func ParseAndMerge(klineData []string, fflowData []string) map[string]*AlignedSt
 }
 
 func (s *SyncService) FetchHistoryFlowDataV2(code string, limit int) (map[string]*AlignedStockData, error) {
-	// 1. 判断市场前缀 (严谨逻辑)
-	secid := "0." + code // 默认深市
-	if strings.HasPrefix(code, "6") {
-		secid = "1." + code // 沪市
+	// 1. 判断市场前缀（以 stocks 表为准）
+	secid := s.instruments.SecID(code)
+	if secid == "" {
+		return nil, fmt.Errorf("无效的证券代码: %s", code)
 	}
 
 	// 2. 构造 URL (严格按照 ParseAndMerge 的索引顺序)
@@ -638,12 +767,10 @@ type OrderFlowStats struct {
 func (s *SyncService) FetchAllDayTicks(code string) (*OrderFlowStats, error) {
 	stats := &OrderFlowStats{}
 
-	// 构造 secid
-	secid := ""
-	if strings.HasPrefix(code, "6") {
-		secid = "1." + code
-	} else {
-		secid = "0." + code
+	// 构造 secid（指数、ETF、北交所等均以 stocks 表为准）
+	secid := s.instruments.SecID(code)
+	if secid == "" {
+		return nil, fmt.Errorf("无效的证券代码: %s", code)
 	}
 	pos := -0
 	ticks, err := s.fetchTickBatch(secid, pos)
@@ -658,9 +785,10 @@ func (s *SyncService) FetchAllDayTicks(code string) (*OrderFlowStats, error) {
 
 func AnalyzeL2Market(ticks []TickData) *OrderFlowStats {
 	res := OrderFlowStats{}
+	cal := GetTradingCalendar()
 	for _, tick := range ticks {
-		// 严谨逻辑：过滤非交易时段
-		if tick.Time < "09:30:00" || (tick.Time > "11:30:00" && tick.Time < "13:00:00") {
+		// 严谨逻辑：过滤非交易时段（开盘集合竞价、午间休市、盘后）
+		if !cal.IsTradingClock(tick.Time) {
 			continue
 		}
 
@@ -2,12 +2,16 @@ package services
 
 import (
 	"fmt"
+	"path/filepath"
 	"stock-analyzer-wails/repositories"
 	"testing"
 )
 
 func TestFetchAndAlignHistory(t *testing.T) {
-	// 1. 设置测试环境
+	// 1. 设置测试环境（回放 testdata/fixtures 下的合成夹具，不访问网络）
+	t.Setenv(EnvHTTPMode, HTTPModeReplay)
+	t.Setenv(EnvFixtureDir, filepath.Join("testdata", "fixtures"))
+
 	dbService, err := NewDBService()
 	if err != nil {
 		t.Fatalf("NewDBService failed: %v", err)
@@ -0,0 +1,12 @@
+-- 早期版本数据库（没有 schema_version）：
+-- watchlist 没有 name 列，stocks.code 为唯一索引，日 K 线按股票分表且没有 adjust 列
+CREATE TABLE watchlist (code text PRIMARY KEY, data text, added_at datetime DEFAULT CURRENT_TIMESTAMP);
+INSERT INTO watchlist (code, data) VALUES ('600519', '{"code":"600519","name":"贵州茅台"}'), ('000002', '');
+
+CREATE TABLE stocks (id integer PRIMARY KEY AUTOINCREMENT, code text NOT NULL, name text, market text, full_code text NOT NULL, type text, updated_at datetime DEFAULT CURRENT_TIMESTAMP);
+CREATE UNIQUE INDEX idx_stocks_code ON stocks(code);
+CREATE UNIQUE INDEX idx_stocks_full_code ON stocks(full_code);
+INSERT INTO stocks (code, name, market, full_code, type) VALUES ('000001', '平安银行', 'SZ', 'SZ000001', 'main');
+
+CREATE TABLE kline_600519 (id integer PRIMARY KEY AUTOINCREMENT, date text NOT NULL UNIQUE, open real NOT NULL, high real NOT NULL, low real NOT NULL, close real NOT NULL, volume integer NOT NULL, created_at datetime, updated_at datetime);
+INSERT INTO kline_600519 (date, open, high, low, close, volume) VALUES ('2024-01-02', 10, 11, 9, 10.5, 100), ('2024-01-03', 10.5, 11, 10, 10.8, 120);
@@ -0,0 +1,14 @@
+-- 引入 name 列之后、版本化迁移之前的数据库（没有 schema_version）：
+-- watchlist 中存在 name 为 NULL 的记录，K 线有分钟线缓存表和指数缓存表
+CREATE TABLE watchlist (code text PRIMARY KEY, name text, data text, added_at datetime DEFAULT CURRENT_TIMESTAMP);
+INSERT INTO watchlist (code, name, data) VALUES ('600519', NULL, '{"code":"600519","name":"贵州茅台"}'), ('000002', '', NULL), ('300750', '宁德时代', '{}');
+
+CREATE TABLE config (key text PRIMARY KEY, value text);
+INSERT INTO config (key, value) VALUES ('market_data_provider', 'tencent');
+
+CREATE TABLE kline_600519 (id integer PRIMARY KEY AUTOINCREMENT, date text NOT NULL UNIQUE, open real NOT NULL, high real NOT NULL, low real NOT NULL, close real NOT NULL, volume integer NOT NULL, adjust text NOT NULL DEFAULT 'forward', created_at datetime, updated_at datetime);
+INSERT INTO kline_600519 (date, open, high, low, close, volume, adjust) VALUES ('2024-01-02', 10, 11, 9, 10.5, 100, 'none');
+CREATE TABLE kline_600519_5min (id integer PRIMARY KEY AUTOINCREMENT, date text NOT NULL UNIQUE, open real NOT NULL, high real NOT NULL, low real NOT NULL, close real NOT NULL, volume integer NOT NULL, adjust text NOT NULL DEFAULT 'forward', created_at datetime, updated_at datetime);
+INSERT INTO kline_600519_5min (date, open, high, low, close, volume, adjust) VALUES ('2024-01-02 09:35', 10, 10.2, 9.9, 10.1, 20, 'forward');
+CREATE TABLE kline_SH000001 (id integer PRIMARY KEY AUTOINCREMENT, date text NOT NULL UNIQUE, open real NOT NULL, high real NOT NULL, low real NOT NULL, close real NOT NULL, volume integer NOT NULL, adjust text NOT NULL DEFAULT 'forward', created_at datetime, updated_at datetime);
+INSERT INTO kline_SH000001 (date, open, high, low, close, volume) VALUES ('2024-01-02', 2960, 2970, 2950, 2962, 9000);
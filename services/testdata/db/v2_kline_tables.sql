@@ -0,0 +1,9 @@
+-- 已执行到 v2 的数据库：只需要执行 v3（K 线缓存迁入 kline_bars）
+CREATE TABLE schema_version (version integer PRIMARY KEY, description text, applied_at datetime, duration_ms integer);
+INSERT INTO schema_version (version, description, applied_at, duration_ms) VALUES (1, '修复 watchlist 表结构与空名称', '2026-01-01 10:00:00', 3), (2, 'stocks.code 唯一索引改为普通索引', '2026-01-01 10:00:00', 1);
+
+CREATE TABLE watchlist (code text PRIMARY KEY, name text, data text, added_at datetime DEFAULT CURRENT_TIMESTAMP);
+INSERT INTO watchlist (code, name, data) VALUES ('600519', '贵州茅台', '{}');
+
+CREATE TABLE kline_000001 (id integer PRIMARY KEY AUTOINCREMENT, date text NOT NULL UNIQUE, open real NOT NULL, high real NOT NULL, low real NOT NULL, close real NOT NULL, volume integer NOT NULL, adjust text NOT NULL DEFAULT 'forward', created_at datetime, updated_at datetime);
+INSERT INTO kline_000001 (date, open, high, low, close, volume) VALUES ('2024-01-02', 9.3, 9.4, 9.2, 9.35, 5000), ('2024-01-03', 9.35, 9.5, 9.3, 9.45, 6000);
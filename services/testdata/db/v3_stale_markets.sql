@@ -0,0 +1,9 @@
+-- 已执行到 v3 的数据库：早期同步把北交所 920 代码段写成了沪市（SH920xxx）
+CREATE TABLE schema_version (version integer PRIMARY KEY, description text, applied_at datetime, duration_ms integer);
+INSERT INTO schema_version (version, description, applied_at, duration_ms) VALUES (1, '修复 watchlist 表结构与空名称', '2026-01-01 10:00:00', 3), (2, 'stocks.code 唯一索引改为普通索引', '2026-01-01 10:00:00', 1), (3, '按股票分表的 K 线缓存迁入 kline_bars', '2026-01-01 10:00:00', 2);
+
+CREATE TABLE stocks (id integer PRIMARY KEY AUTOINCREMENT, code text NOT NULL, name text, market text, full_code text NOT NULL, type text, is_active integer DEFAULT 1, updated_at datetime DEFAULT CURRENT_TIMESTAMP);
+CREATE INDEX idx_stocks_code ON stocks(code);
+CREATE UNIQUE INDEX idx_stocks_full_code ON stocks(full_code);
+INSERT INTO stocks (code, name, market, full_code, type) VALUES ('000001', '平安银行', 'SZ', 'SZ000001', '主板'), ('000001', '上证指数', 'SH', 'SH000001', '指数');
+INSERT INTO stocks (code, name, market, full_code, type) VALUES ('920001', '北交所一', 'SH', 'SH920001', '主板'), ('920002', '北交所二', 'SH', 'SH920002', '主板'), ('920002', '北交所二', 'BJ', 'BJ920002', '北交所');
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// newTestDB 在临时目录创建数据库（已执行迁移），测试结束时关闭
+func newTestDB(t *testing.T) *DBService {
+	t.Helper()
+	db, err := NewDBServiceWithPath(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewDBServiceWithPath: %v", err)
+	}
+	t.Cleanup(db.Close)
+	return db
+}
+
+// mustCreate 依次写入记录，失败时终止测试
+func mustCreate(t *testing.T, db *DBService, values ...interface{}) {
+	t.Helper()
+	for _, v := range values {
+		if err := db.GetDB().Create(v).Error; err != nil {
+			t.Fatalf("create %T: %v", v, err)
+		}
+	}
+}
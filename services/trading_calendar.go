@@ -0,0 +1,366 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"stock-analyzer-wails/internal/logger"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// bundledTradingCalendar 随程序发布的交易日历（沪深北交易所休市安排与交易时段）
+//
+//go:embed data/trading_calendar.json
+var bundledTradingCalendar []byte
+
+// TradingCalendarFileName 应用数据目录下的交易日历文件名，存在且覆盖范围不早于内置日历时优先使用，
+// 用于在不升级程序的情况下更新下一年度的休市安排
+const TradingCalendarFileName = "trading_calendar.json"
+
+// chinaLocation 交易所时区（UTC+8，无夏令时；不依赖系统时区数据库）
+var chinaLocation = time.FixedZone("CST", 8*3600)
+
+// 交易时段阶段
+const (
+	SessionPhaseClosed         = "closed"          // 休市日，或开盘前/收盘后
+	SessionPhaseCallAuction    = "call_auction"    // 开盘集合竞价
+	SessionPhaseContinuous     = "continuous"      // 连续竞价
+	SessionPhaseClosingAuction = "closing_auction" // 收盘集合竞价
+	SessionPhaseBreak          = "break"           // 交易日内的时段间隙（竞价撮合后、午间休市）
+)
+
+// TradingSession 交易时段，Start/End 为 HH:MM（含首尾分钟的 00 秒）
+type TradingSession struct {
+	Phase string `json:"phase"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// defaultTradingSessions 沪深交易所交易时段（内置日历无效时使用）
+var defaultTradingSessions = []TradingSession{
+	{Phase: SessionPhaseCallAuction, Start: "09:15", End: "09:25"},
+	{Phase: SessionPhaseContinuous, Start: "09:30", End: "11:30"},
+	{Phase: SessionPhaseContinuous, Start: "13:00", End: "14:57"},
+	{Phase: SessionPhaseClosingAuction, Start: "14:57", End: "15:00"},
+}
+
+// tradingCalendarFile 交易日历文件格式
+type tradingCalendarFile struct {
+	Version  string              `json:"version"`
+	From     string              `json:"from"` // 休市安排覆盖的起止日期，范围外仅按周末判断
+	To       string              `json:"to"`
+	Sessions []TradingSession    `json:"sessions"`
+	Holidays map[string][]string `json:"holidays"` // 年份 -> 工作日休市日期
+}
+
+// TradingCalendarInfo 交易日历概况
+type TradingCalendarInfo struct {
+	Version  string `json:"version"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Holidays int    `json:"holidays"`
+	Source   string `json:"source"` // bundled 或文件路径
+}
+
+// MarketStatus 某一时刻的市场状态
+type MarketStatus struct {
+	Date           string `json:"date"`
+	Time           string `json:"time"`
+	IsTradingDay   bool   `json:"isTradingDay"`
+	Phase          string `json:"phase"`
+	IsOpen         bool   `json:"isOpen"` // 是否处于集合竞价或连续竞价
+	PrevTradingDay string `json:"prevTradingDay"`
+	NextTradingDay string `json:"nextTradingDay"`
+	Covered        bool   `json:"covered"` // 日期是否在休市安排覆盖范围内
+}
+
+// TradingCalendar A 股交易日历：判断交易日、交易时段，推算前后交易日。
+// 休市日期以交易所公告为准，周末一律休市（调休的周末不开市）。
+type TradingCalendar struct {
+	version  string
+	from     string
+	to       string
+	source   string
+	sessions []TradingSession
+	holidays map[string]bool
+}
+
+// NewTradingCalendar 从交易日历文件内容创建日历
+func NewTradingCalendar(data []byte, source string) (*TradingCalendar, error) {
+	var f tradingCalendarFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("解析交易日历失败: %w", err)
+	}
+	if _, err := time.Parse("2006-01-02", f.From); err != nil {
+		return nil, fmt.Errorf("交易日历起始日期无效: %q", f.From)
+	}
+	if _, err := time.Parse("2006-01-02", f.To); err != nil || f.To < f.From {
+		return nil, fmt.Errorf("交易日历截止日期无效: %q", f.To)
+	}
+	if len(f.Sessions) == 0 {
+		return nil, errors.New("交易日历缺少交易时段")
+	}
+	for _, s := range f.Sessions {
+		if _, err := time.Parse("15:04", s.Start); err != nil {
+			return nil, fmt.Errorf("交易时段开始时间无效: %q", s.Start)
+		}
+		if _, err := time.Parse("15:04", s.End); err != nil || s.End <= s.Start {
+			return nil, fmt.Errorf("交易时段结束时间无效: %q", s.End)
+		}
+	}
+
+	c := &TradingCalendar{
+		version:  f.Version,
+		from:     f.From,
+		to:       f.To,
+		source:   source,
+		sessions: append([]TradingSession(nil), f.Sessions...),
+		holidays: make(map[string]bool),
+	}
+	sort.SliceStable(c.sessions, func(i, j int) bool { return c.sessions[i].Start < c.sessions[j].Start })
+	for _, dates := range f.Holidays {
+		for _, d := range dates {
+			if _, err := time.Parse("2006-01-02", d); err != nil {
+				return nil, fmt.Errorf("休市日期无效: %q", d)
+			}
+			c.holidays[d] = true
+		}
+	}
+	return c, nil
+}
+
+var (
+	tradingCalendarMu sync.RWMutex
+	tradingCalendar   *TradingCalendar
+)
+
+// GetTradingCalendar 返回当前使用的交易日历（首次调用时加载）
+func GetTradingCalendar() *TradingCalendar {
+	tradingCalendarMu.RLock()
+	c := tradingCalendar
+	tradingCalendarMu.RUnlock()
+	if c != nil {
+		return c
+	}
+
+	tradingCalendarMu.Lock()
+	defer tradingCalendarMu.Unlock()
+	if tradingCalendar == nil {
+		tradingCalendar = loadTradingCalendar(filepath.Join(GetAppDataDir(), TradingCalendarFileName))
+	}
+	return tradingCalendar
+}
+
+// ReloadTradingCalendar 重新加载交易日历（更新应用数据目录下的日历文件后调用）
+func ReloadTradingCalendar() TradingCalendarInfo {
+	c := loadTradingCalendar(filepath.Join(GetAppDataDir(), TradingCalendarFileName))
+	tradingCalendarMu.Lock()
+	tradingCalendar = c
+	tradingCalendarMu.Unlock()
+	return c.Info()
+}
+
+// loadTradingCalendar 加载交易日历：外部文件有效且覆盖范围不早于内置日历时使用外部文件，否则使用内置日历
+func loadTradingCalendar(path string) *TradingCalendar {
+	bundled, err := NewTradingCalendar(bundledTradingCalendar, "bundled")
+	if err != nil {
+		// 内置文件随程序发布，解析失败属于打包错误；退化为仅按周末判断，保证监控与同步仍可运行
+		logger.Error("内置交易日历无效，仅按周末判断休市",
+			zap.String("module", "services.trading_calendar"),
+			zap.String("op", "loadTradingCalendar"),
+			zap.Error(err),
+		)
+		bundled = &TradingCalendar{
+			from:     "0000-01-01",
+			to:       "0000-01-01",
+			source:   "fallback",
+			sessions: defaultTradingSessions,
+			holidays: make(map[string]bool),
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("读取交易日历文件失败，使用内置日历",
+				zap.String("module", "services.trading_calendar"),
+				zap.String("op", "loadTradingCalendar"),
+				zap.String("path", path),
+				zap.Error(err),
+			)
+		}
+		return bundled
+	}
+	external, err := NewTradingCalendar(data, path)
+	if err != nil {
+		logger.Warn("交易日历文件无效，使用内置日历",
+			zap.String("module", "services.trading_calendar"),
+			zap.String("op", "loadTradingCalendar"),
+			zap.String("path", path),
+			zap.Error(err),
+		)
+		return bundled
+	}
+	if external.to < bundled.to {
+		logger.Info("交易日历文件早于内置日历，使用内置日历",
+			zap.String("module", "services.trading_calendar"),
+			zap.String("op", "loadTradingCalendar"),
+			zap.String("path", path),
+			zap.String("file_to", external.to),
+			zap.String("bundled_to", bundled.to),
+		)
+		return bundled
+	}
+	return external
+}
+
+// Info 返回日历概况
+func (c *TradingCalendar) Info() TradingCalendarInfo {
+	return TradingCalendarInfo{
+		Version:  c.version,
+		From:     c.from,
+		To:       c.to,
+		Holidays: len(c.holidays),
+		Source:   c.source,
+	}
+}
+
+// Sessions 返回交易时段（按开始时间升序）
+func (c *TradingCalendar) Sessions() []TradingSession {
+	return append([]TradingSession(nil), c.sessions...)
+}
+
+// Covers 判断日期是否在休市安排覆盖范围内（范围外仅按周末判断）
+func (c *TradingCalendar) Covers(t time.Time) bool {
+	d := t.In(chinaLocation).Format("2006-01-02")
+	return d >= c.from && d <= c.to
+}
+
+// IsTradingDay 判断是否为交易日（按交易所时区的日期）
+func (c *TradingCalendar) IsTradingDay(t time.Time) bool {
+	t = t.In(chinaLocation)
+	if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return false
+	}
+	return !c.holidays[t.Format("2006-01-02")]
+}
+
+// NextTradingDay 返回 t 之后（不含当天）的第一个交易日，时间为当天 00:00
+func (c *TradingCalendar) NextTradingDay(t time.Time) time.Time {
+	return c.AddTradingDays(t, 1)
+}
+
+// PrevTradingDay 返回 t 之前（不含当天）的最近一个交易日，时间为当天 00:00
+func (c *TradingCalendar) PrevTradingDay(t time.Time) time.Time {
+	return c.AddTradingDays(t, -1)
+}
+
+// LatestTradingDay 返回 t 当天（若为交易日）或之前最近的交易日，时间为当天 00:00
+func (c *TradingCalendar) LatestTradingDay(t time.Time) time.Time {
+	if c.IsTradingDay(t) {
+		return startOfChinaDay(t)
+	}
+	return c.PrevTradingDay(t)
+}
+
+// AddTradingDays 返回 t 之后（n > 0）或之前（n < 0）的第 |n| 个交易日（不含当天），n 为 0 时返回当天 00:00
+func (c *TradingCalendar) AddTradingDays(t time.Time, n int) time.Time {
+	d := startOfChinaDay(t)
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for n > 0 {
+		d = d.AddDate(0, 0, step)
+		if c.IsTradingDay(d) {
+			n--
+		}
+	}
+	return d
+}
+
+// TradingDaysBetween 返回 (from, to] 区间内的交易日数量，from 晚于 to 时返回 0
+func (c *TradingCalendar) TradingDaysBetween(from, to time.Time) int {
+	d, end := startOfChinaDay(from), startOfChinaDay(to)
+	count := 0
+	for d.Before(end) {
+		d = d.AddDate(0, 0, 1)
+		if c.IsTradingDay(d) {
+			count++
+		}
+	}
+	return count
+}
+
+// Phase 返回 t 所处的交易阶段
+func (c *TradingCalendar) Phase(t time.Time) string {
+	t = t.In(chinaLocation)
+	if !c.IsTradingDay(t) {
+		return SessionPhaseClosed
+	}
+	return c.clockPhase(t.Format("15:04:05"))
+}
+
+// IsSessionOpen 判断 t 是否处于集合竞价或连续竞价时段（盘中监控只需在此时运行）
+func (c *TradingCalendar) IsSessionOpen(t time.Time) bool {
+	switch c.Phase(t) {
+	case SessionPhaseCallAuction, SessionPhaseContinuous, SessionPhaseClosingAuction:
+		return true
+	}
+	return false
+}
+
+// IsTradingClock 判断交易日内的时刻（HH:MM 或 HH:MM:SS）是否处于连续竞价或收盘集合竞价，
+// 用于过滤逐笔成交中的开盘竞价与盘后数据
+func (c *TradingCalendar) IsTradingClock(clock string) bool {
+	switch c.clockPhase(clock) {
+	case SessionPhaseContinuous, SessionPhaseClosingAuction:
+		return true
+	}
+	return false
+}
+
+// Status 返回 t 时刻的市场状态
+func (c *TradingCalendar) Status(t time.Time) MarketStatus {
+	t = t.In(chinaLocation)
+	return MarketStatus{
+		Date:           t.Format("2006-01-02"),
+		Time:           t.Format("15:04:05"),
+		IsTradingDay:   c.IsTradingDay(t),
+		Phase:          c.Phase(t),
+		IsOpen:         c.IsSessionOpen(t),
+		PrevTradingDay: c.PrevTradingDay(t).Format("2006-01-02"),
+		NextTradingDay: c.NextTradingDay(t).Format("2006-01-02"),
+		Covered:        c.Covers(t),
+	}
+}
+
+// clockPhase 返回交易日内某一时刻的阶段，时段首尾的整分钟均计入（如 11:30:00、15:00:00 的成交）
+func (c *TradingCalendar) clockPhase(clock string) string {
+	if len(clock) == len("15:04") {
+		clock += ":00"
+	}
+	for _, s := range c.sessions {
+		if clock >= s.Start+":00" && clock <= s.End+":00" {
+			return s.Phase
+		}
+	}
+	first, last := c.sessions[0], c.sessions[len(c.sessions)-1]
+	if clock > first.Start+":00" && clock < last.End+":00" {
+		return SessionPhaseBreak
+	}
+	return SessionPhaseClosed
+}
+
+// startOfChinaDay 返回交易所时区当天 00:00
+func startOfChinaDay(t time.Time) time.Time {
+	t = t.In(chinaLocation)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, chinaLocation)
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustBundledCalendar(t *testing.T) *TradingCalendar {
+	t.Helper()
+	c, err := NewTradingCalendar(bundledTradingCalendar, "bundled")
+	if err != nil {
+		t.Fatalf("bundled calendar invalid: %v", err)
+	}
+	return c
+}
+
+func cst(value string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", value, chinaLocation)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestTradingCalendar_BundledData(t *testing.T) {
+	c := mustBundledCalendar(t)
+	info := c.Info()
+	if info.Holidays == 0 || info.From > "2024-01-01" || info.To < "2025-12-31" {
+		t.Fatalf("unexpected bundled coverage: %+v", info)
+	}
+	// 休市表只记录工作日，周末本就休市
+	for d := range c.holidays {
+		day, _ := time.ParseInLocation("2006-01-02", d, chinaLocation)
+		if wd := day.Weekday(); wd == time.Saturday || wd == time.Sunday {
+			t.Fatalf("holiday %s falls on a weekend", d)
+		}
+		if d < c.from || d > c.to {
+			t.Fatalf("holiday %s outside coverage %s~%s", d, c.from, c.to)
+		}
+	}
+}
+
+func TestTradingCalendar_Days(t *testing.T) {
+	c := mustBundledCalendar(t)
+
+	cases := map[string]bool{
+		"2024-02-08": true,  // 春节前最后一个交易日
+		"2024-02-09": false, // 除夕休市
+		"2024-02-17": false, // 周六
+		"2024-02-18": false, // 调休周日也不开市
+		"2024-02-19": true,
+		"2025-10-08": false,
+		"2025-10-09": true,
+	}
+	for d, want := range cases {
+		if got := c.IsTradingDay(cst(d + " 10:00:00")); got != want {
+			t.Fatalf("IsTradingDay(%s)=%v, want %v", d, got, want)
+		}
+	}
+
+	if got := c.NextTradingDay(cst("2024-02-08 15:30:00")).Format("2006-01-02"); got != "2024-02-19" {
+		t.Fatalf("NextTradingDay=%s", got)
+	}
+	if got := c.PrevTradingDay(cst("2024-10-08 09:00:00")).Format("2006-01-02"); got != "2024-09-30" {
+		t.Fatalf("PrevTradingDay=%s", got)
+	}
+	if got := c.LatestTradingDay(cst("2024-02-12 10:00:00")).Format("2006-01-02"); got != "2024-02-08" {
+		t.Fatalf("LatestTradingDay=%s", got)
+	}
+	if got := c.AddTradingDays(cst("2024-01-02 10:00:00"), -2).Format("2006-01-02"); got != "2023-12-28" {
+		t.Fatalf("AddTradingDays(-2)=%s", got)
+	}
+	// 2024-09-30 之后的 5 个交易日跨过国庆
+	if got := c.AddTradingDays(cst("2024-09-30 10:00:00"), 5).Format("2006-01-02"); got != "2024-10-14" {
+		t.Fatalf("AddTradingDays(5)=%s", got)
+	}
+	if n := c.TradingDaysBetween(cst("2024-09-27 00:00:00"), cst("2024-10-08 00:00:00")); n != 2 {
+		t.Fatalf("TradingDaysBetween=%d, want 2", n)
+	}
+
+	// 其他时区的时间按北京时间日期判断：UTC 周五 20:00 已是北京时间周六
+	if c.IsTradingDay(time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected Saturday in exchange time zone to be closed")
+	}
+}
+
+func TestTradingCalendar_Phase(t *testing.T) {
+	c := mustBundledCalendar(t)
+
+	cases := map[string]string{
+		"2024-03-01 08:59:00": SessionPhaseClosed,
+		"2024-03-01 09:20:00": SessionPhaseCallAuction,
+		"2024-03-01 09:27:00": SessionPhaseBreak,
+		"2024-03-01 10:00:00": SessionPhaseContinuous,
+		"2024-03-01 12:00:00": SessionPhaseBreak,
+		"2024-03-01 14:58:00": SessionPhaseClosingAuction,
+		"2024-03-01 15:00:00": SessionPhaseClosingAuction,
+		"2024-03-01 15:01:00": SessionPhaseClosed,
+		"2024-02-09 10:00:00": SessionPhaseClosed, // 休市日
+	}
+	for at, want := range cases {
+		if got := c.Phase(cst(at)); got != want {
+			t.Fatalf("Phase(%s)=%s, want %s", at, got, want)
+		}
+	}
+	if !c.IsSessionOpen(cst("2024-03-01 09:20:00")) || c.IsSessionOpen(cst("2024-03-01 12:00:00")) {
+		t.Fatalf("unexpected IsSessionOpen result")
+	}
+
+	clocks := map[string]bool{
+		"09:25:00": false, // 开盘集合竞价撮合
+		"09:30:00": true,
+		"11:30:00": true,
+		"11:30:01": false,
+		"13:00":    true,
+		"15:00:00": true,
+		"15:05:00": false, // 盘后
+	}
+	for clock, want := range clocks {
+		if got := c.IsTradingClock(clock); got != want {
+			t.Fatalf("IsTradingClock(%s)=%v, want %v", clock, got, want)
+		}
+	}
+
+	status := c.Status(cst("2024-02-08 14:00:00"))
+	if !status.IsOpen || status.NextTradingDay != "2024-02-19" || !status.Covered {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+}
+
+func TestLoadTradingCalendar_File(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, TradingCalendarFileName)
+	bundled := mustBundledCalendar(t)
+
+	// 文件不存在时使用内置日历
+	if c := loadTradingCalendar(path); c.Info().Source != "bundled" {
+		t.Fatalf("expected bundled calendar, got %+v", c.Info())
+	}
+
+	// 覆盖范围更新的文件优先
+	newer := strings.Replace(string(bundledTradingCalendar), `"to": "`+bundled.to+`"`, `"to": "2099-12-31"`, 1)
+	newer = strings.Replace(newer, `"holidays": {`, `"holidays": {"2099": ["2099-01-01"],`, 1)
+	if err := os.WriteFile(path, []byte(newer), 0o644); err != nil {
+		t.Fatalf("write calendar: %v", err)
+	}
+	c := loadTradingCalendar(path)
+	if c.Info().Source != path || c.IsTradingDay(cst("2099-01-01 10:00:00")) {
+		t.Fatalf("expected file calendar to be used, got %+v", c.Info())
+	}
+
+	// 早于内置日历的文件被忽略
+	older := strings.Replace(string(bundledTradingCalendar), `"to": "`+bundled.to+`"`, `"to": "2020-12-31"`, 1)
+	if err := os.WriteFile(path, []byte(older), 0o644); err != nil {
+		t.Fatalf("write calendar: %v", err)
+	}
+	if c := loadTradingCalendar(path); c.Info().Source != "bundled" {
+		t.Fatalf("expected stale file to be ignored, got %+v", c.Info())
+	}
+
+	// 无效文件被忽略
+	if err := os.WriteFile(path, []byte(`{"from": "bad"}`), 0o644); err != nil {
+		t.Fatalf("write calendar: %v", err)
+	}
+	if c := loadTradingCalendar(path); c.Info().Source != "bundled" {
+		t.Fatalf("expected invalid file to be ignored, got %+v", c.Info())
+	}
+}
+
+func TestAnalyzeL2Market_FiltersSessions(t *testing.T) {
+	ticks := []TickData{
+		{Time: "09:25:00", Volume: 1000, Orders: 1, Direction: 1}, // 集合竞价
+		{Time: "10:00:00", Volume: 10, Orders: 1, Direction: 1},
+		{Time: "12:00:00", Volume: 1000, Orders: 1, Direction: 1}, // 午间休市
+		{Time: "15:00:00", Volume: 20, Orders: 1, Direction: 2},
+		{Time: "15:10:00", Volume: 1000, Orders: 1, Direction: 2}, // 盘后
+	}
+	stats := AnalyzeL2Market(ticks)
+	if stats.TotalVolume != 30 || stats.ActiveBuy != 10 || stats.ActiveSell != 20 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
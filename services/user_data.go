@@ -0,0 +1,646 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"stock-analyzer-wails/internal/logger"
+	"stock-analyzer-wails/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 用户数据存档格式
+const (
+	UserDataArchiveFormat  = "stock-analyzer-user-data"
+	UserDataArchiveVersion = 1
+)
+
+// 用户数据分区
+const (
+	UserDataWatchlist       = "watchlist"
+	UserDataPriceAlerts     = "price_alerts"
+	UserDataAlertTemplates  = "alert_templates"
+	UserDataPositions       = "positions"
+	UserDataStrategyConfigs = "strategy_configs"
+	UserDataGlobalStrategy  = "global_strategy_config"
+	UserDataSettings        = "settings"
+)
+
+// 导入方式（按分区指定）
+const (
+	UserDataImportSkip    = "skip"    // 不导入
+	UserDataImportMerge   = "merge"   // 只新增本地没有的记录，冲突时保留本地
+	UserDataImportReplace = "replace" // 清空本地后写入存档（配置类分区只覆盖存档中的键）
+)
+
+// userDataSections 分区的固定顺序
+var userDataSections = []string{
+	UserDataWatchlist,
+	UserDataPriceAlerts,
+	UserDataAlertTemplates,
+	UserDataPositions,
+	UserDataStrategyConfigs,
+	UserDataGlobalStrategy,
+	UserDataSettings,
+}
+
+// 全局策略配置对应的配置键
+const (
+	configTrailingActivation = "trailing_stop_default_activation"
+	configTrailingCallback   = "trailing_stop_default_callback"
+)
+
+// userSettingKeys 随存档导出的一般设置项
+var userSettingKeys = []string{
+	"ai_provider",
+	"ai_base_url",
+	"ai_model",
+	"market_data_provider",
+	"quote_cache_ttl_ms",
+	"intraday_persist_enabled",
+	"kline_sync_concurrency",
+	configRetentionPolicy,
+}
+
+// secretSettingKeys 敏感设置项，只有显式要求时才导出
+var secretSettingKeys = []string{"ai_api_key"}
+
+// UserDataArchive 可跨机器迁移的用户数据存档（JSON）
+type UserDataArchive struct {
+	Format          string    `json:"format"`
+	Version         int       `json:"version"`
+	ExportedAt      time.Time `json:"exportedAt"`
+	SchemaVersion   int       `json:"schemaVersion"`
+	IncludesSecrets bool      `json:"includesSecrets"`
+
+	Watchlist            []models.WatchlistEntity           `json:"watchlist"`
+	PriceAlerts          []models.PriceThresholdAlertEntity `json:"priceAlerts"`
+	AlertTemplates       []models.PriceAlertTemplateEntity  `json:"alertTemplates"`
+	Positions            []models.PositionEntity            `json:"positions"`
+	StrategyConfigs      []models.StrategyConfigEntity      `json:"strategyConfigs"`
+	GlobalStrategyConfig *GlobalStrategyConfig              `json:"globalStrategyConfig,omitempty"`
+	Settings             map[string]string                  `json:"settings"`
+}
+
+// UserDataSectionPreview 单个分区的导入预览
+type UserDataSectionPreview struct {
+	Section      string   `json:"section"`
+	Total        int      `json:"total"`        // 存档中的记录数
+	Local        int      `json:"local"`        // 本地现有记录数
+	New          int      `json:"new"`          // 本地没有的记录
+	Identical    int      `json:"identical"`    // 与本地完全相同的记录
+	Conflicts    int      `json:"conflicts"`    // 与本地同键但内容不同的记录
+	ConflictKeys []string `json:"conflictKeys"` // 冲突记录的键（股票代码、策略名称等）
+}
+
+// UserDataImportPreview 导入预览
+type UserDataImportPreview struct {
+	ExportedAt      string                   `json:"exportedAt"`
+	SchemaVersion   int                      `json:"schemaVersion"`
+	IncludesSecrets bool                     `json:"includesSecrets"`
+	Sections        []UserDataSectionPreview `json:"sections"`
+}
+
+// UserDataSectionResult 单个分区的导入结果
+type UserDataSectionResult struct {
+	Section string `json:"section"`
+	Mode    string `json:"mode"`
+	Deleted int    `json:"deleted"` // replace 时清除的本地记录
+	Written int    `json:"written"` // 新增或覆盖的记录
+	Kept    int    `json:"kept"`    // merge 时因冲突或相同而保留本地的记录
+}
+
+// UserDataImportResult 导入结果
+type UserDataImportResult struct {
+	BackupPath string                  `json:"backupPath"` // 导入前自动备份的数据库
+	Sections   []UserDataSectionResult `json:"sections"`
+}
+
+// userDataItem 存档中的一条记录及其与本地的比较结果
+type userDataItem struct {
+	key   string
+	state int
+	save  func(tx *gorm.DB) error
+}
+
+const (
+	userDataNew = iota
+	userDataIdentical
+	userDataConflict
+)
+
+// userDataPlan 一个分区的导入计划
+type userDataPlan struct {
+	local int
+	items []userDataItem
+	clear func(tx *gorm.DB) (int64, error) // replace 时清空本地；nil 表示只覆盖存档中的键
+}
+
+// UserDataService 用户数据导出/导入
+type UserDataService struct {
+	dbService *DBService
+	now       func() time.Time
+}
+
+// NewUserDataService 创建用户数据导出/导入服务
+func NewUserDataService(db *DBService) *UserDataService {
+	return &UserDataService{dbService: db, now: time.Now}
+}
+
+// Export 导出用户数据。includeSecrets 为 false 时不包含 API Key 等敏感设置。
+func (s *UserDataService) Export(includeSecrets bool) (*UserDataArchive, error) {
+	db := s.dbService.GetDB()
+	version, err := s.dbService.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &UserDataArchive{
+		Format:          UserDataArchiveFormat,
+		Version:         UserDataArchiveVersion,
+		ExportedAt:      s.now(),
+		SchemaVersion:   version,
+		IncludesSecrets: includeSecrets,
+		Settings:        map[string]string{},
+	}
+	if err := db.Order("code ASC").Find(&archive.Watchlist).Error; err != nil {
+		return nil, fmt.Errorf("读取自选股失败: %w", err)
+	}
+	if err := db.Order("id ASC").Find(&archive.PriceAlerts).Error; err != nil {
+		return nil, fmt.Errorf("读取价格预警失败: %w", err)
+	}
+	if err := db.Order("id ASC").Find(&archive.AlertTemplates).Error; err != nil {
+		return nil, fmt.Errorf("读取预警模板失败: %w", err)
+	}
+	if err := db.Order("stock_code ASC").Find(&archive.Positions).Error; err != nil {
+		return nil, fmt.Errorf("读取持仓失败: %w", err)
+	}
+	if err := db.Order("id ASC").Find(&archive.StrategyConfigs).Error; err != nil {
+		return nil, fmt.Errorf("读取策略配置失败: %w", err)
+	}
+
+	configs, err := loadConfigValues(db)
+	if err != nil {
+		return nil, err
+	}
+	if global, ok := parseGlobalStrategyConfig(configs); ok {
+		archive.GlobalStrategyConfig = &global
+	}
+	keys := userSettingKeys
+	if includeSecrets {
+		keys = append(append([]string{}, userSettingKeys...), secretSettingKeys...)
+	}
+	for _, key := range keys {
+		if value, ok := configs[key]; ok {
+			archive.Settings[key] = value
+		}
+	}
+	return archive, nil
+}
+
+// ExportToFile 导出用户数据并写入 JSON 文件
+func (s *UserDataService) ExportToFile(path string, includeSecrets bool) (*UserDataArchive, error) {
+	archive, err := s.Export(includeSecrets)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化用户数据失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建导出目录失败: %w", err)
+	}
+	// 可能包含 API Key，只允许当前用户读取
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("写入导出文件失败: %w", err)
+	}
+
+	logger.Info("用户数据已导出",
+		zap.String("module", "services.user_data"),
+		zap.String("op", "ExportToFile"),
+		zap.String("path", path),
+		zap.Bool("includeSecrets", includeSecrets),
+	)
+	return archive, nil
+}
+
+// ParseUserDataArchive 解析并校验用户数据存档
+func ParseUserDataArchive(data []byte) (*UserDataArchive, error) {
+	var archive UserDataArchive
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, fmt.Errorf("解析用户数据存档失败: %w", err)
+	}
+	if archive.Format != UserDataArchiveFormat {
+		return nil, fmt.Errorf("不是有效的用户数据存档")
+	}
+	if archive.Version < 1 || archive.Version > UserDataArchiveVersion {
+		return nil, fmt.Errorf("不支持的存档版本 %d（当前支持 %d）", archive.Version, UserDataArchiveVersion)
+	}
+	return &archive, nil
+}
+
+// ReadUserDataArchive 读取并校验用户数据存档文件
+func ReadUserDataArchive(path string) (*UserDataArchive, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取存档文件失败: %w", err)
+	}
+	return ParseUserDataArchive(data)
+}
+
+// PreviewImport 比较存档与本地数据，按分区统计新增、相同与冲突的记录
+func (s *UserDataService) PreviewImport(archive *UserDataArchive) (*UserDataImportPreview, error) {
+	preview := &UserDataImportPreview{
+		ExportedAt:      archive.ExportedAt.Format("2006-01-02 15:04:05"),
+		SchemaVersion:   archive.SchemaVersion,
+		IncludesSecrets: archive.IncludesSecrets,
+	}
+	db := s.dbService.GetDB()
+	for _, section := range userDataSections {
+		plan, err := buildUserDataPlan(db, archive, section)
+		if err != nil {
+			return nil, err
+		}
+		p := UserDataSectionPreview{Section: section, Total: len(plan.items), Local: plan.local, ConflictKeys: []string{}}
+		for _, item := range plan.items {
+			switch item.state {
+			case userDataNew:
+				p.New++
+			case userDataIdentical:
+				p.Identical++
+			case userDataConflict:
+				p.Conflicts++
+				p.ConflictKeys = append(p.ConflictKeys, item.key)
+			}
+		}
+		preview.Sections = append(preview.Sections, p)
+	}
+	return preview, nil
+}
+
+// Import 按分区导入存档。modes 中未指定的分区不导入；导入前自动备份数据库，全部分区在同一事务中写入。
+func (s *UserDataService) Import(archive *UserDataArchive, modes map[string]string) (*UserDataImportResult, error) {
+	for section, mode := range modes {
+		if !isUserDataSection(section) {
+			return nil, fmt.Errorf("未知的数据分区: %s", section)
+		}
+		if mode != UserDataImportSkip && mode != UserDataImportMerge && mode != UserDataImportReplace {
+			return nil, fmt.Errorf("分区 %s 的导入方式无效: %s", section, mode)
+		}
+	}
+
+	backup, err := s.dbService.backupAs(DatabaseBackupPreImport)
+	if err != nil {
+		return nil, fmt.Errorf("导入前备份数据库失败: %w", err)
+	}
+	result := &UserDataImportResult{BackupPath: backup.Path}
+
+	err = s.dbService.GetDB().Transaction(func(tx *gorm.DB) error {
+		for _, section := range userDataSections {
+			mode := modes[section]
+			if mode == "" || mode == UserDataImportSkip {
+				continue
+			}
+			plan, err := buildUserDataPlan(tx, archive, section)
+			if err != nil {
+				return err
+			}
+			r, err := applyUserDataPlan(tx, plan, mode)
+			if err != nil {
+				return fmt.Errorf("导入 %s 失败: %w", section, err)
+			}
+			r.Section = section
+			result.Sections = append(result.Sections, r)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("用户数据已导入",
+		zap.String("module", "services.user_data"),
+		zap.String("op", "Import"),
+		zap.Any("modes", modes),
+		zap.String("backup", backup.Path),
+	)
+	return result, nil
+}
+
+// applyUserDataPlan 按导入方式执行一个分区的计划
+func applyUserDataPlan(tx *gorm.DB, plan *userDataPlan, mode string) (UserDataSectionResult, error) {
+	r := UserDataSectionResult{Mode: mode}
+	if mode == UserDataImportReplace && plan.clear != nil {
+		n, err := plan.clear(tx)
+		if err != nil {
+			return r, err
+		}
+		r.Deleted = int(n)
+	}
+	for _, item := range plan.items {
+		if mode == UserDataImportMerge && item.state != userDataNew {
+			r.Kept++
+			continue
+		}
+		if err := item.save(tx); err != nil {
+			return r, err
+		}
+		r.Written++
+	}
+	return r, nil
+}
+
+func isUserDataSection(section string) bool {
+	for _, s := range userDataSections {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+// buildUserDataPlan 读取本地数据，生成分区的导入计划
+func buildUserDataPlan(db *gorm.DB, archive *UserDataArchive, section string) (*userDataPlan, error) {
+	switch section {
+	case UserDataWatchlist:
+		return watchlistPlan(db, archive.Watchlist)
+	case UserDataPriceAlerts:
+		return priceAlertsPlan(db, archive.PriceAlerts)
+	case UserDataAlertTemplates:
+		return alertTemplatesPlan(db, archive.AlertTemplates)
+	case UserDataPositions:
+		return positionsPlan(db, archive.Positions)
+	case UserDataStrategyConfigs:
+		return strategyConfigsPlan(db, archive.StrategyConfigs)
+	case UserDataGlobalStrategy:
+		return globalStrategyPlan(db, archive.GlobalStrategyConfig)
+	case UserDataSettings:
+		return settingsPlan(db, archive.Settings)
+	}
+	return nil, fmt.Errorf("未知的数据分区: %s", section)
+}
+
+// compareState 根据本地是否存在及内容是否相同得到记录状态
+func compareState(exists, identical bool) int {
+	switch {
+	case !exists:
+		return userDataNew
+	case identical:
+		return userDataIdentical
+	default:
+		return userDataConflict
+	}
+}
+
+// upsert 按主键写入，已存在时覆盖全部字段
+func upsert(tx *gorm.DB, value interface{}) error {
+	return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(value).Error
+}
+
+// clearTable 返回清空整张表的函数
+func clearTable(model interface{}) func(tx *gorm.DB) (int64, error) {
+	return func(tx *gorm.DB) (int64, error) {
+		result := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(model)
+		return result.RowsAffected, result.Error
+	}
+}
+
+// watchlistPlan 自选股按代码匹配，名称相同视为相同（data 为行情快照，不参与比较）
+func watchlistPlan(db *gorm.DB, rows []models.WatchlistEntity) (*userDataPlan, error) {
+	var local []models.WatchlistEntity
+	if err := db.Find(&local).Error; err != nil {
+		return nil, fmt.Errorf("读取自选股失败: %w", err)
+	}
+	byCode := make(map[string]models.WatchlistEntity, len(local))
+	for _, l := range local {
+		byCode[l.Code] = l
+	}
+
+	plan := &userDataPlan{local: len(local), clear: clearTable(&models.WatchlistEntity{})}
+	for _, row := range rows {
+		row := row
+		l, ok := byCode[row.Code]
+		plan.items = append(plan.items, userDataItem{
+			key:   row.Code,
+			state: compareState(ok, l.Name == row.Name),
+			save:  func(tx *gorm.DB) error { return upsert(tx, &row) },
+		})
+	}
+	return plan, nil
+}
+
+// priceAlertsPlan 价格预警按 股票代码 + 类型 + 条件 匹配，导入时重新分配 ID
+func priceAlertsPlan(db *gorm.DB, rows []models.PriceThresholdAlertEntity) (*userDataPlan, error) {
+	var local []models.PriceThresholdAlertEntity
+	if err := db.Find(&local).Error; err != nil {
+		return nil, fmt.Errorf("读取价格预警失败: %w", err)
+	}
+	byKey := make(map[string]models.PriceThresholdAlertEntity, len(local))
+	for _, l := range local {
+		byKey[priceAlertKey(l)] = l
+	}
+
+	plan := &userDataPlan{local: len(local), clear: clearTable(&models.PriceThresholdAlertEntity{})}
+	for _, row := range rows {
+		row := row
+		row.ID = 0
+		l, ok := byKey[priceAlertKey(row)]
+		identical := l.StockName == row.StockName && l.IsActive == row.IsActive &&
+			l.Sensitivity == row.Sensitivity && l.CooldownHours == row.CooldownHours &&
+			l.PostTriggerAction == row.PostTriggerAction && l.EnableSound == row.EnableSound &&
+			l.EnableDesktop == row.EnableDesktop && l.TemplateID == row.TemplateID
+		plan.items = append(plan.items, userDataItem{
+			key:   row.StockCode + " " + row.AlertType,
+			state: compareState(ok, identical),
+			save:  func(tx *gorm.DB) error { return tx.Create(&row).Error },
+		})
+	}
+	return plan, nil
+}
+
+// priceAlertKey 预警的匹配键，条件 JSON 压缩后比较以忽略格式差异
+func priceAlertKey(a models.PriceThresholdAlertEntity) string {
+	conditions := a.Conditions
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(conditions)); err == nil {
+		conditions = buf.String()
+	}
+	return a.StockCode + "|" + a.AlertType + "|" + conditions
+}
+
+// alertTemplatesPlan 预警模板按 ID 匹配
+func alertTemplatesPlan(db *gorm.DB, rows []models.PriceAlertTemplateEntity) (*userDataPlan, error) {
+	var local []models.PriceAlertTemplateEntity
+	if err := db.Find(&local).Error; err != nil {
+		return nil, fmt.Errorf("读取预警模板失败: %w", err)
+	}
+	byID := make(map[string]models.PriceAlertTemplateEntity, len(local))
+	for _, l := range local {
+		byID[l.ID] = l
+	}
+
+	plan := &userDataPlan{local: len(local), clear: clearTable(&models.PriceAlertTemplateEntity{})}
+	for _, row := range rows {
+		row := row
+		l, ok := byID[row.ID]
+		identical := l.Name == row.Name && l.Description == row.Description &&
+			l.AlertType == row.AlertType && l.Conditions == row.Conditions
+		plan.items = append(plan.items, userDataItem{
+			key:   row.ID,
+			state: compareState(ok, identical),
+			save:  func(tx *gorm.DB) error { return upsert(tx, &row) },
+		})
+	}
+	return plan, nil
+}
+
+// positionsPlan 持仓按股票代码匹配，更新时间不参与比较
+func positionsPlan(db *gorm.DB, rows []models.PositionEntity) (*userDataPlan, error) {
+	var local []models.PositionEntity
+	if err := db.Find(&local).Error; err != nil {
+		return nil, fmt.Errorf("读取持仓失败: %w", err)
+	}
+	byCode := make(map[string]models.PositionEntity, len(local))
+	for _, l := range local {
+		byCode[l.StockCode] = l
+	}
+
+	plan := &userDataPlan{local: len(local), clear: clearTable(&models.PositionEntity{})}
+	for _, row := range rows {
+		row := row
+		l, ok := byCode[row.StockCode]
+		identical := l.StockName == row.StockName && l.EntryPrice == row.EntryPrice &&
+			l.EntryTime.Equal(row.EntryTime) && l.CurrentStatus == row.CurrentStatus &&
+			l.LogicStatus == row.LogicStatus && l.StrategyJSON == row.StrategyJSON &&
+			l.TrailingConfigJSON == row.TrailingConfigJSON
+		plan.items = append(plan.items, userDataItem{
+			key:   row.StockCode,
+			state: compareState(ok, identical),
+			save:  func(tx *gorm.DB) error { return upsert(tx, &row) },
+		})
+	}
+	return plan, nil
+}
+
+// strategyConfigsPlan 策略配置按名称匹配，导入时重新分配 ID（回测结果不参与比较）
+func strategyConfigsPlan(db *gorm.DB, rows []models.StrategyConfigEntity) (*userDataPlan, error) {
+	var local []models.StrategyConfigEntity
+	if err := db.Find(&local).Error; err != nil {
+		return nil, fmt.Errorf("读取策略配置失败: %w", err)
+	}
+	byName := make(map[string]models.StrategyConfigEntity, len(local))
+	for _, l := range local {
+		byName[l.Name] = l
+	}
+
+	plan := &userDataPlan{local: len(local), clear: clearTable(&models.StrategyConfigEntity{})}
+	for _, row := range rows {
+		row := row
+		row.ID = 0
+		l, ok := byName[row.Name]
+		identical := l.Description == row.Description && l.StrategyType == row.StrategyType && l.Parameters == row.Parameters
+		plan.items = append(plan.items, userDataItem{
+			key:   row.Name,
+			state: compareState(ok, identical),
+			save:  func(tx *gorm.DB) error { return tx.Create(&row).Error },
+		})
+	}
+	return plan, nil
+}
+
+// globalStrategyPlan 全局策略配置作为一条记录比较，写入方式与 UpdateGlobalStrategyConfig 一致
+func globalStrategyPlan(db *gorm.DB, global *GlobalStrategyConfig) (*userDataPlan, error) {
+	configs, err := loadConfigValues(db)
+	if err != nil {
+		return nil, err
+	}
+	local, ok := parseGlobalStrategyConfig(configs)
+	plan := &userDataPlan{}
+	if ok {
+		plan.local = 1
+	}
+	if global == nil {
+		return plan, nil
+	}
+	cfg := *global
+	plan.items = append(plan.items, userDataItem{
+		key:   UserDataGlobalStrategy,
+		state: compareState(ok, local == cfg),
+		save: func(tx *gorm.DB) error {
+			if err := upsert(tx, &models.ConfigEntity{Key: configTrailingActivation, Value: fmt.Sprintf("%f", cfg.TrailingStopActivation)}); err != nil {
+				return err
+			}
+			return upsert(tx, &models.ConfigEntity{Key: configTrailingCallback, Value: fmt.Sprintf("%f", cfg.TrailingStopCallback)})
+		},
+	})
+	return plan, nil
+}
+
+// settingsPlan 设置项按键匹配，只接受已知的设置键
+func settingsPlan(db *gorm.DB, settings map[string]string) (*userDataPlan, error) {
+	configs, err := loadConfigValues(db)
+	if err != nil {
+		return nil, err
+	}
+	allowed := make(map[string]bool)
+	for _, key := range append(append([]string{}, userSettingKeys...), secretSettingKeys...) {
+		allowed[key] = true
+	}
+
+	plan := &userDataPlan{}
+	for key := range allowed {
+		if _, ok := configs[key]; ok {
+			plan.local++
+		}
+	}
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		if allowed[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		entity := models.ConfigEntity{Key: key, Value: settings[key]}
+		local, ok := configs[key]
+		plan.items = append(plan.items, userDataItem{
+			key:   key,
+			state: compareState(ok, local == entity.Value),
+			save:  func(tx *gorm.DB) error { return upsert(tx, &entity) },
+		})
+	}
+	return plan, nil
+}
+
+// loadConfigValues 读取全部配置项
+func loadConfigValues(db *gorm.DB) (map[string]string, error) {
+	var rows []models.ConfigEntity
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("读取配置失败: %w", err)
+	}
+	values := make(map[string]string, len(rows))
+	for _, row := range rows {
+		values[row.Key] = row.Value
+	}
+	return values, nil
+}
+
+// parseGlobalStrategyConfig 从配置项解析全局策略配置，两个键都存在且有效时返回 true
+func parseGlobalStrategyConfig(configs map[string]string) (GlobalStrategyConfig, bool) {
+	activation, err1 := strconv.ParseFloat(configs[configTrailingActivation], 64)
+	callback, err2 := strconv.ParseFloat(configs[configTrailingCallback], 64)
+	if err1 != nil || err2 != nil {
+		return GlobalStrategyConfig{}, false
+	}
+	return GlobalStrategyConfig{TrailingStopActivation: activation, TrailingStopCallback: callback}, true
+}
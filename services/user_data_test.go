@@ -0,0 +1,254 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"stock-analyzer-wails/models"
+)
+
+func seedUserData(t *testing.T, db *DBService) {
+	t.Helper()
+	mustCreate(t, db,
+		&models.WatchlistEntity{Code: "600519", Name: "贵州茅台", Data: "{}"},
+		&models.WatchlistEntity{Code: "000001", Name: "平安银行", Data: "{}"},
+		&models.PriceThresholdAlertEntity{StockCode: "600519", StockName: "贵州茅台", AlertType: "target_price",
+			Conditions: `{"logic":"AND","conditions":[{"field":"close_price","operator":">=","value":1800}]}`, IsActive: true, Sensitivity: 0.001, CooldownHours: 1},
+		&models.PositionEntity{StockCode: "600519", StockName: "贵州茅台", EntryPrice: 1700, EntryTime: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), CurrentStatus: "holding", LogicStatus: "valid"},
+		&models.StrategyConfigEntity{Name: "双均线", StrategyType: "simple_ma", Parameters: `{"short":5,"long":20}`},
+		&models.ConfigEntity{Key: "ai_provider", Value: "Qwen"},
+		&models.ConfigEntity{Key: "ai_api_key", Value: "sk-secret"},
+	)
+}
+
+func TestUserData_ExportExcludesSecretsByDefault(t *testing.T) {
+	db := newTestDB(t)
+	seedUserData(t, db)
+	svc := NewUserDataService(db)
+
+	archive, err := svc.Export(false)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if archive.IncludesSecrets || archive.Settings["ai_api_key"] != "" {
+		t.Fatalf("expected api key to be excluded, got %+v", archive.Settings)
+	}
+	if archive.Settings["ai_provider"] != "Qwen" || archive.Settings["market_data_provider"] != "eastmoney" {
+		t.Fatalf("unexpected settings: %+v", archive.Settings)
+	}
+	if archive.GlobalStrategyConfig == nil || archive.GlobalStrategyConfig.TrailingStopActivation != 0.05 {
+		t.Fatalf("unexpected global strategy config: %+v", archive.GlobalStrategyConfig)
+	}
+	if len(archive.Watchlist) != 2 || len(archive.PriceAlerts) != 1 || len(archive.Positions) != 1 || len(archive.StrategyConfigs) != 1 || len(archive.AlertTemplates) == 0 {
+		t.Fatalf("unexpected archive contents: %+v", archive)
+	}
+
+	withSecrets, err := svc.Export(true)
+	if err != nil || !withSecrets.IncludesSecrets || withSecrets.Settings["ai_api_key"] != "sk-secret" {
+		t.Fatalf("expected api key when requested, got %+v, err=%v", withSecrets, err)
+	}
+}
+
+func TestUserData_RoundTripToEmptyDatabase(t *testing.T) {
+	src := newTestDB(t)
+	seedUserData(t, src)
+	path := filepath.Join(t.TempDir(), "export.json")
+	if _, err := NewUserDataService(src).ExportToFile(path, false); err != nil {
+		t.Fatalf("ExportToFile: %v", err)
+	}
+
+	archive, err := ReadUserDataArchive(path)
+	if err != nil {
+		t.Fatalf("ReadUserDataArchive: %v", err)
+	}
+	dst := newTestDB(t)
+	svc := NewUserDataService(dst)
+	preview, err := svc.PreviewImport(archive)
+	if err != nil {
+		t.Fatalf("PreviewImport: %v", err)
+	}
+	sections := map[string]UserDataSectionPreview{}
+	for _, p := range preview.Sections {
+		sections[p.Section] = p
+	}
+	if p := sections[UserDataWatchlist]; p.New != 2 || p.Conflicts != 0 {
+		t.Fatalf("unexpected watchlist preview: %+v", p)
+	}
+	// 默认模板两边相同
+	if p := sections[UserDataAlertTemplates]; p.Identical != p.Total || p.New != 0 {
+		t.Fatalf("unexpected template preview: %+v", p)
+	}
+	if p := sections[UserDataSettings]; p.Conflicts != 0 || p.New != 1 {
+		t.Fatalf("unexpected settings preview: %+v", p)
+	}
+
+	modes := map[string]string{}
+	for _, section := range userDataSections {
+		modes[section] = UserDataImportMerge
+	}
+	result, err := svc.Import(archive, modes)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if _, err := os.Stat(result.BackupPath); err != nil {
+		t.Fatalf("expected pre-import backup: %v", err)
+	}
+
+	var position models.PositionEntity
+	if err := dst.GetDB().First(&position, "stock_code = ?", "600519").Error; err != nil || position.EntryPrice != 1700 {
+		t.Fatalf("unexpected imported position: %+v, err=%v", position, err)
+	}
+	var alerts []models.PriceThresholdAlertEntity
+	if err := dst.GetDB().Find(&alerts).Error; err != nil || len(alerts) != 1 || alerts[0].StockCode != "600519" {
+		t.Fatalf("unexpected imported alerts: %+v, err=%v", alerts, err)
+	}
+	var key models.ConfigEntity
+	if err := dst.GetDB().First(&key, "key = ?", "ai_api_key").Error; err == nil {
+		t.Fatalf("api key must not be imported from an archive without secrets, got %+v", key)
+	}
+
+	// 再次导入：全部相同，不产生重复记录
+	again, err := svc.PreviewImport(archive)
+	if err != nil {
+		t.Fatalf("PreviewImport: %v", err)
+	}
+	for _, p := range again.Sections {
+		if p.New != 0 || p.Conflicts != 0 {
+			t.Fatalf("expected section %s to be identical after import, got %+v", p.Section, p)
+		}
+	}
+}
+
+func TestUserData_MergeKeepsLocalAndReplaceOverwrites(t *testing.T) {
+	src := newTestDB(t)
+	seedUserData(t, src)
+	archive, err := NewUserDataService(src).Export(false)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	dst := newTestDB(t)
+	mustCreate(t, dst,
+		&models.WatchlistEntity{Code: "600519", Name: "茅台", Data: "{}"},
+		&models.WatchlistEntity{Code: "300750", Name: "宁德时代", Data: "{}"},
+		&models.StrategyConfigEntity{Name: "双均线", StrategyType: "simple_ma", Parameters: `{"short":10,"long":30}`},
+	)
+	svc := NewUserDataService(dst)
+
+	preview, err := svc.PreviewImport(archive)
+	if err != nil {
+		t.Fatalf("PreviewImport: %v", err)
+	}
+	for _, p := range preview.Sections {
+		switch p.Section {
+		case UserDataWatchlist:
+			if p.New != 1 || p.Conflicts != 1 || p.ConflictKeys[0] != "600519" || p.Local != 2 {
+				t.Fatalf("unexpected watchlist preview: %+v", p)
+			}
+		case UserDataStrategyConfigs:
+			if p.Conflicts != 1 || p.ConflictKeys[0] != "双均线" {
+				t.Fatalf("unexpected strategy preview: %+v", p)
+			}
+		}
+	}
+
+	if _, err := svc.Import(archive, map[string]string{UserDataWatchlist: UserDataImportMerge, UserDataStrategyConfigs: UserDataImportMerge}); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if got := watchlistNames(t, dst); len(got) != 3 || got["600519"] != "茅台" {
+		t.Fatalf("merge should add new codes and keep local conflicts, got %v", got)
+	}
+	var strategy models.StrategyConfigEntity
+	if err := dst.GetDB().First(&strategy, "name = ?", "双均线").Error; err != nil || strategy.Parameters != `{"short":10,"long":30}` {
+		t.Fatalf("merge should keep local strategy, got %+v, err=%v", strategy, err)
+	}
+
+	result, err := svc.Import(archive, map[string]string{UserDataWatchlist: UserDataImportReplace, UserDataStrategyConfigs: UserDataImportReplace})
+	if err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if got := watchlistNames(t, dst); len(got) != 2 || got["600519"] != "贵州茅台" || got["300750"] != "" {
+		t.Fatalf("replace should mirror the archive, got %v", got)
+	}
+	if result.Sections[0].Deleted != 3 || result.Sections[0].Written != 2 {
+		t.Fatalf("unexpected replace result: %+v", result.Sections)
+	}
+	var strategies []models.StrategyConfigEntity
+	if err := dst.GetDB().Find(&strategies).Error; err != nil || len(strategies) != 1 || strategies[0].Parameters != `{"short":5,"long":20}` {
+		t.Fatalf("replace should overwrite strategies, got %+v, err=%v", strategies, err)
+	}
+}
+
+func TestUserData_RejectsInvalidArchive(t *testing.T) {
+	if _, err := ParseUserDataArchive([]byte(`{"format":"other","version":1}`)); err == nil {
+		t.Fatalf("expected unknown format to be rejected")
+	}
+	if _, err := ParseUserDataArchive([]byte(`{"format":"stock-analyzer-user-data","version":99}`)); err == nil {
+		t.Fatalf("expected newer archive version to be rejected")
+	}
+
+	svc := NewUserDataService(newTestDB(t))
+	if _, err := svc.Import(&UserDataArchive{}, map[string]string{UserDataWatchlist: "overwrite"}); err == nil {
+		t.Fatalf("expected invalid mode to be rejected")
+	}
+}
+
+func TestDBService_BackupAndStagedRestore(t *testing.T) {
+	db := newTestDB(t)
+	mustCreate(t, db, &models.WatchlistEntity{Code: "600519", Name: "贵州茅台", Data: "{}"})
+	backup, err := db.Backup()
+	if err != nil || backup.Kind != DatabaseBackupManual {
+		t.Fatalf("Backup = %+v, %v", backup, err)
+	}
+	mustCreate(t, db, &models.WatchlistEntity{Code: "000001", Name: "平安银行", Data: "{}"})
+
+	if err := db.StageRestore(filepath.Join(t.TempDir(), "missing.bak")); err == nil {
+		t.Fatalf("expected missing file to be rejected")
+	}
+	junk := filepath.Join(t.TempDir(), "junk.bak")
+	if err := os.WriteFile(junk, []byte("not a database"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.StageRestore(junk); err == nil || db.HasPendingRestore() {
+		t.Fatalf("expected invalid backup to be rejected, err=%v", err)
+	}
+
+	if err := db.StageRestore(backup.Path); err != nil {
+		t.Fatalf("StageRestore: %v", err)
+	}
+	if !db.HasPendingRestore() {
+		t.Fatalf("expected a pending restore")
+	}
+	// 暂存后当前连接不受影响
+	if got := watchlistNames(t, db); len(got) != 2 {
+		t.Fatalf("expected current database to be untouched, got %v", got)
+	}
+
+	path := db.GetDBPath()
+	db.Close()
+	restored, err := NewDBServiceWithPath(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer restored.Close()
+	if restored.HasPendingRestore() {
+		t.Fatalf("expected pending restore to be consumed")
+	}
+	if got := watchlistNames(t, restored); len(got) != 1 || got["600519"] != "贵州茅台" {
+		t.Fatalf("expected restored watchlist, got %v", got)
+	}
+
+	backups, err := restored.ListBackups()
+	if err != nil {
+		t.Fatalf("ListBackups: %v", err)
+	}
+	kinds := map[string]int{}
+	for _, b := range backups {
+		kinds[b.Kind]++
+	}
+	if kinds[DatabaseBackupManual] != 1 || kinds[DatabaseBackupPreRestore] != 1 {
+		t.Fatalf("unexpected backups: %+v", backups)
+	}
+}